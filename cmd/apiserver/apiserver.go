@@ -4,19 +4,20 @@ import (
 	"flag"
 
 	"p9t.io/kuberboat/cmd/apiserver/app"
+	"p9t.io/kuberboat/pkg/apiserver/scale"
 )
 
-var (
-	// configPath is the path to configuration file
-	etcdServers string
-)
+var opts app.Options
 
 func init() {
 	flag.Set("logtostderr", "true")
-	flag.StringVar(&etcdServers, "etcd-servers", "localhost:2379", "List of etcd servers to connect with (scheme://ip:port), comma separated.")
+	flag.StringVar(&opts.EtcdServers, "etcd-servers", "localhost:2379", "List of etcd servers to connect with (scheme://ip:port), comma separated.")
+	flag.StringVar(&opts.MetricsSource, "metrics-source", app.MetricsSourcePrometheus, "Source of the pod usage for autoscalers, prometheus or kubelet.")
+	flag.StringVar(&opts.Prometheus.Address, "prometheus-address", scale.DefaultPrometheusAddress, "Address of the Prometheus server, if it is the metrics source.")
+	flag.DurationVar(&opts.Prometheus.Window, "metrics-window", scale.DefaultQueryWindow, "Window over which Prometheus averages the usage of a pod.")
 }
 
 func main() {
 	flag.Parse()
-	app.StartServer(etcdServers)
+	app.StartServer(&opts)
 }
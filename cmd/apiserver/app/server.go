@@ -4,22 +4,29 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net"
 
 	"github.com/golang/glog"
 	"google.golang.org/grpc"
 	"p9t.io/kuberboat/pkg/api/core"
 	"p9t.io/kuberboat/pkg/apiserver"
+	"p9t.io/kuberboat/pkg/apiserver/credential"
 	"p9t.io/kuberboat/pkg/apiserver/deployment"
 	"p9t.io/kuberboat/pkg/apiserver/dns"
 	"p9t.io/kuberboat/pkg/apiserver/etcd"
 	"p9t.io/kuberboat/pkg/apiserver/job"
+	"p9t.io/kuberboat/pkg/apiserver/metrics"
 	"p9t.io/kuberboat/pkg/apiserver/node"
 	"p9t.io/kuberboat/pkg/apiserver/pod"
 	"p9t.io/kuberboat/pkg/apiserver/recover"
+	"p9t.io/kuberboat/pkg/apiserver/replicaset"
 	"p9t.io/kuberboat/pkg/apiserver/scale"
 	"p9t.io/kuberboat/pkg/apiserver/schedule"
+	"p9t.io/kuberboat/pkg/apiserver/scheduledscale"
 	"p9t.io/kuberboat/pkg/apiserver/service"
+	"p9t.io/kuberboat/pkg/apiserver/statefulset"
+	"p9t.io/kuberboat/pkg/apiserver/vpa"
 	pb "p9t.io/kuberboat/pkg/proto"
 )
 
@@ -27,15 +34,21 @@ import (
 var nodeManager node.NodeManager
 var componentManager apiserver.ComponentManager
 var legacyManager apiserver.LegacyManager
-var metricsManager scale.MetricsManager
+var metricsSource scale.MetricsSource
 var podScheduler schedule.PodScheduler
 var podController pod.Controller
 var jobController job.Controller
 var serviceController service.Controller
+var replicaSetController replicaset.Controller
+var statefulSetController statefulset.Controller
 var deploymentController deployment.Contoller
 var nodeController node.Controller
 var dnsController dns.Controller
 var autoscalerController scale.Controller
+var scheduledScalerController scheduledscale.Controller
+var verticalAutoscalerController vpa.Controller
+var credentialController credential.Controller
+var metricsController metrics.Controller
 
 type server struct {
 	pb.UnimplementedApiServerKubeletServiceServer
@@ -118,6 +131,152 @@ func (s *server) GetJobLog(ctx context.Context, req *pb.LogJobRequest) (*pb.LogJ
 	return &pb.LogJobResponse{Log: resp}, err
 }
 
+func (s *server) StreamPodLog(req *pb.StreamPodLogRequest, stream pb.ApiServerCtlService_StreamPodLogServer) error {
+	client, err := podController.GetPodClient(req.PodName)
+	if err != nil {
+		return err
+	}
+	// The kubelet stream shares the context of kubectl's stream, so that it is closed as soon as
+	// kubectl goes away.
+	logStream, err := client.StreamPodLog(stream.Context(), req.PodName, &core.PodLogOptions{
+		Container:    req.ContainerName,
+		Follow:       req.Follow,
+		TailLines:    req.TailLines,
+		SinceSeconds: req.SinceSeconds,
+		Timestamps:   req.Timestamps,
+		Previous:     req.Previous,
+	})
+	if err != nil {
+		return err
+	}
+	for {
+		resp, err := logStream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(&pb.StreamPodLogResponse{Content: resp.Content}); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *server) Exec(stream pb.ApiServerCtlService_ExecServer) error {
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	client, err := podController.GetPodClient(req.PodName)
+	if err != nil {
+		return err
+	}
+	execStream, err := client.Exec(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	// Relay requests from kubectl to kubelet until kubectl closes its side.
+	relayRequest := func(req *pb.ExecRequest) error {
+		return execStream.Send(&pb.KubeletExecRequest{
+			PodName:       req.PodName,
+			ContainerName: req.ContainerName,
+			Command:       req.Command,
+			Stdin:         req.Stdin,
+			Tty:           req.Tty,
+			StdinData:     req.StdinData,
+			CloseStdin:    req.CloseStdin,
+			Width:         req.Width,
+			Height:        req.Height,
+		})
+	}
+	if err := relayRequest(req); err != nil {
+		return err
+	}
+	go func() {
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				execStream.CloseSend()
+				return
+			}
+			if err := relayRequest(req); err != nil {
+				return
+			}
+		}
+	}()
+
+	// Relay responses from kubelet to kubectl until the command exits.
+	for {
+		resp, err := execStream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(&pb.ExecResponse{
+			Stdout:   resp.Stdout,
+			Stderr:   resp.Stderr,
+			Exited:   resp.Exited,
+			ExitCode: resp.ExitCode,
+		}); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *server) PortForward(stream pb.ApiServerCtlService_PortForwardServer) error {
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	client, err := podController.GetPodClient(req.PodName)
+	if err != nil {
+		return err
+	}
+	forwardStream, err := client.PortForward(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	// Relay data from kubectl to kubelet until kubectl closes its side.
+	if err := forwardStream.Send(&pb.KubeletPortForwardRequest{
+		PodName: req.PodName,
+		Port:    req.Port,
+		Data:    req.Data,
+	}); err != nil {
+		return err
+	}
+	go func() {
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				forwardStream.CloseSend()
+				return
+			}
+			if err := forwardStream.Send(&pb.KubeletPortForwardRequest{Data: req.Data}); err != nil {
+				return
+			}
+		}
+	}()
+
+	// Relay data from kubelet to kubectl until the pod closes the connection.
+	for {
+		resp, err := forwardStream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(&pb.PortForwardResponse{Data: resp.Data}); err != nil {
+			return err
+		}
+	}
+}
+
 func (s *server) RegisterNode(ctx context.Context, req *pb.RegisterNodeRequest) (*pb.DefaultResponse, error) {
 	var node core.Node
 	if err := json.Unmarshal(req.Node, &node); err != nil {
@@ -154,6 +313,54 @@ func (s *server) DeleteDeployment(ctx context.Context, req *pb.DeleteDeploymentR
 	return &pb.DefaultResponse{Status: 0}, nil
 }
 
+func (s *server) CreateReplicaSet(ctx context.Context, req *pb.CreateReplicaSetRequest) (*pb.DefaultResponse, error) {
+	var replicaSet core.ReplicaSet
+	if err := json.Unmarshal(req.ReplicaSet, &replicaSet); err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
+	}
+	if err := replicaSetController.ApplyReplicaSet(&replicaSet); err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
+	}
+	return &pb.DefaultResponse{Status: 0}, nil
+}
+
+func (s *server) DeleteReplicaSet(ctx context.Context, req *pb.DeleteReplicaSetRequest) (*pb.DefaultResponse, error) {
+	if req.ReplicaSetName == "" {
+		if err := replicaSetController.DeleteAllReplicaSets(); err != nil {
+			return &pb.DefaultResponse{Status: -1}, err
+		}
+	} else {
+		if err := replicaSetController.DeleteReplicaSetByName(req.ReplicaSetName, ""); err != nil {
+			return &pb.DefaultResponse{Status: -1}, err
+		}
+	}
+	return &pb.DefaultResponse{Status: 0}, nil
+}
+
+func (s *server) CreateStatefulSet(ctx context.Context, req *pb.CreateStatefulSetRequest) (*pb.DefaultResponse, error) {
+	var statefulSet core.StatefulSet
+	if err := json.Unmarshal(req.StatefulSet, &statefulSet); err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
+	}
+	if err := statefulSetController.ApplyStatefulSet(&statefulSet); err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
+	}
+	return &pb.DefaultResponse{Status: 0}, nil
+}
+
+func (s *server) DeleteStatefulSet(ctx context.Context, req *pb.DeleteStatefulSetRequest) (*pb.DefaultResponse, error) {
+	if req.StatefulSetName == "" {
+		if err := statefulSetController.DeleteAllStatefulSets(); err != nil {
+			return &pb.DefaultResponse{Status: -1}, err
+		}
+	} else {
+		if err := statefulSetController.DeleteStatefulSetByName(req.StatefulSetName); err != nil {
+			return &pb.DefaultResponse{Status: -1}, err
+		}
+	}
+	return &pb.DefaultResponse{Status: 0}, nil
+}
+
 func (s *server) UpdatePodStatus(ctx context.Context, req *pb.UpdatePodStatusRequest) (*pb.DefaultResponse, error) {
 	var status core.PodStatus
 	if err := json.Unmarshal(req.PodStatus, &status); err != nil {
@@ -163,25 +370,67 @@ func (s *server) UpdatePodStatus(ctx context.Context, req *pb.UpdatePodStatusReq
 	if err != nil {
 		return &pb.DefaultResponse{Status: -1}, err
 	}
+	dispatchPodStatusEvents(req.PodName, prevStatus, &status)
+	return &pb.DefaultResponse{Status: 0}, nil
+}
 
+// dispatchPodStatusEvents dispatches the events caused by a change of a pod's status.
+func dispatchPodStatusEvents(podName string, prevStatus *core.PodStatus, status *core.PodStatus) {
 	// Try to dispatch PodReadyEvent.
 	if prevStatus.Phase != core.PodReady && status.Phase == core.PodReady {
-		glog.Infof("EVENT: pod %v is ready", req.PodName)
-		apiserver.Dispatch(&apiserver.PodReadyEvent{PodName: req.PodName})
+		glog.Infof("EVENT: pod %v is ready", podName)
+		apiserver.Dispatch(&apiserver.PodReadyEvent{PodName: podName})
 	}
 	// Try to dispatch PodFailEvent.
 	if prevStatus.Phase != core.PodFailed && status.Phase == core.PodFailed {
-		glog.Infof("EVENT: pod %v failed", req.PodName)
-		apiserver.Dispatch(&apiserver.PodFailEvent{PodName: req.PodName})
+		glog.Infof("EVENT: pod %v failed", podName)
+		apiserver.Dispatch(&apiserver.PodFailEvent{PodName: podName})
 	}
 	// Try to dispatch PodSucceedEvent
 	if prevStatus.Phase != core.PodSucceeded && status.Phase == core.PodSucceeded {
-		glog.Infof("EVENT: pod %v succeeded", req.PodName)
-		apiserver.Dispatch(&apiserver.PodSucceedEvent{PodName: req.PodName})
+		glog.Infof("EVENT: pod %v succeeded", podName)
+		apiserver.Dispatch(&apiserver.PodSucceedEvent{PodName: podName})
+	}
+}
+
+func (*server) UpdateMirrorPod(ctx context.Context, req *pb.UpdateMirrorPodRequest) (*pb.DefaultResponse, error) {
+	var pod core.Pod
+	if err := json.Unmarshal(req.Pod, &pod); err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
+	}
+	// The mirror pod of a previous version of the static pod is replaced.
+	if found, _ := podController.GetPods(false, []string{pod.Name}); len(found) == 1 &&
+		core.IsMirrorPod(found[0]) && found[0].UUID != pod.UUID {
+		if err := deleteMirrorPod(pod.Name); err != nil {
+			return &pb.DefaultResponse{Status: -1}, err
+		}
+	}
+	prevStatus, err := podController.UpdateMirrorPod(&pod, node.PeerIP(ctx))
+	if err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
+	}
+	dispatchPodStatusEvents(pod.Name, prevStatus, &pod.Status)
+	return &pb.DefaultResponse{Status: 0}, nil
+}
+
+func (*server) DeleteMirrorPod(ctx context.Context, req *pb.DeleteMirrorPodRequest) (*pb.DefaultResponse, error) {
+	if err := deleteMirrorPod(req.PodName); err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
 	}
 	return &pb.DefaultResponse{Status: 0}, nil
 }
 
+func deleteMirrorPod(name string) error {
+	pod, err := podController.DeleteMirrorPod(name)
+	if err != nil {
+		return err
+	}
+	legacy := legacyManager.GetPodLegacyByName(name)
+	apiserver.Dispatch(&apiserver.PodDeletionEvent{Pod: pod, PodLegacy: legacy})
+	legacyManager.DeletePodLegacyByName(name)
+	return nil
+}
+
 func (*server) NotifyPodDeletion(ctx context.Context, req *pb.NotifyPodDeletionRequest) (*pb.DefaultResponse, error) {
 	var deletedPod core.Pod
 	if err := json.Unmarshal(req.DeletedPod, &deletedPod); err != nil {
@@ -193,6 +442,37 @@ func (*server) NotifyPodDeletion(ctx context.Context, req *pb.NotifyPodDeletionR
 	return &pb.DefaultResponse{Status: 0}, nil
 }
 
+func (*server) GetNodePods(ctx context.Context, req *pb.EmptyRequest) (*pb.GetNodePodsResponse, error) {
+	pods := podController.GetPodsByHostIP(node.PeerIP(ctx))
+	data, err := json.Marshal(pods)
+	if err != nil {
+		return &pb.GetNodePodsResponse{Status: -1}, err
+	}
+	return &pb.GetNodePodsResponse{Status: 0, Pods: data}, nil
+}
+
+func (*server) UpdateNodePressures(ctx context.Context, req *pb.UpdateNodePressuresRequest) (*pb.DefaultResponse, error) {
+	pressures := make([]core.NodePressure, 0, len(req.Pressures))
+	for _, pressure := range req.Pressures {
+		pressures = append(pressures, core.NodePressure(pressure))
+	}
+	if err := nodeController.UpdateNodePressures(node.PeerIP(ctx), pressures); err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
+	}
+	return &pb.DefaultResponse{Status: 0}, nil
+}
+
+func (*server) UpdateNodeCapacity(ctx context.Context, req *pb.UpdateNodeCapacityRequest) (*pb.DefaultResponse, error) {
+	var capacity core.ResourceList
+	if err := json.Unmarshal(req.Capacity, &capacity); err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
+	}
+	if err := nodeController.UpdateNodeCapacity(node.PeerIP(ctx), capacity); err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
+	}
+	return &pb.DefaultResponse{Status: 0}, nil
+}
+
 func (*server) CreateService(ctx context.Context, req *pb.CreateServiceRequest) (*pb.DefaultResponse, error) {
 	var service core.Service
 	if err := json.Unmarshal(req.Service, &service); err != nil {
@@ -256,6 +536,145 @@ func (*server) DescribeDeployments(ctx context.Context, req *pb.DescribeDeployme
 	}, nil
 }
 
+func (*server) DescribeReplicaSets(ctx context.Context, req *pb.DescribeReplicaSetsRequest) (*pb.DescribeReplicaSetsResponse, error) {
+	foundReplicaSets, replicaSetPods, notFoundReplicaSets := replicaSetController.DescribeReplicaSets(req.All, req.ReplicaSetNames)
+	serializeErrResponse := &pb.DescribeReplicaSetsResponse{
+		Status:             -1,
+		ReplicaSets:        nil,
+		ReplicaSetPodNames: nil,
+	}
+
+	foundReplicaSetsData, err := json.Marshal(foundReplicaSets)
+	if err != nil {
+		return serializeErrResponse, err
+	}
+
+	replicaSetPodsData, err := json.Marshal(replicaSetPods)
+	if err != nil {
+		return serializeErrResponse, err
+	}
+
+	notFoundReplicaSetsData, err := json.Marshal(notFoundReplicaSets)
+	if err != nil {
+		return serializeErrResponse, err
+	}
+
+	var status int32
+	if len(notFoundReplicaSets) > 0 {
+		status = -2
+	} else {
+		status = 0
+	}
+
+	return &pb.DescribeReplicaSetsResponse{
+		Status:              status,
+		ReplicaSets:         foundReplicaSetsData,
+		ReplicaSetPodNames:  replicaSetPodsData,
+		NotFoundReplicaSets: notFoundReplicaSetsData,
+	}, nil
+}
+
+func (*server) DescribeStatefulSets(ctx context.Context, req *pb.DescribeStatefulSetsRequest) (*pb.DescribeStatefulSetsResponse, error) {
+	foundStatefulSets, statefulSetPods, notFoundStatefulSets := statefulSetController.DescribeStatefulSets(req.All, req.StatefulSetNames)
+	serializeErrResponse := &pb.DescribeStatefulSetsResponse{
+		Status:              -1,
+		StatefulSets:        nil,
+		StatefulSetPodNames: nil,
+	}
+
+	foundStatefulSetsData, err := json.Marshal(foundStatefulSets)
+	if err != nil {
+		return serializeErrResponse, err
+	}
+
+	statefulSetPodsData, err := json.Marshal(statefulSetPods)
+	if err != nil {
+		return serializeErrResponse, err
+	}
+
+	notFoundStatefulSetsData, err := json.Marshal(notFoundStatefulSets)
+	if err != nil {
+		return serializeErrResponse, err
+	}
+
+	var status int32
+	if len(notFoundStatefulSets) > 0 {
+		status = -2
+	} else {
+		status = 0
+	}
+
+	return &pb.DescribeStatefulSetsResponse{
+		Status:               status,
+		StatefulSets:         foundStatefulSetsData,
+		StatefulSetPodNames:  statefulSetPodsData,
+		NotFoundStatefulSets: notFoundStatefulSetsData,
+	}, nil
+}
+
+func (*server) DeploymentHistory(ctx context.Context, req *pb.DeploymentHistoryRequest) (*pb.DeploymentHistoryResponse, error) {
+	revisions, err := deploymentController.DeploymentHistory(req.DeploymentName)
+	if err != nil {
+		return &pb.DeploymentHistoryResponse{Status: -1}, err
+	}
+	revisionsData, err := json.Marshal(revisions)
+	if err != nil {
+		return &pb.DeploymentHistoryResponse{Status: -1}, err
+	}
+	return &pb.DeploymentHistoryResponse{Status: 0, Revisions: revisionsData}, nil
+}
+
+func (*server) RollbackDeployment(ctx context.Context, req *pb.RollbackDeploymentRequest) (*pb.DefaultResponse, error) {
+	if err := deploymentController.RollbackDeployment(req.DeploymentName, req.ToRevision); err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
+	}
+	return &pb.DefaultResponse{Status: 0}, nil
+}
+
+func (*server) PauseDeployment(ctx context.Context, req *pb.PauseDeploymentRequest) (*pb.DefaultResponse, error) {
+	if err := deploymentController.PauseDeployment(req.DeploymentName); err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
+	}
+	return &pb.DefaultResponse{Status: 0}, nil
+}
+
+func (*server) ResumeDeployment(ctx context.Context, req *pb.ResumeDeploymentRequest) (*pb.DefaultResponse, error) {
+	if err := deploymentController.ResumeDeployment(req.DeploymentName); err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
+	}
+	return &pb.DefaultResponse{Status: 0}, nil
+}
+
+func (*server) PromoteDeployment(ctx context.Context, req *pb.PromoteDeploymentRequest) (*pb.DefaultResponse, error) {
+	if err := deploymentController.PromoteDeployment(req.DeploymentName); err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
+	}
+	return &pb.DefaultResponse{Status: 0}, nil
+}
+
+func (*server) GetScale(ctx context.Context, req *pb.GetScaleRequest) (*pb.GetScaleResponse, error) {
+	scale, err := deploymentController.GetScale(req.DeploymentName)
+	if err != nil {
+		return &pb.GetScaleResponse{Status: -1}, err
+	}
+	scaleData, err := json.Marshal(scale)
+	if err != nil {
+		return &pb.GetScaleResponse{Status: -1}, err
+	}
+	return &pb.GetScaleResponse{Status: 0, Scale: scaleData}, nil
+}
+
+func (*server) UpdateScale(ctx context.Context, req *pb.UpdateScaleRequest) (*pb.DefaultResponse, error) {
+	var currentReplicas *uint32 = nil
+	if req.CheckCurrentReplicas {
+		currentReplicas = &req.CurrentReplicas
+	}
+	if err := deploymentController.UpdateScale(req.DeploymentName, req.Replicas, currentReplicas); err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
+	}
+	return &pb.DefaultResponse{Status: 0}, nil
+}
+
 func (*server) DescribeServices(ctx context.Context, req *pb.DescribeServicesRequest) (*pb.DescribeServicesResponse, error) {
 	foundServices, servicePods, notFoundServices := serviceController.DescribeServices(req.All, req.ServiceNames)
 	serializeErrResponse := &pb.DescribeServicesResponse{
@@ -349,6 +768,25 @@ func (*server) CreateAutoscaler(ctx context.Context, req *pb.CreateAutoscalerReq
 	return &pb.DefaultResponse{Status: 0}, nil
 }
 
+func (*server) UpdateAutoscaler(ctx context.Context, req *pb.UpdateAutoscalerRequest) (*pb.DefaultResponse, error) {
+	var autoscaler core.HorizontalPodAutoscaler
+
+	if err := json.Unmarshal(req.Autoscaler, &autoscaler); err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
+	}
+	if err := autoscalerController.UpdateAutoscaler(&autoscaler); err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
+	}
+	return &pb.DefaultResponse{Status: 0}, nil
+}
+
+func (*server) DeleteAutoscaler(ctx context.Context, req *pb.DeleteAutoscalerRequest) (*pb.DefaultResponse, error) {
+	if err := autoscalerController.DeleteAutoscalerByName(req.AutoscalerName); err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
+	}
+	return &pb.DefaultResponse{Status: 0}, nil
+}
+
 func (*server) DescribeNodes(ctx context.Context, req *pb.EmptyRequest) (*pb.DescribeNodesResponse, error) {
 	nodes := nodeController.GetRegisteredNodes()
 	data, err := json.Marshal(nodes)
@@ -358,6 +796,78 @@ func (*server) DescribeNodes(ctx context.Context, req *pb.EmptyRequest) (*pb.Des
 	return &pb.DescribeNodesResponse{Status: 0, Nodes: data}, nil
 }
 
+func (*server) GetStatsSummaries(ctx context.Context, req *pb.GetStatsSummariesRequest) (
+	*pb.GetStatsSummariesResponse,
+	error,
+) {
+	summaries, notFound := nodeController.StatsSummaries(req.NodeNames)
+	data, err := json.Marshal(summaries)
+	if err != nil {
+		return &pb.GetStatsSummariesResponse{Status: -1}, err
+	}
+
+	var status int32
+	if len(notFound) > 0 {
+		status = -2
+	} else {
+		status = 0
+	}
+
+	return &pb.GetStatsSummariesResponse{
+		Status:            status,
+		Summaries:         data,
+		NotFoundNodeNames: notFound,
+	}, nil
+}
+
+func (*server) GetNodeMetrics(ctx context.Context, req *pb.GetNodeMetricsRequest) (
+	*pb.GetNodeMetricsResponse,
+	error,
+) {
+	nodeMetrics, notFound := metricsController.NodeMetrics(req.NodeNames)
+	data, err := json.Marshal(nodeMetrics)
+	if err != nil {
+		return &pb.GetNodeMetricsResponse{Status: -1}, err
+	}
+
+	var status int32
+	if len(notFound) > 0 {
+		status = -2
+	} else {
+		status = 0
+	}
+
+	return &pb.GetNodeMetricsResponse{
+		Status:            status,
+		NodeMetrics:       data,
+		NotFoundNodeNames: notFound,
+	}, nil
+}
+
+func (*server) GetPodMetrics(ctx context.Context, req *pb.GetPodMetricsRequest) (
+	*pb.GetPodMetricsResponse,
+	error,
+) {
+	podMetrics, notFound := metricsController.PodMetrics(req.PodNames, req.Selector)
+	data, err := json.Marshal(podMetrics)
+	if err != nil {
+		return &pb.GetPodMetricsResponse{Status: -1}, err
+	}
+
+	var status int32
+	if len(notFound) > 0 {
+		status = -2
+	} else {
+		status = 0
+	}
+
+	return &pb.GetPodMetricsResponse{
+		Status:           status,
+		PodMetrics:       data,
+		NotFoundPodNames: notFound,
+	}, nil
+}
+
 func (*server) DescribeAutoscalers(ctx context.Context, req *pb.DescribeAutoscalersRequest) (
 	*pb.DescribeAutoscalersResponse,
 	error,
@@ -395,22 +905,243 @@ func (*server) DescribeAutoscalers(ctx context.Context, req *pb.DescribeAutoscal
 	}, nil
 }
 
-func StartServer(etcdServers string) {
-	if err := etcd.InitializeClient(etcdServers); err != nil {
+func (*server) CreateScheduledScaler(ctx context.Context, req *pb.CreateScheduledScalerRequest) (*pb.DefaultResponse, error) {
+	var scaler core.ScheduledScaler
+	if err := json.Unmarshal(req.ScheduledScaler, &scaler); err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
+	}
+	if err := scheduledScalerController.ApplyScheduledScaler(&scaler); err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
+	}
+	return &pb.DefaultResponse{Status: 0}, nil
+}
+
+func (*server) DeleteScheduledScaler(ctx context.Context, req *pb.DeleteScheduledScalerRequest) (*pb.DefaultResponse, error) {
+	if err := scheduledScalerController.DeleteScheduledScalerByName(req.ScheduledScalerName); err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
+	}
+	return &pb.DefaultResponse{Status: 0}, nil
+}
+
+func (*server) DescribeScheduledScalers(ctx context.Context, req *pb.DescribeScheduledScalersRequest) (
+	*pb.DescribeScheduledScalersResponse,
+	error,
+) {
+	foundScalers, notFoundScalers := scheduledScalerController.DescribeScheduledScalers(
+		req.All,
+		req.ScheduledScalerNames,
+	)
+	serializeErrorResponse := &pb.DescribeScheduledScalersResponse{Status: -1}
+
+	foundScalersData, err := json.Marshal(foundScalers)
+	if err != nil {
+		return serializeErrorResponse, err
+	}
+	notFoundScalersData, err := json.Marshal(notFoundScalers)
+	if err != nil {
+		return serializeErrorResponse, err
+	}
+
+	var status int32
+	if len(notFoundScalers) > 0 {
+		status = -2
+	} else {
+		status = 0
+	}
+
+	return &pb.DescribeScheduledScalersResponse{
+		Status:                   status,
+		ScheduledScalers:         foundScalersData,
+		NotFoundScheduledScalers: notFoundScalersData,
+	}, nil
+}
+
+func (*server) CreateVerticalAutoscaler(ctx context.Context, req *pb.CreateVerticalAutoscalerRequest) (*pb.DefaultResponse, error) {
+	var autoscaler core.VerticalPodAutoscaler
+	if err := json.Unmarshal(req.VerticalAutoscaler, &autoscaler); err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
+	}
+	if err := verticalAutoscalerController.ApplyVerticalAutoscaler(&autoscaler); err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
+	}
+	return &pb.DefaultResponse{Status: 0}, nil
+}
+
+func (*server) DeleteVerticalAutoscaler(ctx context.Context, req *pb.DeleteVerticalAutoscalerRequest) (*pb.DefaultResponse, error) {
+	if err := verticalAutoscalerController.DeleteVerticalAutoscalerByName(req.VerticalAutoscalerName); err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
+	}
+	return &pb.DefaultResponse{Status: 0}, nil
+}
+
+func (*server) DescribeVerticalAutoscalers(ctx context.Context, req *pb.DescribeVerticalAutoscalersRequest) (
+	*pb.DescribeVerticalAutoscalersResponse,
+	error,
+) {
+	foundAutoscalers, notFoundAutoscalers := verticalAutoscalerController.DescribeVerticalAutoscalers(
+		req.All,
+		req.VerticalAutoscalerNames,
+	)
+	serializeErrorResponse := &pb.DescribeVerticalAutoscalersResponse{Status: -1}
+
+	foundAutoscalersData, err := json.Marshal(foundAutoscalers)
+	if err != nil {
+		return serializeErrorResponse, err
+	}
+	notFoundAutoscalersData, err := json.Marshal(notFoundAutoscalers)
+	if err != nil {
+		return serializeErrorResponse, err
+	}
+
+	var status int32
+	if len(notFoundAutoscalers) > 0 {
+		status = -2
+	} else {
+		status = 0
+	}
+
+	return &pb.DescribeVerticalAutoscalersResponse{
+		Status:                      status,
+		VerticalAutoscalers:         foundAutoscalersData,
+		NotFoundVerticalAutoscalers: notFoundAutoscalersData,
+	}, nil
+}
+
+func (*server) CreateRegistryCredential(ctx context.Context, req *pb.CreateRegistryCredentialRequest) (*pb.DefaultResponse, error) {
+	var credential core.RegistryCredential
+	if err := json.Unmarshal(req.RegistryCredential, &credential); err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
+	}
+	if err := credentialController.CreateRegistryCredential(&credential); err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
+	}
+	return &pb.DefaultResponse{Status: 0}, nil
+}
+
+func (*server) DeleteRegistryCredential(ctx context.Context, req *pb.DeleteRegistryCredentialRequest) (*pb.DefaultResponse, error) {
+	if err := credentialController.DeleteRegistryCredentialByName(req.RegistryCredentialName); err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
+	}
+	return &pb.DefaultResponse{Status: 0}, nil
+}
+
+func (*server) DescribeRegistryCredentials(ctx context.Context, req *pb.DescribeRegistryCredentialsRequest) (
+	*pb.DescribeRegistryCredentialsResponse,
+	error,
+) {
+	foundCredentials, notFoundCredentials := credentialController.DescribeRegistryCredentials(
+		req.All,
+		req.RegistryCredentialNames,
+	)
+	serializeErrorResponse := &pb.DescribeRegistryCredentialsResponse{Status: -1}
+
+	foundCredentialsData, err := json.Marshal(foundCredentials)
+	if err != nil {
+		return serializeErrorResponse, err
+	}
+	notFoundCredentialsData, err := json.Marshal(notFoundCredentials)
+	if err != nil {
+		return serializeErrorResponse, err
+	}
+
+	var status int32
+	if len(notFoundCredentials) > 0 {
+		status = -2
+	} else {
+		status = 0
+	}
+
+	return &pb.DescribeRegistryCredentialsResponse{
+		Status:                      status,
+		RegistryCredentials:         foundCredentialsData,
+		NotFoundRegistryCredentials: notFoundCredentialsData,
+	}, nil
+}
+
+func (*server) GetRegistryCredentials(ctx context.Context, req *pb.GetRegistryCredentialsRequest) (
+	*pb.GetRegistryCredentialsResponse,
+	error,
+) {
+	found, notFound := credentialController.GetRegistryCredentials(req.Names)
+	data, err := json.Marshal(found)
+	if err != nil {
+		return &pb.GetRegistryCredentialsResponse{Status: -1}, err
+	}
+	var status int32
+	if len(notFound) > 0 {
+		status = -2
+	}
+	return &pb.GetRegistryCredentialsResponse{
+		Status:              status,
+		RegistryCredentials: data,
+		NotFoundNames:       notFound,
+	}, nil
+}
+
+// These are the valid sources of the metrics of autoscalers.
+const (
+	// MetricsSourcePrometheus queries Prometheus, which scrapes cadvisor on every node.
+	MetricsSourcePrometheus = "prometheus"
+	// MetricsSourceKubelet asks the kubelets for their stats summaries.
+	MetricsSourceKubelet = "kubelet"
+)
+
+// Options are the command line options of the api server.
+type Options struct {
+	// EtcdServers is the comma separated list of etcd servers.
+	EtcdServers string
+	// MetricsSource is where autoscalers get the usage of pods from.
+	MetricsSource string
+	// Prometheus tells where Prometheus is if it is the metrics source.
+	Prometheus scale.PrometheusConfig
+}
+
+// newMetricsSource creates the metrics source of autoscalers selected by opts.
+func newMetricsSource(opts *Options) (scale.MetricsSource, error) {
+	switch opts.MetricsSource {
+	case MetricsSourcePrometheus:
+		return scale.NewPrometheusSource(opts.Prometheus)
+	case MetricsSourceKubelet:
+		return scale.NewKubeletStatsSource(metricsController), nil
+	default:
+		return nil, fmt.Errorf(
+			"invalid metrics source %v, should be %v or %v",
+			opts.MetricsSource,
+			MetricsSourcePrometheus,
+			MetricsSourceKubelet,
+		)
+	}
+}
+
+func StartServer(opts *Options) {
+	if err := etcd.InitializeClient(opts.EtcdServers); err != nil {
 		glog.Fatal(err)
 	}
 	nodeManager = node.NewNodeManager()
 	componentManager = apiserver.NewComponentManager()
 	legacyManager = apiserver.NewLegacyManager(componentManager)
-	metricsManager = scale.NewMetricsManager(componentManager)
 	podScheduler = schedule.NewPodScheduler(nodeManager, componentManager)
 	podController = pod.NewPodController(componentManager, podScheduler, nodeManager, legacyManager)
 	jobController = job.NewJobController(podController, nodeManager, componentManager)
 	serviceController = service.NewServiceController(componentManager, nodeManager)
-	deploymentController = deployment.NewDeploymentController(componentManager, podController)
+	replicaSetController = replicaset.NewReplicaSetController(componentManager, podController)
+	deploymentController = deployment.NewDeploymentController(componentManager, replicaSetController, serviceController)
+	statefulSetController = statefulset.NewStatefulSetController(componentManager, podController, nodeManager)
 	nodeController = node.NewNodeController(nodeManager)
 	dnsController = dns.NewDNSController(componentManager)
-	autoscalerController = scale.NewAutoscalerController(componentManager, metricsManager)
+	credentialController = credential.NewCredentialController(componentManager)
+	metricsController = metrics.NewMetricsController(componentManager, nodeManager, nodeController)
+	var err error
+	if metricsSource, err = newMetricsSource(opts); err != nil {
+		glog.Fatal(err)
+	}
+	autoscalerController = scale.NewAutoscalerController(componentManager, metricsSource, deploymentController)
+	scheduledScalerController = scheduledscale.NewScheduledScalerController(componentManager, autoscalerController)
+	verticalAutoscalerController = vpa.NewVerticalAutoscalerController(
+		componentManager,
+		deploymentController,
+		metricsSource,
+	)
 
 	if err := recover.Recover(&nodeManager, &componentManager, serviceController); err != nil {
 		glog.Fatal(err)
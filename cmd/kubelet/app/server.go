@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net"
 	"os"
 
@@ -13,6 +14,7 @@ import (
 	"p9t.io/kuberboat/pkg/api/core"
 	kubeerror "p9t.io/kuberboat/pkg/api/error"
 	kl "p9t.io/kuberboat/pkg/kubelet"
+	"p9t.io/kuberboat/pkg/kubelet/eviction"
 	"p9t.io/kuberboat/pkg/kubelet/pod"
 	pb "p9t.io/kuberboat/pkg/proto"
 )
@@ -73,6 +75,168 @@ func (s *server) GetPodLog(ctx context.Context, req *pb.KubeletGetPodLogRequest)
 	return &pb.KubeletGetPodLogResponse{Log: kubelet.GetPodLog(ctx, req.PodName)}, nil
 }
 
+// podLogWriter forwards everything written to it as chunks of a pod log stream.
+type podLogWriter struct {
+	stream pb.KubeletApiServerService_StreamPodLogServer
+}
+
+func (w *podLogWriter) Write(p []byte) (int, error) {
+	content := make([]byte, len(p))
+	copy(content, p)
+	if err := w.stream.Send(&pb.KubeletStreamPodLogResponse{Content: content}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (s *server) StreamPodLog(req *pb.KubeletStreamPodLogRequest, stream pb.KubeletApiServerService_StreamPodLogServer) error {
+	opts := &core.PodLogOptions{
+		Container:    req.ContainerName,
+		Follow:       req.Follow,
+		TailLines:    req.TailLines,
+		SinceSeconds: req.SinceSeconds,
+		Timestamps:   req.Timestamps,
+		Previous:     req.Previous,
+	}
+	return kubelet.StreamPodLog(stream.Context(), req.PodName, opts, &podLogWriter{stream: stream})
+}
+
+// execOutputWriter forwards everything written to it as stdout or stderr of an exec stream.
+type execOutputWriter struct {
+	stream pb.KubeletApiServerService_ExecServer
+	stderr bool
+}
+
+func (w *execOutputWriter) Write(p []byte) (int, error) {
+	content := make([]byte, len(p))
+	copy(content, p)
+	resp := &pb.KubeletExecResponse{}
+	if w.stderr {
+		resp.Stderr = content
+	} else {
+		resp.Stdout = content
+	}
+	if err := w.stream.Send(resp); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (s *server) Exec(stream pb.KubeletApiServerService_ExecServer) error {
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	opts := &core.ExecOptions{
+		Container: req.ContainerName,
+		Command:   req.Command,
+		Stdin:     req.Stdin,
+		TTY:       req.Tty,
+	}
+
+	// Pump stdin and terminal size changes from the stream until the client closes it.
+	stdinReader, stdinWriter := io.Pipe()
+	// Closing the reader unblocks the pump if the exec ends before stdin is drained.
+	defer stdinReader.Close()
+	resize := make(chan core.TerminalSize, 1)
+	if req.Width > 0 && req.Height > 0 {
+		resize <- core.TerminalSize{Width: uint16(req.Width), Height: uint16(req.Height)}
+	}
+	go func() {
+		defer close(resize)
+		defer stdinWriter.Close()
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			if len(req.StdinData) > 0 {
+				if _, err := stdinWriter.Write(req.StdinData); err != nil {
+					return
+				}
+			}
+			if req.CloseStdin {
+				stdinWriter.Close()
+			}
+			if req.Width > 0 && req.Height > 0 {
+				// Nobody reads the sizes without a TTY, so a size is dropped rather than block the
+				// pump while the previous one is pending.
+				select {
+				case resize <- core.TerminalSize{Width: uint16(req.Width), Height: uint16(req.Height)}:
+				default:
+				}
+			}
+		}
+	}()
+
+	exitCode, err := kubelet.ExecInContainer(
+		stream.Context(),
+		req.PodName,
+		opts,
+		stdinReader,
+		&execOutputWriter{stream: stream},
+		&execOutputWriter{stream: stream, stderr: true},
+		resize,
+	)
+	if err != nil {
+		return err
+	}
+	return stream.Send(&pb.KubeletExecResponse{Exited: true, ExitCode: int32(exitCode)})
+}
+
+func (s *server) PortForward(stream pb.KubeletApiServerService_PortForwardServer) error {
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	if req.Port == 0 || req.Port > 65535 {
+		return fmt.Errorf("invalid port %v", req.Port)
+	}
+	conn, err := kubelet.DialPodPort(stream.Context(), req.PodName, uint16(req.Port))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Write data from the stream to the connection until the client closes the stream.
+	go func() {
+		data := req.Data
+		for {
+			if len(data) > 0 {
+				if _, err := conn.Write(data); err != nil {
+					return
+				}
+			}
+			req, err := stream.Recv()
+			if err != nil {
+				if tcpConn, ok := conn.(*net.TCPConn); ok && err == io.EOF {
+					tcpConn.CloseWrite()
+				} else {
+					conn.Close()
+				}
+				return
+			}
+			data = req.Data
+		}
+	}()
+
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := conn.Read(buf)
+		if n > 0 {
+			if err := stream.Send(&pb.KubeletPortForwardResponse{Data: buf[:n]}); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
 func (s *server) CreateService(ctx context.Context, req *pb.KubeletCreateServiceRequest) (*pb.DefaultResponse, error) {
 	if len(req.PodNames) != len(req.PodIps) {
 		return &pb.DefaultResponse{Status: -2}, kubeerror.KubeError{
@@ -120,11 +284,69 @@ func (s *server) DeletePodFromServices(ctx context.Context, req *pb.KubeletUpdat
 	return &pb.DefaultResponse{Status: 0}, nil
 }
 
-func StartServer() {
+func (s *server) SetServicePods(ctx context.Context, req *pb.KubeletSetServicePodsRequest) (*pb.DefaultResponse, error) {
+	if len(req.PodNames) != len(req.PodIps) {
+		return &pb.DefaultResponse{Status: -2}, kubeerror.KubeError{
+			Type:    kubeerror.KubeErrGrpc,
+			Message: "different numbers of pod id and pod ip",
+		}
+	}
+	err := kubeProxy.SetServicePods(req.ServiceName, req.PodNames, req.PodIps)
+	if err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
+	}
+	return &pb.DefaultResponse{Status: 0}, nil
+}
+
+func (s *server) GetStatsSummary(ctx context.Context, req *pb.EmptyRequest) (*pb.KubeletGetStatsSummaryResponse, error) {
+	summary, err := kubelet.StatsSummary(ctx)
+	if err != nil {
+		return &pb.KubeletGetStatsSummaryResponse{Status: -1}, err
+	}
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return &pb.KubeletGetStatsSummaryResponse{Status: -1}, err
+	}
+	return &pb.KubeletGetStatsSummaryResponse{Status: 0, Summary: data}, nil
+}
+
+// Options are the command line options of the kubelet.
+type Options struct {
+	// PodManifestPath is the directory of the manifests of static pods.
+	PodManifestPath string
+	// EvictionHard are the thresholds of node resources below which pods are evicted.
+	EvictionHard string
+	// ImageGC tells when unused images are removed.
+	ImageGC kl.ImageGCPolicy
+}
+
+func StartServer(opts *Options) {
+	thresholds, err := eviction.ParseThresholds(opts.EvictionHard)
+	if err != nil {
+		glog.Fatal(err)
+	}
+	if err := opts.ImageGC.Validate(); err != nil {
+		glog.Fatal(err)
+	}
+
 	podMetaManager = pod.NewMetaManager()
 	kubelet = kl.NewKubelet(podMetaManager)
 	kubeProxy = kl.NewKubeProxy(podMetaManager)
 
+	kubelet.StartEvictionManager(thresholds)
+	kubelet.StartImageGC(opts.ImageGC)
+	if opts.PodManifestPath != "" {
+		go kubelet.RunStaticPods(opts.PodManifestPath)
+	}
+
+	// Reconnect to the apiserver that the kubelet was registered with before restarting, so that
+	// the pods left running on the node are recovered.
+	if apiserver, ok := kl.LoadApiserverStatus(); ok {
+		if err := kubelet.ConnectToServer(apiserver); err != nil {
+			glog.Errorf("cannot reconnect to apiserver: %v", err.Error())
+		}
+	}
+
 	grpcServer := grpc.NewServer()
 	pb.RegisterKubeletApiServerServiceServer(grpcServer, &server{})
 
@@ -4,18 +4,25 @@ import (
 	"flag"
 
 	"p9t.io/kuberboat/cmd/kubelet/app"
+	"p9t.io/kuberboat/pkg/kubelet/eviction"
 )
 
 var (
 	// dnsIP is the IP address of CoreDNS name server.
 	dnsIP string
+	// opts are the options passed to the kubelet server.
+	opts app.Options
 )
 
 func init() {
 	flag.Set("logtostderr", "true")
+	flag.StringVar(&opts.PodManifestPath, "pod-manifest-path", "", "directory of static pod manifests, static pods are disabled if empty")
+	flag.IntVar(&opts.ImageGC.HighThresholdPercent, "image-gc-high-threshold", 85, "percent of disk usage of images above which image garbage collection runs, disabled if 100")
+	flag.IntVar(&opts.ImageGC.LowThresholdPercent, "image-gc-low-threshold", 80, "percent of disk usage of images that image garbage collection frees down to")
+	flag.StringVar(&opts.EvictionHard, "eviction-hard", eviction.DefaultThresholds, "thresholds of node resources below which pods are evicted, eviction is disabled if empty")
 }
 
 func main() {
 	flag.Parse()
-	app.StartServer()
+	app.StartServer(&opts)
 }
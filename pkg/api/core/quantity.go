@@ -0,0 +1,152 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+)
+
+// Quantity is an amount of a resource. It is written as a decimal number with an optional
+// suffix: m for thousandths (500m is half a CPU core), k, M, G and T for powers of 1000, or Ki,
+// Mi, Gi and Ti for powers of 1024 (512Mi is 512 mebibytes). Quantities are stored in
+// thousandths, and amounts finer than that are rounded up.
+type Quantity struct {
+	milli int64
+}
+
+// quantityPattern matches a non-negative decimal number followed by an optional suffix.
+var quantityPattern = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)(m|k|M|G|T|Ki|Mi|Gi|Ti)?$`)
+
+// quantitySuffixes maps the suffixes of quantities to their multipliers.
+var quantitySuffixes = map[string]*big.Rat{
+	"":   big.NewRat(1, 1),
+	"m":  big.NewRat(1, 1000),
+	"k":  big.NewRat(1000, 1),
+	"M":  big.NewRat(1000*1000, 1),
+	"G":  big.NewRat(1000*1000*1000, 1),
+	"T":  big.NewRat(1000*1000*1000*1000, 1),
+	"Ki": big.NewRat(1<<10, 1),
+	"Mi": big.NewRat(1<<20, 1),
+	"Gi": big.NewRat(1<<30, 1),
+	"Ti": big.NewRat(1<<40, 1),
+}
+
+// ParseQuantity parses a quantity such as 2, 0.5, 250m or 512Mi.
+func ParseQuantity(s string) (Quantity, error) {
+	matches := quantityPattern.FindStringSubmatch(s)
+	if matches == nil {
+		return Quantity{}, fmt.Errorf("invalid quantity %q", s)
+	}
+	number, ok := new(big.Rat).SetString(matches[1])
+	if !ok {
+		return Quantity{}, fmt.Errorf("invalid quantity %q", s)
+	}
+	milli := number.Mul(number, quantitySuffixes[matches[2]])
+	milli.Mul(milli, big.NewRat(1000, 1))
+
+	// Round up to a whole number of thousandths.
+	value := new(big.Int).Quo(milli.Num(), milli.Denom())
+	if new(big.Rat).SetInt(value).Cmp(milli) < 0 {
+		value.Add(value, big.NewInt(1))
+	}
+	if !value.IsInt64() {
+		return Quantity{}, fmt.Errorf("quantity %q is too large", s)
+	}
+	return Quantity{milli: value.Int64()}, nil
+}
+
+// MustParseQuantity parses a quantity and panics if it is invalid. It is meant for constants.
+func MustParseQuantity(s string) Quantity {
+	q, err := ParseQuantity(s)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}
+
+// NewMilliQuantity returns the quantity of milli thousandths.
+func NewMilliQuantity(milli int64) Quantity {
+	return Quantity{milli: milli}
+}
+
+// MilliValue returns the quantity in thousandths, e.g. millicores for CPU.
+func (q Quantity) MilliValue() int64 {
+	return q.milli
+}
+
+// Value returns the quantity rounded up to a whole number, e.g. bytes for memory.
+func (q Quantity) Value() int64 {
+	return (q.milli + 999) / 1000
+}
+
+// IsZero tells whether the quantity is zero.
+func (q Quantity) IsZero() bool {
+	return q.milli == 0
+}
+
+// Add returns the sum of two quantities.
+func (q Quantity) Add(other Quantity) Quantity {
+	return Quantity{milli: q.milli + other.milli}
+}
+
+// Cmp returns -1, 0 or 1 if the quantity is less than, equal to or greater than other.
+func (q Quantity) Cmp(other Quantity) int {
+	switch {
+	case q.milli < other.milli:
+		return -1
+	case q.milli > other.milli:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// String formats the quantity as a whole number if it is one, and in thousandths otherwise.
+func (q Quantity) String() string {
+	if q.milli%1000 == 0 {
+		return strconv.FormatInt(q.milli/1000, 10)
+	}
+	return fmt.Sprintf("%vm", q.milli)
+}
+
+func (q Quantity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.String())
+}
+
+// UnmarshalJSON accepts a quantity string as well as a plain number.
+func (q *Quantity) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var number json.Number
+		if err := json.Unmarshal(data, &number); err != nil {
+			return err
+		}
+		s = number.String()
+	}
+	parsed, err := ParseQuantity(s)
+	if err != nil {
+		return err
+	}
+	*q = parsed
+	return nil
+}
+
+func (q Quantity) MarshalYAML() (interface{}, error) {
+	return q.String(), nil
+}
+
+// UnmarshalYAML accepts a quantity string as well as a plain number.
+func (q *Quantity) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	parsed, err := ParseQuantity(s)
+	if err != nil {
+		return err
+	}
+	*q = parsed
+	return nil
+}
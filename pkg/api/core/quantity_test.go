@@ -0,0 +1,54 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestParseQuantity(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := map[string]int64{
+		"2":     2000,
+		"0.5":   500,
+		"250m":  250,
+		"1k":    1000 * 1000,
+		"1G":    1000 * 1000 * 1000 * 1000,
+		"512Mi": 512 * (1 << 20) * 1000,
+		"0.1m":  1,
+	}
+	for s, milli := range cases {
+		q, err := ParseQuantity(s)
+		assert.Nil(err, s)
+		assert.Equal(milli, q.MilliValue(), s)
+	}
+
+	for _, s := range []string{"", "-1", "1.", "1Xi", "abc"} {
+		_, err := ParseQuantity(s)
+		assert.NotNil(err, s)
+	}
+
+	assert.Equal("500m", MustParseQuantity("0.5").String())
+	assert.Equal("128", MustParseQuantity("128").String())
+	assert.Equal(int64(1), MustParseQuantity("1m").Value())
+}
+
+func TestQuantityUnmarshal(t *testing.T) {
+	assert := assert.New(t)
+
+	var list ResourceList
+	assert.Nil(yaml.Unmarshal([]byte("cpu: 1\nmemory: 64Mi\n"), &list))
+	assert.Equal(int64(1000), list[ResourceCPU].MilliValue())
+	assert.Equal(int64(64<<20), list[ResourceMemory].Value())
+
+	assert.Nil(json.Unmarshal([]byte(`{"cpu":"250m","memory":1024}`), &list))
+	assert.Equal(int64(250), list[ResourceCPU].MilliValue())
+	assert.Equal(int64(1024), list[ResourceMemory].Value())
+
+	data, err := json.Marshal(list)
+	assert.Nil(err)
+	assert.Equal(`{"cpu":"250m","memory":"1024"}`, string(data))
+}
@@ -0,0 +1,70 @@
+package core
+
+import "fmt"
+
+// ContainerRequests returns the resource requests of a container. A resource that has a limit but
+// no request is requested up to its limit.
+func ContainerRequests(c *Container) ResourceList {
+	requests := ResourceList{}
+	for name, limit := range c.Resources.Limits {
+		requests[name] = limit
+	}
+	for name, request := range c.Resources.Requests {
+		requests[name] = request
+	}
+	return requests
+}
+
+// PodRequests returns the sum of the resource requests of the containers of a pod.
+func PodRequests(pod *Pod) ResourceList {
+	requests := ResourceList{}
+	for i := range pod.Spec.Containers {
+		for name, request := range ContainerRequests(&pod.Spec.Containers[i]) {
+			requests[name] = requests[name].Add(request)
+		}
+	}
+	return requests
+}
+
+// PodQOSClass derives the quality of service of a pod from the resources of its containers.
+func PodQOSClass(pod *Pod) QOSClass {
+	guaranteed := true
+	bestEffort := true
+	for i := range pod.Spec.Containers {
+		c := &pod.Spec.Containers[i]
+		if len(c.Resources.Requests) > 0 || len(c.Resources.Limits) > 0 {
+			bestEffort = false
+		}
+		requests := ContainerRequests(c)
+		for _, name := range []ResourceName{ResourceCPU, ResourceMemory} {
+			limit, ok := c.Resources.Limits[name]
+			if !ok || requests[name].Cmp(limit) != 0 {
+				guaranteed = false
+			}
+		}
+	}
+	switch {
+	case bestEffort:
+		return QOSBestEffort
+	case guaranteed:
+		return QOSGuaranteed
+	default:
+		return QOSBurstable
+	}
+}
+
+// ValidateResources checks that no request of a container exceeds its limit.
+func ValidateResources(c *Container) error {
+	for name, request := range c.Resources.Requests {
+		if limit, ok := c.Resources.Limits[name]; ok && request.Cmp(limit) > 0 {
+			return fmt.Errorf(
+				"%v request %v of container %v exceeds its limit %v",
+				name,
+				request,
+				c.Name,
+				limit,
+			)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func podWithResources(resources ...ResourceRequirements) *Pod {
+	pod := &Pod{}
+	for _, r := range resources {
+		pod.Spec.Containers = append(pod.Spec.Containers, Container{Resources: r})
+	}
+	return pod
+}
+
+func TestPodRequests(t *testing.T) {
+	pod := podWithResources(
+		ResourceRequirements{
+			Requests: ResourceList{ResourceCPU: MustParseQuantity("250m")},
+			Limits: ResourceList{
+				ResourceCPU:    MustParseQuantity("1"),
+				ResourceMemory: MustParseQuantity("64Mi"),
+			},
+		},
+		ResourceRequirements{
+			Requests: ResourceList{ResourceCPU: MustParseQuantity("500m")},
+		},
+	)
+	requests := PodRequests(pod)
+	assert.Equal(t, int64(750), requests[ResourceCPU].MilliValue())
+	assert.Equal(t, int64(64<<20), requests[ResourceMemory].Value())
+}
+
+func TestPodQOSClass(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(QOSBestEffort, PodQOSClass(podWithResources(ResourceRequirements{})))
+
+	guaranteed := ResourceRequirements{
+		Limits: ResourceList{
+			ResourceCPU:    MustParseQuantity("1"),
+			ResourceMemory: MustParseQuantity("64Mi"),
+		},
+	}
+	assert.Equal(QOSGuaranteed, PodQOSClass(podWithResources(guaranteed)))
+
+	burstable := ResourceRequirements{
+		Requests: ResourceList{ResourceCPU: MustParseQuantity("500m")},
+		Limits:   guaranteed.Limits,
+	}
+	assert.Equal(QOSBurstable, PodQOSClass(podWithResources(burstable)))
+	assert.Equal(QOSBurstable, PodQOSClass(podWithResources(guaranteed, ResourceRequirements{})))
+}
+
+func TestValidateResources(t *testing.T) {
+	c := &Container{
+		Name: "c",
+		Resources: ResourceRequirements{
+			Requests: ResourceList{ResourceMemory: MustParseQuantity("128Mi")},
+			Limits:   ResourceList{ResourceMemory: MustParseQuantity("64Mi")},
+		},
+	}
+	assert.NotNil(t, ValidateResources(c))
+	c.Resources.Requests[ResourceMemory] = MustParseQuantity("64Mi")
+	assert.Nil(t, ValidateResources(c))
+}
+
+func TestUnmarshalFlatResources(t *testing.T) {
+	assert := assert.New(t)
+
+	var container Container
+	manifest := "name: redis\nresources:\n  cpu: 2\n  memory: 128000000\n"
+	assert.Nil(yaml.Unmarshal([]byte(manifest), &container))
+	assert.Equal(int64(2000), container.Resources.Limits[ResourceCPU].MilliValue())
+	assert.Equal(int64(128000000), container.Resources.Limits[ResourceMemory].Value())
+	assert.Equal(container.Resources.Limits, container.Resources.Requests)
+
+	manifest = "name: redis\nresources:\n  requests:\n    cpu: 500m\n  limits:\n    cpu: 1\n"
+	assert.Nil(yaml.Unmarshal([]byte(manifest), &container))
+	assert.Equal(int64(500), container.Resources.Requests[ResourceCPU].MilliValue())
+	assert.Equal(int64(1000), container.Resources.Limits[ResourceCPU].MilliValue())
+
+	manifest = "name: redis\nresources:\n  cpu: 2\n  limits:\n    cpu: 1\n"
+	assert.NotNil(yaml.Unmarshal([]byte(manifest), &container))
+
+	// Pods stored in etcd by earlier versions.
+	container = Container{}
+	assert.Nil(json.Unmarshal([]byte(`{"Name":"redis","Resources":{"cpu":2,"memory":128000000}}`), &container))
+	assert.Equal(int64(2000), container.Resources.Requests[ResourceCPU].MilliValue())
+	assert.Equal(int64(128000000), container.Resources.Limits[ResourceMemory].Value())
+
+	data, err := json.Marshal(&container)
+	assert.Nil(err)
+	var decoded Container
+	assert.Nil(json.Unmarshal(data, &decoded))
+	assert.Equal(container.Resources, decoded.Resources)
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// capabilityPattern matches the name of a Linux capability without the CAP_ prefix.
+var capabilityPattern = regexp.MustCompile(`^[A-Z][A-Z_]*$`)
+
+// EffectiveSecurityContext merges the security context of a container with that of its pod. The
+// result is never nil.
+func EffectiveSecurityContext(pod *Pod, c *Container) *SecurityContext {
+	effective := &SecurityContext{}
+	if c.SecurityContext != nil {
+		*effective = *c.SecurityContext
+	}
+	if podContext := pod.Spec.SecurityContext; podContext != nil {
+		if effective.RunAsUser == nil {
+			effective.RunAsUser = podContext.RunAsUser
+		}
+		if effective.RunAsGroup == nil {
+			effective.RunAsGroup = podContext.RunAsGroup
+		}
+		if effective.SeccompProfile == "" {
+			effective.SeccompProfile = podContext.SeccompProfile
+		}
+	}
+	return effective
+}
+
+// NormalizeCapability strips the CAP_ prefix of a capability and upper-cases it.
+func NormalizeCapability(capability string) string {
+	return strings.TrimPrefix(strings.ToUpper(capability), "CAP_")
+}
+
+// ValidateSecurityContext checks the security context of every container of a pod.
+func ValidateSecurityContext(pod *Pod) error {
+	for i := range pod.Spec.Containers {
+		c := &pod.Spec.Containers[i]
+		sc := EffectiveSecurityContext(pod, c)
+		if sc.RunAsUser != nil && *sc.RunAsUser < 0 {
+			return fmt.Errorf("runAsUser of container %v must not be negative", c.Name)
+		}
+		if sc.RunAsGroup != nil {
+			if *sc.RunAsGroup < 0 {
+				return fmt.Errorf("runAsGroup of container %v must not be negative", c.Name)
+			}
+			if sc.RunAsUser == nil {
+				return fmt.Errorf("runAsGroup of container %v requires runAsUser", c.Name)
+			}
+		}
+		if sc.Privileged && sc.AllowPrivilegeEscalation != nil && !*sc.AllowPrivilegeEscalation {
+			return fmt.Errorf(
+				"container %v cannot be privileged without allowing privilege escalation",
+				c.Name,
+			)
+		}
+		if sc.Capabilities != nil {
+			for _, capability := range append(sc.Capabilities.Add, sc.Capabilities.Drop...) {
+				if !capabilityPattern.MatchString(NormalizeCapability(capability)) {
+					return fmt.Errorf("invalid capability of container %v: %v", c.Name, capability)
+				}
+			}
+		}
+		if err := validateSeccompProfile(sc.SeccompProfile); err != nil {
+			return fmt.Errorf("invalid seccomp profile of container %v: %v", c.Name, err.Error())
+		}
+	}
+	return nil
+}
+
+func validateSeccompProfile(profile string) error {
+	switch profile {
+	case "", SeccompProfileRuntimeDefault, SeccompProfileUnconfined:
+		return nil
+	}
+	if !strings.HasPrefix(profile, SeccompProfileLocalhostPrefix) {
+		return fmt.Errorf(
+			"%v is neither %v, %v nor prefixed with %v",
+			profile,
+			SeccompProfileRuntimeDefault,
+			SeccompProfileUnconfined,
+			SeccompProfileLocalhostPrefix,
+		)
+	}
+	name := strings.TrimPrefix(profile, SeccompProfileLocalhostPrefix)
+	if name == "" || strings.Contains(name, "..") || strings.HasPrefix(name, "/") {
+		return fmt.Errorf("%v must name a file in the seccomp profile directory", profile)
+	}
+	return nil
+}
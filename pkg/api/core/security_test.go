@@ -0,0 +1,56 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEffectiveSecurityContext(t *testing.T) {
+	podUser, containerUser := int64(1000), int64(2000)
+	pod := &Pod{}
+	pod.Spec.SecurityContext = &PodSecurityContext{
+		RunAsUser:      &podUser,
+		SeccompProfile: SeccompProfileUnconfined,
+	}
+	pod.Spec.Containers = []Container{
+		{},
+		{SecurityContext: &SecurityContext{RunAsUser: &containerUser}},
+	}
+
+	sc := EffectiveSecurityContext(pod, &pod.Spec.Containers[0])
+	assert.Equal(t, podUser, *sc.RunAsUser)
+	assert.Equal(t, SeccompProfileUnconfined, sc.SeccompProfile)
+	sc = EffectiveSecurityContext(pod, &pod.Spec.Containers[1])
+	assert.Equal(t, containerUser, *sc.RunAsUser)
+	assert.Nil(t, pod.Spec.Containers[0].SecurityContext)
+}
+
+func TestValidateSecurityContext(t *testing.T) {
+	assert := assert.New(t)
+	user, negative, no := int64(1000), int64(-1), false
+
+	valid := []*SecurityContext{
+		{RunAsUser: &user, RunAsGroup: &user},
+		{Capabilities: &Capabilities{Add: []string{"NET_ADMIN"}, Drop: []string{"cap_chown", "ALL"}}},
+		{AllowPrivilegeEscalation: &no, SeccompProfile: "localhost/audit.json"},
+		{Privileged: true, SeccompProfile: SeccompProfileRuntimeDefault},
+	}
+	for _, sc := range valid {
+		pod := &Pod{Spec: PodSpec{Containers: []Container{{SecurityContext: sc}}}}
+		assert.Nil(ValidateSecurityContext(pod))
+	}
+
+	invalid := []*SecurityContext{
+		{RunAsUser: &negative},
+		{RunAsGroup: &user},
+		{Privileged: true, AllowPrivilegeEscalation: &no},
+		{Capabilities: &Capabilities{Add: []string{"NET ADMIN"}}},
+		{SeccompProfile: "docker/default"},
+		{SeccompProfile: "localhost/../etc/passwd"},
+	}
+	for _, sc := range invalid {
+		pod := &Pod{Spec: PodSpec{Containers: []Container{{SecurityContext: sc}}}}
+		assert.NotNil(ValidateSecurityContext(pod))
+	}
+}
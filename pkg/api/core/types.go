@@ -14,15 +14,21 @@ type Container struct {
 	Name string
 	// Container image name.
 	Image string
+	// ImagePullPolicy tells when the image is pulled. Defaults to Always if the image has no tag or
+	// the tag is latest, and to IfNotPresent otherwise.
+	ImagePullPolicy PullPolicy `yaml:"imagePullPolicy"`
 	// List of ports to expose from the container.
 	Ports []uint16
-	// Compute Resources required by this container.
-	Resources map[ResourceName]uint64
+	// Compute resources requested by this container and the limits it is confined to.
+	Resources ResourceRequirements
 	// Entrypoint of the container. Equivalent to `docker run --entrypoint ...`.
 	// The container image's ENTRYPOINT is used if this is not provided.
 	Commands []string
 	// Pod volumes to mount into the container's filesystem.
 	VolumeMounts []VolumeMount `yaml:"volumeMounts"`
+	// SecurityContext holds the privileges of the container. Its user, group and seccomp profile
+	// override those of the pod.
+	SecurityContext *SecurityContext `yaml:"securityContext"`
 }
 
 // ContainerPort represents a network port in a single container.
@@ -39,18 +45,106 @@ type VolumeMount struct {
 	MountPath string `yaml:"mountPath"`
 }
 
+// SecurityContext holds the privileges and access control settings of a container.
+type SecurityContext struct {
+	// RunAsUser is the UID the entrypoint of the container runs as. Defaults to the user of the
+	// image.
+	RunAsUser *int64 `yaml:"runAsUser"`
+	// RunAsGroup is the GID the entrypoint of the container runs as. It requires RunAsUser.
+	RunAsGroup *int64 `yaml:"runAsGroup"`
+	// Privileged gives the container all the capabilities and access to the devices of the host.
+	Privileged bool
+	// ReadOnlyRootFilesystem mounts the root filesystem of the container as read-only.
+	ReadOnlyRootFilesystem bool `yaml:"readOnlyRootFilesystem"`
+	// AllowPrivilegeEscalation tells whether a process can gain more privileges than its parent,
+	// e.g. through setuid binaries. Setting it to false sets no_new_privs on the container.
+	AllowPrivilegeEscalation *bool `yaml:"allowPrivilegeEscalation"`
+	// Capabilities are the Linux capabilities added to or dropped from the default set.
+	Capabilities *Capabilities
+	// SeccompProfile is the seccomp profile of the container, see SeccompProfileRuntimeDefault.
+	SeccompProfile string `yaml:"seccompProfile"`
+}
+
+// Capabilities are Linux capabilities such as NET_ADMIN, with or without the CAP_ prefix. ALL
+// stands for every capability.
+type Capabilities struct {
+	Add  []string
+	Drop []string
+}
+
+// PodSecurityContext holds the settings applied to all the containers of a pod, unless a
+// container overrides them.
+type PodSecurityContext struct {
+	RunAsUser      *int64 `yaml:"runAsUser"`
+	RunAsGroup     *int64 `yaml:"runAsGroup"`
+	SeccompProfile string `yaml:"seccompProfile"`
+}
+
+// These are the valid seccomp profiles.
+const (
+	// SeccompProfileRuntimeDefault is the default profile of the container runtime. It is used if
+	// no profile is specified.
+	SeccompProfileRuntimeDefault = "RuntimeDefault"
+	// SeccompProfileUnconfined disables seccomp filtering.
+	SeccompProfileUnconfined = "Unconfined"
+	// SeccompProfileLocalhostPrefix is followed by the name of a profile file in the seccomp
+	// profile directory of the kubelet, e.g. localhost/audit.json.
+	SeccompProfileLocalhostPrefix = "localhost/"
+)
+
+// PullPolicy describes when the image of a container is pulled.
+type PullPolicy string
+
+// These are the valid pull policies of an image.
+const (
+	// PullAlways means the image is pulled every time a container of the pod is started.
+	PullAlways PullPolicy = "Always"
+	// PullIfNotPresent means the image is only pulled if it is not present on the node.
+	PullIfNotPresent PullPolicy = "IfNotPresent"
+	// PullNever means the image is never pulled, and the pod waits until it is present on the node.
+	PullNever PullPolicy = "Never"
+)
+
 // ResourceName is the name identifying various resources in a ResourceList that a single container can use.
 type ResourceName string
 
 // These are the valid types of resources that a docker container can be confined to.
 const (
-	// ResourceCPU represents the number of cores a container can use.
-	// Using half a core is not supported.
+	// ResourceCPU represents the number of cores a container can use, e.g. 2 or 500m for half
+	// a core.
 	ResourceCPU ResourceName = "cpu"
-	// ResourceMemory represents the memory in bytes that a container can use.
+	// ResourceMemory represents the memory in bytes that a container can use, e.g. 128974848 or
+	// 512Mi.
 	ResourceMemory ResourceName = "memory"
 )
 
+// ResourceList is a set of amounts of resources.
+type ResourceList map[ResourceName]Quantity
+
+// ResourceRequirements describes the compute resources of a container.
+type ResourceRequirements struct {
+	// Requests are the resources that the container needs. The scheduler only places a pod on a
+	// node that has the requests of all its containers left. A missing request defaults to the
+	// limit of the same resource.
+	Requests ResourceList
+	// Limits are the maximal resources that the container is allowed to use.
+	Limits ResourceList
+}
+
+// QOSClass is the quality of service of a pod, derived from the resources of its containers.
+// Pods of a lower class are killed first when the node runs out of memory.
+type QOSClass string
+
+// These are the valid QoS classes of a pod.
+const (
+	// QOSGuaranteed means every container has CPU and memory limits, and requests equal to them.
+	QOSGuaranteed QOSClass = "Guaranteed"
+	// QOSBurstable means the pod is neither Guaranteed nor BestEffort.
+	QOSBurstable QOSClass = "Burstable"
+	// QOSBestEffort means no container has any request or limit.
+	QOSBestEffort QOSClass = "BestEffort"
+)
+
 // Kind specified the category of an object.
 type Kind string
 
@@ -60,6 +154,10 @@ const (
 	PodType Kind = "Pod"
 	// DeploymentType means the resource is a deployment.
 	DeploymentType = "Deployment"
+	// ReplicaSetType means the resource is a replica set.
+	ReplicaSetType = "ReplicaSet"
+	// StatefulSetType means the resource is a stateful set.
+	StatefulSetType = "StatefulSet"
 	// NodeType means the resource is a node.
 	NodeType = "Node"
 	// ServiceType means the resource is a service
@@ -70,6 +168,12 @@ const (
 	JobType = "Job"
 	// AutoscalerType means the resource is an autoscaler.
 	AutoscalerType = "HorizontalPodAutoscaler"
+	// RegistryCredentialType means the resource is a credential of an image registry.
+	RegistryCredentialType = "RegistryCredential"
+	// ScheduledScalerType means the resource scales a deployment on a schedule.
+	ScheduledScalerType = "ScheduledScaler"
+	// VerticalAutoscalerType means the resource recommends the resources of containers.
+	VerticalAutoscalerType = "VerticalPodAutoscaler"
 )
 
 // PodPhase is a label for the condition of a pod at the current time.
@@ -118,6 +222,18 @@ type PodSpec struct {
 	Volumes []string
 	// Affinity is the name of a pod with which the pod would like to be together (on the same node).
 	Affinity string
+	// ImagePullSecrets are the names of the registry credentials used to pull the images of the
+	// containers. The credential whose server matches the registry of an image is used.
+	ImagePullSecrets []string `yaml:"imagePullSecrets"`
+	// SecurityContext holds the settings shared by the containers of the pod.
+	SecurityContext *PodSecurityContext `yaml:"securityContext"`
+	// NodeName is the name of the node the pod must run on, bypassing round robin. Empty if the
+	// pod can run on any node.
+	NodeName string `yaml:"nodeName"`
+	// VolumeClaims maps the names of volumes mounted by the containers to volumes that outlive the
+	// pod, such as the volume of each pod of a stateful set. The other volumes are created and
+	// removed along with the pod.
+	VolumeClaims map[string]string `yaml:"volumeClaims"`
 }
 
 // PodStatus represents information about the status of a pod.
@@ -130,8 +246,30 @@ type PodStatus struct {
 	PodIP string
 	// RunningContainers is the number of containers (aside from sandbox) that are running.
 	RunningContainers int
+	// A brief CamelCase message indicating why the pod is in this phase, e.g. 'Evicted'.
+	Reason string
+	// A human readable message indicating details about why the pod is in this phase.
+	Message string
+	// QOSClass is the quality of service of the pod, set when the pod is created.
+	QOSClass QOSClass
 }
 
+// These are the valid reasons of pod phases.
+const (
+	// PodReasonEvicted means the pod was stopped by the kubelet because its node was running out
+	// of resources.
+	PodReasonEvicted = "Evicted"
+	// PodReasonImagePullBackOff means the pod is pending because an image could not be pulled, and
+	// the kubelet is waiting before it tries again.
+	PodReasonImagePullBackOff = "ImagePullBackOff"
+	// PodReasonErrImageNeverPull means the pod is pending because an image is not present on the
+	// node and its pull policy is Never.
+	PodReasonErrImageNeverPull = "ErrImageNeverPull"
+	// PodReasonPullingImage means the pod is pending because its images are being pulled. The
+	// progress of the pull is in the message.
+	PodReasonPullingImage = "PullingImage"
+)
+
 // Pod is a collection of containers that can run on a host. This resource is created
 // by clients and scheduled onto hosts.
 type Pod struct {
@@ -148,6 +286,52 @@ type Pod struct {
 	Status PodStatus
 }
 
+// MirrorPodLabel marks a pod as the mirror of a static pod, which a kubelet runs from its manifest
+// directory without the api server. The value is the host name of the node. Mirror pods are
+// read-only on the api server.
+const MirrorPodLabel = "MirrorPodLabel"
+
+// PodTemplateHashLabel is added to the pods of a replica set owned by a deployment. The value is
+// the hash of their template that suffixes the name of the replica set, so that a service can
+// select the pods of one template.
+const PodTemplateHashLabel = "pod-template-hash"
+
+// PodLogOptions is the set of options used when streaming the log of a container in a pod.
+type PodLogOptions struct {
+	// Container is the name of the container whose log is streamed. May be empty if the pod
+	// has only one container.
+	Container string
+	// Follow keeps the stream open and sends new log lines as they are written.
+	Follow bool
+	// TailLines is the number of lines from the end of the log to show. Negative means all.
+	TailLines int64
+	// SinceSeconds only shows log lines newer than this many seconds. Zero means all.
+	SinceSeconds int64
+	// Timestamps prefixes each log line with its timestamp.
+	Timestamps bool
+	// Previous streams the log of the previous terminated instance of the container.
+	Previous bool
+}
+
+// ExecOptions is the set of options used when executing a command in a container of a pod.
+type ExecOptions struct {
+	// Container is the name of the container in which the command runs. May be empty if the pod
+	// has only one container.
+	Container string
+	// Command is the command to execute and its arguments.
+	Command []string
+	// Stdin attaches the standard input of the command.
+	Stdin bool
+	// TTY allocates a pseudo terminal for the command.
+	TTY bool
+}
+
+// TerminalSize is the size of a terminal in characters.
+type TerminalSize struct {
+	Width  uint16
+	Height uint16
+}
+
 // ServicePort is a set of ports that describes the port mapping of the service.
 type ServicePort struct {
 	// The port that will be exposed on the service. Pods in the cluster can find the
@@ -165,10 +349,18 @@ type ServiceSpec struct {
 	Ports []ServicePort
 	// Selector selects the pods whose labels match with the selector.
 	Selector map[string]string
-	// ClusterIP is the virtual IP address of the service and is assigned by the master.
-	ClusterIP string
+	// ClusterIP is the virtual IP address of the service and is assigned by the master. If it is
+	// None, the service is headless: it has no cluster IP, but each of its ready pods is given the
+	// DNS name <pod>.<service>.svc.cluster.local.
+	ClusterIP string `yaml:"clusterIP"`
 }
 
+// ClusterIPNone is the cluster IP of a headless service.
+const ClusterIPNone = "None"
+
+// ServiceDomain is the domain under which headless services name their pods.
+const ServiceDomain = "svc.cluster.local"
+
 // Service is a named abstraction of software service consisting of several pods. The pods can be
 // found in the cluster through the service abstraction (more specifically, cluster IP).
 type Service struct {
@@ -184,11 +376,81 @@ type Service struct {
 type DeploymentSpec struct {
 	// Replicas is the desired number of pods.
 	Replicas uint32
+	// Strategy is how the pods are replaced when the template changes, RollingUpdate, Recreate,
+	// Canary or BlueGreen. Defaults to RollingUpdate.
+	Strategy DeploymentStrategyType `yaml:"strategy"`
 	// RollingUpdate specifies how the deployment should be rolling updated.
 	RollingUpdate RollingUpdateSepc `yaml:"rollingUpdate"`
+	// Canary specifies the steps of the Canary strategy.
+	Canary CanaryStrategy `yaml:"canary"`
+	// BlueGreen specifies the services switched by the BlueGreen strategy.
+	BlueGreen BlueGreenStrategy `yaml:"blueGreen"`
+	// MinReadySeconds is how long a pod must stay ready before it counts as ready. Defaults to 0.
+	MinReadySeconds uint32 `yaml:"minReadySeconds"`
 	// Template is the object that describes the pod that will be created if
 	// insufficient replicas are detected.
 	Template PodTemplateSpec
+	// RevisionHistoryLimit is the number of old templates kept for rollback. Defaults to 10.
+	RevisionHistoryLimit uint32 `yaml:"revisionHistoryLimit"`
+	// ChangeCause describes why the template is changed. It is recorded in the revision history.
+	ChangeCause string `yaml:"changeCause"`
+	// Paused stops rolling out the template. Pods of the old template are kept until the deployment
+	// resumes.
+	Paused bool `yaml:"paused"`
+	// ProgressDeadlineSeconds is how long a rollout may make no progress before it is considered
+	// failed. Defaults to 600.
+	ProgressDeadlineSeconds uint32 `yaml:"progressDeadlineSeconds"`
+}
+
+// DeploymentStrategyType is how a deployment replaces its pods when its template changes.
+type DeploymentStrategyType string
+
+const (
+	// RollingUpdateDeploymentStrategy replaces the pods gradually, as bounded by maxSurge and
+	// maxUnavailable, so that both templates run for a while.
+	RollingUpdateDeploymentStrategy DeploymentStrategyType = "RollingUpdate"
+	// RecreateDeploymentStrategy deletes all the pods of the old template before creating any of
+	// the new one, so that the two templates never run at the same time.
+	RecreateDeploymentStrategy DeploymentStrategyType = "Recreate"
+	// CanaryDeploymentStrategy moves a growing share of the pods to the new template step by step,
+	// pausing between the steps for a while or until the deployment is promoted.
+	CanaryDeploymentStrategy DeploymentStrategyType = "Canary"
+	// BlueGreenDeploymentStrategy brings up a full set of pods of the new template next to the old
+	// ones, and switches the active service to them once promoted. The old pods are kept for
+	// rollback.
+	BlueGreenDeploymentStrategy DeploymentStrategyType = "BlueGreen"
+)
+
+// CanaryStrategy specifies how a deployment is rolled out with the Canary strategy.
+type CanaryStrategy struct {
+	// Steps are done in order. Once all are done, all the pods are moved to the new template.
+	Steps []CanaryStep `yaml:"steps"`
+}
+
+// CanaryStep is a step of a canary rollout, which either sets the share of the new template or
+// pauses the rollout.
+type CanaryStep struct {
+	// SetWeight is the percentage of the replicas moved to the new template, rounded up. The step
+	// is done once they are ready. Unused by pause steps.
+	SetWeight uint32 `yaml:"setWeight"`
+	// Pause makes the step a pause step.
+	Pause bool `yaml:"pause"`
+	// PauseSeconds is how long a pause step lasts. If it is 0, the step lasts until the deployment
+	// is promoted.
+	PauseSeconds uint32 `yaml:"pauseSeconds"`
+}
+
+// BlueGreenStrategy specifies how a deployment is rolled out with the BlueGreen strategy.
+type BlueGreenStrategy struct {
+	// ActiveService is the name of the service serving the deployment. Its selector is switched to
+	// the pods of the new template when the deployment is promoted.
+	ActiveService string `yaml:"activeService"`
+	// PreviewService is the name of an optional service whose selector is switched to the pods of
+	// the new template as soon as a rollout starts, so that they can be tested before promotion.
+	PreviewService string `yaml:"previewService"`
+	// AutoPromotionSeconds is how long the new pods must all be ready before the deployment is
+	// promoted on its own. If it is 0, the deployment waits to be promoted.
+	AutoPromotionSeconds uint32 `yaml:"autoPromotionSeconds"`
 }
 
 // RollingUpdateSpec specifies how a deployment should be updated when it's template or label changes.
@@ -219,6 +481,100 @@ type DeploymentStatus struct {
 	UpdatedReplicas uint32
 	// Total number of ready pods created by this deployment.
 	ReadyReplicas uint32
+	// Revision is the revision of the current template.
+	Revision uint64
+	// ObservedGeneration is the generation of the deployment last handled by the deployment
+	// controller. The status is up to date with the spec once it equals the generation.
+	ObservedGeneration uint64
+	// Conditions are the latest observations of the state of the deployment.
+	Conditions []DeploymentCondition
+	// CanaryStep is the index of the current step of a canary rollout. It equals the number of
+	// steps once all of them are done.
+	CanaryStep uint32
+	// StepStartTime is when the current step of a canary rollout started, or when the new pods of
+	// a blue-green rollout were all ready.
+	StepStartTime time.Time
+	// ActiveReplicaSet is the name of the replica set selected by the active service of a
+	// blue-green deployment.
+	ActiveReplicaSet string
+	// AwaitingPromotion tells whether the rollout is held by a canary pause step, or waits for a
+	// blue-green deployment to be promoted.
+	AwaitingPromotion bool
+}
+
+// ConditionStatus tells whether a condition holds.
+type ConditionStatus string
+
+const (
+	ConditionTrue    ConditionStatus = "True"
+	ConditionFalse   ConditionStatus = "False"
+	ConditionUnknown ConditionStatus = "Unknown"
+)
+
+// DeploymentConditionType is the type of a condition of a deployment.
+type DeploymentConditionType string
+
+const (
+	// DeploymentProgressing tells whether a rollout is progressing or has completed. It is false
+	// once the rollout makes no progress within the progress deadline, and unknown while the
+	// deployment is paused.
+	DeploymentProgressing DeploymentConditionType = "Progressing"
+	// DeploymentAvailable tells whether at least Replicas - MaxUnavailable pods are ready.
+	DeploymentAvailable DeploymentConditionType = "Available"
+)
+
+const (
+	// DeploymentReasonNewTemplate means a rollout of a new template has started.
+	DeploymentReasonNewTemplate = "NewTemplate"
+	// DeploymentReasonRollingUpdate means the rollout has made progress recently.
+	DeploymentReasonRollingUpdate = "RollingUpdate"
+	// DeploymentReasonRolloutComplete means all the pods are ready and of the current template.
+	DeploymentReasonRolloutComplete = "RolloutComplete"
+	// DeploymentReasonProgressDeadlineExceeded means the rollout has failed.
+	DeploymentReasonProgressDeadlineExceeded = "ProgressDeadlineExceeded"
+	// DeploymentReasonPaused means the rollout is paused.
+	DeploymentReasonPaused = "DeploymentPaused"
+	// DeploymentReasonResumed means the rollout has resumed.
+	DeploymentReasonResumed = "DeploymentResumed"
+	// DeploymentReasonAwaitingPromotion means the rollout is held by a canary pause step or waits
+	// for a blue-green deployment to be promoted.
+	DeploymentReasonAwaitingPromotion = "AwaitingPromotion"
+	// DeploymentReasonMinimumReplicasAvailable means enough pods are ready.
+	DeploymentReasonMinimumReplicasAvailable = "MinimumReplicasAvailable"
+	// DeploymentReasonMinimumReplicasUnavailable means too few pods are ready.
+	DeploymentReasonMinimumReplicasUnavailable = "MinimumReplicasUnavailable"
+)
+
+// DeploymentCondition describes the state of a deployment at a certain point.
+type DeploymentCondition struct {
+	// Type of the condition.
+	Type DeploymentConditionType
+	// Status of the condition, one of True, False and Unknown.
+	Status ConditionStatus
+	// Reason is a CamelCase word explaining the status.
+	Reason string
+	// Message is a human readable explanation of the status.
+	Message string
+	// LastUpdateTime is the last time the condition was updated. For Progressing, it is the last
+	// time the rollout made progress.
+	LastUpdateTime time.Time
+	// LastTransitionTime is the last time the status changed.
+	LastTransitionTime time.Time
+}
+
+// DeploymentRevision is a template that a deployment has rolled out, whose replica set is kept so
+// that the deployment can be rolled back to it.
+type DeploymentRevision struct {
+	// Revision increases by one each time the template of the deployment changes.
+	Revision uint64
+	// TemplateHash is the hash of the template, which also suffixes the name of its replica set.
+	TemplateHash string
+	// ChangeCause describes why the deployment was changed to the template.
+	ChangeCause string
+	// CreationTimestamp is when the replica set of the revision was created.
+	CreationTimestamp time.Time
+	// Template is the template of the revision.
+	Template PodTemplateSpec
 }
 
 // Deployment is a collection of pods that are monitored. It ensures the number of pods in a deployment is stable.
@@ -228,6 +584,8 @@ type Deployment struct {
 	// Standard object's metadata.
 	// For deployment, Label is unused.
 	ObjectMeta `yaml:"metadata"`
+	// Generation increases by one each time the spec changes. Populated by the system.
+	Generation uint64
 	// Specification of the desired behavior of the pod.
 	// Entirely populated by the user, though there might be default values..
 	// Currently the only source of a PodSpec is a yaml file.
@@ -237,6 +595,122 @@ type Deployment struct {
 	Status DeploymentStatus
 }
 
+// Scale is the number of replicas of a deployment, which is read and set apart from the rest of
+// the deployment.
+type Scale struct {
+	// Name is the name of the deployment.
+	Name string
+	// Spec is the desired number of replicas.
+	Spec ScaleSpec
+	// Status is the observed number of replicas. Populated by the system.
+	Status ScaleStatus
+}
+
+// ScaleSpec is the desired number of replicas of a deployment.
+type ScaleSpec struct {
+	// Replicas is the desired number of pods.
+	Replicas uint32
+}
+
+// ScaleStatus is the observed number of replicas of a deployment.
+type ScaleStatus struct {
+	// Replicas is the number of pods of the deployment, of any template.
+	Replicas uint32
+	// ReadyReplicas is the number of ready pods of the deployment.
+	ReadyReplicas uint32
+}
+
+// ReplicaSetSpec is the set of properties of a replica set that can be specified using a yaml file.
+type ReplicaSetSpec struct {
+	// Replicas is the desired number of pods.
+	Replicas uint32
+	// MinReadySeconds is how long a pod must stay ready before it counts as ready. Defaults to 0.
+	MinReadySeconds uint32 `yaml:"minReadySeconds"`
+	// Template is the object that describes the pods of the replica set. Changing it does not
+	// affect the existing pods.
+	Template PodTemplateSpec
+}
+
+// ReplicaSetStatus holds information about the observed status of a replica set.
+type ReplicaSetStatus struct {
+	// Total number of pods created by this replica set. They need not be ready.
+	Replicas uint32
+	// Total number of pods created by this replica set that have stayed ready for MinReadySeconds.
+	ReadyReplicas uint32
+}
+
+// ReplicaSet keeps a stable number of pods of a single template. Deployments roll out their
+// templates through the replica sets they own, and replica sets can also be created on their own.
+type ReplicaSet struct {
+	// The type of a replica set is ReplicaSet.
+	Kind
+	// Standard object's metadata.
+	ObjectMeta `yaml:"metadata"`
+	// Specification of the desired behavior of the replica set.
+	Spec ReplicaSetSpec `yaml:"spec"`
+	// Status is the most recently observed status of the replica set.
+	// Entirely populated by the system.
+	Status ReplicaSetStatus
+	// OwnerName is the name of the deployment owning the replica set, or empty if the replica set
+	// was created on its own. Populated by the system.
+	OwnerName string
+	// TemplateHash is the hash of the template of a replica set owned by a deployment, which
+	// suffixes its name. Populated by the system.
+	TemplateHash string
+	// Revision is the revision of the deployment that last rolled out the template of the replica
+	// set. Populated by the system.
+	Revision uint64
+	// ChangeCause describes why the deployment rolled out the template. Populated by the system.
+	ChangeCause string
+}
+
+// StatefulSetSpec is the set of properties of a stateful set that can be specified using a yaml
+// file.
+type StatefulSetSpec struct {
+	// Replicas is the desired number of pods. The pods are named <name>-0 to <name>-<Replicas-1>.
+	Replicas uint32
+	// ServiceName is the name of the headless service giving the pods their DNS names. It must
+	// select the pods by the labels of the template.
+	ServiceName string `yaml:"serviceName"`
+	// VolumeClaimTemplates are the names of the volumes of which each pod gets its own, named
+	// <volume>-<pod>. The volume of a pod outlives it, and is mounted again by the pod of the same
+	// ordinal, which runs on the same node.
+	VolumeClaimTemplates []string `yaml:"volumeClaimTemplates"`
+	// Template is the object that describes the pods of the stateful set. Changing it does not
+	// affect the existing pods.
+	Template PodTemplateSpec
+}
+
+// StatefulSetStatus holds information about the observed status of a stateful set.
+type StatefulSetStatus struct {
+	// Total number of pods of this stateful set. They need not be ready.
+	Replicas uint32
+	// Total number of ready pods of this stateful set.
+	ReadyReplicas uint32
+	// ClaimNodes are the names of the nodes holding the volume claims of the pods, indexed by pod
+	// name.
+	ClaimNodes map[string]string
+}
+
+// StatefulSet keeps pods with stable names and storage. Its pods are created one at a time in the
+// order of their ordinals, each waiting for the previous one to be ready, and deleted in reverse
+// order.
+type StatefulSet struct {
+	// The type of a stateful set is StatefulSet.
+	Kind
+	// Standard object's metadata.
+	ObjectMeta `yaml:"metadata"`
+	// Specification of the desired behavior of the stateful set.
+	Spec StatefulSetSpec `yaml:"spec"`
+	// Status is the most recently observed status of the stateful set.
+	// Entirely populated by the system.
+	Status StatefulSetStatus
+}
+
+// StatefulSetLabel is added to the pods of a stateful set. The value is the name of the stateful
+// set.
+const StatefulSetLabel = "statefulset"
+
 // The status of master node including apiserver ip and port.
 type ApiserverStatus struct {
 	// Apiserver IP
@@ -273,6 +747,19 @@ const (
 	NodeUnavailable NodeCondition = "Unavailable"
 )
 
+// NodePressure is a resource that a node is running out of.
+type NodePressure string
+
+// These are valid pressures of node.
+const (
+	// NodeMemoryPressure means the available memory of the node is below the eviction threshold.
+	NodeMemoryPressure NodePressure = "MemoryPressure"
+	// NodeDiskPressure means the free disk space of the node is below the eviction threshold.
+	NodeDiskPressure NodePressure = "DiskPressure"
+	// NodePIDPressure means the number of available process IDs is below the eviction threshold.
+	NodePIDPressure NodePressure = "PIDPressure"
+)
+
 // NodeStatus represents information about the status of a node.
 type NodeStatus struct {
 	// NodePhase is a simple, high-level summary of where the node is in its lifecycle.
@@ -284,6 +771,12 @@ type NodeStatus struct {
 	Address string
 	// Port of the kubelet grpc server on node
 	Port uint16 `json:"kubeletPort"`
+	// Pressures are the resources that the node is running out of, as reported by the kubelet.
+	// Pods are not scheduled on a node under pressure.
+	Pressures []NodePressure
+	// Capacity is the CPU and memory of the node, as reported by the kubelet. Pods are only
+	// scheduled on a node whose capacity covers their requests.
+	Capacity ResourceList
 }
 
 // Node represents a host machine where Pods are actually running.
@@ -298,6 +791,102 @@ type Node struct {
 	Status NodeStatus
 }
 
+// StatsSummary is a snapshot of the resource usage of a node and the pods running on it, as
+// collected by its kubelet.
+type StatsSummary struct {
+	// NodeName is the name of the node, filled in by the api server.
+	NodeName string
+	// Timestamp is when the stats were collected.
+	Timestamp time.Time
+	Node      NodeStats
+	Pods      []PodStats
+}
+
+// NodeStats is the resource usage of a whole node.
+type NodeStats struct {
+	CPU    CPUStats
+	Memory MemoryStats
+	// Fs is the usage of the root filesystem of the node.
+	Fs FsStats
+}
+
+// PodStats is the resource usage of a pod, which is the sum of that of its containers.
+type PodStats struct {
+	Name       string
+	Containers []ContainerStats
+	CPU        CPUStats
+	Memory     MemoryStats
+	// Network is the traffic of the pod, which all its containers share.
+	Network NetworkStats
+	// Fs is the usage of the writable layers of the containers of the pod.
+	Fs FsStats
+}
+
+// ContainerStats is the resource usage of a container.
+type ContainerStats struct {
+	Name   string
+	CPU    CPUStats
+	Memory MemoryStats
+	// Fs is the usage of the writable layer of the container.
+	Fs FsStats
+}
+
+// CPUStats is the CPU usage of a node, pod or container.
+type CPUStats struct {
+	// UsageNanoCores is the average CPU usage over the last sampling interval, in billionths of a
+	// core.
+	UsageNanoCores uint64
+	// UsageCoreNanoSeconds is the cumulative CPU time consumed.
+	UsageCoreNanoSeconds uint64
+}
+
+// MemoryStats is the memory usage of a node, pod or container.
+type MemoryStats struct {
+	UsageBytes uint64
+	// WorkingSetBytes is the memory that cannot be reclaimed under pressure, which is the usage
+	// without the inactive page cache. It is what the eviction manager and kubectl top look at.
+	WorkingSetBytes uint64
+}
+
+// NetworkStats is the cumulative traffic of all the interfaces of a pod.
+type NetworkStats struct {
+	RxBytes uint64
+	TxBytes uint64
+}
+
+// FsStats is the usage of a filesystem. The capacity and available bytes are those of the
+// filesystem holding the data, which may be shared.
+type FsStats struct {
+	UsedBytes      uint64
+	CapacityBytes  uint64
+	AvailableBytes uint64
+}
+
+// NodeMetrics is the current resource usage of a node, as served by the metrics API.
+type NodeMetrics struct {
+	Name      string
+	Timestamp time.Time
+	// Usage is the CPU usage in cores and the memory working set in bytes.
+	Usage ResourceList
+	// Capacity is the CPU and memory of the node, if the kubelet has reported it.
+	Capacity ResourceList
+}
+
+// PodMetrics is the current resource usage of a pod, as served by the metrics API.
+type PodMetrics struct {
+	Name      string
+	Timestamp time.Time
+	// Usage is the sum of the usage of the containers of the pod.
+	Usage      ResourceList
+	Containers []ContainerMetrics
+}
+
+// ContainerMetrics is the current resource usage of a container.
+type ContainerMetrics struct {
+	Name  string
+	Usage ResourceList
+}
+
 // ClusterWithName wraps a cluster with its name
 type ClusterWithName struct {
 	// Server is the URL of the apiserver, default is localhost
@@ -390,13 +979,61 @@ type ScaleTarget struct {
 	Name string
 }
 
-// Metric contains the resource that an autoscaler needs to monitor on and its maximal
-// utilization per pod.
+// Metric contains what an autoscaler needs to monitor on and its target.
 type Metric struct {
-	// Resource is the type of resource that an autoscaler watches on.
+	// Type is the kind of metric. Defaults to Resource.
+	Type MetricType
+	// Resource is the type of resource that an autoscaler watches on, if Type is Resource.
 	Resource ResourceName
-	// TargetUtilization is the resource's maximal utilization per pod.
+	// TargetUtilization is the resource's maximal utilization per pod, if Type is Resource.
 	TargetUtilization uint64 `yaml:"targetUtilization"`
+	// Pods is the metric of each pod, if Type is Pods.
+	Pods *PodsMetricSource
+	// External is the metric not tied to any pod, if Type is External.
+	External *ExternalMetricSource
+}
+
+// MetricType is the kind of metric an autoscaler watches on.
+type MetricType string
+
+// These are the valid types of metrics.
+const (
+	// MetricTypeResource is the CPU or memory usage of the pods.
+	MetricTypeResource MetricType = "Resource"
+	// MetricTypePods is a custom metric of each pod, e.g. requests per second, averaged over the
+	// pods.
+	MetricTypePods MetricType = "Pods"
+	// MetricTypeExternal is a metric outside of the pods, e.g. the depth of a queue.
+	MetricTypeExternal MetricType = "External"
+)
+
+// PodsMetricSource is a custom metric of each pod of the target. The autoscaler keeps its average
+// over the pods at TargetAverageValue.
+type PodsMetricSource struct {
+	// MetricName is the name of the metric, whose series are labelled with the name of the pod,
+	// e.g. http_requests_per_second{pod="web-1"}.
+	MetricName string `yaml:"metricName"`
+	// Query is a PromQL query used instead of MetricName. Every $pod in it is replaced with the
+	// name of the pod, and every $window with the query window.
+	Query string
+	// TargetAverageValue is the desired value of the metric per pod.
+	TargetAverageValue float64 `yaml:"targetAverageValue"`
+}
+
+// ExternalMetricSource is a metric not tied to any pod. The autoscaler keeps either the metric
+// at TargetValue, or the metric divided by the number of pods at TargetAverageValue.
+type ExternalMetricSource struct {
+	// MetricName is the name of the metric. The series matching Selector are summed.
+	MetricName string `yaml:"metricName"`
+	// Selector selects the series of the metric by their labels.
+	Selector map[string]string
+	// Query is a PromQL query used instead of MetricName. Every $window in it is replaced with the
+	// query window.
+	Query string
+	// TargetValue is the desired value of the metric.
+	TargetValue *float64 `yaml:"targetValue"`
+	// TargetAverageValue is the desired value of the metric per pod.
+	TargetAverageValue *float64 `yaml:"targetAverageValue"`
 }
 
 // AutoscalerSpec describes the attributes of an autoscaler configuration.
@@ -410,9 +1047,92 @@ type AutoscalerSpec struct {
 	// ScaleInterval is the interval at which an autoscaler scales in seconds.
 	// The minimum is 12s.
 	ScaleInterval int64 `yaml:"scaleInterval"`
-	// Metrics is the metrics that autoscaler needs to monitor on. Only CPU and memory
-	// are supported.
+	// Metrics is the metrics that autoscaler needs to monitor on. The number of replicas is the
+	// highest of those recommended by the metrics.
 	Metrics []Metric
+	// Tolerance is how far the ratio of the usage to the target of a metric may deviate from 1
+	// before the autoscaler scales, e.g. 0.1 ignores deviations within 10%. Defaults to 0.1.
+	Tolerance *float64
+	// Behavior configures how fast the autoscaler scales up and down.
+	Behavior AutoscalerBehavior
+}
+
+// AutoscalerBehavior configures the scaling up and down of an autoscaler separately.
+type AutoscalerBehavior struct {
+	// ScaleUp defaults to no stabilization, and to adding 4 pods or doubling the pods every 15
+	// seconds, whichever is more.
+	ScaleUp *ScalingRules `yaml:"scaleUp"`
+	// ScaleDown defaults to a stabilization window of 300 seconds, and to removing any number of
+	// pods every 15 seconds.
+	ScaleDown *ScalingRules `yaml:"scaleDown"`
+}
+
+// ScalingRules limit how fast an autoscaler scales in one direction.
+type ScalingRules struct {
+	// StabilizationWindowSeconds is the period over which past recommendations are considered, so
+	// that the number of replicas does not flap. When scaling down the highest recommendation in
+	// the window is used, and when scaling up the lowest.
+	StabilizationWindowSeconds *int32 `yaml:"stabilizationWindowSeconds"`
+	// SelectPolicy tells which policy applies when there are several. Defaults to Max.
+	SelectPolicy ScalingPolicySelect `yaml:"selectPolicy"`
+	// Policies limit the change of replicas over a period.
+	Policies []ScalingPolicy
+}
+
+// ScalingPolicySelect tells which of the scaling policies applies.
+type ScalingPolicySelect string
+
+// These are the valid selections of scaling policies.
+const (
+	// ScalingPolicySelectMax selects the policy allowing the largest change of replicas.
+	ScalingPolicySelectMax ScalingPolicySelect = "Max"
+	// ScalingPolicySelectMin selects the policy allowing the smallest change of replicas.
+	ScalingPolicySelectMin ScalingPolicySelect = "Min"
+	// ScalingPolicySelectDisabled disables scaling in the direction.
+	ScalingPolicySelectDisabled ScalingPolicySelect = "Disabled"
+)
+
+// ScalingPolicy limits the change of replicas over a period, either as a number of pods or as a
+// percentage of the replicas at the start of the period.
+type ScalingPolicy struct {
+	Type ScalingPolicyType
+	// Value is the number of pods or the percentage. It must be positive.
+	Value int32
+	// PeriodSeconds is the length of the period, between 1 and 1800 seconds.
+	PeriodSeconds int32 `yaml:"periodSeconds"`
+}
+
+// ScalingPolicyType is the unit of a scaling policy.
+type ScalingPolicyType string
+
+// These are the valid types of scaling policies.
+const (
+	ScalingPolicyPods    ScalingPolicyType = "Pods"
+	ScalingPolicyPercent ScalingPolicyType = "Percent"
+)
+
+// AutoscalerStatus is the most recently observed state of an autoscaler.
+type AutoscalerStatus struct {
+	// CurrentReplicas is the number of pods of the target when last observed.
+	CurrentReplicas uint32
+	// DesiredReplicas is the number of pods the autoscaler last asked the target for.
+	DesiredReplicas uint32
+	// LastScaleTime is when the autoscaler last changed the number of replicas.
+	LastScaleTime *time.Time
+	// CurrentMetrics are the average usage per pod last observed for each metric.
+	CurrentMetrics []MetricStatus
+}
+
+// MetricStatus is the observed value of a metric.
+type MetricStatus struct {
+	Type MetricType
+	// Resource is set for resource metrics, and Name for pods and external metrics.
+	Resource ResourceName
+	Name     string
+	// CurrentAverage is the average value per pod, in cores for CPU and in bytes for memory.
+	CurrentAverage float64
+	// CurrentValue is the total value of an external metric targeting a value.
+	CurrentValue float64
 }
 
 // HorizontalPodAutoscaler monitors an object and do pod scaling out or scaling in when the
@@ -424,4 +1144,150 @@ type HorizontalPodAutoscaler struct {
 	ObjectMeta `yaml:"metadata"`
 	// AutoscalerSpec is the desired autoscaler configuration.
 	Spec AutoscalerSpec
+	// Status is the most recently observed state of the autoscaler.
+	Status AutoscalerStatus
+}
+
+// ScalingSchedule bounds the replicas of a target from the time its cron expression fires until
+// another schedule of the same scheduled scaler fires.
+type ScalingSchedule struct {
+	// Name identifies the schedule in the status.
+	Name string
+	// Schedule is a cron expression of five fields: minute, hour, day of month, month and day of
+	// week, e.g. "0 8 * * mon-fri".
+	Schedule string
+	// MinReplicas is the fewest replicas of the target while the schedule is active.
+	MinReplicas uint32 `yaml:"minReplicas"`
+	// MaxReplicas is the most replicas of the target while the schedule is active. No limit if 0.
+	MaxReplicas uint32 `yaml:"maxReplicas"`
+}
+
+// ScheduledScalerSpec describes when and how a scheduled scaler scales its target.
+type ScheduledScalerSpec struct {
+	// ScaleTargetRef describes the target of a scheduled scaler. Only deployment is supported.
+	ScaleTargetRef ScaleTarget `yaml:"scaleTargetRef"`
+	// TimeZone is the IANA name of the time zone of the schedules, e.g. Asia/Shanghai. Defaults
+	// to UTC.
+	TimeZone string `yaml:"timeZone"`
+	// Schedules are the schedules of the target. The one that fired last is active.
+	Schedules []ScalingSchedule
+}
+
+// ScheduledScalerStatus is the most recently observed state of a scheduled scaler.
+type ScheduledScalerStatus struct {
+	// ActiveSchedule is the name of the schedule that fired last, if any.
+	ActiveSchedule string
+	// LastScheduleTime is when the active schedule fired.
+	LastScheduleTime *time.Time
+	// NextScheduleTime is when a schedule fires next.
+	NextScheduleTime *time.Time
+}
+
+// ScheduledScaler sets the number of replicas of a deployment according to cron schedules. If the
+// deployment has an autoscaler, the autoscaler scales within the bounds of the active schedule
+// instead.
+type ScheduledScaler struct {
+	// The type of a scheduled scaler is ScheduledScaler.
+	Kind
+	// Standard object's meta. Only name is used.
+	ObjectMeta `yaml:"metadata"`
+	// Spec is the desired configuration of the scheduled scaler.
+	Spec ScheduledScalerSpec
+	// Status is the most recently observed state of the scheduled scaler.
+	Status ScheduledScalerStatus
+}
+
+// VerticalUpdateMode tells whether a vertical autoscaler applies its recommendations.
+type VerticalUpdateMode string
+
+// These are the valid update modes of a vertical autoscaler.
+const (
+	// VerticalUpdateOff only publishes the recommendations in the status.
+	VerticalUpdateOff VerticalUpdateMode = "Off"
+	// VerticalUpdateAuto also applies the recommendations to the template of the target by a
+	// rolling update, when the requests of the target fall out of the recommended bounds.
+	VerticalUpdateAuto VerticalUpdateMode = "Auto"
+)
+
+// ContainerResourcePolicy bounds the recommendations for a container.
+type ContainerResourcePolicy struct {
+	// ContainerName is the name of the container in the template of the target.
+	ContainerName string `yaml:"containerName"`
+	// MinAllowed is the least that may be recommended for each resource.
+	MinAllowed ResourceList `yaml:"minAllowed"`
+	// MaxAllowed is the most that may be recommended for each resource.
+	MaxAllowed ResourceList `yaml:"maxAllowed"`
+}
+
+// VerticalAutoscalerSpec describes what a vertical autoscaler observes and how it recommends.
+type VerticalAutoscalerSpec struct {
+	// TargetRef describes the target of a vertical autoscaler. Only deployment is supported.
+	TargetRef ScaleTarget `yaml:"targetRef"`
+	// UpdateMode is Off or Auto. Defaults to Off.
+	UpdateMode VerticalUpdateMode `yaml:"updateMode"`
+	// SampleInterval is the interval at which the usage of the containers is sampled in seconds.
+	// Defaults to 60, and the minimum is 10.
+	SampleInterval int64 `yaml:"sampleInterval"`
+	// ResourcePolicies bound the recommendations for some of the containers.
+	ResourcePolicies []ContainerResourcePolicy `yaml:"resourcePolicies"`
+}
+
+// ContainerRecommendation is the resources recommended for a container.
+type ContainerRecommendation struct {
+	ContainerName string
+	// Target is the recommended requests.
+	Target ResourceList
+	// Limits are the recommended limits, keeping the ratio of the limits to the requests in the
+	// template. Resources without a limit in the template have none recommended.
+	Limits ResourceList
+	// LowerBound and UpperBound are the least and the most requests that are not worth
+	// changing.
+	LowerBound ResourceList
+	UpperBound ResourceList
+	// Samples is the number of usage samples the recommendation is based on.
+	Samples int
+}
+
+// VerticalAutoscalerStatus is the most recently observed state of a vertical autoscaler.
+type VerticalAutoscalerStatus struct {
+	// Recommendations are those of each container of the target.
+	Recommendations []ContainerRecommendation
+	// LastUpdateTime is when the recommendations were last updated.
+	LastUpdateTime *time.Time
+	// LastApplyTime is when the recommendations were last applied to the target.
+	LastApplyTime *time.Time
+}
+
+// VerticalPodAutoscaler observes the CPU and memory usage of the containers of a deployment over
+// time and recommends their resources.
+type VerticalPodAutoscaler struct {
+	// The type of a vertical autoscaler is VerticalPodAutoscaler.
+	Kind
+	// Standard object's meta. Only name is used.
+	ObjectMeta `yaml:"metadata"`
+	// Spec is the desired configuration of the vertical autoscaler.
+	Spec VerticalAutoscalerSpec
+	// Status is the most recently observed state of the vertical autoscaler.
+	Status VerticalAutoscalerStatus
+}
+
+// RegistryCredentialSpec is the login to an image registry.
+type RegistryCredentialSpec struct {
+	// Server is the address of the registry, e.g. docker.io or registry.example.com:5000.
+	Server string
+	// Username is the user to log in as.
+	Username string
+	// Password is the password or access token of the user.
+	Password string
+}
+
+// RegistryCredential allows pods to pull images from a private registry by referencing it in
+// their image pull secrets.
+type RegistryCredential struct {
+	// The type of a registry credential is RegistryCredential.
+	Kind
+	// Standard object's meta. Only name is used.
+	ObjectMeta `yaml:"metadata"`
+	// Spec is the login to the registry.
+	Spec RegistryCredentialSpec
 }
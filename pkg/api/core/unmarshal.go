@@ -1,6 +1,10 @@
 package core
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
 	"github.com/creasty/defaults"
 )
 
@@ -36,3 +40,83 @@ func (cwn *ClusterWithName) UnmarshalYAML(unmarshal func(interface{}) error) err
 	}
 	return nil
 }
+
+// UnmarshalYAML also accepts the flat resources used before requests and limits were split, such
+// as `resources: {cpu: 2}`, and takes them as both the limits and the requests.
+func (r *ResourceRequirements) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var raw map[string]interface{}
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	flat, err := isFlatResources(keys)
+	if err != nil {
+		return err
+	}
+	if flat {
+		var limits ResourceList
+		if err := unmarshal(&limits); err != nil {
+			return err
+		}
+		*r = flatResourceRequirements(limits)
+		return nil
+	}
+	type alias ResourceRequirements
+	return unmarshal((*alias)(r))
+}
+
+// UnmarshalJSON also accepts the flat resources used before requests and limits were split, which
+// pods stored by earlier versions still carry.
+func (r *ResourceRequirements) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	flat, err := isFlatResources(keys)
+	if err != nil {
+		return err
+	}
+	if flat {
+		var limits ResourceList
+		if err := json.Unmarshal(data, &limits); err != nil {
+			return err
+		}
+		*r = flatResourceRequirements(limits)
+		return nil
+	}
+	type alias ResourceRequirements
+	return json.Unmarshal(data, (*alias)(r))
+}
+
+// isFlatResources tells whether the keys of container resources are resource names rather than
+// requests and limits. Mixing both is an error.
+func isFlatResources(keys []string) (bool, error) {
+	structured, flat := false, false
+	for _, key := range keys {
+		switch strings.ToLower(key) {
+		case "requests", "limits":
+			structured = true
+		default:
+			flat = true
+		}
+	}
+	if structured && flat {
+		return false, fmt.Errorf("resources mix requests and limits with resource names: %v", keys)
+	}
+	return flat, nil
+}
+
+func flatResourceRequirements(limits ResourceList) ResourceRequirements {
+	requests := make(ResourceList, len(limits))
+	for name, limit := range limits {
+		requests[name] = limit
+	}
+	return ResourceRequirements{Requests: requests, Limits: limits}
+}
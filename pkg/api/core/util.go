@@ -3,6 +3,7 @@ package core
 import (
 	"container/list"
 	"fmt"
+	"strings"
 )
 
 // GetPodSpecificName prepends the name of any resource, be it container, volume
@@ -15,6 +16,29 @@ func GetPodSpecificPauseName(pod *Pod) string {
 	return GetPodSpecificName(pod, "pause")
 }
 
+// IsMirrorPod tells whether the pod is the mirror of a static pod.
+func IsMirrorPod(pod *Pod) bool {
+	_, ok := pod.Labels[MirrorPodLabel]
+	return ok
+}
+
+// ContainerPullPolicy returns the image pull policy of a container, defaulting to Always for
+// images without a tag or tagged latest, and to IfNotPresent for any other image.
+func ContainerPullPolicy(c *Container) PullPolicy {
+	if c.ImagePullPolicy != "" {
+		return c.ImagePullPolicy
+	}
+	if strings.Contains(c.Image, "@") {
+		return PullIfNotPresent
+	}
+	// A colon after the last slash separates the tag, while a colon before it is a registry port.
+	name := c.Image[strings.LastIndex(c.Image, "/")+1:]
+	if i := strings.LastIndex(name, ":"); i >= 0 && name[i+1:] != "latest" {
+		return PullIfNotPresent
+	}
+	return PullAlways
+}
+
 func GetPodNames(pods *list.List) []string {
 	podNames := make([]string, 0, pods.Len())
 	for e := pods.Front(); e != nil; e = e.Next() {
@@ -22,3 +46,17 @@ func GetPodNames(pods *list.List) []string {
 	}
 	return podNames
 }
+
+// PodTemplateHash returns the value of the pod-template-hash label on the pods of a replica set
+// owned by a deployment, or "" for a standalone replica set.
+func PodTemplateHash(replicaSet *ReplicaSet) string {
+	if len(replicaSet.TemplateHash) < 10 {
+		return replicaSet.TemplateHash
+	}
+	return replicaSet.TemplateHash[0:10]
+}
+
+// PodDomainName returns the DNS name given to a pod by a headless service.
+func PodDomainName(podName string, serviceName string) string {
+	return fmt.Sprintf("%v.%v.%v", podName, serviceName, ServiceDomain)
+}
@@ -69,6 +69,33 @@ func (c *ApiserverClient) GetPodLog(name string) (*pb.KubeletGetPodLogResponse,
 	return c.kubeletClient.GetPodLog(ctx, &pb.KubeletGetPodLogRequest{PodName: name})
 }
 
+// StreamPodLog opens a log stream of a container in a pod. The stream is closed when ctx is done.
+func (c *ApiserverClient) StreamPodLog(
+	ctx context.Context,
+	podName string,
+	opts *core.PodLogOptions,
+) (pb.KubeletApiServerService_StreamPodLogClient, error) {
+	return c.kubeletClient.StreamPodLog(ctx, &pb.KubeletStreamPodLogRequest{
+		PodName:       podName,
+		ContainerName: opts.Container,
+		Follow:        opts.Follow,
+		TailLines:     opts.TailLines,
+		SinceSeconds:  opts.SinceSeconds,
+		Timestamps:    opts.Timestamps,
+		Previous:      opts.Previous,
+	})
+}
+
+// Exec opens an exec stream to the kubelet. The stream is closed when ctx is done.
+func (c *ApiserverClient) Exec(ctx context.Context) (pb.KubeletApiServerService_ExecClient, error) {
+	return c.kubeletClient.Exec(ctx)
+}
+
+// PortForward opens a port forward stream to the kubelet. The stream is closed when ctx is done.
+func (c *ApiserverClient) PortForward(ctx context.Context) (pb.KubeletApiServerService_PortForwardClient, error) {
+	return c.kubeletClient.PortForward(ctx)
+}
+
 func (c *ApiserverClient) CreateService(service *core.Service, pods *list.List) (*pb.DefaultResponse, error) {
 	ctx := context.Background()
 	servicePorts := make([][]byte, 0, len(service.Spec.Ports))
@@ -119,3 +146,31 @@ func (c *ApiserverClient) DeletePodFromServices(serviceNames []string, podName s
 		PodName:      podName,
 	})
 }
+
+func (c *ApiserverClient) SetServicePods(serviceName string, podNames []string, podIPs []string) (*pb.DefaultResponse, error) {
+	ctx := context.Background()
+	return c.kubeletClient.SetServicePods(ctx, &pb.KubeletSetServicePodsRequest{
+		ServiceName: serviceName,
+		PodNames:    podNames,
+		PodIps:      podIPs,
+	})
+}
+
+// STATS_TIMEOUT bounds the collection of stats by a kubelet, which samples the CPU usage of every
+// container for about a second.
+var STATS_TIMEOUT time.Duration = 10 * time.Second
+
+// GetStatsSummary gets the resource usage of the node and its pods from the kubelet.
+func (c *ApiserverClient) GetStatsSummary() (*core.StatsSummary, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), STATS_TIMEOUT)
+	defer cancel()
+	resp, err := c.kubeletClient.GetStatsSummary(ctx, &pb.EmptyRequest{})
+	if err != nil {
+		return nil, err
+	}
+	var summary core.StatsSummary
+	if err := json.Unmarshal(resp.Summary, &summary); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
@@ -29,11 +29,12 @@ type ComponentManager interface {
 	// exactly with the given phase.
 	ListPodsByLabelsAndPhase(labels *map[string]string, phase core.PodPhase) *list.List
 
-	// SetDeployment sets a deployment and the pods it creates into ComponentManager. This
-	// function will not check the existence of the deployment.
-	SetDeployment(deployment *core.Deployment, pods *list.List)
-	// DeleteDeploymentByName deletes a deployment by its name as well as all of the pods it creates
-	// from ComponentManager. This function will not check the existence of the deployment.
+	// SetDeployment sets a deployment into ComponentManager. Its pods belong to the replica sets
+	// it owns. This function will not check the existence of the deployment.
+	SetDeployment(deployment *core.Deployment)
+	// DeleteDeploymentByName deletes a deployment by its name from ComponentManager. The replica
+	// sets it owns are left to the caller. This function will not check the existence of the
+	// deployment.
 	DeleteDeploymentByName(deploymentName string)
 	// GetDeploymentByName gets a deployment from ComponentManager by name.
 	GetDeploymentByName(name string) *core.Deployment
@@ -41,15 +42,51 @@ type ComponentManager interface {
 	DeploymentExistsByName(name string) bool
 	// ListDeployments lists all the deployments present.
 	ListDeployments() []*core.Deployment
-	// ListPodsByDeployment lists all the pods given the name of a deployment. This function will not
-	// check the existence of the deployment. If the deployment does not exist, an empty array will be
-	// returned.
+	// ListPodsByDeployment lists all the pods of the replica sets owned by a deployment. This function
+	// will not check the existence of the deployment. If the deployment does not exist, an empty list
+	// will be returned. Unlike ListPodsByReplicaSetName, the list is a copy.
 	ListPodsByDeploymentName(deploymentName string) *list.List
 	// GetDeploymentByPod gets the deployment a pod belongs to by the name of the pod. This function will not
 	// check the existence of the pod. If the pod does not belong to any deployment, the function will return
 	// nil.
 	GetDeploymentByPodName(podName string) *core.Deployment
 
+	// SetReplicaSet sets a replica set and the pods it creates into ComponentManager. This
+	// function will not check the existence of the replica set.
+	SetReplicaSet(replicaSet *core.ReplicaSet, pods *list.List)
+	// DeleteReplicaSetByName deletes a replica set by its name as well as all of the pods it
+	// creates from ComponentManager. This function will not check the existence of the replica set.
+	DeleteReplicaSetByName(replicaSetName string)
+	// GetReplicaSetByName gets a replica set from ComponentManager by name.
+	GetReplicaSetByName(name string) *core.ReplicaSet
+	// ReplicaSetExistsByName checks whether a replica set of a specific name exists.
+	ReplicaSetExistsByName(name string) bool
+	// ListReplicaSets lists all the replica sets present.
+	ListReplicaSets() []*core.ReplicaSet
+	// ListReplicaSetsByDeploymentName lists the replica sets owned by a deployment.
+	ListReplicaSetsByDeploymentName(deploymentName string) []*core.ReplicaSet
+	// ListPodsByReplicaSetName lists all the pods given the name of a replica set. This function
+	// will not check the existence of the replica set. If the replica set does not exist, nil will
+	// be returned.
+	ListPodsByReplicaSetName(replicaSetName string) *list.List
+	// GetReplicaSetByPodName gets the replica set a pod belongs to by the name of the pod. If the
+	// pod does not belong to any replica set, the function will return nil.
+	GetReplicaSetByPodName(podName string) *core.ReplicaSet
+
+	// SetStatefulSet sets a stateful set into ComponentManager. This function will not check the
+	// existence of the stateful set. Its pods are set on their own.
+	SetStatefulSet(statefulSet *core.StatefulSet)
+	// DeleteStatefulSetByName deletes a stateful set by name from ComponentManager, leaving its
+	// pods.
+	DeleteStatefulSetByName(name string)
+	// GetStatefulSetByName gets a stateful set from ComponentManager by name.
+	GetStatefulSetByName(name string) *core.StatefulSet
+	// ListStatefulSets lists all the stateful sets present.
+	ListStatefulSets() []*core.StatefulSet
+	// ListPodsByStatefulSetName lists the pods labelled as belonging to a stateful set. Unlike
+	// ListPodsByReplicaSetName, the list is a copy.
+	ListPodsByStatefulSetName(statefulSetName string) *list.List
+
 	// SetService sets a pod into ComponentManager. This function will not check the existence of the
 	// service. To check for existence, you should call `ServiceExistsByName`.
 	SetService(service *core.Service, pods *list.List)
@@ -98,6 +135,38 @@ type ComponentManager interface {
 	GetAutoscalerByName(name string) *core.HorizontalPodAutoscaler
 	// DeploymentAutoscaled checks whether a deployment is monitored by an autoscaler.
 	DeploymentAutoscaled(deploymentName string) bool
+
+	// SetRegistryCredential sets a registry credential into ComponentManager. This function will not
+	// check the existence of the credential.
+	SetRegistryCredential(credential *core.RegistryCredential)
+	// DeleteRegistryCredentialByName deletes a registry credential by name from ComponentManager.
+	DeleteRegistryCredentialByName(name string)
+	// RegistryCredentialExistsByName checks whether a registry credential of a specific name exists.
+	RegistryCredentialExistsByName(name string) bool
+	// GetRegistryCredentialByName gets a registry credential from ComponentManager by name.
+	GetRegistryCredentialByName(name string) *core.RegistryCredential
+	// ListRegistryCredentials lists all the registry credentials present.
+	ListRegistryCredentials() []*core.RegistryCredential
+
+	// SetScheduledScaler sets a scheduled scaler into ComponentManager. This function will not
+	// check the existence of the scheduled scaler.
+	SetScheduledScaler(scaler *core.ScheduledScaler)
+	// DeleteScheduledScalerByName deletes a scheduled scaler by name from ComponentManager.
+	DeleteScheduledScalerByName(name string)
+	// GetScheduledScalerByName gets a scheduled scaler from ComponentManager by name.
+	GetScheduledScalerByName(name string) *core.ScheduledScaler
+	// ListScheduledScalers lists all the scheduled scalers present.
+	ListScheduledScalers() []*core.ScheduledScaler
+
+	// SetVerticalAutoscaler sets a vertical autoscaler into ComponentManager. This function will
+	// not check the existence of the vertical autoscaler.
+	SetVerticalAutoscaler(autoscaler *core.VerticalPodAutoscaler)
+	// DeleteVerticalAutoscalerByName deletes a vertical autoscaler by name from ComponentManager.
+	DeleteVerticalAutoscalerByName(name string)
+	// GetVerticalAutoscalerByName gets a vertical autoscaler from ComponentManager by name.
+	GetVerticalAutoscalerByName(name string) *core.VerticalPodAutoscaler
+	// ListVerticalAutoscalers lists all the vertical autoscalers present.
+	ListVerticalAutoscalers() []*core.VerticalPodAutoscaler
 }
 
 type componentManagerInner struct {
@@ -112,22 +181,37 @@ type componentManagerInner struct {
 	dns map[string]*core.DNS
 	// Stores the mapping from autoscaler name to autoscaler.
 	autoscalers map[string]*core.HorizontalPodAutoscaler
-	// Stores the mapping from the name of a deployment to the pods it creates.
-	deploymentToPods map[string]*list.List
+	// Stores the mapping from registry credential name to registry credential.
+	registryCredentials map[string]*core.RegistryCredential
+	// Stores the mapping from scheduled scaler name to scheduled scaler.
+	scheduledScalers map[string]*core.ScheduledScaler
+	// Stores the mapping from vertical autoscaler name to vertical autoscaler.
+	verticalAutoscalers map[string]*core.VerticalPodAutoscaler
+	// Stores the mapping from replica set name to replica set.
+	replicaSets map[string]*core.ReplicaSet
+	// Stores the mapping from the name of a replica set to the pods it creates.
+	replicaSetToPods map[string]*list.List
+	// Stores the mapping from stateful set name to stateful set.
+	statefulSets map[string]*core.StatefulSet
 	// Stores the mapping from the name of a service to the pods it selects by label.
 	servicesToPods map[string]*list.List
 }
 
 func NewComponentManager() ComponentManager {
 	return &componentManagerInner{
-		mtx:              sync.RWMutex{},
-		pods:             map[string]*core.Pod{},
-		services:         map[string]*core.Service{},
-		deployments:      map[string]*core.Deployment{},
-		dns:              map[string]*core.DNS{},
-		autoscalers:      map[string]*core.HorizontalPodAutoscaler{},
-		deploymentToPods: map[string]*list.List{},
-		servicesToPods:   map[string]*list.List{},
+		mtx:                 sync.RWMutex{},
+		pods:                map[string]*core.Pod{},
+		services:            map[string]*core.Service{},
+		deployments:         map[string]*core.Deployment{},
+		dns:                 map[string]*core.DNS{},
+		autoscalers:         map[string]*core.HorizontalPodAutoscaler{},
+		registryCredentials: map[string]*core.RegistryCredential{},
+		scheduledScalers:    map[string]*core.ScheduledScaler{},
+		verticalAutoscalers: map[string]*core.VerticalPodAutoscaler{},
+		replicaSets:         map[string]*core.ReplicaSet{},
+		replicaSetToPods:    map[string]*list.List{},
+		statefulSets:        map[string]*core.StatefulSet{},
+		servicesToPods:      map[string]*list.List{},
 	}
 }
 
@@ -149,7 +233,7 @@ func (cm *componentManagerInner) DeletePodByName(name string) {
 			}
 		}
 	}
-	for _, pods := range cm.deploymentToPods {
+	for _, pods := range cm.replicaSetToPods {
 		for it := pods.Front(); it != nil; it = it.Next() {
 			if it.Value.(*core.Pod).Name == name {
 				pods.Remove(it)
@@ -209,26 +293,15 @@ func (cm *componentManagerInner) ListPodsByLabelsAndPhase(
 	return pods
 }
 
-func (cm *componentManagerInner) SetDeployment(deployment *core.Deployment, pods *list.List) {
+func (cm *componentManagerInner) SetDeployment(deployment *core.Deployment) {
 	cm.mtx.Lock()
 	defer cm.mtx.Unlock()
-	for it := pods.Front(); it != nil; it = it.Next() {
-		pod := it.Value.(*core.Pod)
-		cm.pods[pod.Name] = pod
-	}
 	cm.deployments[deployment.Name] = deployment
-	cm.deploymentToPods[deployment.Name] = pods
 }
 
 func (cm *componentManagerInner) DeleteDeploymentByName(deploymentName string) {
 	cm.mtx.Lock()
 	defer cm.mtx.Unlock()
-	pods := cm.deploymentToPods[deploymentName]
-	for it := pods.Front(); it != nil; it = it.Next() {
-		pod := it.Value.(*core.Pod)
-		delete(cm.pods, pod.Name)
-	}
-	delete(cm.deploymentToPods, deploymentName)
 	delete(cm.deployments, deploymentName)
 
 	// Delete corresponding autoscaler.
@@ -266,23 +339,145 @@ func (cm *componentManagerInner) ListDeployments() []*core.Deployment {
 func (cm *componentManagerInner) ListPodsByDeploymentName(deploymentName string) *list.List {
 	cm.mtx.RLock()
 	defer cm.mtx.RUnlock()
-	return cm.deploymentToPods[deploymentName]
+	pods := list.New()
+	for name, replicaSet := range cm.replicaSets {
+		if replicaSet.OwnerName != deploymentName {
+			continue
+		}
+		for it := cm.replicaSetToPods[name].Front(); it != nil; it = it.Next() {
+			pods.PushBack(it.Value)
+		}
+	}
+	return pods
 }
 
 func (cm *componentManagerInner) GetDeploymentByPodName(podName string) *core.Deployment {
+	replicaSet := cm.GetReplicaSetByPodName(podName)
+	if replicaSet == nil || replicaSet.OwnerName == "" {
+		return nil
+	}
+	return cm.GetDeploymentByName(replicaSet.OwnerName)
+}
+
+func (cm *componentManagerInner) SetReplicaSet(replicaSet *core.ReplicaSet, pods *list.List) {
+	cm.mtx.Lock()
+	defer cm.mtx.Unlock()
+	for it := pods.Front(); it != nil; it = it.Next() {
+		pod := it.Value.(*core.Pod)
+		cm.pods[pod.Name] = pod
+	}
+	cm.replicaSets[replicaSet.Name] = replicaSet
+	cm.replicaSetToPods[replicaSet.Name] = pods
+}
+
+func (cm *componentManagerInner) DeleteReplicaSetByName(replicaSetName string) {
+	cm.mtx.Lock()
+	defer cm.mtx.Unlock()
+	if pods, ok := cm.replicaSetToPods[replicaSetName]; ok {
+		for it := pods.Front(); it != nil; it = it.Next() {
+			pod := it.Value.(*core.Pod)
+			delete(cm.pods, pod.Name)
+		}
+	}
+	delete(cm.replicaSetToPods, replicaSetName)
+	delete(cm.replicaSets, replicaSetName)
+}
+
+func (cm *componentManagerInner) GetReplicaSetByName(name string) *core.ReplicaSet {
+	cm.mtx.RLock()
+	defer cm.mtx.RUnlock()
+	return cm.replicaSets[name]
+}
+
+func (cm *componentManagerInner) ReplicaSetExistsByName(name string) bool {
+	cm.mtx.RLock()
+	defer cm.mtx.RUnlock()
+	_, ok := cm.replicaSets[name]
+	return ok
+}
+
+func (cm *componentManagerInner) ListReplicaSets() []*core.ReplicaSet {
+	cm.mtx.RLock()
+	defer cm.mtx.RUnlock()
+	replicaSets := make([]*core.ReplicaSet, 0, len(cm.replicaSets))
+	for _, replicaSet := range cm.replicaSets {
+		replicaSets = append(replicaSets, replicaSet)
+	}
+	return replicaSets
+}
+
+func (cm *componentManagerInner) ListReplicaSetsByDeploymentName(deploymentName string) []*core.ReplicaSet {
+	cm.mtx.RLock()
+	defer cm.mtx.RUnlock()
+	replicaSets := make([]*core.ReplicaSet, 0)
+	for _, replicaSet := range cm.replicaSets {
+		if replicaSet.OwnerName == deploymentName {
+			replicaSets = append(replicaSets, replicaSet)
+		}
+	}
+	return replicaSets
+}
+
+func (cm *componentManagerInner) ListPodsByReplicaSetName(replicaSetName string) *list.List {
 	cm.mtx.RLock()
 	defer cm.mtx.RUnlock()
-	for deploymentName, pods := range cm.deploymentToPods {
+	return cm.replicaSetToPods[replicaSetName]
+}
+
+func (cm *componentManagerInner) GetReplicaSetByPodName(podName string) *core.ReplicaSet {
+	cm.mtx.RLock()
+	defer cm.mtx.RUnlock()
+	for replicaSetName, pods := range cm.replicaSetToPods {
 		for it := pods.Front(); it != nil; it = it.Next() {
 			pod := it.Value.(*core.Pod)
 			if pod.Name == podName {
-				return cm.deployments[deploymentName]
+				return cm.replicaSets[replicaSetName]
 			}
 		}
 	}
 	return nil
 }
 
+func (cm *componentManagerInner) SetStatefulSet(statefulSet *core.StatefulSet) {
+	cm.mtx.Lock()
+	defer cm.mtx.Unlock()
+	cm.statefulSets[statefulSet.Name] = statefulSet
+}
+
+func (cm *componentManagerInner) DeleteStatefulSetByName(name string) {
+	cm.mtx.Lock()
+	defer cm.mtx.Unlock()
+	delete(cm.statefulSets, name)
+}
+
+func (cm *componentManagerInner) GetStatefulSetByName(name string) *core.StatefulSet {
+	cm.mtx.RLock()
+	defer cm.mtx.RUnlock()
+	return cm.statefulSets[name]
+}
+
+func (cm *componentManagerInner) ListStatefulSets() []*core.StatefulSet {
+	cm.mtx.RLock()
+	defer cm.mtx.RUnlock()
+	statefulSets := make([]*core.StatefulSet, 0, len(cm.statefulSets))
+	for _, statefulSet := range cm.statefulSets {
+		statefulSets = append(statefulSets, statefulSet)
+	}
+	return statefulSets
+}
+
+func (cm *componentManagerInner) ListPodsByStatefulSetName(statefulSetName string) *list.List {
+	cm.mtx.RLock()
+	defer cm.mtx.RUnlock()
+	pods := list.New()
+	for _, pod := range cm.pods {
+		if pod.Labels[core.StatefulSetLabel] == statefulSetName {
+			pods.PushBack(pod)
+		}
+	}
+	return pods
+}
+
 func (cm *componentManagerInner) SetService(service *core.Service, pods *list.List) {
 	cm.mtx.Lock()
 	defer cm.mtx.Unlock()
@@ -425,3 +620,94 @@ func (cm *componentManagerInner) DeploymentAutoscaled(deploymentName string) boo
 	}
 	return false
 }
+
+func (cm *componentManagerInner) SetRegistryCredential(credential *core.RegistryCredential) {
+	cm.mtx.Lock()
+	defer cm.mtx.Unlock()
+	cm.registryCredentials[credential.Name] = credential
+}
+
+func (cm *componentManagerInner) DeleteRegistryCredentialByName(name string) {
+	cm.mtx.Lock()
+	defer cm.mtx.Unlock()
+	delete(cm.registryCredentials, name)
+}
+
+func (cm *componentManagerInner) RegistryCredentialExistsByName(name string) bool {
+	cm.mtx.RLock()
+	defer cm.mtx.RUnlock()
+	_, ok := cm.registryCredentials[name]
+	return ok
+}
+
+func (cm *componentManagerInner) GetRegistryCredentialByName(name string) *core.RegistryCredential {
+	cm.mtx.RLock()
+	defer cm.mtx.RUnlock()
+	return cm.registryCredentials[name]
+}
+
+func (cm *componentManagerInner) ListRegistryCredentials() []*core.RegistryCredential {
+	cm.mtx.RLock()
+	defer cm.mtx.RUnlock()
+	credentials := make([]*core.RegistryCredential, 0, len(cm.registryCredentials))
+	for _, credential := range cm.registryCredentials {
+		credentials = append(credentials, credential)
+	}
+	return credentials
+}
+
+func (cm *componentManagerInner) SetScheduledScaler(scaler *core.ScheduledScaler) {
+	cm.mtx.Lock()
+	defer cm.mtx.Unlock()
+	cm.scheduledScalers[scaler.Name] = scaler
+}
+
+func (cm *componentManagerInner) DeleteScheduledScalerByName(name string) {
+	cm.mtx.Lock()
+	defer cm.mtx.Unlock()
+	delete(cm.scheduledScalers, name)
+}
+
+func (cm *componentManagerInner) GetScheduledScalerByName(name string) *core.ScheduledScaler {
+	cm.mtx.RLock()
+	defer cm.mtx.RUnlock()
+	return cm.scheduledScalers[name]
+}
+
+func (cm *componentManagerInner) ListScheduledScalers() []*core.ScheduledScaler {
+	cm.mtx.RLock()
+	defer cm.mtx.RUnlock()
+	scalers := make([]*core.ScheduledScaler, 0, len(cm.scheduledScalers))
+	for _, scaler := range cm.scheduledScalers {
+		scalers = append(scalers, scaler)
+	}
+	return scalers
+}
+
+func (cm *componentManagerInner) SetVerticalAutoscaler(autoscaler *core.VerticalPodAutoscaler) {
+	cm.mtx.Lock()
+	defer cm.mtx.Unlock()
+	cm.verticalAutoscalers[autoscaler.Name] = autoscaler
+}
+
+func (cm *componentManagerInner) DeleteVerticalAutoscalerByName(name string) {
+	cm.mtx.Lock()
+	defer cm.mtx.Unlock()
+	delete(cm.verticalAutoscalers, name)
+}
+
+func (cm *componentManagerInner) GetVerticalAutoscalerByName(name string) *core.VerticalPodAutoscaler {
+	cm.mtx.RLock()
+	defer cm.mtx.RUnlock()
+	return cm.verticalAutoscalers[name]
+}
+
+func (cm *componentManagerInner) ListVerticalAutoscalers() []*core.VerticalPodAutoscaler {
+	cm.mtx.RLock()
+	defer cm.mtx.RUnlock()
+	autoscalers := make([]*core.VerticalPodAutoscaler, 0, len(cm.verticalAutoscalers))
+	for _, autoscaler := range cm.verticalAutoscalers {
+		autoscalers = append(autoscalers, autoscaler)
+	}
+	return autoscalers
+}
@@ -0,0 +1,112 @@
+package credential
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/golang/glog"
+	"p9t.io/kuberboat/pkg/api/core"
+	"p9t.io/kuberboat/pkg/apiserver"
+	"p9t.io/kuberboat/pkg/apiserver/etcd"
+)
+
+// etcdCredentialPrefix is the key prefix of registry credentials.
+const etcdCredentialPrefix = "/RegistryCredentials"
+
+type Controller interface {
+	// CreateRegistryCredential stores a registry credential. It will not override an existing one.
+	CreateRegistryCredential(credential *core.RegistryCredential) error
+	// DeleteRegistryCredentialByName deletes a registry credential indexed by name.
+	DeleteRegistryCredentialByName(name string) error
+	// DescribeRegistryCredentials returns information about registry credentials specified by
+	// names, with passwords redacted. Return value is composed of credentials that are found and
+	// names of credentials that do not exist.
+	DescribeRegistryCredentials(all bool, names []string) ([]*core.RegistryCredential, []string)
+	// GetRegistryCredentials returns the registry credentials specified by names for pulling
+	// images, as well as the names of credentials that do not exist.
+	GetRegistryCredentials(names []string) ([]*core.RegistryCredential, []string)
+}
+
+type basicController struct {
+	mtx              sync.Mutex
+	componentManager apiserver.ComponentManager
+}
+
+func NewCredentialController(componentManager apiserver.ComponentManager) Controller {
+	return &basicController{
+		componentManager: componentManager,
+	}
+}
+
+// etcdKey returns the key of a registry credential on etcd.
+func etcdKey(name string) string {
+	return fmt.Sprintf("%v/%v", etcdCredentialPrefix, name)
+}
+
+func (c *basicController) CreateRegistryCredential(credential *core.RegistryCredential) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if credential.Name == "" {
+		return fmt.Errorf("name of registry credential not specified")
+	}
+	if strings.TrimSpace(credential.Spec.Server) == "" {
+		return fmt.Errorf("server of registry credential %v not specified", credential.Name)
+	}
+	if c.componentManager.RegistryCredentialExistsByName(credential.Name) {
+		return fmt.Errorf("registry credential already exists: %v", credential.Name)
+	}
+	if err := etcd.Put(etcdKey(credential.Name), credential); err != nil {
+		return err
+	}
+	c.componentManager.SetRegistryCredential(credential)
+	glog.Infof("REGISTRY CREDENTIAL [%v]: created for server %v", credential.Name, credential.Spec.Server)
+	return nil
+}
+
+func (c *basicController) DeleteRegistryCredentialByName(name string) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if !c.componentManager.RegistryCredentialExistsByName(name) {
+		return fmt.Errorf("registry credential does not exist: %v", name)
+	}
+	if err := etcd.Delete(etcdKey(name)); err != nil {
+		return err
+	}
+	c.componentManager.DeleteRegistryCredentialByName(name)
+	glog.Infof("REGISTRY CREDENTIAL [%v]: deleted", name)
+	return nil
+}
+
+func (c *basicController) DescribeRegistryCredentials(all bool, names []string) ([]*core.RegistryCredential, []string) {
+	var found []*core.RegistryCredential
+	notFound := make([]string, 0)
+	if all {
+		found = c.componentManager.ListRegistryCredentials()
+	} else {
+		found, notFound = c.GetRegistryCredentials(names)
+	}
+	redacted := make([]*core.RegistryCredential, 0, len(found))
+	for _, credential := range found {
+		copied := *credential
+		copied.Spec.Password = strings.Repeat("*", 8)
+		redacted = append(redacted, &copied)
+	}
+	return redacted, notFound
+}
+
+func (c *basicController) GetRegistryCredentials(names []string) ([]*core.RegistryCredential, []string) {
+	found := make([]*core.RegistryCredential, 0, len(names))
+	notFound := make([]string, 0)
+	for _, name := range names {
+		credential := c.componentManager.GetRegistryCredentialByName(name)
+		if credential == nil {
+			notFound = append(notFound, name)
+			continue
+		}
+		found = append(found, credential)
+	}
+	return found, notFound
+}
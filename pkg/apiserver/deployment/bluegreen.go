@@ -0,0 +1,143 @@
+package deployment
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"p9t.io/kuberboat/pkg/api/core"
+)
+
+// blueGreen brings the new replica set of a deployment up in full next to the active one, points
+// the preview service at it, and promotes it once it is fully available if the deployment is
+// served for the first time or its auto promotion is due. Once the new replica set is active,
+// the newest old replica set still having replicas is kept for rollback, and the other old ones
+// are scaled down. It returns whether the status of the deployment has changed. The caller must
+// hold the lock.
+func (m *basicController) blueGreen(
+	deployment *core.Deployment,
+	newReplicaSet *core.ReplicaSet,
+	oldReplicaSets []*core.ReplicaSet,
+) bool {
+	changed := false
+	m.scaleReplicaSet(deployment, newReplicaSet, deployment.Spec.Replicas)
+	if preview := deployment.Spec.BlueGreen.PreviewService; preview != "" {
+		if err := m.selectReplicaSet(preview, newReplicaSet); err != nil {
+			glog.Errorf("DEPLOYMENT [%v]: failed to switch preview service [%v]: %v", deployment.Name, preview, err)
+		}
+	}
+
+	awaitingPromotion := false
+	if newReplicaSet.Name != deployment.Status.ActiveReplicaSet {
+		now := time.Now()
+		autoPromotion := time.Second * time.Duration(deployment.Spec.BlueGreen.AutoPromotionSeconds)
+		switch {
+		case !isFullyAvailable(deployment, newReplicaSet):
+			if !deployment.Status.StepStartTime.IsZero() {
+				deployment.Status.StepStartTime = time.Time{}
+				changed = true
+			}
+		case deployment.Status.ActiveReplicaSet == "":
+			// Nothing has been promoted yet, so there is nothing to wait for.
+			changed = m.tryPromote(deployment, newReplicaSet) || changed
+		case deployment.Status.StepStartTime.IsZero():
+			deployment.Status.StepStartTime = now
+			changed = true
+			awaitingPromotion = true
+		case deployment.Spec.BlueGreen.AutoPromotionSeconds > 0 &&
+			now.Sub(deployment.Status.StepStartTime) >= autoPromotion:
+			changed = m.tryPromote(deployment, newReplicaSet) || changed
+			awaitingPromotion = newReplicaSet.Name != deployment.Status.ActiveReplicaSet
+		default:
+			awaitingPromotion = true
+		}
+	}
+	if deployment.Status.AwaitingPromotion != awaitingPromotion {
+		deployment.Status.AwaitingPromotion = awaitingPromotion
+		changed = true
+	}
+
+	switch deployment.Status.ActiveReplicaSet {
+	case "":
+		// A deployment just switched to blue-green keeps serving its old pods until promoted.
+	case newReplicaSet.Name:
+		kept := ""
+		for _, replicaSet := range oldReplicaSets {
+			if replicaSet.Spec.Replicas > 0 {
+				kept = replicaSet.Name
+			}
+		}
+		m.scaleDownReplicaSetsExcept(deployment, oldReplicaSets, kept)
+	default:
+		m.scaleDownReplicaSetsExcept(deployment, oldReplicaSets, deployment.Status.ActiveReplicaSet)
+	}
+	return changed
+}
+
+// scaleDownReplicaSetsExcept scales the old replica sets of a deployment down to zero, except the
+// one named kept. The caller must hold the lock.
+func (m *basicController) scaleDownReplicaSetsExcept(
+	deployment *core.Deployment,
+	oldReplicaSets []*core.ReplicaSet,
+	kept string,
+) {
+	for _, replicaSet := range oldReplicaSets {
+		if replicaSet.Name != kept {
+			m.scaleReplicaSet(deployment, replicaSet, 0)
+		}
+	}
+}
+
+// tryPromote promotes the new replica set of a deployment and logs the error if it fails. It
+// returns whether the deployment is promoted. The caller must hold the lock.
+func (m *basicController) tryPromote(deployment *core.Deployment, newReplicaSet *core.ReplicaSet) bool {
+	if err := m.promote(deployment, newReplicaSet); err != nil {
+		glog.Errorf("DEPLOYMENT [%v]: failed to promote replica set [%v]: %v", deployment.Name, newReplicaSet.Name, err)
+		return false
+	}
+	return true
+}
+
+// promote switches the active service of a blue-green deployment to a replica set. The caller
+// must hold the lock.
+func (m *basicController) promote(deployment *core.Deployment, replicaSet *core.ReplicaSet) error {
+	if err := m.selectReplicaSet(deployment.Spec.BlueGreen.ActiveService, replicaSet); err != nil {
+		return err
+	}
+	deployment.Status.ActiveReplicaSet = replicaSet.Name
+	deployment.Status.StepStartTime = time.Time{}
+	deployment.Status.AwaitingPromotion = false
+	glog.Infof(
+		"DEPLOYMENT [%v]: promoted replica set [%v] of revision %v",
+		deployment.Name,
+		replicaSet.Name,
+		replicaSet.Revision,
+	)
+	return nil
+}
+
+// selectReplicaSet switches a service to the pods of a replica set, by adding the template hash of
+// the replica set to its selector.
+func (m *basicController) selectReplicaSet(serviceName string, replicaSet *core.ReplicaSet) error {
+	service := m.componentManager.GetServiceByName(serviceName)
+	if service == nil {
+		return fmt.Errorf("no such service: %v", serviceName)
+	}
+	hash := core.PodTemplateHash(replicaSet)
+	if service.Spec.Selector[core.PodTemplateHashLabel] == hash {
+		return nil
+	}
+	selector := make(map[string]string, len(service.Spec.Selector)+1)
+	for k, v := range service.Spec.Selector {
+		selector[k] = v
+	}
+	selector[core.PodTemplateHashLabel] = hash
+	return m.serviceController.SetServiceSelector(serviceName, selector)
+}
+
+// isFullyAvailable tells whether a replica set of a deployment has all the replicas of the
+// deployment ready.
+func isFullyAvailable(deployment *core.Deployment, replicaSet *core.ReplicaSet) bool {
+	return replicaSet.Spec.Replicas == deployment.Spec.Replicas &&
+		availableReplicas(replicaSet) >= int64(deployment.Spec.Replicas)
+}
@@ -0,0 +1,127 @@
+package deployment
+
+import (
+	"time"
+
+	"github.com/golang/glog"
+	"p9t.io/kuberboat/pkg/api/core"
+)
+
+// canary moves the share of the current step of a canary rollout to the new replica set of a
+// deployment, and goes on to the next step once the step is done. It returns whether the status of
+// the deployment has changed. The caller must hold the lock.
+func (m *basicController) canary(
+	deployment *core.Deployment,
+	newReplicaSet *core.ReplicaSet,
+	oldReplicaSets []*core.ReplicaSet,
+) bool {
+	changed := false
+	steps := deployment.Spec.Canary.Steps
+	now := time.Now()
+	for int(deployment.Status.CanaryStep) < len(steps) {
+		step := steps[deployment.Status.CanaryStep]
+		if step.Pause {
+			if deployment.Status.StepStartTime.IsZero() {
+				deployment.Status.StepStartTime = now
+				changed = true
+			}
+			pause := time.Second * time.Duration(step.PauseSeconds)
+			if step.PauseSeconds == 0 || now.Sub(deployment.Status.StepStartTime) < pause {
+				break
+			}
+		} else {
+			target := canaryNewReplicas(deployment.Spec.Replicas, step.SetWeight)
+			if newReplicaSet.Spec.Replicas < target || availableReplicas(newReplicaSet) < int64(target) {
+				break
+			}
+		}
+		glog.Infof("DEPLOYMENT [%v]: canary step %v is done", deployment.Name, deployment.Status.CanaryStep)
+		nextCanaryStep(deployment, now)
+		changed = true
+	}
+
+	newReplicas, oldReplicas := canaryReplicas(deployment, canaryWeight(deployment), oldReplicaSets)
+	m.scaleReplicaSet(deployment, newReplicaSet, newReplicas)
+	for i, replicas := range oldReplicas {
+		m.scaleReplicaSet(deployment, oldReplicaSets[i], replicas)
+	}
+
+	step := int(deployment.Status.CanaryStep)
+	awaitingPromotion := step < len(steps) && steps[step].Pause
+	if deployment.Status.AwaitingPromotion != awaitingPromotion {
+		deployment.Status.AwaitingPromotion = awaitingPromotion
+		changed = true
+	}
+	return changed
+}
+
+// nextCanaryStep moves a canary rollout on to its next step.
+func nextCanaryStep(deployment *core.Deployment, now time.Time) {
+	deployment.Status.CanaryStep++
+	deployment.Status.StepStartTime = now
+}
+
+// canaryWeight is the percentage of the replicas of a deployment its canary rollout has moved to
+// the new template, as set by the last step with a weight so far. It is 100 once all the steps are
+// done.
+func canaryWeight(deployment *core.Deployment) uint32 {
+	steps := deployment.Spec.Canary.Steps
+	if int(deployment.Status.CanaryStep) >= len(steps) {
+		return 100
+	}
+	var weight uint32 = 0
+	for _, step := range steps[:deployment.Status.CanaryStep+1] {
+		if !step.Pause {
+			weight = step.SetWeight
+		}
+	}
+	return weight
+}
+
+// canaryNewReplicas is the number of replicas a weight moves to the new template, rounded up.
+func canaryNewReplicas(replicas uint32, weight uint32) uint32 {
+	return (replicas*weight + 99) / 100
+}
+
+// canaryReplicas computes the replicas of the new and old replica sets of a deployment under a
+// canary rollout at a weight. oldReplicaSets must be sorted by revision. The old replica sets are
+// scaled down the oldest first, and scaled up the newest first among those still having replicas.
+// If none has, there is nothing left to compare against and the new replica set takes all.
+func canaryReplicas(
+	deployment *core.Deployment,
+	weight uint32,
+	oldReplicaSets []*core.ReplicaSet,
+) (uint32, []uint32) {
+	replicas := deployment.Spec.Replicas
+	newReplicas := canaryNewReplicas(replicas, weight)
+	oldReplicas := make([]uint32, len(oldReplicaSets))
+	newest := -1
+	for i, replicaSet := range oldReplicaSets {
+		oldReplicas[i] = replicaSet.Spec.Replicas
+		if replicaSet.Spec.Replicas > 0 {
+			newest = i
+		}
+	}
+	if newest < 0 {
+		return replicas, oldReplicas
+	}
+
+	var oldTotal int64 = int64(replicas) - int64(newReplicas)
+	var excess int64 = totalSpecReplicas(oldReplicaSets) - oldTotal
+	if excess < 0 {
+		oldReplicas[newest] += uint32(-excess)
+		return newReplicas, oldReplicas
+	}
+	for i := range oldReplicas {
+		if excess == 0 {
+			break
+		}
+		numToScaleDown := uint32(excess)
+		if oldReplicas[i] < numToScaleDown {
+			numToScaleDown = oldReplicas[i]
+		}
+		oldReplicas[i] -= numToScaleDown
+		excess -= int64(numToScaleDown)
+	}
+	return newReplicas, oldReplicas
+}
@@ -0,0 +1,56 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"p9t.io/kuberboat/pkg/api/core"
+	"p9t.io/kuberboat/pkg/apiserver/internal/apiservertest"
+)
+
+func TestCanaryWeight(t *testing.T) {
+	deployment := apiservertest.NewDeployment("nginx:1.22")
+	deployment.Spec.Canary.Steps = []core.CanaryStep{
+		{Pause: true, PauseSeconds: 10},
+		{SetWeight: 20},
+		{Pause: true},
+		{SetWeight: 50},
+	}
+
+	expected := []uint32{0, 20, 20, 50, 100}
+	for step, weight := range expected {
+		deployment.Status.CanaryStep = uint32(step)
+		assert.Equal(t, weight, canaryWeight(deployment))
+	}
+}
+
+func TestCanaryReplicas(t *testing.T) {
+	deployment := apiservertest.NewDeployment("nginx:1.22")
+	deployment.Spec.Replicas = 5
+	oldReplicaSets := []*core.ReplicaSet{
+		{Revision: 1, Spec: core.ReplicaSetSpec{Replicas: 2}, Status: core.ReplicaSetStatus{Replicas: 2, ReadyReplicas: 2}},
+		{Revision: 2, Spec: core.ReplicaSetSpec{Replicas: 3}, Status: core.ReplicaSetStatus{Replicas: 3, ReadyReplicas: 3}},
+	}
+
+	// The new share is rounded up, and the oldest pods go first.
+	newReplicas, oldReplicas := canaryReplicas(deployment, 30, oldReplicaSets)
+	assert.Equal(t, uint32(2), newReplicas)
+	assert.Equal(t, []uint32{0, 3}, oldReplicas)
+
+	// Going back to a smaller weight scales the newest old replica set up.
+	oldReplicaSets = []*core.ReplicaSet{
+		{Revision: 1},
+		{Revision: 2, Spec: core.ReplicaSetSpec{Replicas: 1}, Status: core.ReplicaSetStatus{Replicas: 1, ReadyReplicas: 1}},
+	}
+	newReplicas, oldReplicas = canaryReplicas(deployment, 20, oldReplicaSets)
+	assert.Equal(t, uint32(1), newReplicas)
+	assert.Equal(t, []uint32{0, 4}, oldReplicas)
+
+	newReplicas, oldReplicas = canaryReplicas(deployment, 100, oldReplicaSets)
+	assert.Equal(t, uint32(5), newReplicas)
+	assert.Equal(t, []uint32{0, 0}, oldReplicas)
+
+	// Without any old pod, the new replica set takes all.
+	newReplicas, _ = canaryReplicas(deployment, 20, []*core.ReplicaSet{{Revision: 1}})
+	assert.Equal(t, uint32(5), newReplicas)
+}
@@ -0,0 +1,190 @@
+package deployment
+
+import (
+	"fmt"
+	"time"
+
+	"p9t.io/kuberboat/pkg/api"
+	"p9t.io/kuberboat/pkg/api/core"
+)
+
+const (
+	// defaultProgressDeadlineSeconds is the progress deadline when a deployment does not say.
+	defaultProgressDeadlineSeconds = 600
+)
+
+// replicaCounts are the numbers of pods of a deployment. A rollout makes progress whenever they
+// change.
+type replicaCounts struct {
+	replicas        uint32
+	updatedReplicas uint32
+	readyReplicas   uint32
+}
+
+func countsOf(deployment *core.Deployment) replicaCounts {
+	return replicaCounts{
+		replicas:        deployment.Status.Replicas,
+		updatedReplicas: deployment.Status.UpdatedReplicas,
+		readyReplicas:   deployment.Status.ReadyReplicas,
+	}
+}
+
+// getCondition returns the condition of a type, or nil if there is none.
+func getCondition(status *core.DeploymentStatus, conditionType core.DeploymentConditionType) *core.DeploymentCondition {
+	for i := range status.Conditions {
+		if status.Conditions[i].Type == conditionType {
+			return &status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+func newCondition(
+	conditionType core.DeploymentConditionType,
+	status core.ConditionStatus,
+	reason string,
+	message string,
+	now time.Time,
+) core.DeploymentCondition {
+	return core.DeploymentCondition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastUpdateTime:     now,
+		LastTransitionTime: now,
+	}
+}
+
+// setCondition replaces the condition of the same type. The last transition time is kept if the
+// status does not change.
+func setCondition(status *core.DeploymentStatus, condition core.DeploymentCondition) {
+	if old := getCondition(status, condition.Type); old != nil {
+		if old.Status == condition.Status {
+			condition.LastTransitionTime = old.LastTransitionTime
+		}
+		*old = condition
+		return
+	}
+	status.Conditions = append(status.Conditions, condition)
+}
+
+// isRolloutComplete tells whether all the pods of a deployment are ready and of its template. The
+// old pods of a blue-green deployment are kept for rollback, so its rollout is complete once its
+// active service is switched to the new pods.
+func isRolloutComplete(deployment *core.Deployment) bool {
+	if deployment.Spec.Strategy == core.BlueGreenDeploymentStrategy {
+		return deployment.Status.UpdatedReplicas == deployment.Spec.Replicas &&
+			deployment.Status.ActiveReplicaSet == getReplicaSetName(deployment.Name, computeSpecHash(deployment))
+	}
+	return deployment.Status.UpdatedReplicas == deployment.Spec.Replicas &&
+		deployment.Status.Replicas == deployment.Spec.Replicas
+}
+
+// updateConditions brings the conditions of a deployment up to date. progressed tells whether the
+// pods of the deployment have changed since the conditions were last updated. It returns whether
+// any condition has changed.
+func updateConditions(deployment *core.Deployment, progressed bool, now time.Time) bool {
+	changed := false
+	update := func(condition core.DeploymentCondition) {
+		setCondition(&deployment.Status, condition)
+		changed = true
+	}
+	// sameAs tells whether the current condition of a type has the given status and reason.
+	sameAs := func(conditionType core.DeploymentConditionType, status core.ConditionStatus, reason string) bool {
+		old := getCondition(&deployment.Status, conditionType)
+		return old != nil && old.Status == status && old.Reason == reason
+	}
+
+	minReadyReplicas := api.Max64(
+		0,
+		int64(deployment.Spec.Replicas)-int64(deployment.Spec.RollingUpdate.MaxUnavailable),
+	)
+	if int64(deployment.Status.ReadyReplicas) >= minReadyReplicas {
+		if !sameAs(core.DeploymentAvailable, core.ConditionTrue, core.DeploymentReasonMinimumReplicasAvailable) {
+			update(newCondition(
+				core.DeploymentAvailable,
+				core.ConditionTrue,
+				core.DeploymentReasonMinimumReplicasAvailable,
+				"deployment has minimum availability",
+				now,
+			))
+		}
+	} else if !sameAs(core.DeploymentAvailable, core.ConditionFalse, core.DeploymentReasonMinimumReplicasUnavailable) {
+		update(newCondition(
+			core.DeploymentAvailable,
+			core.ConditionFalse,
+			core.DeploymentReasonMinimumReplicasUnavailable,
+			"deployment does not have minimum availability",
+			now,
+		))
+	}
+
+	progressing := getCondition(&deployment.Status, core.DeploymentProgressing)
+	switch {
+	case deployment.Spec.Paused:
+		if !sameAs(core.DeploymentProgressing, core.ConditionUnknown, core.DeploymentReasonPaused) {
+			update(newCondition(
+				core.DeploymentProgressing,
+				core.ConditionUnknown,
+				core.DeploymentReasonPaused,
+				"deployment is paused",
+				now,
+			))
+		}
+	case deployment.Status.AwaitingPromotion:
+		if !sameAs(core.DeploymentProgressing, core.ConditionUnknown, core.DeploymentReasonAwaitingPromotion) {
+			update(newCondition(
+				core.DeploymentProgressing,
+				core.ConditionUnknown,
+				core.DeploymentReasonAwaitingPromotion,
+				fmt.Sprintf("revision %v is awaiting promotion", deployment.Status.Revision),
+				now,
+			))
+		}
+	case progressing != nil && (progressing.Reason == core.DeploymentReasonPaused ||
+		progressing.Reason == core.DeploymentReasonAwaitingPromotion):
+		// The deadline restarts when the deployment resumes.
+		update(newCondition(
+			core.DeploymentProgressing,
+			core.ConditionTrue,
+			core.DeploymentReasonResumed,
+			"deployment is resumed",
+			now,
+		))
+	case isRolloutComplete(deployment):
+		if !sameAs(core.DeploymentProgressing, core.ConditionTrue, core.DeploymentReasonRolloutComplete) {
+			update(newCondition(
+				core.DeploymentProgressing,
+				core.ConditionTrue,
+				core.DeploymentReasonRolloutComplete,
+				fmt.Sprintf("revision %v has successfully rolled out", deployment.Status.Revision),
+				now,
+			))
+		}
+	case progressing == nil || progressed:
+		update(newCondition(
+			core.DeploymentProgressing,
+			core.ConditionTrue,
+			core.DeploymentReasonRollingUpdate,
+			fmt.Sprintf("revision %v is progressing", deployment.Status.Revision),
+			now,
+		))
+	case progressing.Status == core.ConditionTrue:
+		deadlineSeconds := deployment.Spec.ProgressDeadlineSeconds
+		if deadlineSeconds == 0 {
+			deadlineSeconds = defaultProgressDeadlineSeconds
+		}
+		deadline := time.Second * time.Duration(deadlineSeconds)
+		if now.Sub(progressing.LastUpdateTime) > deadline {
+			update(newCondition(
+				core.DeploymentProgressing,
+				core.ConditionFalse,
+				core.DeploymentReasonProgressDeadlineExceeded,
+				fmt.Sprintf("revision %v has timed out progressing", deployment.Status.Revision),
+				now,
+			))
+		}
+	}
+	return changed
+}
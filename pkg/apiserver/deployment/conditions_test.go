@@ -0,0 +1,101 @@
+package deployment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"p9t.io/kuberboat/pkg/api/core"
+	"p9t.io/kuberboat/pkg/apiserver/internal/apiservertest"
+)
+
+func TestUpdateConditionsProgressDeadline(t *testing.T) {
+	now := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	deployment := apiservertest.NewDeployment("nginx:1.22")
+	deployment.Spec.Replicas = 3
+	deployment.Spec.RollingUpdate.MaxUnavailable = 1
+	deployment.Spec.ProgressDeadlineSeconds = 60
+	deployment.Status = core.DeploymentStatus{Replicas: 3, ReadyReplicas: 3, UpdatedReplicas: 1, Revision: 2}
+
+	assert.True(t, updateConditions(deployment, false, now))
+	progressing := getCondition(&deployment.Status, core.DeploymentProgressing)
+	assert.Equal(t, core.ConditionTrue, progressing.Status)
+	assert.Equal(t, core.DeploymentReasonRollingUpdate, progressing.Reason)
+	available := getCondition(&deployment.Status, core.DeploymentAvailable)
+	assert.Equal(t, core.ConditionTrue, available.Status)
+
+	// Nothing changes until the deadline passes without progress.
+	assert.False(t, updateConditions(deployment, false, now.Add(time.Minute)))
+	assert.True(t, updateConditions(deployment, true, now.Add(time.Minute)))
+	assert.False(t, updateConditions(deployment, false, now.Add(2*time.Minute)))
+	assert.True(t, updateConditions(deployment, false, now.Add(2*time.Minute+time.Second)))
+	progressing = getCondition(&deployment.Status, core.DeploymentProgressing)
+	assert.Equal(t, core.ConditionFalse, progressing.Status)
+	assert.Equal(t, core.DeploymentReasonProgressDeadlineExceeded, progressing.Reason)
+	assert.Equal(t, now.Add(2*time.Minute+time.Second), progressing.LastTransitionTime)
+
+	// Losing pods makes the deployment unavailable.
+	deployment.Status.ReadyReplicas = 1
+	assert.True(t, updateConditions(deployment, true, now.Add(3*time.Minute)))
+	available = getCondition(&deployment.Status, core.DeploymentAvailable)
+	assert.Equal(t, core.ConditionFalse, available.Status)
+	assert.Equal(t, core.DeploymentReasonMinimumReplicasUnavailable, available.Reason)
+
+	deployment.Status = core.DeploymentStatus{
+		Replicas:        3,
+		ReadyReplicas:   3,
+		UpdatedReplicas: 3,
+		Conditions:      deployment.Status.Conditions,
+	}
+	assert.True(t, updateConditions(deployment, true, now.Add(4*time.Minute)))
+	progressing = getCondition(&deployment.Status, core.DeploymentProgressing)
+	assert.Equal(t, core.ConditionTrue, progressing.Status)
+	assert.Equal(t, core.DeploymentReasonRolloutComplete, progressing.Reason)
+	assert.Equal(t, 2, len(deployment.Status.Conditions))
+}
+
+func TestUpdateConditionsPauseAndResume(t *testing.T) {
+	now := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	deployment := apiservertest.NewDeployment("nginx:1.22")
+	deployment.Spec.Replicas = 2
+	deployment.Spec.ProgressDeadlineSeconds = 60
+	deployment.Status = core.DeploymentStatus{Replicas: 2, ReadyReplicas: 2}
+	updateConditions(deployment, false, now)
+
+	// A paused deployment never exceeds its deadline.
+	deployment.Spec.Paused = true
+	assert.True(t, updateConditions(deployment, false, now.Add(time.Minute)))
+	assert.False(t, updateConditions(deployment, false, now.Add(time.Hour)))
+	progressing := getCondition(&deployment.Status, core.DeploymentProgressing)
+	assert.Equal(t, core.ConditionUnknown, progressing.Status)
+
+	// The deadline restarts when it resumes.
+	deployment.Spec.Paused = false
+	assert.True(t, updateConditions(deployment, false, now.Add(time.Hour)))
+	progressing = getCondition(&deployment.Status, core.DeploymentProgressing)
+	assert.Equal(t, core.DeploymentReasonResumed, progressing.Reason)
+	assert.False(t, updateConditions(deployment, false, now.Add(time.Hour+time.Minute)))
+	assert.True(t, updateConditions(deployment, false, now.Add(time.Hour+time.Minute+time.Second)))
+}
+
+func TestUpdateConditionsAwaitingPromotion(t *testing.T) {
+	now := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	deployment := apiservertest.NewDeployment("nginx:1.22")
+	deployment.Spec.Replicas = 2
+	deployment.Spec.Strategy = core.BlueGreenDeploymentStrategy
+	deployment.Status = core.DeploymentStatus{Replicas: 4, UpdatedReplicas: 2, ReadyReplicas: 4, AwaitingPromotion: true}
+	updateConditions(deployment, false, now)
+	progressing := getCondition(&deployment.Status, core.DeploymentProgressing)
+	assert.Equal(t, core.ConditionUnknown, progressing.Status)
+	assert.Equal(t, core.DeploymentReasonAwaitingPromotion, progressing.Reason)
+
+	// Old pods are kept for rollback, so the rollout is complete once the new ones are active.
+	deployment.Status.AwaitingPromotion = false
+	deployment.Status.ActiveReplicaSet = getReplicaSetName(deployment.Name, computeSpecHash(deployment))
+	assert.True(t, updateConditions(deployment, false, now))
+	progressing = getCondition(&deployment.Status, core.DeploymentProgressing)
+	assert.Equal(t, core.DeploymentReasonResumed, progressing.Reason)
+	assert.True(t, updateConditions(deployment, false, now))
+	progressing = getCondition(&deployment.Status, core.DeploymentProgressing)
+	assert.Equal(t, core.DeploymentReasonRolloutComplete, progressing.Reason)
+}
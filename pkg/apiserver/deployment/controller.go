@@ -1,9 +1,10 @@
 package deployment
 
 import (
-	"container/list"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -13,7 +14,8 @@ import (
 	"p9t.io/kuberboat/pkg/api/core"
 	"p9t.io/kuberboat/pkg/apiserver"
 	"p9t.io/kuberboat/pkg/apiserver/etcd"
-	"p9t.io/kuberboat/pkg/apiserver/pod"
+	"p9t.io/kuberboat/pkg/apiserver/replicaset"
+	"p9t.io/kuberboat/pkg/apiserver/service"
 )
 
 const (
@@ -31,33 +33,62 @@ type Contoller interface {
 	// Either way, the deployment object in ComponentManager will be replaced with the new deployment,
 	// so deployment needs to inherit the status of its older version (if it exists).
 	ApplyDeployment(deployment *core.Deployment) error
-	// DeleteDeploymentByName deletes the deployment and its pods.
-	// IMPORTANT: Must be called BEFORE metadata is modified, so the deployment can know what pods to delete.
+	// DeleteDeploymentByName deletes the deployment and the replica sets it owns.
 	DeleteDeploymentByName(name string) error
 	// DeleteAllDeployments deletes all deployments by calling DeleteDeploymentByName.
 	DeleteAllDeployments() error
+	// DeploymentHistory returns the revision history of a deployment, the oldest first. Each revision
+	// is a replica set owned by the deployment.
+	DeploymentHistory(name string) ([]core.DeploymentRevision, error)
+	// RollbackDeployment rolls a deployment back to a revision in its history, or to the revision
+	// before the current one if toRevision is 0.
+	RollbackDeployment(name string, toRevision uint64) error
+	// PauseDeployment stops rolling out the template of a deployment.
+	PauseDeployment(name string) error
+	// ResumeDeployment resumes a paused deployment.
+	ResumeDeployment(name string) error
+	// PromoteDeployment ends the current pause step of a canary rollout, or switches the active
+	// service of a blue-green deployment to the pods of its new template.
+	PromoteDeployment(name string) error
+	// GetScale returns the desired and observed replicas of a deployment.
+	GetScale(name string) (*core.Scale, error)
+	// UpdateScale sets the desired replicas of a deployment and persists them, leaving the rest of
+	// its spec as it is. If currentReplicas is not nil, the replicas are only set if the deployment
+	// still desires that many.
+	UpdateScale(name string, replicas uint32, currentReplicas *uint32) error
 	// monitorDeployment checks if the status of deployments matches their specs.
-	// If not, make adjustments.
+	// If not, scale their replica sets.
 	monitorDeployment()
 }
 
 type basicController struct {
 	mtx sync.Mutex
-	// podManager provides basicManager interfaces to manipulate pods.
+	// podManager provides basicManager interfaces to manipulate deployments and replica sets.
 	componentManager apiserver.ComponentManager
-	// podController performs the actual creating/deleting pods.
-	podController pod.Controller
-	// expectDeletedPod is used to avoid updating deployment status twice when a deployment
-	// requests a pod to be deleted. The first update happens when issuing deletion request,
-	// and the second (if not checked) will happen in pod deletion handler.
-	expectDeletedPod map[string]struct{}
+	// replicaSetController creates, scales and deletes the replica sets of deployments, which in
+	// turn manage the pods.
+	replicaSetController replicaset.Controller
+	// serviceController switches the services of blue-green deployments between replica sets.
+	serviceController service.Controller
+	// observedCounts are the numbers of pods of each deployment when its conditions were last
+	// updated, used to tell whether its rollout is making progress.
+	observedCounts map[string]replicaCounts
+	// terminatingPods are the names of the old pods of each deployment being recreated whose deletion
+	// the kubelets have not confirmed yet. The new pods are only created once they are all gone.
+	terminatingPods map[string]map[string]bool
 }
 
-func NewDeploymentController(componentManager apiserver.ComponentManager, pc pod.Controller) *basicController {
+func NewDeploymentController(
+	componentManager apiserver.ComponentManager,
+	rsc replicaset.Controller,
+	sc service.Controller,
+) *basicController {
 	controller := &basicController{
-		componentManager: componentManager,
-		podController:    pc,
-		expectDeletedPod: map[string]struct{}{},
+		componentManager:     componentManager,
+		replicaSetController: rsc,
+		serviceController:    sc,
+		observedCounts:       map[string]replicaCounts{},
+		terminatingPods:      map[string]map[string]bool{},
 	}
 	go func() {
 		for range time.Tick(time.Second * monitorInterval) {
@@ -66,8 +97,6 @@ func NewDeploymentController(componentManager apiserver.ComponentManager, pc pod
 	}()
 
 	apiserver.SubscribeToEvent(controller, apiserver.PodDeletion)
-	apiserver.SubscribeToEvent(controller, apiserver.PodReady)
-	apiserver.SubscribeToEvent(controller, apiserver.PodFail)
 
 	return controller
 }
@@ -120,32 +149,71 @@ func (m *basicController) ApplyDeployment(deployment *core.Deployment) error {
 	m.mtx.Lock()
 	defer m.mtx.Unlock()
 
+	if deployment.Spec.ProgressDeadlineSeconds == 0 {
+		deployment.Spec.ProgressDeadlineSeconds = defaultProgressDeadlineSeconds
+	}
+	switch deployment.Spec.Strategy {
+	case "":
+		deployment.Spec.Strategy = core.RollingUpdateDeploymentStrategy
+	case core.RollingUpdateDeploymentStrategy, core.RecreateDeploymentStrategy:
+	case core.CanaryDeploymentStrategy, core.BlueGreenDeploymentStrategy:
+		if err := checkStrategy(deployment); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported deployment strategy: %v", deployment.Spec.Strategy)
+	}
+
 	isDeploymentExistent := m.componentManager.DeploymentExistsByName(deployment.Name)
 	if isDeploymentExistent {
 		existingDeployment := m.componentManager.GetDeploymentByName(deployment.Name)
+		templateUpdated := !isDeploymentUpdated(existingDeployment, deployment)
+		if templateUpdated {
+			if err := checkStrategy(deployment); err != nil {
+				return err
+			}
+		}
 
-		// Trigger rolling update by setting updatedPods to 0.
-		if !isDeploymentUpdated(existingDeployment, deployment) {
-			if deployment.Spec.RollingUpdate.MaxSurge == 0 && deployment.Spec.RollingUpdate.MaxUnavailable == 0 {
-				return errors.New("cannot trigger rolling update when maxSurge and maxUnavailable are both 0")
+		// Build the update on a copy, so that a failed update leaves the deployment as it is in etcd.
+		updated := copyDeployment(existingDeployment)
+		if !isSpecUpdated(existingDeployment, deployment) {
+			updated.Generation++
+		}
+		updated.Spec.RevisionHistoryLimit = deployment.Spec.RevisionHistoryLimit
+		updated.Spec.Paused = deployment.Spec.Paused
+		updated.Spec.ProgressDeadlineSeconds = deployment.Spec.ProgressDeadlineSeconds
+		updated.Spec.Strategy = deployment.Spec.Strategy
+		updated.Spec.MinReadySeconds = deployment.Spec.MinReadySeconds
+		updated.Spec.Canary = deployment.Spec.Canary
+		updated.Spec.BlueGreen = deployment.Spec.BlueGreen
+
+		// Trigger rolling update by rolling out the replica set of the new template.
+		if templateUpdated {
+			if err := m.rollOut(updated, deployment, deployment.Spec.ChangeCause); err != nil {
+				return err
 			}
-			updateDeploymentTemplate(existingDeployment, deployment)
-			existingDeployment.Status.UpdatedReplicas = 0
 		}
-		existingDeployment.Spec.Replicas = deployment.Spec.Replicas
-		existingDeployment.Spec.RollingUpdate = deployment.Spec.RollingUpdate
+		updated.Spec.Replicas = deployment.Spec.Replicas
+		updated.Spec.RollingUpdate = deployment.Spec.RollingUpdate
+		updated.Spec.ChangeCause = deployment.Spec.ChangeCause
 
 		// Update the deployment metadata.
 		// Should have updated etcd before modifying existingDeployment.
-		if err := setDeploymentInEtcd(deployment.Name, existingDeployment); err != nil {
+		if err := setDeploymentInEtcd(deployment.Name, updated); err != nil {
 			return err
 		}
+		*existingDeployment = *updated
 	} else {
 		initDeployment(deployment)
+		if err := m.rollOut(deployment, deployment, deployment.Spec.ChangeCause); err != nil {
+			return err
+		}
+		// There are no old pods to be careful with.
+		deployment.Status.CanaryStep = uint32(len(deployment.Spec.Canary.Steps))
 		if err := setDeploymentInEtcd(deployment.Name, deployment); err != nil {
 			return err
 		}
-		m.componentManager.SetDeployment(deployment, list.New())
+		m.componentManager.SetDeployment(deployment)
 	}
 
 	glog.Infof(
@@ -157,121 +225,280 @@ func (m *basicController) ApplyDeployment(deployment *core.Deployment) error {
 	return nil
 }
 
-// Only Replicas will be incremented. ReadyReplcas and UpdatedRelicas will be modified when receiving events.
-func (m *basicController) morePods(deployment *core.Deployment, existingPods *list.List, numPodsToAdd int) {
-	if existingPods == nil {
-		panic("pod list is nil even after checking")
+// checkStrategy checks whether the strategy of a deployment can replace its pods.
+func checkStrategy(deployment *core.Deployment) error {
+	switch deployment.Spec.Strategy {
+	case core.RecreateDeploymentStrategy:
+	case core.CanaryDeploymentStrategy:
+		for i, step := range deployment.Spec.Canary.Steps {
+			if !step.Pause && step.SetWeight > 100 {
+				return fmt.Errorf("weight of canary step %v is over 100: %v", i, step.SetWeight)
+			}
+		}
+	case core.BlueGreenDeploymentStrategy:
+		if deployment.Spec.BlueGreen.ActiveService == "" {
+			return errors.New("blue-green strategy requires an active service")
+		}
+	default:
+		if deployment.Spec.RollingUpdate.MaxSurge == 0 && deployment.Spec.RollingUpdate.MaxUnavailable == 0 {
+			return errors.New("cannot trigger rolling update when maxSurge and maxUnavailable are both 0")
+		}
 	}
+	return nil
+}
 
-	glog.Infof("DEPLOYMENT [%v]: adding %v pods", deployment.Name, numPodsToAdd)
-	numPodsAdded := 0
-	// Create new pods from template. Keep creating even if it fails.
-	specHash := computeSpecHash(deployment)
-	for i := 0; i < int(numPodsToAdd); i++ {
-		p := &core.Pod{Kind: core.PodType}
-		p.Name = getPodName(deployment, specHash)
-		p.Labels = deployment.Spec.Template.Labels
-		p.Spec = deployment.Spec.Template.Spec
-
-		if err := m.podController.CreatePod(p); err != nil {
-			glog.Errorf("DEPLOYMENT [%v]: failed to create pod: ", deployment.Name, err.Error())
-			continue
-		} else {
-			deployment.Status.Replicas++
-			numPodsAdded++
+// rollOut makes the replica set of the template of target the newest revision of a deployment,
+// creating the replica set if the template was never rolled out, and starts a rolling update to it.
+// The replica set keeps its change cause unless a new one is given. The caller must hold the lock.
+func (m *basicController) rollOut(existingDeployment *core.Deployment, target *core.Deployment, changeCause string) error {
+	specHash := computeSpecHash(target)
+	name := getReplicaSetName(existingDeployment.Name, specHash)
+	revision := maxRevision(m.componentManager.ListReplicaSetsByDeploymentName(existingDeployment.Name)) + 1
+	if replicaSet := m.componentManager.GetReplicaSetByName(name); replicaSet != nil {
+		if replicaSet.OwnerName != existingDeployment.Name {
+			return fmt.Errorf("replica set %v is not owned by deployment %v", name, existingDeployment.Name)
 		}
+		if err := m.replicaSetController.UpdateReplicaSet(name, func(replicaSet *core.ReplicaSet) {
+			replicaSet.Revision = revision
+			if changeCause != "" {
+				replicaSet.ChangeCause = changeCause
+			}
+		}); err != nil {
+			return err
+		}
+	} else {
+		replicaSet := &core.ReplicaSet{
+			OwnerName:    existingDeployment.Name,
+			TemplateHash: specHash,
+			Revision:     revision,
+			ChangeCause:  changeCause,
+		}
+		replicaSet.Name = name
+		replicaSet.Spec.MinReadySeconds = target.Spec.MinReadySeconds
+		replicaSet.Spec.Template = target.Spec.Template
+		if err := m.replicaSetController.CreateReplicaSet(replicaSet); err != nil {
+			return err
+		}
+	}
+	updateDeploymentTemplate(existingDeployment, target)
+	existingDeployment.Status.Revision = revision
+	existingDeployment.Status.CanaryStep = 0
+	existingDeployment.Status.StepStartTime = time.Time{}
+	existingDeployment.Status.AwaitingPromotion = false
+	setCondition(&existingDeployment.Status, newCondition(
+		core.DeploymentProgressing,
+		core.ConditionTrue,
+		core.DeploymentReasonNewTemplate,
+		fmt.Sprintf("revision %v is rolling out", revision),
+		time.Now(),
+	))
+	return nil
+}
+
+func (m *basicController) DeploymentHistory(name string) ([]core.DeploymentRevision, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
 
-		existingPods.PushBack(p)
-		glog.Infof("DEPLOYMENT [%v]: added pod [%v]", deployment.Name, p.Name)
+	if !m.componentManager.DeploymentExistsByName(name) {
+		return nil, fmt.Errorf("no such deployment: %v", name)
 	}
-	if err := etcd.Put(fmt.Sprintf("/Deployments/Meta/%s", deployment.Name), deployment); err != nil {
-		glog.Errorf("failed to update deployment's metadata: %v", err)
+	return revisionsOf(m.componentManager.ListReplicaSetsByDeploymentName(name)), nil
+}
+
+func (m *basicController) RollbackDeployment(name string, toRevision uint64) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	deployment := m.componentManager.GetDeploymentByName(name)
+	if deployment == nil {
+		return fmt.Errorf("no such deployment: %v", name)
 	}
-	if err := etcd.Put(fmt.Sprintf("/Deployments/Pods/%s", deployment.Name), core.GetPodNames(existingPods)); err != nil {
-		glog.Errorf("failed to update deployment's corresponding pods: %v", err)
+	revisions := revisionsOf(m.componentManager.ListReplicaSetsByDeploymentName(name))
+	revision, err := findRevision(revisions, toRevision, deployment.Status.Revision)
+	if err != nil {
+		return err
 	}
-	glog.Infof("DEPLOYMENT [%v]: expected to add %v pods, actually added %v", deployment.Name, numPodsToAdd, numPodsAdded)
-}
 
-// Replicas, ReadyReplicas and UpdatedReplicas are updated immediately after grpc returns successfully.
-// Try to find and delete outdated pods first.
-func (m *basicController) fewerPods(deployment *core.Deployment, existingPods *list.List, numPodsToDelete int) {
-	if existingPods == nil {
-		panic("pod list is nil even after checking")
+	target := *deployment
+	target.Spec.Template = revision.Template
+	if isDeploymentUpdated(deployment, &target) {
+		glog.Infof("DEPLOYMENT [%v]: already at the template of revision %v", name, revision.Revision)
+		return nil
+	}
+	if err := checkStrategy(deployment); err != nil {
+		return err
+	}
+	rolledBackFrom := revision.Revision
+	if err := m.rollOut(deployment, &target, ""); err != nil {
+		return err
+	}
+	deployment.Generation++
+	// The replica set of a blue-green deployment may still be kept in full for an instant rollback.
+	if deployment.Spec.Strategy == core.BlueGreenDeploymentStrategy {
+		if newReplicaSet, _ := m.getReplicaSets(deployment); newReplicaSet != nil &&
+			isFullyAvailable(deployment, newReplicaSet) {
+			if err := m.promote(deployment, newReplicaSet); err != nil {
+				return err
+			}
+		}
 	}
+	if err := setDeploymentInEtcd(name, deployment); err != nil {
+		return err
+	}
+
+	glog.Infof(
+		"DEPLOYMENT [%v]: rolling back to the template of revision %v as revision %v",
+		name,
+		rolledBackFrom,
+		deployment.Status.Revision,
+	)
+	return nil
+}
+
+func (m *basicController) PauseDeployment(name string) error {
+	return m.setPaused(name, true)
+}
+
+func (m *basicController) ResumeDeployment(name string) error {
+	return m.setPaused(name, false)
+}
+
+// setPaused pauses or resumes a deployment. The conditions follow when the deployment is next
+// monitored.
+func (m *basicController) setPaused(name string, paused bool) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
 
-	glog.Infof("DEPLOYMENT [%v]: deleting %v pods", deployment.Name, numPodsToDelete)
-	// Remove the latest pods
-	if existingPods.Len() < numPodsToDelete {
-		glog.Errorf("deployment status and number of pods to remove do not match: %v vs. %v", existingPods.Len(), numPodsToDelete)
+	deployment := m.componentManager.GetDeploymentByName(name)
+	if deployment == nil {
+		return fmt.Errorf("no such deployment: %v", name)
+	}
+	if deployment.Spec.Paused == paused {
+		return nil
 	}
+	deployment.Spec.Paused = paused
+	deployment.Generation++
+	if err := setDeploymentInEtcd(name, deployment); err != nil {
+		return err
+	}
+	if paused {
+		glog.Infof("DEPLOYMENT [%v]: paused", name)
+	} else {
+		glog.Infof("DEPLOYMENT [%v]: resumed", name)
+	}
+	return nil
+}
 
-	// Try to delete outdated pods first.
-	numOutdatedPodsDeleted := 0
-	outdatedPods := findOutdatedPods(deployment, existingPods)
-	for idx, p := range outdatedPods {
-		if idx >= numPodsToDelete {
-			break
+func (m *basicController) PromoteDeployment(name string) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	deployment := m.componentManager.GetDeploymentByName(name)
+	if deployment == nil {
+		return fmt.Errorf("no such deployment: %v", name)
+	}
+	switch deployment.Spec.Strategy {
+	case core.CanaryDeploymentStrategy:
+		steps := deployment.Spec.Canary.Steps
+		step := deployment.Status.CanaryStep
+		if int(step) >= len(steps) || !steps[step].Pause {
+			return fmt.Errorf("deployment %v is not paused by a canary step", name)
 		}
-		if err := m.deleteDeploymentPod(deployment, p); err != nil {
-			glog.Errorf("DEPLOYMENT [%v]: failed to delete pod: %v", deployment.Name, err.Error())
-			continue
+		nextCanaryStep(deployment, time.Now())
+		deployment.Status.AwaitingPromotion = false
+		glog.Infof("DEPLOYMENT [%v]: promoted past canary step %v", name, step)
+	case core.BlueGreenDeploymentStrategy:
+		newReplicaSet, _ := m.getReplicaSets(deployment)
+		if newReplicaSet == nil || !isFullyAvailable(deployment, newReplicaSet) {
+			return fmt.Errorf("pods of the new template of deployment %v are not all ready", name)
 		}
-		numOutdatedPodsDeleted++
-	}
-
-	numPodsDeleted := 0
-	for i := 0; i < api.Max(0, int(numPodsToDelete)-len(outdatedPods)); i++ {
-		it := existingPods.Back()
-		p := it.Value.(*core.Pod)
-		if err := m.deleteDeploymentPod(deployment, p); err != nil {
-			glog.Errorf("DEPLOYMENT [%v]: failed to delete pod: %v", deployment.Name, err.Error())
-			continue
+		if newReplicaSet.Name == deployment.Status.ActiveReplicaSet {
+			return fmt.Errorf("deployment %v is already active on its new template", name)
 		}
-		numPodsDeleted++
+		if err := m.promote(deployment, newReplicaSet); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("deployment %v with strategy %v cannot be promoted", name, deployment.Spec.Strategy)
 	}
+	return setDeploymentInEtcd(name, deployment)
+}
 
-	if err := etcd.Put(fmt.Sprintf("/Deployments/Meta/%s", deployment.Name), deployment); err != nil {
-		glog.Errorf("failed to update deployment's metadata: %v", err)
+func (m *basicController) GetScale(name string) (*core.Scale, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	deployment := m.componentManager.GetDeploymentByName(name)
+	if deployment == nil {
+		return nil, fmt.Errorf("no such deployment: %v", name)
+	}
+	return &core.Scale{
+		Name: name,
+		Spec: core.ScaleSpec{Replicas: deployment.Spec.Replicas},
+		Status: core.ScaleStatus{
+			Replicas:      deployment.Status.Replicas,
+			ReadyReplicas: deployment.Status.ReadyReplicas,
+		},
+	}, nil
+}
+
+func (m *basicController) UpdateScale(name string, replicas uint32, currentReplicas *uint32) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	deployment := m.componentManager.GetDeploymentByName(name)
+	if deployment == nil {
+		return fmt.Errorf("no such deployment: %v", name)
 	}
-	if err := etcd.Put(fmt.Sprintf("/Deployments/Pods/%s", deployment.Name), core.GetPodNames(existingPods)); err != nil {
-		glog.Errorf("failed to update deployment's corresponding pods: %v", err)
+	if currentReplicas != nil && *currentReplicas != deployment.Spec.Replicas {
+		return fmt.Errorf(
+			"expected %v replicas of deployment %v, but it has %v",
+			*currentReplicas,
+			name,
+			deployment.Spec.Replicas,
+		)
 	}
-	glog.Infof("DEPLOYMENT [%v]: expected to delete %v pods, actually deleted %v updated, %v outdated",
-		deployment.Name,
-		numPodsToDelete,
-		numPodsDeleted,
-		numOutdatedPodsDeleted)
+	if deployment.Spec.Replicas == replicas {
+		return nil
+	}
+
+	// Persist the new replicas before they take effect.
+	scaled := *deployment
+	scaled.Spec.Replicas = replicas
+	scaled.Generation++
+	if err := setDeploymentInEtcd(name, &scaled); err != nil {
+		return err
+	}
+	oldReplicas := deployment.Spec.Replicas
+	deployment.Spec.Replicas = scaled.Spec.Replicas
+	deployment.Generation = scaled.Generation
+
+	glog.Infof("DEPLOYMENT [%v]: scaled from %v to %v replicas", name, oldReplicas, replicas)
+	return nil
 }
 
 func (m *basicController) DeleteDeploymentByName(name string) error {
 	m.mtx.Lock()
 	defer m.mtx.Unlock()
 
-	if m.componentManager.DeploymentExistsByName(name) {
-		glog.Infof("DEPLOYMENT [%v]: deleting", name)
-		// Delete all pods belonging to the deployment.
-		podList := m.componentManager.ListPodsByDeploymentName(name)
-		if podList == nil {
-			glog.Errorf("DEPLOYMENT [%v]: nil pod list", name)
-			return fmt.Errorf("unable to find pods for deployment [%v]", name)
-		}
-		// Repeatedly call Front(), because list is being changed for each call to DeletePodByName.
-		for i := podList.Front(); podList.Len() > 0; i = podList.Front() {
-			podName := i.Value.(*core.Pod).Name
-			if err := m.podController.DeletePodByName(i.Value.(*core.Pod).Name); err != nil {
-				glog.Errorf("DEPLOYMENT [%v]: unable to delete pod [%v]: %v", name, podName, err.Error())
-			}
-			glog.Infof("DEPLOYMENT [%v]: deleted pod [%v]", name, podName)
-		}
-		// Delete the deployment in etcd and memory.
-		DeleteDeploymentInEtcd(name)
-		m.componentManager.DeleteDeploymentByName(name)
-		glog.Infof("DEPLOYMENT [%v]: deployment deleted", name)
-	} else {
+	if !m.componentManager.DeploymentExistsByName(name) {
 		return fmt.Errorf("no such deployment: %v", name)
 	}
-
+	glog.Infof("DEPLOYMENT [%v]: deleting", name)
+	// Delete all replica sets owned by the deployment, which delete their pods.
+	for _, replicaSet := range m.componentManager.ListReplicaSetsByDeploymentName(name) {
+		if err := m.replicaSetController.DeleteReplicaSetByName(replicaSet.Name, name); err != nil {
+			glog.Errorf("DEPLOYMENT [%v]: unable to delete replica set [%v]: %v", name, replicaSet.Name, err.Error())
+			continue
+		}
+		glog.Infof("DEPLOYMENT [%v]: deleted replica set [%v]", name, replicaSet.Name)
+	}
+	// Delete the deployment in etcd and memory.
+	DeleteDeploymentInEtcd(name)
+	m.componentManager.DeleteDeploymentByName(name)
+	delete(m.observedCounts, name)
+	delete(m.terminatingPods, name)
+	glog.Infof("DEPLOYMENT [%v]: deployment deleted", name)
 	return nil
 }
 
@@ -285,155 +512,286 @@ func (m *basicController) DeleteAllDeployments() error {
 	return nil
 }
 
-func (m *basicController) HandleEvent(event apiserver.Event) {
+// getReplicaSets returns the replica set of the current template of a deployment, or nil if there
+// is none, and the other replica sets it owns sorted by revision.
+func (m *basicController) getReplicaSets(deployment *core.Deployment) (*core.ReplicaSet, []*core.ReplicaSet) {
+	specHash := computeSpecHash(deployment)
+	var newReplicaSet *core.ReplicaSet = nil
+	oldReplicaSets := make([]*core.ReplicaSet, 0)
+	for _, replicaSet := range m.componentManager.ListReplicaSetsByDeploymentName(deployment.Name) {
+		if replicaSet.TemplateHash == specHash {
+			newReplicaSet = replicaSet
+		} else {
+			oldReplicaSets = append(oldReplicaSets, replicaSet)
+		}
+	}
+	sort.Slice(oldReplicaSets, func(i, j int) bool {
+		return oldReplicaSets[i].Revision < oldReplicaSets[j].Revision
+	})
+	return newReplicaSet, oldReplicaSets
+}
+
+func (m *basicController) monitorDeployment() {
 	m.mtx.Lock()
 	defer m.mtx.Unlock()
 
-	var err error = nil
-
-	switch event.Type() {
-	case apiserver.PodDeletion:
-		legacy := event.(*apiserver.PodDeletionEvent).PodLegacy
-		var deploymentName string
-		if legacy != nil {
-			deploymentName = legacy.DeploymentName
-		}
-		m.handlePodDeletion(event.(*apiserver.PodDeletionEvent).Pod, deploymentName)
-	case apiserver.PodReady:
-		podName := event.(*apiserver.PodReadyEvent).PodName
-		if m.componentManager.PodExistsByName(podName) {
-			err = m.handlePodReady(m.componentManager.GetPodByName(podName))
+	deployments := m.componentManager.ListDeployments()
+	for _, deployment := range deployments {
+		newReplicaSet, oldReplicaSets := m.getReplicaSets(deployment)
+		if newReplicaSet == nil {
+			glog.Warningf("DEPLOYMENT [%v]: no replica set for the current template, rolling it out again", deployment.Name)
+			if err := m.rollOut(deployment, deployment, deployment.Spec.ChangeCause); err != nil {
+				glog.Errorf("DEPLOYMENT [%v]: %v", deployment.Name, err)
+				continue
+			}
+			if err := setDeploymentInEtcd(deployment.Name, deployment); err != nil {
+				glog.Errorf("failed to update deployment's metadata: %v", err)
+			}
+			continue
 		}
-	case apiserver.PodFail:
-		podName := event.(*apiserver.PodFailEvent).PodName
-		pod := m.componentManager.GetPodByName(podName)
-		if pod == nil {
-			glog.Errorf("failed pod does not exist: %v", podName)
-			return
+
+		statusChanged := false
+		switch {
+		case deployment.Spec.Paused && totalSpecReplicas(oldReplicaSets) > 0:
+			// A paused deployment keeps its pods of the old template until it resumes.
+		case deployment.Spec.Strategy == core.RecreateDeploymentStrategy:
+			m.recreate(deployment, newReplicaSet, oldReplicaSets)
+		case deployment.Spec.Strategy == core.CanaryDeploymentStrategy:
+			statusChanged = m.canary(deployment, newReplicaSet, oldReplicaSets)
+		case deployment.Spec.Strategy == core.BlueGreenDeploymentStrategy:
+			statusChanged = m.blueGreen(deployment, newReplicaSet, oldReplicaSets)
+		default:
+			m.rollingUpdate(deployment, newReplicaSet, oldReplicaSets)
 		}
-		if deployment := m.componentManager.GetDeploymentByPodName(podName); deployment != nil {
-			m.deleteDeploymentPod(deployment, pod)
+		// A deployment switched away from a strategy awaiting promotion no longer waits.
+		if deployment.Status.AwaitingPromotion && !deployment.Spec.Paused &&
+			deployment.Spec.Strategy != core.CanaryDeploymentStrategy &&
+			deployment.Spec.Strategy != core.BlueGreenDeploymentStrategy {
+			deployment.Status.AwaitingPromotion = false
+			statusChanged = true
 		}
-	}
-
-	if err != nil {
-		glog.Error(err)
+		m.cleanUpReplicaSets(deployment, oldReplicaSets)
+		statusChanged = syncStatus(deployment, newReplicaSet, oldReplicaSets) || statusChanged
+		m.observeDeployment(deployment, statusChanged)
 	}
 }
 
-func (m *basicController) handlePodDeletion(pod *core.Pod, deploymentName string) error {
-	// Avoid updating pod status twice.
-	if _, present := m.expectDeletedPod[pod.Name]; present {
-		delete(m.expectDeletedPod, pod.Name)
-		return nil
+// rollingUpdate scales the new replica set of a deployment up and the old ones down, as bounded by
+// maxSurge and maxUnavailable. Scaling a deployment without old replica sets is a rolling update
+// that finishes at once. The caller must hold the lock.
+func (m *basicController) rollingUpdate(
+	deployment *core.Deployment,
+	newReplicaSet *core.ReplicaSet,
+	oldReplicaSets []*core.ReplicaSet,
+) {
+	m.scaleReplicaSet(deployment, newReplicaSet, newReplicaSetReplicas(deployment, newReplicaSet, oldReplicaSets))
+	for i, replicas := range oldReplicaSetsReplicas(deployment, newReplicaSet, oldReplicaSets) {
+		m.scaleReplicaSet(deployment, oldReplicaSets[i], replicas)
 	}
-	// If deployment is not found, then the pod must be deleted because its managing deployment is deleted.
-	if deployment := m.componentManager.GetDeploymentByName(deploymentName); deployment != nil {
-		updateDeploymentStatusOnPodRemoval(deployment, pod)
-		if err := etcd.Put(fmt.Sprintf("/Deployments/Meta/%s", deploymentName), deployment); err != nil {
-			return err
-		}
-	}
-	return nil
 }
 
-func (m *basicController) handlePodReady(pod *core.Pod) error {
-	if pod == nil {
-		return fmt.Errorf("pod is nil")
-	}
-	// It's not likely that the number of pods exceed the deployment's desired number, the only case being when
-	// the number of desired replicas is decreased by auto scaler or the user. In that case, applyDeployment will handle pod deletion.
-	if deployment := m.componentManager.GetDeploymentByPodName(pod.Name); deployment != nil {
-		deployment.Status.ReadyReplicas++
-		// If a pod becomes ready but is not updated, it could only be that the deployment template has been chaged
-		// during pod creation.
-		if isPodUpdated(deployment, pod) {
-			deployment.Status.UpdatedReplicas++
-			if err := etcd.Put(fmt.Sprintf("/Deployments/Meta/%s", deployment.Name), deployment); err != nil {
-				return err
+// recreate scales the old replica sets of a deployment down to zero, and scales the new one up once
+// the kubelets have confirmed that all the old pods are gone. The caller must hold the lock.
+func (m *basicController) recreate(
+	deployment *core.Deployment,
+	newReplicaSet *core.ReplicaSet,
+	oldReplicaSets []*core.ReplicaSet,
+) {
+	terminating, ok := m.terminatingPods[deployment.Name]
+	if !ok {
+		terminating = map[string]bool{}
+		m.terminatingPods[deployment.Name] = terminating
+	}
+	for _, replicaSet := range oldReplicaSets {
+		// The pods are remembered before they are deleted, since the kubelets delete them later on.
+		if pods := m.componentManager.ListPodsByReplicaSetName(replicaSet.Name); pods != nil {
+			for _, podName := range core.GetPodNames(pods) {
+				terminating[podName] = true
 			}
 		}
+		m.scaleReplicaSet(deployment, replicaSet, 0)
 	}
-
-	return nil
+	if len(terminating) > 0 {
+		return
+	}
+	delete(m.terminatingPods, deployment.Name)
+	m.scaleReplicaSet(deployment, newReplicaSet, deployment.Spec.Replicas)
 }
 
-func (m *basicController) monitorDeployment() {
+func (m *basicController) HandleEvent(event apiserver.Event) {
 	m.mtx.Lock()
 	defer m.mtx.Unlock()
 
-	deployments := m.componentManager.ListDeployments()
-	for _, deployment := range deployments {
-		pods := m.componentManager.ListPodsByDeploymentName(deployment.Name)
-		if pods == nil {
-			glog.Errorf("DEPLOYMENT [%v]: nil pod list", deployment.Name)
-			continue
+	switch event.Type() {
+	case apiserver.PodDeletion:
+		podName := event.(*apiserver.PodDeletionEvent).Pod.Name
+		for _, terminating := range m.terminatingPods {
+			delete(terminating, podName)
 		}
-		// Only consider maxSurge and maxUnavailable when the deployment is under rolling update.
-		if deployment.Status.UpdatedReplicas < deployment.Status.ReadyReplicas {
-			// Pods might be created and deleted at the same time, so cannot reuse normal update logic.
-			// Delete outdated pods.
-			numPodDiff := computeRollingUpdatePodDeletion(deployment, pods)
-			if numPodDiff > 0 {
-				m.fewerPods(deployment, pods, numPodDiff)
-			}
+	}
+}
 
-			numPodDiff = computeRollingUpdatePodCreation(deployment, pods)
-			if numPodDiff > 0 {
-				m.morePods(deployment, pods, numPodDiff)
-			}
-		} else {
-			numPodDiff := int(deployment.Spec.Replicas) - int(deployment.Status.Replicas)
-			if numPodDiff > 0 {
-				m.morePods(deployment, pods, numPodDiff)
-			} else if numPodDiff < 0 {
-				m.fewerPods(deployment, pods, -numPodDiff)
-			}
-		}
+// scaleReplicaSet sets the number of replicas of a replica set owned by a deployment, and keeps its
+// MinReadySeconds the same as the deployment. The caller must hold the lock.
+func (m *basicController) scaleReplicaSet(deployment *core.Deployment, replicaSet *core.ReplicaSet, replicas uint32) {
+	minReadySeconds := deployment.Spec.MinReadySeconds
+	if replicaSet.Spec.Replicas == replicas && replicaSet.Spec.MinReadySeconds == minReadySeconds {
+		return
+	}
+	oldReplicas := replicaSet.Spec.Replicas
+	if err := m.replicaSetController.UpdateReplicaSet(replicaSet.Name, func(replicaSet *core.ReplicaSet) {
+		replicaSet.Spec.Replicas = replicas
+		replicaSet.Spec.MinReadySeconds = minReadySeconds
+	}); err != nil {
+		glog.Errorf("DEPLOYMENT [%v]: failed to scale replica set [%v]: %v", deployment.Name, replicaSet.Name, err)
+		return
+	}
+	if oldReplicas != replicas {
+		glog.Infof(
+			"DEPLOYMENT [%v]: scaled replica set [%v] from %v to %v",
+			deployment.Name,
+			replicaSet.Name,
+			oldReplicas,
+			replicas,
+		)
 	}
 }
 
-func (m *basicController) deleteDeploymentPod(deployment *core.Deployment, pod *core.Pod) error {
-	if err := m.podController.DeletePodByName(pod.Name); err != nil {
-		return err
+// cleanUpReplicaSets deletes the old replica sets of a deployment beyond its revision history limit.
+// The caller must hold the lock.
+func (m *basicController) cleanUpReplicaSets(deployment *core.Deployment, oldReplicaSets []*core.ReplicaSet) {
+	for _, replicaSet := range replicaSetsToCleanUp(deployment, oldReplicaSets) {
+		if err := m.replicaSetController.DeleteReplicaSetByName(replicaSet.Name, deployment.Name); err != nil {
+			glog.Errorf("DEPLOYMENT [%v]: failed to delete replica set [%v]: %v", deployment.Name, replicaSet.Name, err)
+			continue
+		}
+		glog.Infof(
+			"DEPLOYMENT [%v]: deleted replica set [%v] of revision %v beyond the history limit",
+			deployment.Name,
+			replicaSet.Name,
+			replicaSet.Revision,
+		)
 	}
+}
 
-	// DeletePodByName will alter deployment's pod list, so no need to modify it here.
-	m.expectDeletedPod[pod.Name] = struct{}{}
-	updateDeploymentStatusOnPodRemoval(deployment, pod)
-	glog.Infof("DEPLOYMENT [%v]: deleted pod [%v]", deployment.Name, pod.Name)
+// syncStatus sums up the status of the replica sets of a deployment into its status. It returns
+// whether the status has changed.
+func syncStatus(deployment *core.Deployment, newReplicaSet *core.ReplicaSet, oldReplicaSets []*core.ReplicaSet) bool {
+	counts := replicaCounts{
+		replicas:        newReplicaSet.Status.Replicas,
+		updatedReplicas: newReplicaSet.Status.ReadyReplicas,
+		readyReplicas:   newReplicaSet.Status.ReadyReplicas,
+	}
+	for _, replicaSet := range oldReplicaSets {
+		counts.replicas += replicaSet.Status.Replicas
+		counts.readyReplicas += replicaSet.Status.ReadyReplicas
+	}
+	if counts == countsOf(deployment) {
+		return false
+	}
+	deployment.Status.Replicas = counts.replicas
+	deployment.Status.UpdatedReplicas = counts.updatedReplicas
+	deployment.Status.ReadyReplicas = counts.readyReplicas
+	return true
+}
 
-	return nil
+// observeDeployment updates the observed generation and the conditions of a deployment after it is
+// monitored, and persists it if anything has changed. The caller must hold the lock.
+func (m *basicController) observeDeployment(deployment *core.Deployment, statusChanged bool) {
+	counts := countsOf(deployment)
+	lastCounts, ok := m.observedCounts[deployment.Name]
+	m.observedCounts[deployment.Name] = counts
+	progressed := ok && lastCounts != counts
+
+	changed := updateConditions(deployment, progressed, time.Now()) || statusChanged
+	if deployment.Status.ObservedGeneration != deployment.Generation {
+		deployment.Status.ObservedGeneration = deployment.Generation
+		changed = true
+	}
+	if !changed {
+		return
+	}
+	if err := setDeploymentInEtcd(deployment.Name, deployment); err != nil {
+		glog.Errorf("failed to update deployment's metadata: %v", err)
+	}
+	if progressing := getCondition(&deployment.Status, core.DeploymentProgressing); progressing != nil &&
+		progressing.Reason == core.DeploymentReasonProgressDeadlineExceeded {
+		glog.Warningf("DEPLOYMENT [%v]: %v", deployment.Name, progressing.Message)
+	}
 }
 
 func initDeployment(deployment *core.Deployment) {
 	deployment.UUID = uuid.New()
 	deployment.CreationTimestamp = time.Now()
-	deployment.Status.Replicas = 0
-	deployment.Status.ReadyReplicas = 0
-	deployment.Status.UpdatedReplicas = 0
+	deployment.Generation = 1
+	deployment.Status = core.DeploymentStatus{}
+}
+
+// AdoptingReplicaSet returns the replica set to take over the pods of a deployment stored by a
+// version that kept them in the deployment itself. It is the replica set of the current template,
+// so that the deployment finds it rather than rolling out again.
+func AdoptingReplicaSet(deployment *core.Deployment) *core.ReplicaSet {
+	specHash := computeSpecHash(deployment)
+	revision := deployment.Status.Revision
+	if revision == 0 {
+		revision = 1
+	}
+	replicaSet := &core.ReplicaSet{
+		Kind:         core.ReplicaSetType,
+		OwnerName:    deployment.Name,
+		TemplateHash: specHash,
+		Revision:     revision,
+		ChangeCause:  deployment.Spec.ChangeCause,
+	}
+	replicaSet.Name = getReplicaSetName(deployment.Name, specHash)
+	replicaSet.UUID = uuid.New()
+	replicaSet.CreationTimestamp = deployment.CreationTimestamp
+	replicaSet.Spec.Replicas = deployment.Spec.Replicas
+	replicaSet.Spec.MinReadySeconds = deployment.Spec.MinReadySeconds
+	replicaSet.Spec.Template = deployment.Spec.Template
+	return replicaSet
 }
 
-func getPodName(d *core.Deployment, specHash string) string {
-	// Pod UUID is still unknown, so we will just generate a new UUID.
-	return d.Name + "-" + specHash[0:10] + "-" + uuid.NewString()[0:5]
+func getReplicaSetName(deploymentName string, specHash string) string {
+	return deploymentName + "-" + specHash[0:10]
 }
 
-// computeSpecHash, isDeploymentUpdated, updateDeploymentTemplate, isPodUpdated must be consistent.
+// computeSpecHash, isDeploymentUpdated and updateDeploymentTemplate must be consistent.
+//
+// The hash names the replica set of a template, so it must not change across restarts. Unlike
+// api.Hash on the template itself, hashing its JSON encoding does not depend on the addresses its
+// pointer fields hold.
 func computeSpecHash(deployment *core.Deployment) string {
 	type relevantSpec struct {
-		labels  map[string]string
-		podSpec core.PodSpec
+		Labels  map[string]string
+		PodSpec core.PodSpec
 	}
-	return api.Hash(&relevantSpec{
-		labels:  deployment.Spec.Template.ObjectMeta.Labels,
-		podSpec: deployment.Spec.Template.Spec,
+	data, err := json.Marshal(&relevantSpec{
+		Labels:  deployment.Spec.Template.ObjectMeta.Labels,
+		PodSpec: deployment.Spec.Template.Spec,
 	})
+	if err != nil {
+		glog.Fatalf("DEPLOYMENT [%v]: failed to encode template: %v", deployment.Name, err)
+	}
+	return api.Hash(string(data))
 }
 
 func isDeploymentUpdated(d1 *core.Deployment, d2 *core.Deployment) bool {
-	return api.Hash(d1.Spec.Template.Labels) == api.Hash(d2.Spec.Template.Labels) &&
-		api.Hash(d1.Spec.Template.Spec) == api.Hash(d2.Spec.Template.Spec)
+	return computeSpecHash(d1) == computeSpecHash(d2)
+}
+
+// isSpecUpdated tells whether two deployments have the same spec, apart from the change cause.
+func isSpecUpdated(d1 *core.Deployment, d2 *core.Deployment) bool {
+	return isDeploymentUpdated(d1, d2) &&
+		d1.Spec.Replicas == d2.Spec.Replicas &&
+		d1.Spec.RollingUpdate == d2.Spec.RollingUpdate &&
+		d1.Spec.RevisionHistoryLimit == d2.Spec.RevisionHistoryLimit &&
+		d1.Spec.Paused == d2.Spec.Paused &&
+		d1.Spec.ProgressDeadlineSeconds == d2.Spec.ProgressDeadlineSeconds &&
+		d1.Spec.Strategy == d2.Spec.Strategy &&
+		d1.Spec.MinReadySeconds == d2.Spec.MinReadySeconds &&
+		api.Hash(d1.Spec.Canary) == api.Hash(d2.Spec.Canary) &&
+		d1.Spec.BlueGreen == d2.Spec.BlueGreen
 }
 
 func updateDeploymentTemplate(existingDeployment *core.Deployment, newDeployment *core.Deployment) {
@@ -441,21 +799,12 @@ func updateDeploymentTemplate(existingDeployment *core.Deployment, newDeployment
 	existingDeployment.Spec.Template.Spec = newDeployment.Spec.Template.Spec
 }
 
-func isPodUpdated(deployment *core.Deployment, pod *core.Pod) bool {
-	return api.Hash(deployment.Spec.Template.Labels) == api.Hash(pod.Labels) &&
-		api.Hash(deployment.Spec.Template.Spec) == api.Hash(pod.Spec)
-}
-
-func updateDeploymentStatusOnPodRemoval(deployment *core.Deployment, pod *core.Pod) {
-	// Deleting pod is presumably guaranteed to succeed.
-	deployment.Status.Replicas--
-	if isPodUpdated(deployment, pod) && (pod.Status.Phase == core.PodReady || pod.Status.Phase == core.PodFailed) {
-		deployment.Status.UpdatedReplicas--
-	}
-	// Failed pods must be Ready previously.
-	if pod.Status.Phase == core.PodReady || pod.Status.Phase == core.PodFailed {
-		deployment.Status.ReadyReplicas--
-	}
+// copyDeployment copies a deployment deeply enough for its spec and status to be changed without
+// affecting the original.
+func copyDeployment(deployment *core.Deployment) *core.Deployment {
+	copied := *deployment
+	copied.Status.Conditions = append([]core.DeploymentCondition(nil), deployment.Status.Conditions...)
+	return &copied
 }
 
 func setDeploymentInEtcd(name string, deployment *core.Deployment) error {
@@ -463,51 +812,98 @@ func setDeploymentInEtcd(name string, deployment *core.Deployment) error {
 }
 
 func DeleteDeploymentInEtcd(deploymentName string) error {
-	if err := etcd.Delete(fmt.Sprintf("/Deployments/Meta/%s", deploymentName)); err != nil {
-		return err
-	}
-	if err := etcd.Delete(fmt.Sprintf("/Deployments/Pods/%s", deploymentName)); err != nil {
-		return err
+	return etcd.Delete(fmt.Sprintf("/Deployments/Meta/%s", deploymentName))
+}
+
+// totalSpecReplicas sums up the desired replicas of replica sets.
+func totalSpecReplicas(replicaSets ...[]*core.ReplicaSet) int64 {
+	var total int64 = 0
+	for _, group := range replicaSets {
+		for _, replicaSet := range group {
+			total += int64(replicaSet.Spec.Replicas)
+		}
 	}
-	return nil
+	return total
 }
 
-func computeRollingUpdatePodDeletion(deployment *core.Deployment, pods *list.List) int {
-	var readyReplicas int64 = int64(deployment.Status.ReadyReplicas)
-	var outdatedReplicas int64 = int64(len(findOutdatedPods(deployment, pods)))
-	var replicas int64 = int64(deployment.Spec.Replicas)
-	var maxUnavailable int64 = int64(deployment.Spec.RollingUpdate.MaxUnavailable)
-	var minReadyReplicas int64 = api.Max64(0, replicas-maxUnavailable)
-	return int(api.Min64(outdatedReplicas, api.Max64(0, readyReplicas-minReadyReplicas)))
+// availableReplicas is the number of ready pods a replica set is sure to keep. It deletes the pods
+// that are not ready first, so at most Spec.Replicas ready pods are left after it scales down.
+func availableReplicas(replicaSet *core.ReplicaSet) int64 {
+	return api.Min64(int64(replicaSet.Spec.Replicas), int64(replicaSet.Status.ReadyReplicas))
 }
 
-func computeRollingUpdatePodCreation(deployment *core.Deployment, pods *list.List) int {
-	var specReplicas int64 = int64(deployment.Spec.Replicas)
-	var statusReplicas int64 = int64(deployment.Status.Replicas)
-	var maxReplicas int64 = specReplicas + int64(deployment.Spec.RollingUpdate.MaxSurge)
-	var allUpdatedReplicas int64 = int64(len(findUpdatedPods(deployment, pods)))
-	var idealReplicasToCreate int64 = api.Max64(0, specReplicas-allUpdatedReplicas)
-	return int(api.Min64(maxReplicas-statusReplicas, idealReplicasToCreate))
+// newReplicaSetReplicas computes the replicas of the new replica set of a deployment under rolling
+// update, so that all the replica sets have at most Replicas + MaxSurge pods.
+func newReplicaSetReplicas(
+	deployment *core.Deployment,
+	newReplicaSet *core.ReplicaSet,
+	oldReplicaSets []*core.ReplicaSet,
+) uint32 {
+	var replicas int64 = int64(deployment.Spec.Replicas)
+	var newReplicas int64 = int64(newReplicaSet.Spec.Replicas)
+	if newReplicas >= replicas {
+		return uint32(replicas)
+	}
+	var maxTotalReplicas int64 = replicas + int64(deployment.Spec.RollingUpdate.MaxSurge)
+	var totalReplicas int64 = totalSpecReplicas([]*core.ReplicaSet{newReplicaSet}, oldReplicaSets)
+	if totalReplicas >= maxTotalReplicas {
+		return uint32(newReplicas)
+	}
+	return uint32(newReplicas + api.Min64(maxTotalReplicas-totalReplicas, replicas-newReplicas))
 }
 
-func findOutdatedPods(deployment *core.Deployment, pods *list.List) []*core.Pod {
-	outdatedPods := make([]*core.Pod, 0)
-	for i := pods.Front(); i != nil; i = i.Next() {
-		pod := i.Value.(*core.Pod)
-		if !isPodUpdated(deployment, pod) {
-			outdatedPods = append(outdatedPods, pod)
+// oldReplicaSetsReplicas computes the replicas of the old replica sets of a deployment under rolling
+// update, so that at least Replicas - MaxUnavailable pods stay ready. The pods that are not ready
+// are scaled down first, and then the ready ones, the oldest replica sets first.
+func oldReplicaSetsReplicas(
+	deployment *core.Deployment,
+	newReplicaSet *core.ReplicaSet,
+	oldReplicaSets []*core.ReplicaSet,
+) []uint32 {
+	oldReplicas := make([]uint32, len(oldReplicaSets))
+	for i, replicaSet := range oldReplicaSets {
+		oldReplicas[i] = replicaSet.Spec.Replicas
+	}
+
+	var minAvailable int64 = api.Max64(
+		0,
+		int64(deployment.Spec.Replicas)-int64(deployment.Spec.RollingUpdate.MaxUnavailable),
+	)
+	var newUnavailable int64 = int64(newReplicaSet.Spec.Replicas) - availableReplicas(newReplicaSet)
+	var maxScaledDown int64 = totalSpecReplicas([]*core.ReplicaSet{newReplicaSet}, oldReplicaSets) -
+		minAvailable - newUnavailable
+	if maxScaledDown <= 0 {
+		return oldReplicas
+	}
+
+	// Scaling down the pods that are not ready does not make the deployment less available.
+	var scaledDown int64 = 0
+	for i, replicaSet := range oldReplicaSets {
+		if scaledDown >= maxScaledDown {
+			break
+		}
+		unavailable := int64(replicaSet.Spec.Replicas) - availableReplicas(replicaSet)
+		if unavailable <= 0 {
+			continue
 		}
+		numToScaleDown := api.Min64(unavailable, maxScaledDown-scaledDown)
+		oldReplicas[i] -= uint32(numToScaleDown)
+		scaledDown += numToScaleDown
 	}
-	return outdatedPods
-}
 
-func findUpdatedPods(deployment *core.Deployment, pods *list.List) []*core.Pod {
-	outdatedPods := make([]*core.Pod, 0)
-	for i := pods.Front(); i != nil; i = i.Next() {
-		pod := i.Value.(*core.Pod)
-		if isPodUpdated(deployment, pod) {
-			outdatedPods = append(outdatedPods, pod)
+	var available int64 = availableReplicas(newReplicaSet)
+	for i, replicaSet := range oldReplicaSets {
+		available += api.Min64(int64(oldReplicas[i]), int64(replicaSet.Status.ReadyReplicas))
+	}
+	var totalToScaleDown int64 = available - minAvailable
+	scaledDown = 0
+	for i := range oldReplicaSets {
+		if scaledDown >= totalToScaleDown {
+			break
 		}
+		numToScaleDown := api.Min64(int64(oldReplicas[i]), totalToScaleDown-scaledDown)
+		oldReplicas[i] -= uint32(numToScaleDown)
+		scaledDown += numToScaleDown
 	}
-	return outdatedPods
+	return oldReplicas
 }
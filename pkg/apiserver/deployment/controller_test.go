@@ -0,0 +1,176 @@
+package deployment
+
+import (
+	"container/list"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"p9t.io/kuberboat/pkg/api/core"
+	"p9t.io/kuberboat/pkg/apiserver"
+	"p9t.io/kuberboat/pkg/apiserver/internal/apiservertest"
+	"p9t.io/kuberboat/pkg/apiserver/replicaset"
+)
+
+// fakeReplicaSetController updates the replica sets in a component manager without persisting them.
+type fakeReplicaSetController struct {
+	replicaset.Controller
+	componentManager apiserver.ComponentManager
+}
+
+func (fc *fakeReplicaSetController) UpdateReplicaSet(name string, update func(replicaSet *core.ReplicaSet)) error {
+	update(fc.componentManager.GetReplicaSetByName(name))
+	return nil
+}
+
+func TestCheckStrategy(t *testing.T) {
+	deployment := apiservertest.NewDeployment("nginx:1.22")
+	deployment.Spec.Strategy = core.RollingUpdateDeploymentStrategy
+	assert.NotNil(t, checkStrategy(deployment))
+	deployment.Spec.RollingUpdate.MaxSurge = 1
+	assert.Nil(t, checkStrategy(deployment))
+
+	// Recreate needs neither maxSurge nor maxUnavailable.
+	deployment.Spec.RollingUpdate.MaxSurge = 0
+	deployment.Spec.Strategy = core.RecreateDeploymentStrategy
+	assert.Nil(t, checkStrategy(deployment))
+
+	deployment.Spec.Strategy = core.CanaryDeploymentStrategy
+	deployment.Spec.Canary.Steps = []core.CanaryStep{{SetWeight: 20}, {Pause: true}, {SetWeight: 120}}
+	assert.NotNil(t, checkStrategy(deployment))
+	deployment.Spec.Canary.Steps[2].SetWeight = 100
+	assert.Nil(t, checkStrategy(deployment))
+
+	deployment.Spec.Strategy = core.BlueGreenDeploymentStrategy
+	assert.NotNil(t, checkStrategy(deployment))
+	deployment.Spec.BlueGreen.ActiveService = "nginx-active"
+	assert.Nil(t, checkStrategy(deployment))
+}
+
+func TestApplyDeploymentRejectedUpdate(t *testing.T) {
+	cm := apiserver.NewComponentManager()
+	m := &basicController{componentManager: cm}
+	existing := apiservertest.NewDeployment("nginx:1.22")
+	existing.Spec.Strategy = core.RecreateDeploymentStrategy
+	existing.Generation = 1
+	cm.SetDeployment(existing)
+
+	// A rolling update with neither maxSurge nor maxUnavailable is rejected as a whole.
+	update := apiservertest.NewDeployment("nginx:1.23")
+	update.Spec.Strategy = core.RollingUpdateDeploymentStrategy
+	update.Spec.MinReadySeconds = 10
+	assert.NotNil(t, m.ApplyDeployment(update))
+	assert.Equal(t, core.RecreateDeploymentStrategy, existing.Spec.Strategy)
+	assert.Equal(t, uint32(0), existing.Spec.MinReadySeconds)
+	assert.Equal(t, uint64(1), existing.Generation)
+	assert.Equal(t, "nginx:1.22", existing.Spec.Template.Spec.Containers[0].Image)
+}
+
+func TestRecreateWaitsForConfirmedDeletion(t *testing.T) {
+	cm := apiserver.NewComponentManager()
+	m := &basicController{
+		componentManager:     cm,
+		replicaSetController: &fakeReplicaSetController{componentManager: cm},
+		terminatingPods:      map[string]map[string]bool{},
+	}
+	deployment := apiservertest.NewDeployment("nginx:1.23")
+	deployment.Spec.Strategy = core.RecreateDeploymentStrategy
+	deployment.Spec.Replicas = 2
+	cm.SetDeployment(deployment)
+	newReplicaSet := &core.ReplicaSet{OwnerName: deployment.Name, TemplateHash: computeSpecHash(deployment)}
+	newReplicaSet.Name = "nginx-new"
+	cm.SetReplicaSet(newReplicaSet, list.New())
+	oldReplicaSet := &core.ReplicaSet{OwnerName: deployment.Name, TemplateHash: "old"}
+	oldReplicaSet.Name = "nginx-old"
+	oldReplicaSet.Spec.Replicas = 1
+	oldReplicaSet.Status.Replicas = 1
+	oldPod := &core.Pod{}
+	oldPod.Name = "nginx-old-pod"
+	oldPods := list.New()
+	oldPods.PushBack(oldPod)
+	cm.SetReplicaSet(oldReplicaSet, oldPods)
+
+	m.recreate(deployment, newReplicaSet, []*core.ReplicaSet{oldReplicaSet})
+	assert.Equal(t, uint32(0), oldReplicaSet.Spec.Replicas)
+	assert.Equal(t, uint32(0), newReplicaSet.Spec.Replicas)
+
+	// The old pod is deleted on the apiserver, but its container may still be running.
+	cm.DeletePodByName(oldPod.Name)
+	oldReplicaSet.Status.Replicas = 0
+	m.recreate(deployment, newReplicaSet, []*core.ReplicaSet{oldReplicaSet})
+	assert.Equal(t, uint32(0), newReplicaSet.Spec.Replicas)
+
+	m.HandleEvent(&apiserver.PodDeletionEvent{Pod: oldPod})
+	m.recreate(deployment, newReplicaSet, []*core.ReplicaSet{oldReplicaSet})
+	assert.Equal(t, uint32(2), newReplicaSet.Spec.Replicas)
+}
+
+func TestComputeSpecHash(t *testing.T) {
+	d1 := apiservertest.NewDeployment("nginx:1.22")
+	d1.Spec.Template.Spec.Containers[0].SecurityContext = &core.SecurityContext{}
+	d2 := apiservertest.NewDeployment("nginx:1.22")
+	d2.Spec.Template.Spec.Containers[0].SecurityContext = &core.SecurityContext{}
+	d2.Spec.Replicas = 3
+
+	// The hash only depends on the template, not on where its pointers point.
+	assert.Equal(t, computeSpecHash(d1), computeSpecHash(d2))
+	assert.True(t, isDeploymentUpdated(d1, d2))
+	assert.NotEqual(t, computeSpecHash(d1), computeSpecHash(apiservertest.NewDeployment("nginx:1.23")))
+}
+
+func TestRollingUpdateReplicas(t *testing.T) {
+	deployment := apiservertest.NewDeployment("nginx:1.22")
+	deployment.Spec.Replicas = 3
+	deployment.Spec.RollingUpdate = core.RollingUpdateSepc{MaxSurge: 1, MaxUnavailable: 0}
+	newReplicaSet := &core.ReplicaSet{Revision: 2}
+	oldReplicaSets := []*core.ReplicaSet{
+		{Revision: 1, Spec: core.ReplicaSetSpec{Replicas: 3}, Status: core.ReplicaSetStatus{Replicas: 3, ReadyReplicas: 3}},
+	}
+
+	// Surge by one new pod, and keep all the old ones until it is ready.
+	assert.Equal(t, uint32(1), newReplicaSetReplicas(deployment, newReplicaSet, oldReplicaSets))
+	newReplicaSet.Spec.Replicas = 1
+	assert.Equal(t, []uint32{3}, oldReplicaSetsReplicas(deployment, newReplicaSet, oldReplicaSets))
+	assert.Equal(t, uint32(1), newReplicaSetReplicas(deployment, newReplicaSet, oldReplicaSets))
+
+	newReplicaSet.Status = core.ReplicaSetStatus{Replicas: 1, ReadyReplicas: 1}
+	assert.Equal(t, []uint32{2}, oldReplicaSetsReplicas(deployment, newReplicaSet, oldReplicaSets))
+
+	// Pods that are not ready are scaled down first, without waiting for the new pods.
+	deployment.Spec.RollingUpdate = core.RollingUpdateSepc{MaxSurge: 0, MaxUnavailable: 1}
+	newReplicaSet = &core.ReplicaSet{Revision: 3}
+	oldReplicaSets = []*core.ReplicaSet{
+		{Revision: 1, Spec: core.ReplicaSetSpec{Replicas: 1}, Status: core.ReplicaSetStatus{Replicas: 1}},
+		{Revision: 2, Spec: core.ReplicaSetSpec{Replicas: 2}, Status: core.ReplicaSetStatus{Replicas: 2, ReadyReplicas: 2}},
+	}
+	assert.Equal(t, uint32(0), newReplicaSetReplicas(deployment, newReplicaSet, oldReplicaSets))
+	assert.Equal(t, []uint32{0, 2}, oldReplicaSetsReplicas(deployment, newReplicaSet, oldReplicaSets))
+
+	// Scaling a deployment without old replica sets happens at once.
+	newReplicaSet = &core.ReplicaSet{
+		Revision: 1,
+		Spec:     core.ReplicaSetSpec{Replicas: 2},
+		Status:   core.ReplicaSetStatus{Replicas: 2, ReadyReplicas: 2},
+	}
+	deployment.Spec.Replicas = 5
+	assert.Equal(t, uint32(5), newReplicaSetReplicas(deployment, newReplicaSet, nil))
+	deployment.Spec.Replicas = 1
+	assert.Equal(t, uint32(1), newReplicaSetReplicas(deployment, newReplicaSet, nil))
+}
+
+func TestSyncStatus(t *testing.T) {
+	deployment := apiservertest.NewDeployment("nginx:1.22")
+	newReplicaSet := &core.ReplicaSet{
+		Revision: 2,
+		Spec:     core.ReplicaSetSpec{Replicas: 2},
+		Status:   core.ReplicaSetStatus{Replicas: 2, ReadyReplicas: 1},
+	}
+	oldReplicaSets := []*core.ReplicaSet{
+		{Revision: 1, Spec: core.ReplicaSetSpec{Replicas: 2}, Status: core.ReplicaSetStatus{Replicas: 2, ReadyReplicas: 2}},
+	}
+
+	assert.True(t, syncStatus(deployment, newReplicaSet, oldReplicaSets))
+	assert.Equal(t, uint32(4), deployment.Status.Replicas)
+	assert.Equal(t, uint32(3), deployment.Status.ReadyReplicas)
+	assert.Equal(t, uint32(1), deployment.Status.UpdatedReplicas)
+	assert.False(t, syncStatus(deployment, newReplicaSet, oldReplicaSets))
+}
@@ -0,0 +1,83 @@
+package deployment
+
+import (
+	"fmt"
+	"sort"
+
+	"p9t.io/kuberboat/pkg/api/core"
+)
+
+const (
+	// defaultRevisionHistoryLimit is the number of old templates kept when a deployment does not say.
+	defaultRevisionHistoryLimit = 10
+)
+
+// revisionsOf lists the revisions of a deployment from the replica sets it owns, the oldest first.
+func revisionsOf(replicaSets []*core.ReplicaSet) []core.DeploymentRevision {
+	revisions := make([]core.DeploymentRevision, 0, len(replicaSets))
+	for _, replicaSet := range replicaSets {
+		revisions = append(revisions, core.DeploymentRevision{
+			Revision:          replicaSet.Revision,
+			TemplateHash:      replicaSet.TemplateHash,
+			ChangeCause:       replicaSet.ChangeCause,
+			CreationTimestamp: replicaSet.CreationTimestamp,
+			Template:          replicaSet.Spec.Template,
+		})
+	}
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Revision < revisions[j].Revision
+	})
+	return revisions
+}
+
+// maxRevision returns the newest revision among the replica sets of a deployment, or 0 if it has
+// none.
+func maxRevision(replicaSets []*core.ReplicaSet) uint64 {
+	var revision uint64 = 0
+	for _, replicaSet := range replicaSets {
+		if replicaSet.Revision > revision {
+			revision = replicaSet.Revision
+		}
+	}
+	return revision
+}
+
+// findRevision finds a revision in history to roll back to. Revision 0 stands for the one before
+// the current revision.
+func findRevision(history []core.DeploymentRevision, revision uint64, current uint64) (*core.DeploymentRevision, error) {
+	if revision == 0 {
+		for i := len(history) - 1; i >= 0; i-- {
+			if history[i].Revision < current {
+				return &history[i], nil
+			}
+		}
+		return nil, fmt.Errorf("no previous revision to roll back to")
+	}
+	for i := range history {
+		if history[i].Revision == revision {
+			return &history[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unable to find revision %v", revision)
+}
+
+// replicaSetsToCleanUp picks the old replica sets of a deployment beyond its revision history
+// limit. oldReplicaSets must be sorted by revision. The oldest are picked first, and only those
+// without any pod.
+func replicaSetsToCleanUp(deployment *core.Deployment, oldReplicaSets []*core.ReplicaSet) []*core.ReplicaSet {
+	limit := int(deployment.Spec.RevisionHistoryLimit)
+	if limit == 0 {
+		limit = defaultRevisionHistoryLimit
+	}
+	numToCleanUp := len(oldReplicaSets) - limit
+	picked := make([]*core.ReplicaSet, 0)
+	for _, replicaSet := range oldReplicaSets {
+		if len(picked) >= numToCleanUp {
+			break
+		}
+		if replicaSet.Spec.Replicas == 0 && replicaSet.Status.Replicas == 0 {
+			picked = append(picked, replicaSet)
+		}
+	}
+	return picked
+}
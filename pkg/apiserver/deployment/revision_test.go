@@ -0,0 +1,62 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"p9t.io/kuberboat/pkg/api/core"
+	"p9t.io/kuberboat/pkg/apiserver/internal/apiservertest"
+)
+
+func TestRevisionsOf(t *testing.T) {
+	replicaSets := []*core.ReplicaSet{
+		{Revision: 3},
+		{Revision: 1, ChangeCause: "initial"},
+		{Revision: 2, Spec: core.ReplicaSetSpec{Replicas: 2}, Status: core.ReplicaSetStatus{Replicas: 2, ReadyReplicas: 2}},
+	}
+	replicaSets[1].Spec.Template = apiservertest.NewDeployment("nginx:1.21").Spec.Template
+
+	revisions := revisionsOf(replicaSets)
+	assert.Equal(t, 3, len(revisions))
+	assert.Equal(t, uint64(1), revisions[0].Revision)
+	assert.Equal(t, "initial", revisions[0].ChangeCause)
+	assert.Equal(t, "nginx:1.21", revisions[0].Template.Spec.Containers[0].Image)
+	assert.Equal(t, uint64(3), revisions[2].Revision)
+	assert.Equal(t, uint64(3), maxRevision(replicaSets))
+	assert.Equal(t, uint64(0), maxRevision(nil))
+}
+
+func TestFindRevision(t *testing.T) {
+	history := []core.DeploymentRevision{{Revision: 2}, {Revision: 5}, {Revision: 6}}
+
+	revision, err := findRevision(history, 0, 6)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(5), revision.Revision)
+	revision, err = findRevision(history, 2, 6)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), revision.Revision)
+
+	_, err = findRevision(history, 3, 6)
+	assert.NotNil(t, err)
+	_, err = findRevision(history[:1], 0, 2)
+	assert.NotNil(t, err)
+}
+
+func TestReplicaSetsToCleanUp(t *testing.T) {
+	deployment := apiservertest.NewDeployment("nginx:1.22")
+	deployment.Spec.RevisionHistoryLimit = 1
+	oldReplicaSets := []*core.ReplicaSet{
+		{Revision: 1},
+		{Revision: 2, Spec: core.ReplicaSetSpec{Replicas: 1}, Status: core.ReplicaSetStatus{Replicas: 1, ReadyReplicas: 1}},
+		{Revision: 3},
+	}
+
+	// Replica sets with pods are kept even if they are old.
+	picked := replicaSetsToCleanUp(deployment, oldReplicaSets)
+	assert.Equal(t, 2, len(picked))
+	assert.Equal(t, uint64(1), picked[0].Revision)
+	assert.Equal(t, uint64(3), picked[1].Revision)
+
+	deployment.Spec.RevisionHistoryLimit = 0
+	assert.Equal(t, 0, len(replicaSetsToCleanUp(deployment, oldReplicaSets)))
+}
@@ -290,3 +290,21 @@ func host2CoreDNSPath(host string) (string, error) {
 }
 
 // TODO: When deleting a service, check if there is a dns configuration pointing to it.
+
+// SetARecord points a domain name at an IP address in CoreDNS, bypassing nginx.
+func SetARecord(host string, ip string) error {
+	etcdKey, err := host2CoreDNSPath(host)
+	if err != nil {
+		return err
+	}
+	return etcd.Put(etcdKey, coreDNSEntry{Host: ip})
+}
+
+// DeleteARecord removes a domain name set by SetARecord from CoreDNS.
+func DeleteARecord(host string) error {
+	etcdKey, err := host2CoreDNSPath(host)
+	if err != nil {
+		return err
+	}
+	return etcd.Delete(etcdKey)
+}
@@ -100,7 +100,7 @@ func TestGenerateNginxConfig(t *testing.T) {
 	componentManager := apiserver.NewComponentManager()
 	dnsController := &basicController{
 		componentManager: componentManager,
-		nginxConfigDir:   ".",
+		nginxConfigDir:   t.TempDir(),
 	}
 	for _, service := range testServices {
 		componentManager.SetService(service, list.New())
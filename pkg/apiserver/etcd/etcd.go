@@ -94,6 +94,33 @@ func Get(key string, valueType interface{}, opts ...clientv3.OpOption) ([]interf
 			values = append(values, buffer)
 		}
 		return values, nil
+	case core.RegistryCredential:
+		for _, kv := range resp.Kvs {
+			buffer := valueType
+			if err = json.Unmarshal(kv.Value, &buffer); err != nil {
+				return nil, fmt.Errorf("error unmarshalling data in etcd: %v", err)
+			}
+			values = append(values, buffer)
+		}
+		return values, nil
+	case core.ReplicaSet:
+		for _, kv := range resp.Kvs {
+			buffer := valueType
+			if err = json.Unmarshal(kv.Value, &buffer); err != nil {
+				return nil, fmt.Errorf("error unmarshalling data in etcd: %v", err)
+			}
+			values = append(values, buffer)
+		}
+		return values, nil
+	case core.StatefulSet:
+		for _, kv := range resp.Kvs {
+			buffer := valueType
+			if err = json.Unmarshal(kv.Value, &buffer); err != nil {
+				return nil, fmt.Errorf("error unmarshalling data in etcd: %v", err)
+			}
+			values = append(values, buffer)
+		}
+		return values, nil
 	case net.IP:
 		for _, kv := range resp.Kvs {
 			buffer := valueType
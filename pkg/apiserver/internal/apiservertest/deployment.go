@@ -0,0 +1,41 @@
+// Package apiservertest provides fixtures shared by the tests of the apiserver controllers.
+package apiservertest
+
+import (
+	"container/list"
+	"fmt"
+
+	"p9t.io/kuberboat/pkg/api/core"
+	"p9t.io/kuberboat/pkg/apiserver"
+)
+
+// NewDeployment returns a deployment named test-deployment whose pods run a single container named
+// test-container from image.
+func NewDeployment(image string) *core.Deployment {
+	deployment := &core.Deployment{}
+	deployment.Name = "test-deployment"
+	deployment.Spec.Template.Labels = map[string]string{"app": "test"}
+	deployment.Spec.Template.Spec.Containers = []core.Container{{Name: "test-container", Image: image}}
+	return deployment
+}
+
+// SetDeployment registers a deployment in a component manager with as many ready pods as it has
+// replicas, named test-pod-0, test-pod-1 and so on, which it owns through a replica set. The status
+// of the deployment counts the pods.
+func SetDeployment(componentManager apiserver.ComponentManager, deployment *core.Deployment) {
+	deployment.Status.Replicas = deployment.Spec.Replicas
+	deployment.Status.ReadyReplicas = deployment.Spec.Replicas
+	replicaSet := &core.ReplicaSet{OwnerName: deployment.Name}
+	replicaSet.Name = deployment.Name + "-replicaset"
+	replicaSet.Spec.Replicas = deployment.Spec.Replicas
+	replicaSet.Spec.Template = deployment.Spec.Template
+	pods := list.New()
+	for i := 0; i < int(deployment.Spec.Replicas); i++ {
+		pod := &core.Pod{}
+		pod.Name = fmt.Sprintf("test-pod-%d", i)
+		pod.Status.Phase = core.PodReady
+		pods.PushBack(pod)
+	}
+	componentManager.SetDeployment(deployment)
+	componentManager.SetReplicaSet(replicaSet, pods)
+}
@@ -38,9 +38,11 @@ var jobPodBase core.Pod = core.Pod{
 			{
 				Name:  "slurm-server",
 				Image: "windowsxpbeta/slurm-server:latest",
-				Resources: map[core.ResourceName]uint64{
-					core.ResourceCPU:    1,
-					core.ResourceMemory: 102400000,
+				Resources: core.ResourceRequirements{
+					Limits: core.ResourceList{
+						core.ResourceCPU:    core.MustParseQuantity("1"),
+						core.ResourceMemory: core.MustParseQuantity("102400000"),
+					},
 				},
 				VolumeMounts: []core.VolumeMount{
 					{
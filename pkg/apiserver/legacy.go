@@ -2,9 +2,9 @@ package apiserver
 
 // PodLegacy is the information of a deleted pod that might be used by some controllers for event handling.
 type PodLegacy struct {
-	// DeploymentName is the name of the deployment managing the deleted pod.
-	// Empty if the pod wasn't managed by any deployment.
-	DeploymentName string
+	// ReplicaSetName is the name of the replica set managing the deleted pod.
+	// Empty if the pod wasn't managed by any replica set.
+	ReplicaSetName string
 }
 
 type LegacyManager interface {
@@ -35,8 +35,8 @@ func (m *legacyManagerInner) GetPodLegacyByName(name string) *PodLegacy {
 
 func (m *legacyManagerInner) SetPodLegacy(name string) {
 	legacy := &PodLegacy{}
-	if deployment := m.componentManager.GetDeploymentByPodName(name); deployment != nil {
-		legacy.DeploymentName = deployment.Name
+	if replicaSet := m.componentManager.GetReplicaSetByPodName(name); replicaSet != nil {
+		legacy.ReplicaSetName = replicaSet.Name
 	}
 	m.podLegacy[name] = legacy
 }
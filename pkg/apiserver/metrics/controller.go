@@ -0,0 +1,160 @@
+package metrics
+
+import (
+	"github.com/golang/glog"
+	"p9t.io/kuberboat/pkg/api/core"
+	"p9t.io/kuberboat/pkg/apiserver"
+	"p9t.io/kuberboat/pkg/apiserver/node"
+)
+
+// Controller serves the current CPU and memory usage of nodes and pods, aggregated from the stats
+// summaries of the kubelets.
+type Controller interface {
+	// NodeMetrics returns the usage of the nodes specified by names, or of all nodes if names is
+	// empty, as well as the names of the nodes whose metrics are not available.
+	NodeMetrics(names []string) ([]*core.NodeMetrics, []string)
+	// PodMetrics returns the usage of the pods specified by names whose labels contain selector.
+	// All pods are considered if names is empty. It also returns the names of the pods whose
+	// metrics are not available.
+	PodMetrics(names []string, selector map[string]string) ([]*core.PodMetrics, []string)
+}
+
+type basicController struct {
+	componentManager apiserver.ComponentManager
+	nodeManager      node.NodeManager
+	nodeController   node.Controller
+}
+
+func NewMetricsController(
+	componentManager apiserver.ComponentManager,
+	nodeManager node.NodeManager,
+	nodeController node.Controller,
+) Controller {
+	return &basicController{
+		componentManager: componentManager,
+		nodeManager:      nodeManager,
+		nodeController:   nodeController,
+	}
+}
+
+func (c *basicController) NodeMetrics(names []string) ([]*core.NodeMetrics, []string) {
+	summaries, notFound := c.nodeController.StatsSummaries(names)
+
+	capacities := make(map[string]core.ResourceList)
+	for _, node := range c.nodeManager.RegisteredNodes() {
+		capacities[node.Name] = node.Status.Capacity
+	}
+
+	metrics := make([]*core.NodeMetrics, 0, len(summaries))
+	reported := make(map[string]bool)
+	for _, summary := range summaries {
+		reported[summary.NodeName] = true
+		metrics = append(metrics, &core.NodeMetrics{
+			Name:      summary.NodeName,
+			Timestamp: summary.Timestamp,
+			Usage:     Usage(&summary.Node.CPU, &summary.Node.Memory),
+			Capacity:  capacities[summary.NodeName],
+		})
+	}
+	// Nodes that are registered but failed to report are not available either.
+	for _, name := range names {
+		if _, ok := capacities[name]; ok && !reported[name] {
+			notFound = append(notFound, name)
+		}
+	}
+	return metrics, notFound
+}
+
+func (c *basicController) PodMetrics(names []string, selector map[string]string) ([]*core.PodMetrics, []string) {
+	notFound := make([]string, 0)
+	var pods []*core.Pod
+	if len(names) == 0 {
+		pods = c.componentManager.ListPods()
+	} else {
+		for _, name := range names {
+			if pod := c.componentManager.GetPodByName(name); pod != nil {
+				pods = append(pods, pod)
+			} else {
+				notFound = append(notFound, name)
+			}
+		}
+	}
+
+	// Only the nodes hosting the selected pods are asked for their stats.
+	selected := make(map[string]bool)
+	nodeNames := make([]string, 0)
+	nodeSelected := make(map[string]bool)
+	for _, pod := range pods {
+		if !matchesSelector(pod.Labels, selector) {
+			continue
+		}
+		selected[pod.Name] = true
+		node := c.nodeManager.NodeByIP(pod.Status.HostIP)
+		if node != nil && !nodeSelected[node.Name] {
+			nodeSelected[node.Name] = true
+			nodeNames = append(nodeNames, node.Name)
+		}
+	}
+	if len(nodeNames) == 0 {
+		return []*core.PodMetrics{}, notFound
+	}
+
+	summaries, notFoundNodes := c.nodeController.StatsSummaries(nodeNames)
+	if len(notFoundNodes) > 0 {
+		glog.Warningf("nodes hosting pods are not registered: %v", notFoundNodes)
+	}
+	metrics := make([]*core.PodMetrics, 0, len(selected))
+	for _, summary := range summaries {
+		for i := range summary.Pods {
+			stats := &summary.Pods[i]
+			if !selected[stats.Name] {
+				continue
+			}
+			delete(selected, stats.Name)
+			metrics = append(metrics, podMetrics(stats, summary))
+		}
+	}
+	// Pods that are pending or whose nodes failed to report have no metrics.
+	for _, name := range names {
+		if selected[name] {
+			notFound = append(notFound, name)
+		}
+	}
+	return metrics, notFound
+}
+
+func podMetrics(stats *core.PodStats, summary *core.StatsSummary) *core.PodMetrics {
+	metrics := &core.PodMetrics{
+		Name:       stats.Name,
+		Timestamp:  summary.Timestamp,
+		Usage:      Usage(&stats.CPU, &stats.Memory),
+		Containers: make([]core.ContainerMetrics, 0, len(stats.Containers)),
+	}
+	for i := range stats.Containers {
+		c := &stats.Containers[i]
+		metrics.Containers = append(metrics.Containers, core.ContainerMetrics{
+			Name:  c.Name,
+			Usage: Usage(&c.CPU, &c.Memory),
+		})
+	}
+	return metrics
+}
+
+// Usage converts CPU and memory stats into a resource list of the CPU usage in cores and the
+// memory working set in bytes.
+func Usage(cpu *core.CPUStats, memory *core.MemoryStats) core.ResourceList {
+	return core.ResourceList{
+		core.ResourceCPU:    core.NewMilliQuantity(int64(cpu.UsageNanoCores / 1000000)),
+		core.ResourceMemory: core.NewMilliQuantity(int64(memory.WorkingSetBytes) * 1000),
+	}
+}
+
+// matchesSelector tells whether labels contain every label of selector.
+func matchesSelector(labels map[string]string, selector map[string]string) bool {
+	for key, value := range selector {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,25 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"p9t.io/kuberboat/pkg/api/core"
+)
+
+func TestUsage(t *testing.T) {
+	usage := Usage(
+		&core.CPUStats{UsageNanoCores: 250000000},
+		&core.MemoryStats{UsageBytes: 200 << 20, WorkingSetBytes: 128 << 20},
+	)
+	assert.Equal(t, int64(250), usage[core.ResourceCPU].MilliValue())
+	assert.Equal(t, int64(128<<20), usage[core.ResourceMemory].Value())
+}
+
+func TestMatchesSelector(t *testing.T) {
+	labels := map[string]string{"app": "nginx", "env": "dev"}
+	assert.True(t, matchesSelector(labels, nil))
+	assert.True(t, matchesSelector(labels, map[string]string{"app": "nginx"}))
+	assert.False(t, matchesSelector(labels, map[string]string{"app": "nginx", "env": "prod"}))
+	assert.False(t, matchesSelector(nil, map[string]string{"app": "nginx"}))
+}
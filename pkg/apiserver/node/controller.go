@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang/glog"
@@ -23,9 +24,19 @@ type Controller interface {
 	RegisterNode(ctx context.Context, node *core.Node) error
 	// GetRegisteredNodes returns all registered nodes.
 	GetRegisteredNodes() []*core.Node
+	// UpdateNodePressures records the resource pressures reported by the kubelet at address ip.
+	UpdateNodePressures(ip string, pressures []core.NodePressure) error
+	// UpdateNodeCapacity records the resource capacity reported by the kubelet at address ip.
+	UpdateNodeCapacity(ip string, capacity core.ResourceList) error
+	// StatsSummaries collects the resource usage of the named nodes from their kubelets, or of all
+	// nodes if names is empty. It also returns the names of the nodes that are not registered.
+	// Nodes whose kubelets fail to report are left out.
+	StatsSummaries(names []string) ([]*core.StatsSummary, []string)
 }
 
 type basicController struct {
+	// mtx serializes the updates of node statuses.
+	mtx         sync.Mutex
 	nodeManager NodeManager
 }
 
@@ -42,16 +53,7 @@ func NewNodeController(nodeManager NodeManager) Controller {
 
 func (bc *basicController) RegisterNode(ctx context.Context, node *core.Node) error {
 	// Get node address.
-	var workerIP string
-	p, _ := peer.FromContext(ctx)
-	workerAddr := p.Addr.String()
-	if strings.Count(workerAddr, ":") < 2 {
-		// IPv4 address
-		workerIP = strings.Split(p.Addr.String(), ":")[0]
-	} else {
-		// IPv6 address
-		workerIP = workerAddr[0:strings.LastIndex(workerAddr, ":")]
-	}
+	workerIP := PeerIP(ctx)
 
 	node.CreationTimestamp = time.Now()
 	node.UUID = uuid.New()
@@ -104,3 +106,101 @@ func (bc *basicController) RegisterNode(ctx context.Context, node *core.Node) er
 func (bc *basicController) GetRegisteredNodes() []*core.Node {
 	return bc.nodeManager.RegisteredNodes()
 }
+
+func (bc *basicController) UpdateNodePressures(ip string, pressures []core.NodePressure) error {
+	bc.mtx.Lock()
+	defer bc.mtx.Unlock()
+	node := bc.nodeManager.NodeByIP(ip)
+	if node == nil {
+		return fmt.Errorf("no node registered with IP address %s", ip)
+	}
+	// The scheduler reads node statuses concurrently, so the node is replaced instead of updated.
+	updated := *node
+	updated.Status.Pressures = pressures
+	if err := etcd.Put(fmt.Sprintf("/Nodes/%s", node.Name), &updated); err != nil {
+		return err
+	}
+	if err := bc.nodeManager.ReplaceNode(&updated); err != nil {
+		return err
+	}
+	glog.Infof("NODE [%s]: node pressures updated to %v", node.Name, pressures)
+	return nil
+}
+
+func (bc *basicController) UpdateNodeCapacity(ip string, capacity core.ResourceList) error {
+	bc.mtx.Lock()
+	defer bc.mtx.Unlock()
+	node := bc.nodeManager.NodeByIP(ip)
+	if node == nil {
+		return fmt.Errorf("no node registered with IP address %s", ip)
+	}
+	updated := *node
+	updated.Status.Capacity = capacity
+	if err := etcd.Put(fmt.Sprintf("/Nodes/%s", node.Name), &updated); err != nil {
+		return err
+	}
+	if err := bc.nodeManager.ReplaceNode(&updated); err != nil {
+		return err
+	}
+	glog.Infof("NODE [%s]: node capacity updated to %v", node.Name, capacity)
+	return nil
+}
+
+func (bc *basicController) StatsSummaries(names []string) ([]*core.StatsSummary, []string) {
+	nodes := make([]*core.Node, 0)
+	notFound := make([]string, 0)
+	if len(names) == 0 {
+		nodes = bc.nodeManager.RegisteredNodes()
+	} else {
+		registered := make(map[string]*core.Node)
+		for _, node := range bc.nodeManager.RegisteredNodes() {
+			registered[node.Name] = node
+		}
+		for _, name := range names {
+			if node, ok := registered[name]; ok {
+				nodes = append(nodes, node)
+			} else {
+				notFound = append(notFound, name)
+			}
+		}
+	}
+
+	// Kubelets take about a second to sample the CPU usage, so they are asked in parallel.
+	summaries := make([]*core.StatsSummary, len(nodes))
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(i int, node *core.Node) {
+			defer wg.Done()
+			summary, err := bc.nodeManager.ClientByName(node.Name).GetStatsSummary()
+			if err != nil {
+				glog.Errorf("NODE [%s]: cannot get stats summary: %v", node.Name, err.Error())
+				return
+			}
+			summary.NodeName = node.Name
+			summaries[i] = summary
+		}(i, node)
+	}
+	wg.Wait()
+
+	found := make([]*core.StatsSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		if summary != nil {
+			found = append(found, summary)
+		}
+	}
+	return found, notFound
+}
+
+// PeerIP returns the IP address of the worker that issued the grpc request.
+func PeerIP(ctx context.Context) string {
+	p, _ := peer.FromContext(ctx)
+	workerAddr := p.Addr.String()
+	if strings.Count(workerAddr, ":") < 2 {
+		// IPv4 address
+		return strings.Split(workerAddr, ":")[0]
+	} else {
+		// IPv6 address
+		return workerAddr[0:strings.LastIndex(workerAddr, ":")]
+	}
+}
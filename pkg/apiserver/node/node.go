@@ -3,6 +3,7 @@ package node
 import (
 	"fmt"
 	"sort"
+	"sync"
 
 	"p9t.io/kuberboat/pkg/api/core"
 	"p9t.io/kuberboat/pkg/apiserver/client"
@@ -16,6 +17,9 @@ type NodeWithClient struct {
 type NodeManager interface {
 	// RegisterNode adds metadata for the node and creates grpc client to Kubelet and Kubeproxy to that node.
 	RegisterNode(node *core.Node) error
+	// ReplaceNode swaps the registered node of the same name for node. Nodes handed out before keep
+	// their old status, so updates never change a node under the feet of its readers.
+	ReplaceNode(node *core.Node) error
 	// UnregisterNode is for rolling back registration.
 	UnregisterNode(name string) error
 	// RegisterNodes returns all the node registered.
@@ -33,6 +37,8 @@ type NodeManager interface {
 }
 
 type nodeManagerInner struct {
+	// mtx guards nodes.
+	mtx   sync.RWMutex
 	nodes map[string]*NodeWithClient
 }
 
@@ -43,6 +49,8 @@ func NewNodeManager() NodeManager {
 }
 
 func (nm *nodeManagerInner) RegisterNode(node *core.Node) error {
+	nm.mtx.Lock()
+	defer nm.mtx.Unlock()
 	if nm.nodes[node.Name] != nil {
 		return fmt.Errorf("duplicate node name %s", node.Name)
 	}
@@ -57,7 +65,23 @@ func (nm *nodeManagerInner) RegisterNode(node *core.Node) error {
 	return nil
 }
 
+func (nm *nodeManagerInner) ReplaceNode(node *core.Node) error {
+	nm.mtx.Lock()
+	defer nm.mtx.Unlock()
+	nodeWithClient, ok := nm.nodes[node.Name]
+	if !ok {
+		return fmt.Errorf("no such node: %v", node.Name)
+	}
+	nm.nodes[node.Name] = &NodeWithClient{
+		node:   node,
+		client: nodeWithClient.client,
+	}
+	return nil
+}
+
 func (nm *nodeManagerInner) UnregisterNode(name string) error {
+	nm.mtx.Lock()
+	defer nm.mtx.Unlock()
 	if _, ok := nm.nodes[name]; ok {
 		delete(nm.nodes, name)
 		return nil
@@ -67,6 +91,8 @@ func (nm *nodeManagerInner) UnregisterNode(name string) error {
 }
 
 func (nm *nodeManagerInner) RegisteredNodes() []*core.Node {
+	nm.mtx.RLock()
+	defer nm.mtx.RUnlock()
 	registeredNodes := make(core.NodeTimeSlice, 0, len(nm.nodes))
 	for _, nodeWithClient := range nm.nodes {
 		registeredNodes = append(registeredNodes, nodeWithClient.node)
@@ -76,6 +102,8 @@ func (nm *nodeManagerInner) RegisteredNodes() []*core.Node {
 }
 
 func (nm *nodeManagerInner) NodeByIP(ip string) *core.Node {
+	nm.mtx.RLock()
+	defer nm.mtx.RUnlock()
 	for _, nodeWithClient := range nm.nodes {
 		if nodeWithClient.node.Status.Address == ip {
 			return nodeWithClient.node
@@ -85,6 +113,8 @@ func (nm *nodeManagerInner) NodeByIP(ip string) *core.Node {
 }
 
 func (nm *nodeManagerInner) ClientByName(name string) *client.ApiserverClient {
+	nm.mtx.RLock()
+	defer nm.mtx.RUnlock()
 	if nodeWithClient, ok := nm.nodes[name]; ok {
 		return nodeWithClient.client
 	}
@@ -92,6 +122,8 @@ func (nm *nodeManagerInner) ClientByName(name string) *client.ApiserverClient {
 }
 
 func (nm *nodeManagerInner) ClientByIP(ip string) *client.ApiserverClient {
+	nm.mtx.RLock()
+	defer nm.mtx.RUnlock()
 	for _, nodeWithClient := range nm.nodes {
 		if nodeWithClient.node.Status.Address == ip {
 			return nodeWithClient.client
@@ -101,6 +133,8 @@ func (nm *nodeManagerInner) ClientByIP(ip string) *client.ApiserverClient {
 }
 
 func (nm *nodeManagerInner) Clients() []*client.ApiserverClient {
+	nm.mtx.RLock()
+	defer nm.mtx.RUnlock()
 	clients := make([]*client.ApiserverClient, 0, len(nm.nodes))
 	for _, nodeWithClient := range nm.nodes {
 		clients = append(clients, nodeWithClient.client)
@@ -109,5 +143,7 @@ func (nm *nodeManagerInner) Clients() []*client.ApiserverClient {
 }
 
 func (nm *nodeManagerInner) Empty() bool {
+	nm.mtx.RLock()
+	defer nm.mtx.RUnlock()
 	return len(nm.nodes) == 0
 }
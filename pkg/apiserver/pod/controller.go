@@ -11,6 +11,7 @@ import (
 	"github.com/google/uuid"
 	"p9t.io/kuberboat/pkg/api/core"
 	"p9t.io/kuberboat/pkg/apiserver"
+	"p9t.io/kuberboat/pkg/apiserver/client"
 	"p9t.io/kuberboat/pkg/apiserver/etcd"
 	"p9t.io/kuberboat/pkg/apiserver/node"
 	"p9t.io/kuberboat/pkg/apiserver/schedule"
@@ -36,6 +37,16 @@ type Controller interface {
 	// UpdatePodStatus updates the status of a pod when API server is notified by Kubelet.
 	// Also returns the previous state of the pod.
 	UpdatePodStatus(podName string, podStatus *core.PodStatus) (*core.PodStatus, error)
+	// UpdateMirrorPod creates the mirror pod of a static pod running on the node with address
+	// hostIP, or updates the status of the mirror pod if it exists already.
+	// Also returns the previous status of the mirror pod, which is empty for a new mirror pod.
+	UpdateMirrorPod(pod *core.Pod, hostIP string) (*core.PodStatus, error)
+	// DeleteMirrorPod removes the mirror pod of a static pod and returns the removed pod.
+	DeleteMirrorPod(name string) (*core.Pod, error)
+	// GetPodsByHostIP returns the pods scheduled on the node with the given address.
+	GetPodsByHostIP(hostIP string) []*core.Pod
+	// GetPodClient returns the grpc client to the kubelet on the node where the pod is scheduled.
+	GetPodClient(podName string) (*client.ApiserverClient, error)
 }
 
 type basicController struct {
@@ -94,6 +105,22 @@ func (c *basicController) CreatePod(pod *core.Pod) error {
 	if c.componentManager.PodExistsByName(pod.Name) {
 		return fmt.Errorf("pod already exists: %v", pod.Name)
 	}
+	if core.IsMirrorPod(pod) {
+		return fmt.Errorf("label %v is reserved for mirror pods of static pods", core.MirrorPodLabel)
+	}
+	for _, c := range pod.Spec.Containers {
+		switch c.ImagePullPolicy {
+		case "", core.PullAlways, core.PullIfNotPresent, core.PullNever:
+		default:
+			return fmt.Errorf("invalid image pull policy of container %v: %v", c.Name, c.ImagePullPolicy)
+		}
+		if err := core.ValidateResources(&c); err != nil {
+			return err
+		}
+	}
+	if err := core.ValidateSecurityContext(pod); err != nil {
+		return err
+	}
 	node, err := c.podScheduler.SchedulePod(pod)
 	if err != nil {
 		return err
@@ -129,6 +156,7 @@ func (c *basicController) CreatePod(pod *core.Pod) error {
 	pod.Status.Phase = core.PodPending
 	pod.Status.HostIP = node.Status.Address
 	pod.Status.RunningContainers = 0
+	pod.Status.QOSClass = core.PodQOSClass(pod)
 
 	if err := etcd.Put(fmt.Sprintf("/Pods/%s", pod.Name), pod); err != nil {
 		return err
@@ -159,6 +187,9 @@ func (c *basicController) DeletePodByName(name string) error {
 	if pod == nil {
 		return fmt.Errorf("race condition on pod: %v", name)
 	}
+	if core.IsMirrorPod(pod) {
+		return fmt.Errorf("pod %v is a mirror pod, remove its manifest on node %v instead", name, pod.Labels[core.MirrorPodLabel])
+	}
 
 	ip := pod.Status.HostIP
 	client := c.nodeManager.ClientByIP(ip)
@@ -182,6 +213,10 @@ func (c *basicController) DeletePodByName(name string) error {
 
 func (c *basicController) DeleteAllPods() error {
 	for _, pod := range c.componentManager.ListPods() {
+		// Mirror pods are owned by the kubelets running them.
+		if core.IsMirrorPod(pod) {
+			continue
+		}
 		if err := c.DeletePodByName(pod.Name); err != nil {
 			return err
 		}
@@ -207,3 +242,83 @@ func (c *basicController) UpdatePodStatus(podName string, podStatus *core.PodSta
 	err := etcd.Put(fmt.Sprintf("/Pods/%s", pod.Name), pod)
 	return &prevStatus, err
 }
+
+func (c *basicController) UpdateMirrorPod(pod *core.Pod, hostIP string) (*core.PodStatus, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if !core.IsMirrorPod(pod) {
+		return nil, fmt.Errorf("pod %v is not a mirror pod", pod.Name)
+	}
+	pod.Status.HostIP = hostIP
+
+	if c.componentManager.PodExistsByName(pod.Name) {
+		mirrorPod := c.componentManager.GetPodByName(pod.Name)
+		if mirrorPod == nil {
+			return nil, fmt.Errorf("race condition on pod: %v", pod.Name)
+		}
+		if !core.IsMirrorPod(mirrorPod) || mirrorPod.UUID != pod.UUID {
+			return nil, fmt.Errorf("pod already exists: %v", pod.Name)
+		}
+		prevStatus := mirrorPod.Status
+		mirrorPod.Status = pod.Status
+		err := etcd.Put(fmt.Sprintf("/Pods/%s", mirrorPod.Name), mirrorPod)
+		return &prevStatus, err
+	}
+
+	if err := etcd.Put(fmt.Sprintf("/Pods/%s", pod.Name), pod); err != nil {
+		return nil, err
+	}
+	c.componentManager.SetPod(pod)
+
+	glog.Infof("POD [%v]: mirror pod created for static pod on node with IP %v", pod.Name, hostIP)
+
+	return &core.PodStatus{}, nil
+}
+
+func (c *basicController) DeleteMirrorPod(name string) (*core.Pod, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	pod := c.componentManager.GetPodByName(name)
+	if pod == nil {
+		return nil, fmt.Errorf("no such pod: %v", name)
+	}
+	if !core.IsMirrorPod(pod) {
+		return nil, fmt.Errorf("pod %v is not a mirror pod", name)
+	}
+	if err := etcd.Delete(fmt.Sprintf("/Pods/%s", name)); err != nil {
+		return nil, err
+	}
+	c.legacyManager.SetPodLegacy(name)
+	c.componentManager.DeletePodByName(name)
+
+	glog.Infof("POD [%v]: mirror pod deleted", name)
+
+	return pod, nil
+}
+
+func (c *basicController) GetPodsByHostIP(hostIP string) []*core.Pod {
+	pods := make([]*core.Pod, 0)
+	for _, pod := range c.componentManager.ListPods() {
+		if pod.Status.HostIP == hostIP {
+			pods = append(pods, pod)
+		}
+	}
+	return pods
+}
+
+func (c *basicController) GetPodClient(podName string) (*client.ApiserverClient, error) {
+	pod := c.componentManager.GetPodByName(podName)
+	if pod == nil {
+		return nil, fmt.Errorf("no such pod: %v", podName)
+	}
+	if pod.Status.HostIP == "" {
+		return nil, fmt.Errorf("pod %v is not scheduled yet", podName)
+	}
+	client := c.nodeManager.ClientByIP(pod.Status.HostIP)
+	if client == nil {
+		return nil, fmt.Errorf("cannot find grpc client for worker at address: %v", pod.Status.HostIP)
+	}
+	return client, nil
+}
@@ -2,6 +2,7 @@ package recover
 
 import (
 	"container/list"
+	"encoding/json"
 	"fmt"
 	"net"
 	"os"
@@ -11,6 +12,7 @@ import (
 	"p9t.io/kuberboat/pkg/api"
 	"p9t.io/kuberboat/pkg/api/core"
 	"p9t.io/kuberboat/pkg/apiserver"
+	"p9t.io/kuberboat/pkg/apiserver/deployment"
 	"p9t.io/kuberboat/pkg/apiserver/etcd"
 	"p9t.io/kuberboat/pkg/apiserver/node"
 	metrics "p9t.io/kuberboat/pkg/apiserver/scale"
@@ -102,25 +104,120 @@ func Recover(nm *node.NodeManager, cm *apiserver.ComponentManager, sm service.Co
 	}
 	for _, rawDeployment := range rawDeployments {
 		deployment := rawDeployment.(core.Deployment)
+		(*cm).SetDeployment(&deployment)
+	}
+	// recover all the replica sets
+	var replicaSetType core.ReplicaSet
+	rawReplicaSets, err := etcd.Get("/ReplicaSets/Meta", replicaSetType, clientv3.WithPrefix())
+	if err != nil {
+		return err
+	}
+	for _, rawReplicaSet := range rawReplicaSets {
+		replicaSet := rawReplicaSet.(core.ReplicaSet)
 		var podNames []string
-		rawPodNames, err := etcd.Get(fmt.Sprintf("/Deployments/Pods/%s", deployment.Name), podNames)
+		rawPodNames, err := etcd.Get(fmt.Sprintf("/ReplicaSets/Pods/%s", replicaSet.Name), podNames)
 		if err != nil {
 			return err
 		}
 		if len(rawPodNames) > 1 {
-			glog.Fatal("service should have only one pod array")
+			glog.Fatal("replica set should have only one pod array")
 		}
-		podNames = rawPodNames[0].([]string)
-		deploymentPods := list.New()
+		// The pod array is missing if the apiserver stopped while creating the replica set.
+		if len(rawPodNames) == 1 {
+			podNames = rawPodNames[0].([]string)
+		}
+		replicaSetPods := list.New()
 		for _, podName := range podNames {
 			pod, ok := nameToPods[podName]
 			if !ok {
-				glog.Warningf("deployment has an unknown pod")
+				glog.Warningf("replica set has an unknown pod")
 			} else {
-				deploymentPods.PushBack(pod)
+				replicaSetPods.PushBack(pod)
 			}
 		}
-		(*cm).SetDeployment(&deployment, deploymentPods)
+		(*cm).SetReplicaSet(&replicaSet, replicaSetPods)
+	}
+	// adopt the pods that deployments kept before they managed them through replica sets
+	for _, d := range (*cm).ListDeployments() {
+		data, err := etcd.GetRaw(fmt.Sprintf("/Deployments/Pods/%s", d.Name))
+		if err != nil {
+			// Only deployments stored by earlier versions have their pods listed.
+			continue
+		}
+		var podNames []string
+		if err := json.Unmarshal(data, &podNames); err != nil {
+			return err
+		}
+		if err := adoptDeploymentPods(cm, d, podNames, nameToPods); err != nil {
+			return err
+		}
+	}
+	// recover all the stateful sets
+	var statefulSetType core.StatefulSet
+	rawStatefulSets, err := etcd.Get("/StatefulSets", statefulSetType, clientv3.WithPrefix())
+	if err != nil {
+		return err
+	}
+	for _, rawStatefulSet := range rawStatefulSets {
+		statefulSet := rawStatefulSet.(core.StatefulSet)
+		(*cm).SetStatefulSet(&statefulSet)
+	}
+	// recover all the registry credentials
+	var credentialType core.RegistryCredential
+	rawCredentials, err := etcd.Get("/RegistryCredentials", credentialType, clientv3.WithPrefix())
+	if err != nil {
+		return err
+	}
+	for _, rawCredential := range rawCredentials {
+		credential := rawCredential.(core.RegistryCredential)
+		(*cm).SetRegistryCredential(&credential)
 	}
 	return nil
 }
+
+// adoptDeploymentPods moves the pods listed by a deployment stored by an earlier version into a
+// replica set owned by the deployment, and removes the list.
+func adoptDeploymentPods(
+	cm *apiserver.ComponentManager,
+	d *core.Deployment,
+	podNames []string,
+	nameToPods map[string]*core.Pod,
+) error {
+	replicaSet := deployment.AdoptingReplicaSet(d)
+	// The pods were adopted already if the list outlived a previous recovery.
+	if (*cm).GetReplicaSetByName(replicaSet.Name) == nil {
+		hash := core.PodTemplateHash(replicaSet)
+		pods := list.New()
+		for _, podName := range podNames {
+			pod, ok := nameToPods[podName]
+			if !ok {
+				glog.Warningf("deployment has an unknown pod")
+				continue
+			}
+			labels := make(map[string]string, len(pod.Labels)+1)
+			for k, v := range pod.Labels {
+				labels[k] = v
+			}
+			labels[core.PodTemplateHashLabel] = hash
+			pod.Labels = labels
+			if err := etcd.Put(fmt.Sprintf("/Pods/%s", pod.Name), pod); err != nil {
+				return err
+			}
+			pods.PushBack(pod)
+		}
+		if err := etcd.Put(fmt.Sprintf("/ReplicaSets/Meta/%s", replicaSet.Name), replicaSet); err != nil {
+			return err
+		}
+		if err := etcd.Put(fmt.Sprintf("/ReplicaSets/Pods/%s", replicaSet.Name), core.GetPodNames(pods)); err != nil {
+			return err
+		}
+		(*cm).SetReplicaSet(replicaSet, pods)
+		glog.Infof(
+			"DEPLOYMENT [%v]: adopted %v pods into replica set [%v]",
+			d.Name,
+			pods.Len(),
+			replicaSet.Name,
+		)
+	}
+	return etcd.Delete(fmt.Sprintf("/Deployments/Pods/%s", d.Name))
+}
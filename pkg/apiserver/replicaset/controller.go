@@ -0,0 +1,462 @@
+package replicaset
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/google/uuid"
+	"p9t.io/kuberboat/pkg/api/core"
+	"p9t.io/kuberboat/pkg/apiserver"
+	"p9t.io/kuberboat/pkg/apiserver/etcd"
+	"p9t.io/kuberboat/pkg/apiserver/pod"
+)
+
+const (
+	// Interval in seconds between two checks of replica set spec against status.
+	monitorInterval = 3
+)
+
+// Controller manages replica sets.
+type Controller interface {
+	// DescribeReplicaSets return all the replica sets and their respective pods.
+	DescribeReplicaSets(all bool, names []string) ([]*core.ReplicaSet, [][]string, []string)
+	// ApplyReplicaSet creates a standalone replica set if no replica set with the same name exists.
+	// Otherwise, it updates the replicas and the template of the standalone replica set, which only
+	// affects the pods created afterwards.
+	ApplyReplicaSet(replicaSet *core.ReplicaSet) error
+	// CreateReplicaSet creates a replica set owned by the deployment named by its OwnerName.
+	CreateReplicaSet(replicaSet *core.ReplicaSet) error
+	// UpdateReplicaSet modifies a replica set with update and persists it. The pods of the replica
+	// set follow when it is next monitored.
+	UpdateReplicaSet(name string, update func(replicaSet *core.ReplicaSet)) error
+	// DeleteReplicaSetByName deletes a replica set and its pods. owner must be the name of the
+	// deployment owning the replica set, or empty for a standalone replica set.
+	DeleteReplicaSetByName(name string, owner string) error
+	// DeleteAllReplicaSets deletes all standalone replica sets by calling DeleteReplicaSetByName.
+	DeleteAllReplicaSets() error
+	// monitorReplicaSet checks if the number of pods of replica sets matches their specs.
+	// If not, make adjustments.
+	monitorReplicaSet()
+}
+
+type basicController struct {
+	mtx sync.Mutex
+	// componentManager stores the replica sets and their pods.
+	componentManager apiserver.ComponentManager
+	// podController performs the actual creating/deleting pods.
+	podController pod.Controller
+	// readySince is when each pod of a replica set became ready, used to tell whether it has been
+	// ready for MinReadySeconds.
+	readySince map[string]time.Time
+}
+
+func NewReplicaSetController(componentManager apiserver.ComponentManager, pc pod.Controller) *basicController {
+	controller := &basicController{
+		componentManager: componentManager,
+		podController:    pc,
+		readySince:       map[string]time.Time{},
+	}
+	go func() {
+		for range time.Tick(time.Second * monitorInterval) {
+			controller.monitorReplicaSet()
+		}
+	}()
+
+	apiserver.SubscribeToEvent(controller, apiserver.PodDeletion)
+	apiserver.SubscribeToEvent(controller, apiserver.PodReady)
+	apiserver.SubscribeToEvent(controller, apiserver.PodFail)
+
+	return controller
+}
+
+func (m *basicController) DescribeReplicaSets(all bool, names []string) ([]*core.ReplicaSet, [][]string, []string) {
+	replicaSetPods := make([][]string, 0)
+	if all {
+		replicaSets := m.componentManager.ListReplicaSets()
+		for _, replicaSet := range replicaSets {
+			replicaSetPods = append(replicaSetPods, m.getPodNames(replicaSet.Name))
+		}
+		return replicaSets, replicaSetPods, make([]string, 0)
+	}
+	foundReplicaSets := make([]*core.ReplicaSet, 0)
+	notFoundReplicaSets := make([]string, 0)
+	for _, name := range names {
+		replicaSet := m.componentManager.GetReplicaSetByName(name)
+		if replicaSet == nil {
+			notFoundReplicaSets = append(notFoundReplicaSets, name)
+			continue
+		}
+		foundReplicaSets = append(foundReplicaSets, replicaSet)
+		replicaSetPods = append(replicaSetPods, m.getPodNames(name))
+	}
+	return foundReplicaSets, replicaSetPods, notFoundReplicaSets
+}
+
+func (m *basicController) getPodNames(replicaSetName string) []string {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	pods := m.componentManager.ListPodsByReplicaSetName(replicaSetName)
+	if pods == nil {
+		return []string{}
+	}
+	return core.GetPodNames(pods)
+}
+
+func (m *basicController) ApplyReplicaSet(replicaSet *core.ReplicaSet) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	existingReplicaSet := m.componentManager.GetReplicaSetByName(replicaSet.Name)
+	if existingReplicaSet == nil {
+		replicaSet.OwnerName = ""
+		replicaSet.TemplateHash = ""
+		replicaSet.Revision = 0
+		replicaSet.ChangeCause = ""
+		if err := m.createReplicaSet(replicaSet); err != nil {
+			return err
+		}
+		glog.Infof(
+			"REPLICASET [%v]: replica set created with %d replicas",
+			replicaSet.Name,
+			replicaSet.Spec.Replicas,
+		)
+		return nil
+	}
+
+	if existingReplicaSet.OwnerName != "" {
+		return fmt.Errorf(
+			"replica set %v is owned by deployment %v and cannot be updated",
+			replicaSet.Name,
+			existingReplicaSet.OwnerName,
+		)
+	}
+	// Build the update on a copy, so that a failed update leaves the replica set as it is in etcd.
+	updated := *existingReplicaSet
+	updated.Spec = replicaSet.Spec
+	if err := setReplicaSetInEtcd(&updated); err != nil {
+		return err
+	}
+	*existingReplicaSet = updated
+	glog.Infof(
+		"REPLICASET [%v]: replica set updated to %d replicas",
+		replicaSet.Name,
+		replicaSet.Spec.Replicas,
+	)
+	return nil
+}
+
+func (m *basicController) CreateReplicaSet(replicaSet *core.ReplicaSet) error {
+	if replicaSet.OwnerName == "" {
+		return fmt.Errorf("replica set %v has no owner", replicaSet.Name)
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if m.componentManager.ReplicaSetExistsByName(replicaSet.Name) {
+		return fmt.Errorf("replica set %v already exists", replicaSet.Name)
+	}
+	if err := m.createReplicaSet(replicaSet); err != nil {
+		return err
+	}
+	glog.Infof(
+		"REPLICASET [%v]: replica set created for deployment %v",
+		replicaSet.Name,
+		replicaSet.OwnerName,
+	)
+	return nil
+}
+
+// createReplicaSet stores a new replica set without pods, which are created when it is monitored.
+// The caller must hold the lock.
+func (m *basicController) createReplicaSet(replicaSet *core.ReplicaSet) error {
+	replicaSet.Kind = core.ReplicaSetType
+	replicaSet.UUID = uuid.New()
+	replicaSet.CreationTimestamp = time.Now()
+	replicaSet.Status = core.ReplicaSetStatus{}
+	if err := setReplicaSetInEtcd(replicaSet); err != nil {
+		return err
+	}
+	if err := setReplicaSetPodsInEtcd(replicaSet.Name, list.New()); err != nil {
+		return err
+	}
+	m.componentManager.SetReplicaSet(replicaSet, list.New())
+	return nil
+}
+
+func (m *basicController) UpdateReplicaSet(name string, update func(replicaSet *core.ReplicaSet)) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	replicaSet := m.componentManager.GetReplicaSetByName(name)
+	if replicaSet == nil {
+		return fmt.Errorf("no such replica set: %v", name)
+	}
+	updated := *replicaSet
+	update(&updated)
+	if err := setReplicaSetInEtcd(&updated); err != nil {
+		return err
+	}
+	*replicaSet = updated
+	return nil
+}
+
+func (m *basicController) DeleteReplicaSetByName(name string, owner string) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	replicaSet := m.componentManager.GetReplicaSetByName(name)
+	if replicaSet == nil {
+		return fmt.Errorf("no such replica set: %v", name)
+	}
+	if replicaSet.OwnerName != owner {
+		return fmt.Errorf(
+			"replica set %v is owned by deployment %v and cannot be deleted on its own",
+			name,
+			replicaSet.OwnerName,
+		)
+	}
+
+	glog.Infof("REPLICASET [%v]: deleting", name)
+	// Take the names first, because the list is being changed for each call to DeletePodByName.
+	for _, podName := range core.GetPodNames(m.componentManager.ListPodsByReplicaSetName(name)) {
+		if err := m.podController.DeletePodByName(podName); err != nil {
+			glog.Errorf("REPLICASET [%v]: unable to delete pod [%v]: %v", name, podName, err.Error())
+			continue
+		}
+		delete(m.readySince, podName)
+		glog.Infof("REPLICASET [%v]: deleted pod [%v]", name, podName)
+	}
+	if err := DeleteReplicaSetInEtcd(name); err != nil {
+		return err
+	}
+	m.componentManager.DeleteReplicaSetByName(name)
+	glog.Infof("REPLICASET [%v]: replica set deleted", name)
+	return nil
+}
+
+func (m *basicController) DeleteAllReplicaSets() error {
+	for _, replicaSet := range m.componentManager.ListReplicaSets() {
+		// Replica sets owned by deployments go with their deployments.
+		if replicaSet.OwnerName != "" {
+			continue
+		}
+		if err := m.DeleteReplicaSetByName(replicaSet.Name, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *basicController) HandleEvent(event apiserver.Event) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	switch event.Type() {
+	case apiserver.PodDeletion:
+		podName := event.(*apiserver.PodDeletionEvent).Pod.Name
+		delete(m.readySince, podName)
+		legacy := event.(*apiserver.PodDeletionEvent).PodLegacy
+		if legacy == nil {
+			return
+		}
+		// If the replica set is not found, the pod must be deleted along with it.
+		if replicaSet := m.componentManager.GetReplicaSetByName(legacy.ReplicaSetName); replicaSet != nil {
+			m.refreshStatus(replicaSet)
+		}
+	case apiserver.PodReady:
+		podName := event.(*apiserver.PodReadyEvent).PodName
+		replicaSet := m.componentManager.GetReplicaSetByPodName(podName)
+		if replicaSet == nil {
+			return
+		}
+		m.readySince[podName] = time.Now()
+		m.refreshStatus(replicaSet)
+	case apiserver.PodFail:
+		podName := event.(*apiserver.PodFailEvent).PodName
+		replicaSet := m.componentManager.GetReplicaSetByPodName(podName)
+		if replicaSet == nil {
+			return
+		}
+		// The pod is replaced when the replica set is next monitored.
+		if err := m.deleteReplicaSetPod(replicaSet, podName); err != nil {
+			glog.Errorf("REPLICASET [%v]: failed to delete failed pod [%v]: %v", replicaSet.Name, podName, err)
+		}
+	}
+}
+
+func (m *basicController) monitorReplicaSet() {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	for _, replicaSet := range m.componentManager.ListReplicaSets() {
+		pods := m.componentManager.ListPodsByReplicaSetName(replicaSet.Name)
+		if pods == nil {
+			glog.Errorf("REPLICASET [%v]: nil pod list", replicaSet.Name)
+			continue
+		}
+		numPodDiff := int(replicaSet.Spec.Replicas) - pods.Len()
+		if numPodDiff > 0 {
+			m.morePods(replicaSet, pods, numPodDiff)
+		} else if numPodDiff < 0 {
+			m.fewerPods(replicaSet, pods, -numPodDiff)
+		}
+		m.refreshStatus(replicaSet)
+	}
+}
+
+func (m *basicController) morePods(replicaSet *core.ReplicaSet, pods *list.List, numPodsToAdd int) {
+	glog.Infof("REPLICASET [%v]: adding %v pods", replicaSet.Name, numPodsToAdd)
+	numPodsAdded := 0
+	// Create new pods from template. Keep creating even if it fails.
+	for i := 0; i < numPodsToAdd; i++ {
+		p := &core.Pod{Kind: core.PodType}
+		p.Name = getPodName(replicaSet)
+		p.Labels = podLabels(replicaSet)
+		p.Spec = replicaSet.Spec.Template.Spec
+
+		if err := m.podController.CreatePod(p); err != nil {
+			glog.Errorf("REPLICASET [%v]: failed to create pod: %v", replicaSet.Name, err.Error())
+			continue
+		}
+		numPodsAdded++
+		pods.PushBack(p)
+		glog.Infof("REPLICASET [%v]: added pod [%v]", replicaSet.Name, p.Name)
+	}
+	if err := setReplicaSetPodsInEtcd(replicaSet.Name, pods); err != nil {
+		glog.Errorf("failed to update replica set's corresponding pods: %v", err)
+	}
+	glog.Infof("REPLICASET [%v]: expected to add %v pods, actually added %v", replicaSet.Name, numPodsToAdd, numPodsAdded)
+}
+
+// podLabels returns the labels of a new pod of a replica set. Pods of a replica set owned by a
+// deployment are also labelled with the template hash, so that a service can select exactly them.
+func podLabels(replicaSet *core.ReplicaSet) map[string]string {
+	hash := core.PodTemplateHash(replicaSet)
+	if hash == "" {
+		return replicaSet.Spec.Template.Labels
+	}
+	labels := make(map[string]string, len(replicaSet.Spec.Template.Labels)+1)
+	for k, v := range replicaSet.Spec.Template.Labels {
+		labels[k] = v
+	}
+	labels[core.PodTemplateHashLabel] = hash
+	return labels
+}
+
+// fewerPods deletes the pods that are not ready first, and then the newest ones.
+func (m *basicController) fewerPods(replicaSet *core.ReplicaSet, pods *list.List, numPodsToDelete int) {
+	glog.Infof("REPLICASET [%v]: deleting %v pods", replicaSet.Name, numPodsToDelete)
+	numPodsDeleted := 0
+	for _, pod := range podsToDelete(pods, numPodsToDelete) {
+		if err := m.deleteReplicaSetPod(replicaSet, pod.Name); err != nil {
+			glog.Errorf("REPLICASET [%v]: failed to delete pod: %v", replicaSet.Name, err.Error())
+			continue
+		}
+		numPodsDeleted++
+	}
+	glog.Infof("REPLICASET [%v]: expected to delete %v pods, actually deleted %v", replicaSet.Name, numPodsToDelete, numPodsDeleted)
+}
+
+// deleteReplicaSetPod deletes a pod of a replica set. The caller must hold the lock.
+func (m *basicController) deleteReplicaSetPod(replicaSet *core.ReplicaSet, podName string) error {
+	// DeletePodByName will alter the pod list of the replica set, so no need to modify it here.
+	if err := m.podController.DeletePodByName(podName); err != nil {
+		return err
+	}
+	delete(m.readySince, podName)
+	if err := setReplicaSetPodsInEtcd(replicaSet.Name, m.componentManager.ListPodsByReplicaSetName(replicaSet.Name)); err != nil {
+		glog.Errorf("failed to update replica set's corresponding pods: %v", err)
+	}
+	glog.Infof("REPLICASET [%v]: deleted pod [%v]", replicaSet.Name, podName)
+	return nil
+}
+
+// refreshStatus counts the pods of a replica set, and persists its status if it changes. The caller
+// must hold the lock.
+func (m *basicController) refreshStatus(replicaSet *core.ReplicaSet) {
+	pods := m.componentManager.ListPodsByReplicaSetName(replicaSet.Name)
+	if pods == nil {
+		return
+	}
+	status := computeStatus(replicaSet, pods, m.readySince, time.Now())
+	if status == replicaSet.Status {
+		return
+	}
+	replicaSet.Status = status
+	if err := setReplicaSetInEtcd(replicaSet); err != nil {
+		glog.Errorf("failed to update replica set's metadata: %v", err)
+	}
+}
+
+// computeStatus counts the pods of a replica set, and the ones among them that have been ready for
+// MinReadySeconds. A ready pod whose readiness was not seen, such as one recovered after a restart,
+// is taken as ready from now on.
+func computeStatus(
+	replicaSet *core.ReplicaSet,
+	pods *list.List,
+	readySince map[string]time.Time,
+	now time.Time,
+) core.ReplicaSetStatus {
+	minReadyDuration := time.Second * time.Duration(replicaSet.Spec.MinReadySeconds)
+	status := core.ReplicaSetStatus{Replicas: uint32(pods.Len())}
+	for it := pods.Front(); it != nil; it = it.Next() {
+		pod := it.Value.(*core.Pod)
+		if pod.Status.Phase != core.PodReady {
+			delete(readySince, pod.Name)
+			continue
+		}
+		since, ok := readySince[pod.Name]
+		if !ok {
+			since = now
+			readySince[pod.Name] = now
+		}
+		if now.Sub(since) >= minReadyDuration {
+			status.ReadyReplicas++
+		}
+	}
+	return status
+}
+
+// podsToDelete picks the pods to delete when scaling down, the ones that are not ready first and
+// then the newest ones.
+func podsToDelete(pods *list.List, numPodsToDelete int) []*core.Pod {
+	picked := make([]*core.Pod, 0, numPodsToDelete)
+	for it := pods.Back(); it != nil && len(picked) < numPodsToDelete; it = it.Prev() {
+		if pod := it.Value.(*core.Pod); pod.Status.Phase != core.PodReady {
+			picked = append(picked, pod)
+		}
+	}
+	for it := pods.Back(); it != nil && len(picked) < numPodsToDelete; it = it.Prev() {
+		if pod := it.Value.(*core.Pod); pod.Status.Phase == core.PodReady {
+			picked = append(picked, pod)
+		}
+	}
+	return picked
+}
+
+func getPodName(replicaSet *core.ReplicaSet) string {
+	// Pod UUID is still unknown, so we will just generate a new UUID.
+	return replicaSet.Name + "-" + uuid.NewString()[0:5]
+}
+
+func setReplicaSetInEtcd(replicaSet *core.ReplicaSet) error {
+	return etcd.Put(fmt.Sprintf("/ReplicaSets/Meta/%s", replicaSet.Name), replicaSet)
+}
+
+func setReplicaSetPodsInEtcd(name string, pods *list.List) error {
+	return etcd.Put(fmt.Sprintf("/ReplicaSets/Pods/%s", name), core.GetPodNames(pods))
+}
+
+func DeleteReplicaSetInEtcd(name string) error {
+	if err := etcd.Delete(fmt.Sprintf("/ReplicaSets/Meta/%s", name)); err != nil {
+		return err
+	}
+	if err := etcd.Delete(fmt.Sprintf("/ReplicaSets/Pods/%s", name)); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package replicaset
+
+import (
+	"container/list"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"p9t.io/kuberboat/pkg/api/core"
+)
+
+func TestComputeStatus(t *testing.T) {
+	now := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	replicaSet := &core.ReplicaSet{}
+	replicaSet.Spec.MinReadySeconds = 30
+	pods := list.New()
+	pods.PushBack(&core.Pod{ObjectMeta: core.ObjectMeta{Name: "test-pod-0"}, Status: core.PodStatus{Phase: core.PodReady}})
+	pods.PushBack(&core.Pod{ObjectMeta: core.ObjectMeta{Name: "test-pod-1"}, Status: core.PodStatus{Phase: core.PodReady}})
+	pods.PushBack(&core.Pod{ObjectMeta: core.ObjectMeta{Name: "test-pod-2"}, Status: core.PodStatus{Phase: core.PodPending}})
+	readySince := map[string]time.Time{
+		"test-pod-0": now.Add(-time.Minute),
+		"test-pod-2": now.Add(-time.Minute),
+	}
+
+	// A pod seen ready for the first time starts waiting for MinReadySeconds now.
+	status := computeStatus(replicaSet, pods, readySince, now)
+	assert.Equal(t, core.ReplicaSetStatus{Replicas: 3, ReadyReplicas: 1}, status)
+	assert.Equal(t, now, readySince["test-pod-1"])
+	_, ok := readySince["test-pod-2"]
+	assert.False(t, ok)
+
+	status = computeStatus(replicaSet, pods, readySince, now.Add(30*time.Second))
+	assert.Equal(t, core.ReplicaSetStatus{Replicas: 3, ReadyReplicas: 2}, status)
+}
+
+func TestPodsToDelete(t *testing.T) {
+	pods := list.New()
+	pods.PushBack(&core.Pod{ObjectMeta: core.ObjectMeta{Name: "test-pod-0"}, Status: core.PodStatus{Phase: core.PodReady}})
+	pods.PushBack(&core.Pod{ObjectMeta: core.ObjectMeta{Name: "test-pod-1"}, Status: core.PodStatus{Phase: core.PodPending}})
+	pods.PushBack(&core.Pod{ObjectMeta: core.ObjectMeta{Name: "test-pod-2"}, Status: core.PodStatus{Phase: core.PodReady}})
+	pods.PushBack(&core.Pod{ObjectMeta: core.ObjectMeta{Name: "test-pod-3"}, Status: core.PodStatus{Phase: core.PodReady}})
+
+	picked := podsToDelete(pods, 3)
+	assert.Equal(t, 3, len(picked))
+	assert.Equal(t, "test-pod-1", picked[0].Name)
+	assert.Equal(t, "test-pod-3", picked[1].Name)
+	assert.Equal(t, "test-pod-2", picked[2].Name)
+	assert.Equal(t, 4, len(podsToDelete(pods, 5)))
+}
@@ -0,0 +1,346 @@
+package scale
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"p9t.io/kuberboat/pkg/api/core"
+)
+
+const (
+	// DefaultTolerance ignores deviations of the usage from the target within 10%.
+	DefaultTolerance float64 = 0.1
+	// MaxScalingPolicyPeriod is the longest period of a scaling policy in seconds.
+	MaxScalingPolicyPeriod int32 = 1800
+	// defaultScaleDownStabilization is the stabilization window of scaling down in seconds.
+	defaultScaleDownStabilization int32 = 300
+)
+
+// timestampedRecommendation is a number of replicas that the autoscaler recommended at a time.
+type timestampedRecommendation struct {
+	replicas  uint32
+	timestamp time.Time
+}
+
+// timestampedScaleEvent is a change of the number of replicas made at a time.
+type timestampedScaleEvent struct {
+	change    int32
+	timestamp time.Time
+}
+
+// autoscalerHistory keeps what an autoscaler has recommended and done, for the stabilization
+// windows and the scaling policies.
+type autoscalerHistory struct {
+	recommendations []timestampedRecommendation
+	scaleUpEvents   []timestampedScaleEvent
+	scaleDownEvents []timestampedScaleEvent
+}
+
+// setDefaultBehavior fills in the tolerance and the scaling rules that are not specified.
+func setDefaultBehavior(spec *core.AutoscalerSpec) {
+	if spec.Tolerance == nil {
+		tolerance := DefaultTolerance
+		spec.Tolerance = &tolerance
+	}
+	if spec.Behavior.ScaleUp == nil {
+		spec.Behavior.ScaleUp = &core.ScalingRules{}
+	}
+	if spec.Behavior.ScaleDown == nil {
+		spec.Behavior.ScaleDown = &core.ScalingRules{}
+	}
+	up, down := spec.Behavior.ScaleUp, spec.Behavior.ScaleDown
+	if up.StabilizationWindowSeconds == nil {
+		window := int32(0)
+		up.StabilizationWindowSeconds = &window
+	}
+	if down.StabilizationWindowSeconds == nil {
+		window := defaultScaleDownStabilization
+		down.StabilizationWindowSeconds = &window
+	}
+	if up.SelectPolicy == "" {
+		up.SelectPolicy = core.ScalingPolicySelectMax
+	}
+	if down.SelectPolicy == "" {
+		down.SelectPolicy = core.ScalingPolicySelectMax
+	}
+	if len(up.Policies) == 0 {
+		up.Policies = []core.ScalingPolicy{
+			{Type: core.ScalingPolicyPods, Value: 4, PeriodSeconds: 15},
+			{Type: core.ScalingPolicyPercent, Value: 100, PeriodSeconds: 15},
+		}
+	}
+	if len(down.Policies) == 0 {
+		down.Policies = []core.ScalingPolicy{
+			{Type: core.ScalingPolicyPercent, Value: 100, PeriodSeconds: 15},
+		}
+	}
+}
+
+// validateScaleInterval checks that an autoscaler scales at a positive interval.
+func validateScaleInterval(spec *core.AutoscalerSpec) error {
+	if spec.ScaleInterval <= 0 {
+		return fmt.Errorf("scale interval must be positive, got %v", spec.ScaleInterval)
+	}
+	return nil
+}
+
+// validateBehavior checks the tolerance and the scaling rules of an autoscaler.
+func validateBehavior(spec *core.AutoscalerSpec) error {
+	if spec.Tolerance != nil && (*spec.Tolerance < 0 || *spec.Tolerance >= 1) {
+		return fmt.Errorf("tolerance must be in [0, 1), got %v", *spec.Tolerance)
+	}
+	for direction, rules := range map[string]*core.ScalingRules{
+		"scaleUp":   spec.Behavior.ScaleUp,
+		"scaleDown": spec.Behavior.ScaleDown,
+	} {
+		if rules == nil {
+			continue
+		}
+		if window := rules.StabilizationWindowSeconds; window != nil && (*window < 0 || *window > 3600) {
+			return fmt.Errorf("stabilization window of %v must be in [0, 3600] seconds, got %v", direction, *window)
+		}
+		switch rules.SelectPolicy {
+		case "", core.ScalingPolicySelectMax, core.ScalingPolicySelectMin, core.ScalingPolicySelectDisabled:
+		default:
+			return fmt.Errorf("invalid select policy of %v: %v", direction, rules.SelectPolicy)
+		}
+		for _, policy := range rules.Policies {
+			if policy.Type != core.ScalingPolicyPods && policy.Type != core.ScalingPolicyPercent {
+				return fmt.Errorf("invalid scaling policy type of %v: %v", direction, policy.Type)
+			}
+			if policy.Value <= 0 {
+				return fmt.Errorf("value of %v scaling policy must be positive, got %v", direction, policy.Value)
+			}
+			if policy.PeriodSeconds <= 0 || policy.PeriodSeconds > MaxScalingPolicyPeriod {
+				return fmt.Errorf(
+					"period of %v scaling policy must be in (0, %v] seconds, got %v",
+					direction,
+					MaxScalingPolicyPeriod,
+					policy.PeriodSeconds,
+				)
+			}
+		}
+	}
+	return nil
+}
+
+// replicasForAverage computes the number of replicas needed so that the average of a metric
+// summing up to total over podNum pods meets target. If the average is within tolerance of the
+// target, the number of replicas stays the same.
+func replicasForAverage(total float64, target float64, podNum int, current uint32, tolerance float64) uint32 {
+	if target <= 0 || podNum == 0 {
+		return current
+	}
+	ratio := total / float64(podNum) / target
+	if math.Abs(ratio-1) <= tolerance {
+		return current
+	}
+	return uint32(math.Ceil(total / target))
+}
+
+// replicasForValue computes the number of replicas needed so that a metric that is value with
+// podNum pods meets target, assuming that the metric is inversely proportional to the number of
+// pods.
+func replicasForValue(value float64, target float64, podNum int, current uint32, tolerance float64) uint32 {
+	if target <= 0 || podNum == 0 {
+		return current
+	}
+	ratio := value / target
+	if math.Abs(ratio-1) <= tolerance {
+		return current
+	}
+	return uint32(math.Ceil(ratio * float64(podNum)))
+}
+
+// validateMetrics checks that every metric of an autoscaler has a source and a target.
+func validateMetrics(metrics []core.Metric) error {
+	for _, metric := range metrics {
+		switch metric.Type {
+		case "", core.MetricTypeResource:
+			if metric.Resource != core.ResourceCPU && metric.Resource != core.ResourceMemory {
+				return fmt.Errorf("unsupported resource of metric: %v", metric.Resource)
+			}
+			if metric.TargetUtilization == 0 {
+				return fmt.Errorf("target utilization of %v must be positive", metric.Resource)
+			}
+		case core.MetricTypePods:
+			if metric.Pods == nil {
+				return fmt.Errorf("pods metric is not specified")
+			}
+			if metric.Pods.MetricName == "" && metric.Pods.Query == "" {
+				return fmt.Errorf("pods metric needs a metric name or a query")
+			}
+			if metric.Pods.TargetAverageValue <= 0 {
+				return fmt.Errorf("target average value of pods metric must be positive")
+			}
+		case core.MetricTypeExternal:
+			external := metric.External
+			if external == nil {
+				return fmt.Errorf("external metric is not specified")
+			}
+			if external.MetricName == "" && external.Query == "" {
+				return fmt.Errorf("external metric needs a metric name or a query")
+			}
+			if (external.TargetValue == nil) == (external.TargetAverageValue == nil) {
+				return fmt.Errorf("external metric needs exactly one of target value and target average value")
+			}
+			if (external.TargetValue != nil && *external.TargetValue <= 0) ||
+				(external.TargetAverageValue != nil && *external.TargetAverageValue <= 0) {
+				return fmt.Errorf("target of external metric must be positive")
+			}
+		default:
+			return fmt.Errorf("unsupported type of metric: %v", metric.Type)
+		}
+	}
+	return nil
+}
+
+// stabilize records a recommendation and returns the number of replicas allowed by the
+// stabilization windows: it scales up to at most the lowest recommendation within the scale up
+// window, and down to at least the highest recommendation within the scale down window.
+func (h *autoscalerHistory) stabilize(
+	behavior *core.AutoscalerBehavior,
+	recommendation uint32,
+	current uint32,
+	now time.Time,
+) uint32 {
+	upWindow := time.Duration(*behavior.ScaleUp.StabilizationWindowSeconds) * time.Second
+	downWindow := time.Duration(*behavior.ScaleDown.StabilizationWindowSeconds) * time.Second
+	longest := upWindow
+	if downWindow > longest {
+		longest = downWindow
+	}
+
+	upLimit, downLimit := recommendation, recommendation
+	kept := make([]timestampedRecommendation, 0, len(h.recommendations)+1)
+	for _, r := range h.recommendations {
+		age := now.Sub(r.timestamp)
+		if age > longest {
+			continue
+		}
+		kept = append(kept, r)
+		if age <= upWindow && r.replicas < upLimit {
+			upLimit = r.replicas
+		}
+		if age <= downWindow && r.replicas > downLimit {
+			downLimit = r.replicas
+		}
+	}
+	h.recommendations = append(kept, timestampedRecommendation{replicas: recommendation, timestamp: now})
+
+	if current < upLimit {
+		return upLimit
+	}
+	if current > downLimit {
+		return downLimit
+	}
+	return current
+}
+
+// limitRate bounds a change of replicas by the scaling policies, given the changes made in their
+// periods.
+func (h *autoscalerHistory) limitRate(
+	behavior *core.AutoscalerBehavior,
+	desired uint32,
+	current uint32,
+	now time.Time,
+) uint32 {
+	if desired > current {
+		rules := behavior.ScaleUp
+		if rules.SelectPolicy == core.ScalingPolicySelectDisabled {
+			return current
+		}
+		var limit int64
+		if rules.SelectPolicy == core.ScalingPolicySelectMin {
+			limit = math.MaxInt64
+		}
+		for _, policy := range rules.Policies {
+			// The replicas at the start of the period are the current ones without the scale ups
+			// made during the period.
+			start := int64(current) - sumChanges(h.scaleUpEvents, policy.PeriodSeconds, now)
+			var allowed int64
+			if policy.Type == core.ScalingPolicyPods {
+				allowed = start + int64(policy.Value)
+			} else {
+				allowed = int64(math.Ceil(float64(start) * (1 + float64(policy.Value)/100)))
+			}
+			if (rules.SelectPolicy == core.ScalingPolicySelectMin) == (allowed < limit) {
+				limit = allowed
+			}
+		}
+		if int64(desired) > limit {
+			if limit < int64(current) {
+				return current
+			}
+			return uint32(limit)
+		}
+		return desired
+	}
+
+	if desired < current {
+		rules := behavior.ScaleDown
+		if rules.SelectPolicy == core.ScalingPolicySelectDisabled {
+			return current
+		}
+		limit := int64(math.MaxInt64)
+		if rules.SelectPolicy == core.ScalingPolicySelectMin {
+			limit = math.MinInt64
+		}
+		for _, policy := range rules.Policies {
+			start := int64(current) + sumChanges(h.scaleDownEvents, policy.PeriodSeconds, now)
+			var allowed int64
+			if policy.Type == core.ScalingPolicyPods {
+				allowed = start - int64(policy.Value)
+			} else {
+				allowed = int64(math.Floor(float64(start) * (1 - float64(policy.Value)/100)))
+			}
+			// Max selects the policy allowing the fewest replicas, which is the largest change.
+			if (rules.SelectPolicy == core.ScalingPolicySelectMin) == (allowed > limit) {
+				limit = allowed
+			}
+		}
+		if int64(desired) < limit {
+			if limit > int64(current) {
+				return current
+			}
+			return uint32(limit)
+		}
+		return desired
+	}
+
+	return desired
+}
+
+// recordScale remembers a change of replicas, and forgets changes older than the longest period.
+func (h *autoscalerHistory) recordScale(change int32, now time.Time) {
+	cutoff := now.Add(-time.Duration(MaxScalingPolicyPeriod) * time.Second)
+	prune := func(events []timestampedScaleEvent) []timestampedScaleEvent {
+		kept := make([]timestampedScaleEvent, 0, len(events)+1)
+		for _, e := range events {
+			if e.timestamp.After(cutoff) {
+				kept = append(kept, e)
+			}
+		}
+		return kept
+	}
+	h.scaleUpEvents = prune(h.scaleUpEvents)
+	h.scaleDownEvents = prune(h.scaleDownEvents)
+	if change > 0 {
+		h.scaleUpEvents = append(h.scaleUpEvents, timestampedScaleEvent{change: change, timestamp: now})
+	} else if change < 0 {
+		h.scaleDownEvents = append(h.scaleDownEvents, timestampedScaleEvent{change: -change, timestamp: now})
+	}
+}
+
+// sumChanges sums the changes of replicas made within the last periodSeconds.
+func sumChanges(events []timestampedScaleEvent, periodSeconds int32, now time.Time) int64 {
+	var sum int64
+	period := time.Duration(periodSeconds) * time.Second
+	for _, e := range events {
+		if now.Sub(e.timestamp) < period {
+			sum += int64(e.change)
+		}
+	}
+	return sum
+}
@@ -0,0 +1,198 @@
+package scale
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"p9t.io/kuberboat/pkg/api/core"
+	"p9t.io/kuberboat/pkg/apiserver"
+	"p9t.io/kuberboat/pkg/apiserver/internal/apiservertest"
+)
+
+func TestReplicasForAverage(t *testing.T) {
+	assert := assert.New(t)
+	// 4 pods using 1.5 cores each with a target of 1 core need 6 pods.
+	assert.Equal(uint32(6), replicasForAverage(6, 1, 4, 4, 0.1))
+	// Within tolerance nothing changes.
+	assert.Equal(uint32(4), replicasForAverage(4.2, 1, 4, 4, 0.1))
+	// 4 pods using half a core each with a target of 1 core need only 2 pods.
+	assert.Equal(uint32(2), replicasForAverage(2, 1, 4, 4, 0.1))
+}
+
+func TestProportionalScaleOut(t *testing.T) {
+	componentManager := apiserver.NewComponentManager()
+	metricsSource := NewFakeMetricsSource()
+	controller := NewAutoscalerController(componentManager, metricsSource, newFakeScaler(componentManager)).(*basicController)
+
+	// A burst to 5 times the target scales out at once, up to the scale up policies.
+	deployment := apiservertest.NewDeployment("nginx:1.22")
+	deployment.Spec.Replicas = 2
+	apiservertest.SetDeployment(componentManager, deployment)
+	metricsSource.SetPodUsage("test-pod-0", 5, 0)
+	metricsSource.SetPodUsage("test-pod-1", 5, 0)
+	autoscaler := &core.HorizontalPodAutoscaler{
+		ObjectMeta: core.ObjectMeta{Name: "test-autoscaler"},
+		Spec: core.AutoscalerSpec{
+			MinReplicas: 1,
+			MaxReplicas: 20,
+			Metrics:     []core.Metric{{Resource: core.ResourceCPU, TargetUtilization: 1}},
+		},
+	}
+	controller.monitorAndScaleDeployment(autoscaler, deployment)
+	assert.Equal(t, uint32(6), deployment.Spec.Replicas)
+	assert.Equal(t, uint32(6), autoscaler.Status.DesiredReplicas)
+	assert.NotNil(t, autoscaler.Status.LastScaleTime)
+	assert.Equal(t, 5.0, autoscaler.Status.CurrentMetrics[0].CurrentAverage)
+}
+
+func TestScaleDownStabilization(t *testing.T) {
+	componentManager := apiserver.NewComponentManager()
+	metricsSource := NewFakeMetricsSource()
+	controller := NewAutoscalerController(componentManager, metricsSource, newFakeScaler(componentManager)).(*basicController)
+	now := time.Now()
+	controller.now = func() time.Time { return now }
+
+	deployment := apiservertest.NewDeployment("nginx:1.22")
+	deployment.Spec.Replicas = 4
+	apiservertest.SetDeployment(componentManager, deployment)
+	for _, name := range []string{"test-pod-0", "test-pod-1", "test-pod-2", "test-pod-3"} {
+		metricsSource.SetPodUsage(name, 1, 0)
+	}
+	autoscaler := &core.HorizontalPodAutoscaler{
+		ObjectMeta: core.ObjectMeta{Name: "test-autoscaler"},
+		Spec: core.AutoscalerSpec{
+			MinReplicas: 1,
+			MaxReplicas: 5,
+			Metrics:     []core.Metric{{Resource: core.ResourceCPU, TargetUtilization: 1}},
+		},
+	}
+	controller.monitorAndScaleDeployment(autoscaler, deployment)
+	assert.Equal(t, uint32(4), deployment.Spec.Replicas)
+
+	// The load drops, but the recommendation of 4 is still within the window.
+	for _, name := range []string{"test-pod-0", "test-pod-1", "test-pod-2", "test-pod-3"} {
+		metricsSource.SetPodUsage(name, 0.25, 0)
+	}
+	now = now.Add(time.Minute)
+	controller.monitorAndScaleDeployment(autoscaler, deployment)
+	assert.Equal(t, uint32(4), deployment.Spec.Replicas)
+
+	now = now.Add(5 * time.Minute)
+	controller.monitorAndScaleDeployment(autoscaler, deployment)
+	assert.Equal(t, uint32(1), deployment.Spec.Replicas)
+}
+
+func TestLimitRate(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Now()
+	spec := &core.AutoscalerSpec{}
+	spec.Behavior.ScaleUp = &core.ScalingRules{
+		Policies: []core.ScalingPolicy{{Type: core.ScalingPolicyPods, Value: 2, PeriodSeconds: 60}},
+	}
+	spec.Behavior.ScaleDown = &core.ScalingRules{
+		SelectPolicy: core.ScalingPolicySelectMin,
+		Policies: []core.ScalingPolicy{
+			{Type: core.ScalingPolicyPercent, Value: 50, PeriodSeconds: 60},
+			{Type: core.ScalingPolicyPods, Value: 1, PeriodSeconds: 60},
+		},
+	}
+	setDefaultBehavior(spec)
+
+	history := &autoscalerHistory{}
+	assert.Equal(uint32(6), history.limitRate(&spec.Behavior, 10, 4, now))
+	history.recordScale(2, now)
+	// The 2 pods added in the period count against the policy.
+	assert.Equal(uint32(6), history.limitRate(&spec.Behavior, 10, 6, now.Add(30*time.Second)))
+	assert.Equal(uint32(8), history.limitRate(&spec.Behavior, 10, 6, now.Add(61*time.Second)))
+
+	// Min selects the policy removing a single pod rather than half of them.
+	assert.Equal(uint32(9), history.limitRate(&spec.Behavior, 2, 10, now))
+
+	spec.Behavior.ScaleUp.SelectPolicy = core.ScalingPolicySelectDisabled
+	assert.Equal(uint32(4), history.limitRate(&spec.Behavior, 10, 4, now))
+}
+
+func TestValidateBehavior(t *testing.T) {
+	spec := &core.AutoscalerSpec{}
+	assert.Nil(t, validateBehavior(spec))
+
+	tolerance := 1.5
+	spec.Tolerance = &tolerance
+	assert.NotNil(t, validateBehavior(spec))
+
+	spec.Tolerance = nil
+	spec.Behavior.ScaleUp = &core.ScalingRules{
+		Policies: []core.ScalingPolicy{{Type: core.ScalingPolicyPods, Value: 1, PeriodSeconds: 0}},
+	}
+	assert.NotNil(t, validateBehavior(spec))
+}
+
+func TestValidateScaleInterval(t *testing.T) {
+	spec := &core.AutoscalerSpec{ScaleInterval: 15}
+	assert.Nil(t, validateScaleInterval(spec))
+	spec.ScaleInterval = 0
+	assert.NotNil(t, validateScaleInterval(spec))
+	spec.ScaleInterval = -15
+	assert.NotNil(t, validateScaleInterval(spec))
+}
+
+func TestCustomMetrics(t *testing.T) {
+	componentManager := apiserver.NewComponentManager()
+	metricsSource := NewFakeMetricsSource()
+	controller := NewAutoscalerController(componentManager, metricsSource, newFakeScaler(componentManager)).(*basicController)
+
+	deployment := apiservertest.NewDeployment("nginx:1.22")
+	deployment.Spec.Replicas = 2
+	apiservertest.SetDeployment(componentManager, deployment)
+	metricsSource.SetPodMetric("test-pod-0", "http_requests_per_second", 150)
+	metricsSource.SetPodMetric("test-pod-1", "http_requests_per_second", 150)
+	metricsSource.SetExternalMetric("queue_depth", 30)
+
+	// 300 requests per second need 3 pods at 100 each.
+	requestsMetric := core.Metric{
+		Type: core.MetricTypePods,
+		Pods: &core.PodsMetricSource{MetricName: "http_requests_per_second", TargetAverageValue: 100},
+	}
+	autoscaler := &core.HorizontalPodAutoscaler{
+		ObjectMeta: core.ObjectMeta{Name: "test-autoscaler"},
+		Spec:       core.AutoscalerSpec{MinReplicas: 1, MaxReplicas: 5, Metrics: []core.Metric{requestsMetric}},
+	}
+	controller.monitorAndScaleDeployment(autoscaler, deployment)
+	assert.Equal(t, uint32(3), deployment.Spec.Replicas)
+	assert.Equal(t, 150.0, autoscaler.Status.CurrentMetrics[0].CurrentAverage)
+
+	// A queue of 30 messages needs 5 consumers at 6 messages each, the highest recommendation.
+	average := 6.0
+	queueMetric := core.Metric{
+		Type:     core.MetricTypeExternal,
+		External: &core.ExternalMetricSource{MetricName: "queue_depth", TargetAverageValue: &average},
+	}
+	autoscaler = &core.HorizontalPodAutoscaler{
+		ObjectMeta: core.ObjectMeta{Name: "queue-autoscaler"},
+		Spec:       core.AutoscalerSpec{MinReplicas: 1, MaxReplicas: 5, Metrics: []core.Metric{requestsMetric, queueMetric}},
+	}
+	deployment.Spec.Replicas = 2
+	controller.monitorAndScaleDeployment(autoscaler, deployment)
+	assert.Equal(t, uint32(5), deployment.Spec.Replicas)
+}
+
+func TestReplicasForValue(t *testing.T) {
+	// A latency of 300ms with 2 pods needs 6 pods to get down to 100ms.
+	assert.Equal(t, uint32(6), replicasForValue(300, 100, 2, 2, 0.1))
+	assert.Equal(t, uint32(2), replicasForValue(105, 100, 2, 2, 0.1))
+}
+
+func TestValidateMetrics(t *testing.T) {
+	value := 10.0
+	assert.Nil(t, validateMetrics([]core.Metric{
+		{Resource: core.ResourceCPU, TargetUtilization: 1},
+		{Type: core.MetricTypePods, Pods: &core.PodsMetricSource{Query: "rate(x{pod=\"$pod\"}[$window])", TargetAverageValue: 1}},
+		{Type: core.MetricTypeExternal, External: &core.ExternalMetricSource{MetricName: "q", TargetValue: &value}},
+	}))
+	assert.NotNil(t, validateMetrics([]core.Metric{{Resource: "gpu", TargetUtilization: 1}}))
+	assert.NotNil(t, validateMetrics([]core.Metric{{Type: core.MetricTypePods}}))
+	assert.NotNil(t, validateMetrics([]core.Metric{
+		{Type: core.MetricTypeExternal, External: &core.ExternalMetricSource{MetricName: "q"}},
+	}))
+}
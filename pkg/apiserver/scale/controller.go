@@ -3,7 +3,7 @@ package scale
 import (
 	"container/list"
 	"fmt"
-	"math"
+	"sync"
 	"time"
 
 	"github.com/golang/glog"
@@ -11,41 +11,122 @@ import (
 	"p9t.io/kuberboat/pkg/apiserver"
 )
 
-// To avoid frequent scaling in and out when the resource usage of a pod is close to the
-// threshold, the scale-out threshold is slightly increased by multiplying a constant
-// ScaleOutTargetExpansionRate.
-const ScaleOutTargetExpansionRate float64 = 1.05
-
 type Controller interface {
 	// CreateAutoscaler creates an autoscaler.
 	CreateAutoscaler(autoscaler *core.HorizontalPodAutoscaler) error
+	// UpdateAutoscaler replaces the spec of an existing autoscaler and restarts its monitor.
+	UpdateAutoscaler(autoscaler *core.HorizontalPodAutoscaler) error
+	// DeleteAutoscalerByName stops the monitor of an autoscaler and deletes it.
+	DeleteAutoscalerByName(name string) error
+	// SetScheduledBounds bounds the replicas of a deployment on behalf of a schedule. The
+	// autoscaler of the deployment, if any, scales within these bounds as well as its own.
+	// Otherwise the replicas of the deployment are brought within them directly.
+	SetScheduledBounds(deploymentName string, bounds ReplicaBounds)
+	// ClearScheduledBounds removes the bounds set on a deployment by SetScheduledBounds.
+	ClearScheduledBounds(deploymentName string)
 	// DescribeAutoscalers returns information about autoscalers specified by autoscalerNames.
 	DescribeAutoscalers(all bool, autoscalerNames []string) ([]*core.HorizontalPodAutoscaler, []string)
 }
 
+// ReplicaBounds are the fewest and the most replicas of a deployment. Max of 0 means no limit.
+type ReplicaBounds struct {
+	Min uint32
+	Max uint32
+}
+
+// clip brings a number of replicas within the bounds.
+func (b ReplicaBounds) clip(replicas uint32) uint32 {
+	if replicas < b.Min {
+		return b.Min
+	}
+	if b.Max != 0 && replicas > b.Max {
+		return b.Max
+	}
+	return replicas
+}
+
 type basicController struct {
+	mtx              sync.Mutex
 	componentManager apiserver.ComponentManager
-	metricsManager   MetricsManager
+	metricsSource    MetricsSource
+	// scaler sets the replicas of deployments.
+	scaler Scaler
+	// histories are the past recommendations and scale events of each autoscaler, indexed by name.
+	histories map[string]*autoscalerHistory
+	// stopChs are closed to stop the monitors of autoscalers, indexed by name.
+	stopChs map[string]chan struct{}
+	// scheduledBounds are the bounds set by schedules, indexed by the name of the deployment.
+	scheduledBounds map[string]ReplicaBounds
+	// now returns the current time. It is replaced in tests.
+	now func() time.Time
 }
 
 func NewAutoscalerController(
 	componentManager apiserver.ComponentManager,
-	metricsManager MetricsManager,
+	metricsSource MetricsSource,
+	scaler Scaler,
 ) Controller {
 	return &basicController{
 		componentManager: componentManager,
-		metricsManager:   metricsManager,
+		metricsSource:    metricsSource,
+		scaler:           scaler,
+		histories:        map[string]*autoscalerHistory{},
+		stopChs:          map[string]chan struct{}{},
+		scheduledBounds:  map[string]ReplicaBounds{},
+		now:              time.Now,
 	}
 }
 
+// history returns the history of an autoscaler, creating an empty one if there is none.
+func (bc *basicController) history(autoscalerName string) *autoscalerHistory {
+	bc.mtx.Lock()
+	defer bc.mtx.Unlock()
+	history, ok := bc.histories[autoscalerName]
+	if !ok {
+		history = &autoscalerHistory{}
+		bc.histories[autoscalerName] = history
+	}
+	return history
+}
+
+// startAutoscalerMonitor runs the monitor of an autoscaler in a new goroutine, stopping the
+// previous one if there is any.
 func (bc *basicController) startAutoscalerMonitor(autoscaler *core.HorizontalPodAutoscaler) {
+	stopCh := make(chan struct{})
+	bc.mtx.Lock()
+	if oldStopCh, ok := bc.stopChs[autoscaler.Name]; ok {
+		close(oldStopCh)
+	}
+	bc.stopChs[autoscaler.Name] = stopCh
+	bc.mtx.Unlock()
+	go bc.monitorAutoscaler(autoscaler, stopCh)
+}
+
+// stopAutoscalerMonitor stops the monitor of an autoscaler and forgets its history.
+func (bc *basicController) stopAutoscalerMonitor(autoscalerName string) {
+	bc.mtx.Lock()
+	defer bc.mtx.Unlock()
+	if stopCh, ok := bc.stopChs[autoscalerName]; ok {
+		close(stopCh)
+		delete(bc.stopChs, autoscalerName)
+	}
+	delete(bc.histories, autoscalerName)
+}
+
+func (bc *basicController) monitorAutoscaler(autoscaler *core.HorizontalPodAutoscaler, stopCh chan struct{}) {
 	deploymentName := autoscaler.Spec.ScaleTargetRef.Name
 	monitorInterval := time.Second * time.Duration(autoscaler.Spec.ScaleInterval)
 	ticker := time.NewTicker(monitorInterval)
-	for range ticker.C {
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+		}
 		if !bc.componentManager.DeploymentExistsByName(deploymentName) {
 			// Deployment does not exist. Just delete the autoscaler.
-			bc.componentManager.DeleteAutoscalerByName(autoscaler.Name)
+			bc.deleteOrphanAutoscaler(autoscaler, stopCh)
 			return
 		}
 		deployment := bc.componentManager.GetDeploymentByName(deploymentName)
@@ -53,6 +134,24 @@ func (bc *basicController) startAutoscalerMonitor(autoscaler *core.HorizontalPod
 	}
 }
 
+// deleteOrphanAutoscaler deletes an autoscaler whose deployment is gone, unless it has been updated
+// or deleted since the monitor identified by stopCh was started.
+func (bc *basicController) deleteOrphanAutoscaler(autoscaler *core.HorizontalPodAutoscaler, stopCh chan struct{}) {
+	bc.mtx.Lock()
+	defer bc.mtx.Unlock()
+	if bc.stopChs[autoscaler.Name] != stopCh {
+		return
+	}
+	bc.componentManager.DeleteAutoscalerByName(autoscaler.Name)
+	delete(bc.stopChs, autoscaler.Name)
+	delete(bc.histories, autoscaler.Name)
+	glog.Infof(
+		"AUTOSCALER [%v]: autoscaler deleted since deployment %v is gone",
+		autoscaler.Name,
+		autoscaler.Spec.ScaleTargetRef.Name,
+	)
+}
+
 func (bc *basicController) monitorAndScaleDeployment(
 	autoscaler *core.HorizontalPodAutoscaler,
 	deployment *core.Deployment,
@@ -66,8 +165,8 @@ func (bc *basicController) monitorAndScaleDeployment(
 		return
 	}
 	// Old states have not been cleared. Just wait for next round of monitor.
-	if deployment.Status.ReadyReplicas > autoscaler.Spec.MaxReplicas ||
-		deployment.Status.ReadyReplicas < autoscaler.Spec.MinReplicas {
+	bounds := bc.replicaBounds(autoscaler)
+	if bounds.clip(deployment.Status.ReadyReplicas) != deployment.Status.ReadyReplicas {
 		return
 	}
 	// It should be ensured that all pods in the deployments are ready.
@@ -78,111 +177,166 @@ func (bc *basicController) monitorAndScaleDeployment(
 		}
 	}
 
-	var deploymentCPUUsage float64
-	var cpuUsagePerPod float64
-	var deploymentMemoryUsage uint64
-	var memoryUsagePerPod uint64
-	var err error
-
-	for _, metric := range autoscaler.Spec.Metrics {
-		switch metric.Resource {
-		case core.ResourceCPU:
-			deploymentCPUUsage, err = bc.sumDeploymentCPUUsage(pods)
-			if err != nil {
-				// If any error occurs during fetching the data of a pod, just print out the
-				// error and return. This might be caused by the data inconsistency between
-				// apiserver and node, which would be synchronized later.
-				glog.Warning(err)
-				return
-			}
-			cpuUsagePerPod = deploymentCPUUsage / float64(podNum)
-			if deployment.Status.Replicas < autoscaler.Spec.MaxReplicas &&
-				cpuUsagePerPod > float64(metric.TargetUtilization)*ScaleOutTargetExpansionRate {
-				// We just alter the number of deployment replicas here. DeploymentController
-				// will monitor the change of replica number and do the scale automatically.
-				// We increase the replica number each time by one.
-				deployment.Spec.Replicas++
-				glog.Infof(
-					"AUTOSCALER [%s]: cpu usage per pod reaches %f, deployment %s scales out to %d replicas\n",
-					autoscaler.Name,
-					cpuUsagePerPod,
-					deployment.Name,
-					deployment.Spec.Replicas,
-				)
-				return
-			}
+	if autoscaler.Spec.Tolerance == nil {
+		setDefaultBehavior(&autoscaler.Spec)
+	}
+	current := deployment.Spec.Replicas
 
-		case core.ResourceMemory:
-			deploymentMemoryUsage, err = bc.sumDeploymentMemoryUsage(pods)
-			if err != nil {
-				// Handle the error same as above.
-				glog.Warning(err)
-				return
-			}
-			memoryUsagePerPod = deploymentMemoryUsage / uint64(podNum)
-			if deployment.Status.Replicas < autoscaler.Spec.MaxReplicas &&
-				memoryUsagePerPod > uint64(float64(metric.TargetUtilization)*ScaleOutTargetExpansionRate) {
-				deployment.Spec.Replicas++
-				glog.Infof(
-					"AUTOSCALER [%s]: memory usage per pod reaches %d, deployment %s scales out to %d replicas\n",
-					autoscaler.Name,
-					memoryUsagePerPod,
-					deployment.Name,
-					deployment.Spec.Replicas,
-				)
-				return
-			}
+	// Each metric recommends the number of replicas that brings its average usage per pod to the
+	// target, and the highest recommendation wins.
+	recommendation := uint32(0)
+	metricStatuses := make([]core.MetricStatus, 0, len(autoscaler.Spec.Metrics))
+	for i := range autoscaler.Spec.Metrics {
+		replicas, status, err := bc.replicasForMetric(
+			&autoscaler.Spec.Metrics[i],
+			pods,
+			current,
+			*autoscaler.Spec.Tolerance,
+		)
+		if err != nil {
+			// If any error occurs during fetching the data of a pod, just print out the error and
+			// return. This might be caused by the data inconsistency between apiserver and node,
+			// which would be synchronized later.
+			glog.Warning(err)
+			return
 		}
+		metricStatuses = append(metricStatuses, *status)
+		if replicas > recommendation {
+			recommendation = replicas
+		}
+	}
+	if len(metricStatuses) == 0 {
+		recommendation = current
 	}
+	recommendation = bounds.clip(recommendation)
 
-	// If the deployment does not scale out on all metrics, and if there is no room for scaling in,
-	// just return.
-	if deployment.Status.Replicas <= autoscaler.Spec.MinReplicas {
+	// Stabilization windows keep the replicas from flapping, and policies limit how fast they
+	// change.
+	now := bc.now()
+	history := bc.history(autoscaler.Name)
+	desired := history.stabilize(&autoscaler.Spec.Behavior, recommendation, current, now)
+	desired = bounds.clip(history.limitRate(&autoscaler.Spec.Behavior, desired, current, now))
+
+	autoscaler.Status.CurrentReplicas = deployment.Status.Replicas
+	autoscaler.Status.DesiredReplicas = desired
+	autoscaler.Status.CurrentMetrics = metricStatuses
+	if desired == current {
 		return
 	}
 
-	// Chances are that the deployment might scale in.
-	shouldScaleInMetrics := 0
-	for _, metric := range autoscaler.Spec.Metrics {
+	// We just alter the number of deployment replicas here. DeploymentController will monitor
+	// the change of replica number and do the scale automatically. Someone else may have scaled
+	// the deployment since, in which case the next round decides again.
+	if err := bc.scaler.UpdateScale(deployment.Name, desired, &current); err != nil {
+		glog.Warning(err)
+		return
+	}
+	history.recordScale(int32(desired)-int32(current), now)
+	autoscaler.Status.LastScaleTime = &now
+	glog.Infof(
+		"AUTOSCALER [%s]: deployment %s scales from %d to %d replica(s), usage per pod %v, recommendation %d\n",
+		autoscaler.Name,
+		deployment.Name,
+		current,
+		desired,
+		metricStatuses,
+		recommendation,
+	)
+}
+
+// replicasForMetric computes the number of replicas recommended by a metric, along with its
+// observed value.
+func (bc *basicController) replicasForMetric(
+	metric *core.Metric,
+	pods *list.List,
+	current uint32,
+	tolerance float64,
+) (uint32, *core.MetricStatus, error) {
+	podNum := pods.Len()
+	status := &core.MetricStatus{Type: metric.Type}
+	if status.Type == "" {
+		status.Type = core.MetricTypeResource
+	}
+
+	switch status.Type {
+	case core.MetricTypeResource:
+		status.Resource = metric.Resource
+		var totalUsage float64
 		switch metric.Resource {
 		case core.ResourceCPU:
-			desiredPodNum := int(math.Ceil(
-				deploymentCPUUsage / (float64(metric.TargetUtilization)),
-			))
-			if desiredPodNum < podNum {
-				shouldScaleInMetrics++
+			cpuUsage, err := bc.sumDeploymentCPUUsage(pods)
+			if err != nil {
+				return 0, nil, err
 			}
+			totalUsage = cpuUsage
 		case core.ResourceMemory:
-			var carry int
-			if deploymentMemoryUsage%metric.TargetUtilization != 0 {
-				carry = 1
-			} else {
-				carry = 0
+			memoryUsage, err := bc.sumDeploymentMemoryUsage(pods)
+			if err != nil {
+				return 0, nil, err
 			}
-			desiredPodNum := int(deploymentMemoryUsage/metric.TargetUtilization) + carry
-			if desiredPodNum < podNum {
-				shouldScaleInMetrics++
+			totalUsage = float64(memoryUsage)
+		default:
+			return 0, nil, fmt.Errorf("unsupported resource of metric: %v", metric.Resource)
+		}
+		status.CurrentAverage = totalUsage / float64(podNum)
+		target := float64(metric.TargetUtilization)
+		return replicasForAverage(totalUsage, target, podNum, current, tolerance), status, nil
+
+	case core.MetricTypePods:
+		status.Name = metric.Pods.MetricName
+		var total float64
+		for it := pods.Front(); it != nil; it = it.Next() {
+			value, err := bc.metricsSource.PodMetricValue(it.Value.(*core.Pod), metric.Pods)
+			if err != nil {
+				return 0, nil, err
 			}
+			total += value
 		}
+		status.CurrentAverage = total / float64(podNum)
+		return replicasForAverage(total, metric.Pods.TargetAverageValue, podNum, current, tolerance), status, nil
+
+	case core.MetricTypeExternal:
+		status.Name = metric.External.MetricName
+		value, err := bc.metricsSource.ExternalMetricValue(metric.External)
+		if err != nil {
+			return 0, nil, err
+		}
+		status.CurrentAverage = value / float64(podNum)
+		if metric.External.TargetAverageValue != nil {
+			target := *metric.External.TargetAverageValue
+			return replicasForAverage(value, target, podNum, current, tolerance), status, nil
+		}
+		status.CurrentValue = value
+		return replicasForValue(value, *metric.External.TargetValue, podNum, current, tolerance), status, nil
+
+	default:
+		return 0, nil, fmt.Errorf("unsupported type of metric: %v", metric.Type)
 	}
-	// If all metrics satisfy the condition of scaling in, we do scaling in by decreasing the
-	// replica number each time by one.
-	if shouldScaleInMetrics == len(autoscaler.Spec.Metrics) {
-		deployment.Spec.Replicas--
-		glog.Infof(
-			"AUTOSCALER [%s]: deployment %s scales in to %d replica(s)\n",
-			autoscaler.Name,
-			deployment.Name,
-			deployment.Spec.Replicas,
-		)
+}
+
+// replicaBounds returns the bounds of the replicas of the target of an autoscaler. The bounds set
+// by a schedule are narrowed down to fit within those of the autoscaler.
+func (bc *basicController) replicaBounds(autoscaler *core.HorizontalPodAutoscaler) ReplicaBounds {
+	bounds := ReplicaBounds{Min: autoscaler.Spec.MinReplicas, Max: autoscaler.Spec.MaxReplicas}
+	bc.mtx.Lock()
+	scheduled, ok := bc.scheduledBounds[autoscaler.Spec.ScaleTargetRef.Name]
+	bc.mtx.Unlock()
+	if !ok {
+		return bounds
+	}
+	min := bounds.clip(scheduled.Min)
+	max := bounds.Max
+	if scheduled.Max != 0 {
+		max = ReplicaBounds{Min: min, Max: bounds.Max}.clip(scheduled.Max)
 	}
+	return ReplicaBounds{Min: min, Max: max}
 }
 
 func (bc *basicController) sumDeploymentCPUUsage(podsInDeployment *list.List) (float64, error) {
 	var totalCPUUsage float64 = 0.0
 	for it := podsInDeployment.Front(); it != nil; it = it.Next() {
 		pod := it.Value.(*core.Pod)
-		podCPUUsage, err := bc.metricsManager.PodCPUUsage(pod)
+		podCPUUsage, err := bc.metricsSource.PodCPUUsage(pod)
 		if err != nil {
 			return 0.0, err
 		}
@@ -195,7 +349,7 @@ func (bc *basicController) sumDeploymentMemoryUsage(podsInDeployment *list.List)
 	var memoryUsage uint64 = 0
 	for it := podsInDeployment.Front(); it != nil; it = it.Next() {
 		pod := it.Value.(*core.Pod)
-		podMemoryUsage, err := bc.metricsManager.PodMemoryUsage(pod)
+		podMemoryUsage, err := bc.metricsSource.PodMemoryUsage(pod)
 		if err != nil {
 			return 0, err
 		}
@@ -209,6 +363,17 @@ func (bc *basicController) CreateAutoscaler(autoscaler *core.HorizontalPodAutosc
 		return fmt.Errorf("autoscaler already exists: %v", autoscaler.Name)
 	}
 
+	if err := validateMetrics(autoscaler.Spec.Metrics); err != nil {
+		return err
+	}
+	if err := validateScaleInterval(&autoscaler.Spec); err != nil {
+		return err
+	}
+	if err := validateBehavior(&autoscaler.Spec); err != nil {
+		return err
+	}
+	setDefaultBehavior(&autoscaler.Spec)
+
 	deploymentName := autoscaler.Spec.ScaleTargetRef.Name
 	if !bc.componentManager.DeploymentExistsByName(deploymentName) {
 		return fmt.Errorf("no such deployment to be monitored by autoscaler: %v", deploymentName)
@@ -217,25 +382,133 @@ func (bc *basicController) CreateAutoscaler(autoscaler *core.HorizontalPodAutosc
 		return fmt.Errorf("deployment %v already monitored by autoscaler", deploymentName)
 	}
 
+	deployment := bc.componentManager.GetDeploymentByName(deploymentName)
+	if err := bc.clipReplicas(autoscaler, deployment); err != nil {
+		return err
+	}
 	autoscaler.CreationTimestamp = time.Now()
 	bc.componentManager.SetAutoscaler(autoscaler)
+	autoscaler.Status.CurrentReplicas = deployment.Status.Replicas
+	autoscaler.Status.DesiredReplicas = deployment.Spec.Replicas
+
+	bc.startAutoscalerMonitor(autoscaler)
+
+	glog.Infof("AUTOSCALER [%v]: autoscaler created on deployment %v", autoscaler.Name, deploymentName)
+
+	return nil
+}
+
+func (bc *basicController) UpdateAutoscaler(autoscaler *core.HorizontalPodAutoscaler) error {
+	oldAutoscaler := bc.componentManager.GetAutoscalerByName(autoscaler.Name)
+	if oldAutoscaler == nil {
+		return fmt.Errorf("no such autoscaler: %v", autoscaler.Name)
+	}
+
+	if err := validateMetrics(autoscaler.Spec.Metrics); err != nil {
+		return err
+	}
+	if err := validateScaleInterval(&autoscaler.Spec); err != nil {
+		return err
+	}
+	if err := validateBehavior(&autoscaler.Spec); err != nil {
+		return err
+	}
+	setDefaultBehavior(&autoscaler.Spec)
+
+	deploymentName := autoscaler.Spec.ScaleTargetRef.Name
+	if !bc.componentManager.DeploymentExistsByName(deploymentName) {
+		return fmt.Errorf("no such deployment to be monitored by autoscaler: %v", deploymentName)
+	}
+	for _, other := range bc.componentManager.ListAutoscalers() {
+		if other.Name != autoscaler.Name &&
+			other.Spec.ScaleTargetRef.Kind == core.DeploymentType &&
+			other.Spec.ScaleTargetRef.Name == deploymentName {
+			return fmt.Errorf("deployment %v already monitored by autoscaler %v", deploymentName, other.Name)
+		}
+	}
 
 	deployment := bc.componentManager.GetDeploymentByName(deploymentName)
-	clipDeploymentReplicas(deployment, autoscaler)
+	if err := bc.clipReplicas(autoscaler, deployment); err != nil {
+		return err
+	}
 
-	go bc.startAutoscalerMonitor(autoscaler)
+	// The status survives the update, but the history only makes sense for the same deployment.
+	autoscaler.CreationTimestamp = oldAutoscaler.CreationTimestamp
+	autoscaler.Status = oldAutoscaler.Status
+	if oldAutoscaler.Spec.ScaleTargetRef.Name != deploymentName {
+		autoscaler.Status.LastScaleTime = nil
+		autoscaler.Status.CurrentMetrics = nil
+		bc.mtx.Lock()
+		delete(bc.histories, autoscaler.Name)
+		bc.mtx.Unlock()
+	}
+	bc.componentManager.SetAutoscaler(autoscaler)
+	autoscaler.Status.CurrentReplicas = deployment.Status.Replicas
+	autoscaler.Status.DesiredReplicas = deployment.Spec.Replicas
 
-	glog.Infof("AUTOSCALER [%v]: autoscaler created on deployment %v", autoscaler.Name, deploymentName)
+	bc.startAutoscalerMonitor(autoscaler)
+
+	glog.Infof("AUTOSCALER [%v]: autoscaler updated on deployment %v", autoscaler.Name, deploymentName)
+
+	return nil
+}
+
+// clipReplicas brings the replicas of the deployment of an autoscaler within its bounds.
+func (bc *basicController) clipReplicas(autoscaler *core.HorizontalPodAutoscaler, deployment *core.Deployment) error {
+	replicas := bc.replicaBounds(autoscaler).clip(deployment.Spec.Replicas)
+	if replicas == deployment.Spec.Replicas {
+		return nil
+	}
+	return bc.scaler.UpdateScale(deployment.Name, replicas, nil)
+}
+
+func (bc *basicController) DeleteAutoscalerByName(name string) error {
+	if !bc.componentManager.AutoscalerExistsByName(name) {
+		return fmt.Errorf("no such autoscaler: %v", name)
+	}
+	bc.stopAutoscalerMonitor(name)
+	bc.componentManager.DeleteAutoscalerByName(name)
+
+	glog.Infof("AUTOSCALER [%v]: autoscaler deleted", name)
 
 	return nil
 }
 
-func clipDeploymentReplicas(deployment *core.Deployment, autoscaler *core.HorizontalPodAutoscaler) {
-	if deployment.Spec.Replicas < autoscaler.Spec.MinReplicas {
-		deployment.Spec.Replicas = autoscaler.Spec.MinReplicas
-	} else if deployment.Spec.Replicas > autoscaler.Spec.MaxReplicas {
-		deployment.Spec.Replicas = autoscaler.Spec.MaxReplicas
+func (bc *basicController) SetScheduledBounds(deploymentName string, bounds ReplicaBounds) {
+	bc.mtx.Lock()
+	bc.scheduledBounds[deploymentName] = bounds
+	bc.mtx.Unlock()
+
+	deployment := bc.componentManager.GetDeploymentByName(deploymentName)
+	if deployment == nil {
+		return
+	}
+	for _, autoscaler := range bc.componentManager.ListAutoscalers() {
+		if autoscaler.Spec.ScaleTargetRef.Kind == core.DeploymentType &&
+			autoscaler.Spec.ScaleTargetRef.Name == deploymentName {
+			bounds = bc.replicaBounds(autoscaler)
+			break
+		}
 	}
+	// A new floor takes effect at once instead of waiting for the autoscaler, which does not
+	// scale until the replicas are within its bounds.
+	if replicas := bounds.clip(deployment.Spec.Replicas); replicas != deployment.Spec.Replicas {
+		glog.Infof(
+			"DEPLOYMENT [%v]: scales from %v to %v replica(s) on schedule",
+			deploymentName,
+			deployment.Spec.Replicas,
+			replicas,
+		)
+		if err := bc.scaler.UpdateScale(deploymentName, replicas, nil); err != nil {
+			glog.Errorf("DEPLOYMENT [%v]: failed to scale on schedule: %v", deploymentName, err)
+		}
+	}
+}
+
+func (bc *basicController) ClearScheduledBounds(deploymentName string) {
+	bc.mtx.Lock()
+	defer bc.mtx.Unlock()
+	delete(bc.scheduledBounds, deploymentName)
 }
 
 func (bc *basicController) DescribeAutoscalers(all bool, autoscalerNames []string) (
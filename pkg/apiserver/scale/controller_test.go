@@ -0,0 +1,304 @@
+package scale
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"p9t.io/kuberboat/pkg/api/core"
+	"p9t.io/kuberboat/pkg/apiserver"
+	"p9t.io/kuberboat/pkg/apiserver/internal/apiservertest"
+)
+
+// fakeScaler sets the replicas of the deployments in a component manager without persisting them.
+type fakeScaler struct {
+	componentManager apiserver.ComponentManager
+}
+
+func newFakeScaler(componentManager apiserver.ComponentManager) *fakeScaler {
+	return &fakeScaler{componentManager: componentManager}
+}
+
+func (fs *fakeScaler) GetScale(deploymentName string) (*core.Scale, error) {
+	deployment := fs.componentManager.GetDeploymentByName(deploymentName)
+	if deployment == nil {
+		return nil, fmt.Errorf("no such deployment: %v", deploymentName)
+	}
+	return &core.Scale{
+		Name: deploymentName,
+		Spec: core.ScaleSpec{Replicas: deployment.Spec.Replicas},
+		Status: core.ScaleStatus{
+			Replicas:      deployment.Status.Replicas,
+			ReadyReplicas: deployment.Status.ReadyReplicas,
+		},
+	}, nil
+}
+
+func (fs *fakeScaler) UpdateScale(deploymentName string, replicas uint32, currentReplicas *uint32) error {
+	deployment := fs.componentManager.GetDeploymentByName(deploymentName)
+	if deployment == nil {
+		return fmt.Errorf("no such deployment: %v", deploymentName)
+	}
+	if currentReplicas != nil && *currentReplicas != deployment.Spec.Replicas {
+		return fmt.Errorf("deployment %v has %v replicas instead of %v", deploymentName, deployment.Spec.Replicas, *currentReplicas)
+	}
+	deployment.Spec.Replicas = replicas
+	return nil
+}
+
+func TestMonitorAndScaleDeploymentScalesOut(t *testing.T) {
+	componentManager := apiserver.NewComponentManager()
+	metricsSource := NewFakeMetricsSource()
+	controller := NewAutoscalerController(componentManager, metricsSource, newFakeScaler(componentManager)).(*basicController)
+
+	deployment := apiservertest.NewDeployment("nginx:1.22")
+	deployment.Spec.Replicas = 2
+	apiservertest.SetDeployment(componentManager, deployment)
+	metricsSource.SetPodUsage("test-pod-0", 3, 100)
+	metricsSource.SetPodUsage("test-pod-1", 2, 100)
+	autoscaler := &core.HorizontalPodAutoscaler{
+		ObjectMeta: core.ObjectMeta{Name: "test-autoscaler"},
+		Spec: core.AutoscalerSpec{
+			MinReplicas: 1,
+			MaxReplicas: 5,
+			Metrics:     []core.Metric{{Resource: core.ResourceCPU, TargetUtilization: 2}},
+		},
+	}
+	controller.monitorAndScaleDeployment(autoscaler, deployment)
+	assert.Equal(t, uint32(3), deployment.Spec.Replicas)
+}
+
+func TestMonitorAndScaleDeploymentScalesIn(t *testing.T) {
+	componentManager := apiserver.NewComponentManager()
+	metricsSource := NewFakeMetricsSource()
+	controller := NewAutoscalerController(componentManager, metricsSource, newFakeScaler(componentManager)).(*basicController)
+
+	deployment := apiservertest.NewDeployment("nginx:1.22")
+	deployment.Spec.Replicas = 3
+	apiservertest.SetDeployment(componentManager, deployment)
+	for i := 0; i < 3; i++ {
+		metricsSource.SetPodUsage(fmt.Sprintf("test-pod-%d", i), 0.5, 100)
+	}
+	autoscaler := &core.HorizontalPodAutoscaler{
+		ObjectMeta: core.ObjectMeta{Name: "test-autoscaler"},
+		Spec: core.AutoscalerSpec{
+			MinReplicas: 1,
+			MaxReplicas: 5,
+			Metrics: []core.Metric{
+				{Resource: core.ResourceCPU, TargetUtilization: 1},
+				{Resource: core.ResourceMemory, TargetUtilization: 200},
+			},
+		},
+	}
+	controller.monitorAndScaleDeployment(autoscaler, deployment)
+	assert.Equal(t, uint32(2), deployment.Spec.Replicas)
+
+	// Memory usage of 600 bytes needs 3 pods, so it does not scale in any more.
+	for i := 0; i < 3; i++ {
+		metricsSource.SetPodUsage(fmt.Sprintf("test-pod-%d", i), 0.5, 200)
+	}
+	deployment.Spec.Replicas = 3
+	controller.monitorAndScaleDeployment(autoscaler, deployment)
+	assert.Equal(t, uint32(3), deployment.Spec.Replicas)
+}
+
+func TestMonitorAndScaleDeploymentKeepsReplicas(t *testing.T) {
+	componentManager := apiserver.NewComponentManager()
+	metricsSource := NewFakeMetricsSource()
+	controller := NewAutoscalerController(componentManager, metricsSource, newFakeScaler(componentManager)).(*basicController)
+	autoscaler := &core.HorizontalPodAutoscaler{
+		ObjectMeta: core.ObjectMeta{Name: "test-autoscaler"},
+		Spec: core.AutoscalerSpec{
+			MinReplicas: 1,
+			MaxReplicas: 5,
+			Metrics:     []core.Metric{{Resource: core.ResourceMemory, TargetUtilization: 100}},
+		},
+	}
+
+	// At the maximum number of replicas it cannot scale out.
+	deployment := apiservertest.NewDeployment("nginx:1.22")
+	deployment.Spec.Replicas = 5
+	apiservertest.SetDeployment(componentManager, deployment)
+	for i := 0; i < 5; i++ {
+		metricsSource.SetPodUsage(fmt.Sprintf("test-pod-%d", i), 0, 500)
+	}
+	controller.monitorAndScaleDeployment(autoscaler, deployment)
+	assert.Equal(t, uint32(5), deployment.Spec.Replicas)
+
+	// Missing metrics leave the deployment alone.
+	metricsSource.DeletePodUsage("test-pod-0")
+	for i := 1; i < 5; i++ {
+		metricsSource.SetPodUsage(fmt.Sprintf("test-pod-%d", i), 0, 10)
+	}
+	controller.monitorAndScaleDeployment(autoscaler, deployment)
+	assert.Equal(t, uint32(5), deployment.Spec.Replicas)
+
+	// So does a pod that is not ready yet.
+	metricsSource.SetPodUsage("test-pod-0", 0, 10)
+	componentManager.GetPodByName("test-pod-0").Status.Phase = core.PodPending
+	controller.monitorAndScaleDeployment(autoscaler, deployment)
+	assert.Equal(t, uint32(5), deployment.Spec.Replicas)
+}
+
+func TestUpdateAutoscalerRestartsMonitor(t *testing.T) {
+	componentManager := apiserver.NewComponentManager()
+	metricsSource := NewFakeMetricsSource()
+	controller := NewAutoscalerController(componentManager, metricsSource, newFakeScaler(componentManager)).(*basicController)
+
+	deployment := apiservertest.NewDeployment("nginx:1.22")
+	deployment.Spec.Replicas = 2
+	apiservertest.SetDeployment(componentManager, deployment)
+	metricsSource.SetPodUsage("test-pod-0", 1, 100)
+	metricsSource.SetPodUsage("test-pod-1", 1, 100)
+	autoscaler := &core.HorizontalPodAutoscaler{
+		ObjectMeta: core.ObjectMeta{Name: "test-autoscaler"},
+		Spec: core.AutoscalerSpec{
+			ScaleTargetRef: core.ScaleTarget{Kind: core.DeploymentType, Name: deployment.Name},
+			MinReplicas:    1,
+			MaxReplicas:    5,
+			ScaleInterval:  60,
+			Metrics:        []core.Metric{{Resource: core.ResourceCPU, TargetUtilization: 1}},
+		},
+	}
+	assert.Nil(t, controller.CreateAutoscaler(autoscaler))
+	assert.NotNil(t, controller.CreateAutoscaler(autoscaler))
+	oldStopCh := controller.stopChs[autoscaler.Name]
+
+	updated := &core.HorizontalPodAutoscaler{
+		ObjectMeta: core.ObjectMeta{Name: "test-autoscaler"},
+		Spec: core.AutoscalerSpec{
+			ScaleTargetRef: autoscaler.Spec.ScaleTargetRef,
+			MinReplicas:    3,
+			MaxReplicas:    5,
+			ScaleInterval:  30,
+			Metrics:        []core.Metric{{Resource: core.ResourceMemory, TargetUtilization: 50}},
+		},
+	}
+	assert.Nil(t, controller.UpdateAutoscaler(updated))
+
+	// The old monitor is stopped and the replicas are brought within the new bounds.
+	_, ok := <-oldStopCh
+	assert.False(t, ok)
+	assert.NotEqual(t, oldStopCh, controller.stopChs[autoscaler.Name])
+	assert.Equal(t, updated, componentManager.GetAutoscalerByName(autoscaler.Name))
+	assert.Equal(t, autoscaler.CreationTimestamp, updated.CreationTimestamp)
+	assert.Equal(t, uint32(3), deployment.Spec.Replicas)
+	assert.Equal(t, uint32(3), updated.Status.DesiredReplicas)
+
+	// Invalid specs and unknown autoscalers are rejected.
+	invalid := *autoscaler
+	invalid.Spec.Metrics = []core.Metric{{Resource: core.ResourceCPU}}
+	assert.NotNil(t, controller.UpdateAutoscaler(&invalid))
+	unknown := *autoscaler
+	unknown.Name = "unknown"
+	assert.NotNil(t, controller.UpdateAutoscaler(&unknown))
+}
+
+func TestAutoscalerRejectsNonPositiveScaleInterval(t *testing.T) {
+	componentManager := apiserver.NewComponentManager()
+	metricsSource := NewFakeMetricsSource()
+	controller := NewAutoscalerController(componentManager, metricsSource, newFakeScaler(componentManager)).(*basicController)
+
+	deployment := apiservertest.NewDeployment("nginx:1.22")
+	deployment.Spec.Replicas = 1
+	apiservertest.SetDeployment(componentManager, deployment)
+	metricsSource.SetPodUsage("test-pod-0", 1, 100)
+	autoscaler := &core.HorizontalPodAutoscaler{
+		ObjectMeta: core.ObjectMeta{Name: "test-autoscaler"},
+		Spec: core.AutoscalerSpec{
+			ScaleTargetRef: core.ScaleTarget{Kind: core.DeploymentType, Name: deployment.Name},
+			MinReplicas:    1,
+			MaxReplicas:    5,
+			Metrics:        []core.Metric{{Resource: core.ResourceCPU, TargetUtilization: 1}},
+		},
+	}
+	for _, interval := range []int64{0, -60} {
+		autoscaler.Spec.ScaleInterval = interval
+		assert.NotNil(t, controller.CreateAutoscaler(autoscaler))
+		assert.False(t, componentManager.AutoscalerExistsByName(autoscaler.Name))
+	}
+
+	autoscaler.Spec.ScaleInterval = 60
+	assert.Nil(t, controller.CreateAutoscaler(autoscaler))
+	for _, interval := range []int64{0, -60} {
+		updated := *autoscaler
+		updated.Spec.ScaleInterval = interval
+		assert.NotNil(t, controller.UpdateAutoscaler(&updated))
+		assert.Equal(t, autoscaler, componentManager.GetAutoscalerByName(autoscaler.Name))
+	}
+}
+
+func TestDeleteAutoscalerStopsMonitor(t *testing.T) {
+	componentManager := apiserver.NewComponentManager()
+	metricsSource := NewFakeMetricsSource()
+	controller := NewAutoscalerController(componentManager, metricsSource, newFakeScaler(componentManager)).(*basicController)
+
+	deployment := apiservertest.NewDeployment("nginx:1.22")
+	deployment.Spec.Replicas = 1
+	apiservertest.SetDeployment(componentManager, deployment)
+	metricsSource.SetPodUsage("test-pod-0", 1, 100)
+	autoscaler := &core.HorizontalPodAutoscaler{
+		ObjectMeta: core.ObjectMeta{Name: "test-autoscaler"},
+		Spec: core.AutoscalerSpec{
+			ScaleTargetRef: core.ScaleTarget{Kind: core.DeploymentType, Name: deployment.Name},
+			MinReplicas:    1,
+			MaxReplicas:    5,
+			ScaleInterval:  60,
+			Metrics:        []core.Metric{{Resource: core.ResourceCPU, TargetUtilization: 1}},
+		},
+	}
+	assert.Nil(t, controller.CreateAutoscaler(autoscaler))
+	stopCh := controller.stopChs[autoscaler.Name]
+
+	assert.Nil(t, controller.DeleteAutoscalerByName(autoscaler.Name))
+	_, ok := <-stopCh
+	assert.False(t, ok)
+	assert.False(t, componentManager.AutoscalerExistsByName(autoscaler.Name))
+	assert.False(t, componentManager.DeploymentAutoscaled(deployment.Name))
+	assert.NotNil(t, controller.DeleteAutoscalerByName(autoscaler.Name))
+
+	// The deployment can be autoscaled again.
+	recreated := &core.HorizontalPodAutoscaler{ObjectMeta: autoscaler.ObjectMeta, Spec: autoscaler.Spec}
+	assert.Nil(t, controller.CreateAutoscaler(recreated))
+}
+
+func TestScheduledBoundsNarrowAutoscaler(t *testing.T) {
+	componentManager := apiserver.NewComponentManager()
+	metricsSource := NewFakeMetricsSource()
+	controller := NewAutoscalerController(componentManager, metricsSource, newFakeScaler(componentManager)).(*basicController)
+
+	deployment := apiservertest.NewDeployment("nginx:1.22")
+	deployment.Spec.Replicas = 2
+	apiservertest.SetDeployment(componentManager, deployment)
+	metricsSource.SetPodUsage("test-pod-0", 0.1, 100)
+	metricsSource.SetPodUsage("test-pod-1", 0.1, 100)
+	autoscaler := &core.HorizontalPodAutoscaler{
+		ObjectMeta: core.ObjectMeta{Name: "test-autoscaler"},
+		Spec: core.AutoscalerSpec{
+			ScaleTargetRef: core.ScaleTarget{Kind: core.DeploymentType, Name: deployment.Name},
+			MinReplicas:    1,
+			MaxReplicas:    5,
+			Metrics:        []core.Metric{{Resource: core.ResourceCPU, TargetUtilization: 1}},
+		},
+	}
+	componentManager.SetAutoscaler(autoscaler)
+
+	// A floor raises the replicas at once, and the autoscaler does not scale in below it.
+	controller.SetScheduledBounds(deployment.Name, ReplicaBounds{Min: 3})
+	assert.Equal(t, ReplicaBounds{Min: 3, Max: 5}, controller.replicaBounds(autoscaler))
+	assert.Equal(t, uint32(3), deployment.Spec.Replicas)
+	apiservertest.SetDeployment(componentManager, deployment)
+	metricsSource.SetPodUsage("test-pod-2", 0.1, 100)
+	controller.monitorAndScaleDeployment(autoscaler, deployment)
+	assert.Equal(t, uint32(3), deployment.Spec.Replicas)
+
+	// Scheduled bounds never leave those of the autoscaler.
+	controller.SetScheduledBounds(deployment.Name, ReplicaBounds{Min: 8, Max: 10})
+	assert.Equal(t, ReplicaBounds{Min: 5, Max: 5}, controller.replicaBounds(autoscaler))
+	controller.SetScheduledBounds(deployment.Name, ReplicaBounds{Min: 0, Max: 1})
+	assert.Equal(t, ReplicaBounds{Min: 1, Max: 1}, controller.replicaBounds(autoscaler))
+	assert.Equal(t, uint32(1), deployment.Spec.Replicas)
+
+	controller.ClearScheduledBounds(deployment.Name)
+	assert.Equal(t, ReplicaBounds{Min: 1, Max: 5}, controller.replicaBounds(autoscaler))
+}
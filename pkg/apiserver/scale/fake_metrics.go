@@ -0,0 +1,130 @@
+package scale
+
+import (
+	"fmt"
+	"sync"
+
+	"p9t.io/kuberboat/pkg/api/core"
+)
+
+// FakeMetricsSource is an in-memory metrics source whose usage is set by hand. It is meant for
+// tests.
+type FakeMetricsSource struct {
+	mtx    sync.Mutex
+	cpu    map[string]float64
+	memory map[string]uint64
+	// podMetrics are the custom metrics of pods, indexed by pod name and then metric name.
+	podMetrics map[string]map[string]float64
+	// externalMetrics are indexed by metric name.
+	externalMetrics map[string]float64
+	// containerUsage is the usage of containers, indexed by pod name and then container name.
+	containerUsage map[string]map[string]core.ResourceList
+}
+
+func NewFakeMetricsSource() *FakeMetricsSource {
+	return &FakeMetricsSource{
+		cpu:             map[string]float64{},
+		memory:          map[string]uint64{},
+		podMetrics:      map[string]map[string]float64{},
+		externalMetrics: map[string]float64{},
+		containerUsage:  map[string]map[string]core.ResourceList{},
+	}
+}
+
+// SetPodMetric sets the value of a custom metric of the pod named podName.
+func (fs *FakeMetricsSource) SetPodMetric(podName string, metricName string, value float64) {
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+	if _, ok := fs.podMetrics[podName]; !ok {
+		fs.podMetrics[podName] = map[string]float64{}
+	}
+	fs.podMetrics[podName][metricName] = value
+}
+
+// SetExternalMetric sets the value of an external metric.
+func (fs *FakeMetricsSource) SetExternalMetric(metricName string, value float64) {
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+	fs.externalMetrics[metricName] = value
+}
+
+// SetContainerUsage sets the CPU usage in cores and the memory usage in bytes of a container of
+// the pod named podName.
+func (fs *FakeMetricsSource) SetContainerUsage(podName string, containerName string, cpu float64, memory uint64) {
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+	if _, ok := fs.containerUsage[podName]; !ok {
+		fs.containerUsage[podName] = map[string]core.ResourceList{}
+	}
+	fs.containerUsage[podName][containerName] = ContainerUsage(cpu, memory)
+}
+
+// SetPodUsage sets the CPU usage in cores and the memory usage in bytes of the pod named podName.
+func (fs *FakeMetricsSource) SetPodUsage(podName string, cpu float64, memory uint64) {
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+	fs.cpu[podName] = cpu
+	fs.memory[podName] = memory
+}
+
+// DeletePodUsage forgets the usage of a pod, so that queries about it fail.
+func (fs *FakeMetricsSource) DeletePodUsage(podName string) {
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+	delete(fs.cpu, podName)
+	delete(fs.memory, podName)
+}
+
+func (fs *FakeMetricsSource) PodCPUUsage(pod *core.Pod) (float64, error) {
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+	cpu, ok := fs.cpu[pod.Name]
+	if !ok {
+		return 0.0, fmt.Errorf("no cpu usage of pod %s", pod.Name)
+	}
+	return cpu, nil
+}
+
+func (fs *FakeMetricsSource) PodMemoryUsage(pod *core.Pod) (uint64, error) {
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+	memory, ok := fs.memory[pod.Name]
+	if !ok {
+		return 0, fmt.Errorf("no memory usage of pod %s", pod.Name)
+	}
+	return memory, nil
+}
+
+func (fs *FakeMetricsSource) PodMetricValue(pod *core.Pod, metric *core.PodsMetricSource) (float64, error) {
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+	value, ok := fs.podMetrics[pod.Name][metric.MetricName]
+	if !ok {
+		return 0.0, fmt.Errorf("no metric %s of pod %s", metric.MetricName, pod.Name)
+	}
+	return value, nil
+}
+
+func (fs *FakeMetricsSource) ExternalMetricValue(metric *core.ExternalMetricSource) (float64, error) {
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+	value, ok := fs.externalMetrics[metric.MetricName]
+	if !ok {
+		return 0.0, fmt.Errorf("no external metric %s", metric.MetricName)
+	}
+	return value, nil
+}
+
+func (fs *FakeMetricsSource) ContainerUsage(pod *core.Pod) ([]core.ContainerMetrics, error) {
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+	containers, ok := fs.containerUsage[pod.Name]
+	if !ok {
+		return nil, fmt.Errorf("no container usage of pod %s", pod.Name)
+	}
+	metrics := make([]core.ContainerMetrics, 0, len(containers))
+	for name, usage := range containers {
+		metrics = append(metrics, core.ContainerMetrics{Name: name, Usage: usage})
+	}
+	return metrics, nil
+}
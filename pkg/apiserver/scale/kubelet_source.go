@@ -0,0 +1,67 @@
+package scale
+
+import (
+	"fmt"
+
+	"p9t.io/kuberboat/pkg/api/core"
+)
+
+// PodMetricsProvider serves the current usage of pods, as the metrics API does.
+type PodMetricsProvider interface {
+	// PodMetrics returns the usage of the pods specified by names whose labels contain selector,
+	// as well as the names of the pods whose metrics are not available.
+	PodMetrics(names []string, selector map[string]string) ([]*core.PodMetrics, []string)
+}
+
+// kubeletStatsSource takes the usage of pods from the stats summaries of the kubelets, so that
+// autoscalers work without Prometheus. The usage is sampled when queried rather than averaged.
+type kubeletStatsSource struct {
+	provider PodMetricsProvider
+}
+
+// NewKubeletStatsSource creates a metrics source backed by the stats summaries of the kubelets.
+func NewKubeletStatsSource(provider PodMetricsProvider) MetricsSource {
+	return &kubeletStatsSource{provider: provider}
+}
+
+func (ks *kubeletStatsSource) PodCPUUsage(pod *core.Pod) (float64, error) {
+	usage, err := ks.podUsage(pod)
+	if err != nil {
+		return 0.0, err
+	}
+	cpu := usage[core.ResourceCPU]
+	return float64(cpu.MilliValue()) / 1000, nil
+}
+
+func (ks *kubeletStatsSource) PodMemoryUsage(pod *core.Pod) (uint64, error) {
+	usage, err := ks.podUsage(pod)
+	if err != nil {
+		return 0, err
+	}
+	memory := usage[core.ResourceMemory]
+	return uint64(memory.Value()), nil
+}
+
+func (ks *kubeletStatsSource) PodMetricValue(pod *core.Pod, metric *core.PodsMetricSource) (float64, error) {
+	return 0.0, fmt.Errorf("custom metric %s is not served by the stats of kubelets", metric.MetricName)
+}
+
+func (ks *kubeletStatsSource) ExternalMetricValue(metric *core.ExternalMetricSource) (float64, error) {
+	return 0.0, fmt.Errorf("external metric %s is not served by the stats of kubelets", metric.MetricName)
+}
+
+func (ks *kubeletStatsSource) ContainerUsage(pod *core.Pod) ([]core.ContainerMetrics, error) {
+	metrics, _ := ks.provider.PodMetrics([]string{pod.Name}, nil)
+	if len(metrics) == 0 {
+		return nil, fmt.Errorf("fail to get usage for pod %s: no stats from kubelet", pod.Name)
+	}
+	return metrics[0].Containers, nil
+}
+
+func (ks *kubeletStatsSource) podUsage(pod *core.Pod) (core.ResourceList, error) {
+	metrics, _ := ks.provider.PodMetrics([]string{pod.Name}, nil)
+	if len(metrics) == 0 {
+		return nil, fmt.Errorf("fail to get usage for pod %s: no stats from kubelet", pod.Name)
+	}
+	return metrics[0].Usage, nil
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -14,135 +16,230 @@ import (
 	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/prometheus/common/model"
 	"p9t.io/kuberboat/pkg/api/core"
-	"p9t.io/kuberboat/pkg/apiserver"
 )
 
 const (
-	PrometheusAddress    string        = "http://localhost:9090"
-	QueryTimeout         time.Duration = 5 * time.Second
-	UsageComputeDuration string        = "10s"
+	// DefaultPrometheusAddress is the address of the Prometheus server started by
+	// scripts/prometheus/start_prom.sh.
+	DefaultPrometheusAddress string = "http://localhost:9090"
+	// DefaultQueryWindow is the window over which Prometheus averages the usage of a pod.
+	DefaultQueryWindow time.Duration = 10 * time.Second
+	QueryTimeout       time.Duration = 5 * time.Second
 )
 
-// MetricsManager monitors the CPU and memory usage of all the ready pods at set intervals.
-type MetricsManager interface {
-	// PodCPUUsage queries the average CPU usage of a given pod in the past certain seconds.
+// MetricsSource provides the current CPU and memory usage of pods to autoscalers.
+type MetricsSource interface {
+	// PodCPUUsage queries the average CPU usage of a given pod in cores.
 	PodCPUUsage(pod *core.Pod) (float64, error)
-	// PodMemoryUsage queries the average memory usage of a given pod in the past certain seconds.
+	// PodMemoryUsage queries the average memory usage of a given pod in bytes.
 	PodMemoryUsage(pod *core.Pod) (uint64, error)
+	// PodMetricValue queries the current value of a custom metric of a given pod.
+	PodMetricValue(pod *core.Pod, metric *core.PodsMetricSource) (float64, error)
+	// ExternalMetricValue queries the current value of a metric not tied to any pod.
+	ExternalMetricValue(metric *core.ExternalMetricSource) (float64, error)
+	// ContainerUsage queries the CPU and memory usage of each container of a given pod, except
+	// for the pause container.
+	ContainerUsage(pod *core.Pod) ([]core.ContainerMetrics, error)
 }
 
-type metricsManagerInner struct {
+// PrometheusConfig tells where Prometheus is and how it computes usage.
+type PrometheusConfig struct {
+	// Address is the URL of the Prometheus server.
+	Address string
+	// Window is the range over which the usage of a pod is averaged.
+	Window time.Duration
+}
+
+// prometheusSource queries the usage of pods from Prometheus, which scrapes cadvisor on every node.
+type prometheusSource struct {
 	prometheusAPI v1.API
+	window        string
 }
 
-func NewMetricsManager(componentManager apiserver.ComponentManager) MetricsManager {
+// NewPrometheusSource creates a metrics source backed by Prometheus. The server is not contacted
+// until the first query.
+func NewPrometheusSource(config PrometheusConfig) (MetricsSource, error) {
+	if config.Window < time.Second {
+		return nil, fmt.Errorf("query window of prometheus must be at least 1s, got %v", config.Window)
+	}
 	client, err := api.NewClient(api.Config{
-		Address: PrometheusAddress,
+		Address: config.Address,
 	})
 	if err != nil {
-		glog.Fatal(err)
+		return nil, fmt.Errorf("cannot create prometheus client for %v: %v", config.Address, err.Error())
 	}
-	return &metricsManagerInner{
+	return &prometheusSource{
 		prometheusAPI: v1.NewAPI(client),
-	}
+		window:        model.Duration(config.Window).String(),
+	}, nil
 }
 
-func (mm *metricsManagerInner) PodCPUUsage(pod *core.Pod) (float64, error) {
-	var queryBuilder strings.Builder
+func (ps *prometheusSource) PodCPUUsage(pod *core.Pod) (float64, error) {
+	query := ps.podQuery(pod, ps.containerCPUUsageQuery)
+	value, err := ps.query(query)
+	if err != nil {
+		glog.Errorf("fail to get cpu usage from prometheus: %v\n", err)
+		return 0.0, err
+	}
+	if value == nil {
+		return 0.0, fmt.Errorf("fail to get cpu usage for pod %s: no data from prometheus", pod.Name)
+	}
 
-	// Pause container
-	pauseName := core.GetPodSpecificPauseName(pod)
-	containerQuery := containerCPUUsageQuery(pauseName)
-	queryBuilder.WriteString(containerQuery)
+	glog.V(2).Infof("pod %s cpu usage: %f", pod.Name, *value)
+	return *value, nil
+}
 
-	// Other containers
-	for _, container := range pod.Spec.Containers {
-		containerName := core.GetPodSpecificName(pod, container.Name)
-		containerQuery = containerCPUUsageQuery(containerName)
-		queryBuilder.WriteString(" or ")
-		queryBuilder.WriteString(containerQuery)
+func (ps *prometheusSource) PodMemoryUsage(pod *core.Pod) (uint64, error) {
+	query := ps.podQuery(pod, ps.containerMemoryUsageQuery)
+	value, err := ps.query(query)
+	if err != nil {
+		glog.Errorf("fail to get memory usage from prometheus: %v\n", err)
+		return 0, err
+	}
+	if value == nil {
+		return 0, fmt.Errorf("fail to get memory usage for pod %s", pod.Name)
 	}
 
-	// Sum the results
-	query := "sum(" + queryBuilder.String() + ")"
+	glog.V(2).Infof("pod %s memory usage: %d bytes", pod.Name, uint64(*value))
+	return uint64(*value), nil
+}
 
-	// Query Promethus
-	ctx, cancel := context.WithTimeout(context.Background(), QueryTimeout)
-	defer cancel()
-	result, warnings, err := mm.prometheusAPI.Query(ctx, query, time.Now())
+func (ps *prometheusSource) PodMetricValue(pod *core.Pod, metric *core.PodsMetricSource) (float64, error) {
+	value, err := ps.query(ps.podMetricQuery(pod, metric))
 	if err != nil {
-		glog.Errorf("fail to get cpu usage from prometheus: %v\n", err)
+		glog.Errorf("fail to get metric %s from prometheus: %v\n", metric.MetricName, err)
 		return 0.0, err
 	}
-	if len(warnings) > 0 {
-		glog.Warningf("warnings from prometheus: %v\n", warnings)
+	if value == nil {
+		return 0.0, fmt.Errorf("fail to get metric %s for pod %s: no data from prometheus", metric.MetricName, pod.Name)
 	}
-	if result.(model.Vector).Len() == 0 {
-		return 0.0, fmt.Errorf("fail to get cpu usage for pod %s: no data from prometheus", pod.Name)
+	return *value, nil
+}
+
+func (ps *prometheusSource) ExternalMetricValue(metric *core.ExternalMetricSource) (float64, error) {
+	value, err := ps.query(ps.externalMetricQuery(metric))
+	if err != nil {
+		glog.Errorf("fail to get metric %s from prometheus: %v\n", metric.MetricName, err)
+		return 0.0, err
+	}
+	if value == nil {
+		return 0.0, fmt.Errorf("fail to get external metric %s: no data from prometheus", metric.MetricName)
+	}
+	return *value, nil
+}
+
+func (ps *prometheusSource) ContainerUsage(pod *core.Pod) ([]core.ContainerMetrics, error) {
+	metrics := make([]core.ContainerMetrics, 0, len(pod.Spec.Containers))
+	for _, container := range pod.Spec.Containers {
+		name := core.GetPodSpecificName(pod, container.Name)
+		cpu, err := ps.query(ps.containerCPUUsageQuery(name))
+		if err != nil {
+			return nil, err
+		}
+		memory, err := ps.query(ps.containerMemoryUsageQuery(name))
+		if err != nil {
+			return nil, err
+		}
+		if cpu == nil || memory == nil {
+			return nil, fmt.Errorf("fail to get usage for container %s: no data from prometheus", name)
+		}
+		metrics = append(metrics, core.ContainerMetrics{
+			Name:  container.Name,
+			Usage: ContainerUsage(*cpu, uint64(*memory)),
+		})
+	}
+	return metrics, nil
+}
+
+// ContainerUsage converts CPU usage in cores and memory usage in bytes into a resource list.
+func ContainerUsage(cpu float64, memory uint64) core.ResourceList {
+	return core.ResourceList{
+		core.ResourceCPU:    core.NewMilliQuantity(int64(math.Ceil(cpu * 1000))),
+		core.ResourceMemory: core.NewMilliQuantity(int64(memory) * 1000),
 	}
+}
 
-	fmt.Printf("pod %s cpu usage: %f\n", pod.Name, float64(result.(model.Vector)[0].Value))
-	return float64(result.(model.Vector)[0].Value), nil
+// podMetricQuery generates the PromQL of a custom metric of a pod, averaged over the window.
+func (ps *prometheusSource) podMetricQuery(pod *core.Pod, metric *core.PodsMetricSource) string {
+	if metric.Query != "" {
+		return strings.NewReplacer("$pod", pod.Name, "$window", ps.window).Replace(metric.Query)
+	}
+	return fmt.Sprintf("sum(avg_over_time(%s{pod=\"%s\"}[%s]))", metric.MetricName, pod.Name, ps.window)
+}
+
+// externalMetricQuery generates the PromQL of an external metric, summing the selected series.
+func (ps *prometheusSource) externalMetricQuery(metric *core.ExternalMetricSource) string {
+	if metric.Query != "" {
+		return strings.ReplaceAll(metric.Query, "$window", ps.window)
+	}
+	keys := make([]string, 0, len(metric.Selector))
+	for key := range metric.Selector {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	matchers := make([]string, 0, len(keys))
+	for _, key := range keys {
+		matchers = append(matchers, fmt.Sprintf("%s=%q", key, metric.Selector[key]))
+	}
+	return fmt.Sprintf("sum(%s{%s})", metric.MetricName, strings.Join(matchers, ","))
 }
 
-func (mm *metricsManagerInner) PodMemoryUsage(pod *core.Pod) (uint64, error) {
+// podQuery sums the queries of the pause container and the other containers of a pod.
+func (ps *prometheusSource) podQuery(pod *core.Pod, containerQuery func(string) string) string {
 	var queryBuilder strings.Builder
 
 	// Pause container
-	pauseName := core.GetPodSpecificPauseName(pod)
-	containerQuery := containerMemoryUsageQuery(pauseName)
-	queryBuilder.WriteString(containerQuery)
+	queryBuilder.WriteString(containerQuery(core.GetPodSpecificPauseName(pod)))
 
 	// Other containers
 	for _, container := range pod.Spec.Containers {
-		containerName := core.GetPodSpecificName(pod, container.Name)
-		containerQuery = containerMemoryUsageQuery(containerName)
 		queryBuilder.WriteString(" or ")
-		queryBuilder.WriteString(containerQuery)
+		queryBuilder.WriteString(containerQuery(core.GetPodSpecificName(pod, container.Name)))
 	}
 
 	// Sum the results
-	query := "sum(" + queryBuilder.String() + ")"
+	return "sum(" + queryBuilder.String() + ")"
+}
 
-	// Query Promethus
+// query runs an instant query and returns its first sample, or nil if there is none.
+func (ps *prometheusSource) query(query string) (*float64, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), QueryTimeout)
 	defer cancel()
-	result, warnings, err := mm.prometheusAPI.Query(ctx, query, time.Now())
+	result, warnings, err := ps.prometheusAPI.Query(ctx, query, time.Now())
 	if err != nil {
-		glog.Errorf("fail to get memory usage from prometheus: %v\n", err)
-		return 0, err
+		return nil, err
 	}
 	if len(warnings) > 0 {
 		glog.Warningf("warnings from prometheus: %v\n", warnings)
 	}
-	if result.(model.Vector).Len() == 0 {
-		return 0, fmt.Errorf("fail to get memory usage for pod %s", pod.Name)
+	vector, ok := result.(model.Vector)
+	if !ok || vector.Len() == 0 {
+		return nil, nil
 	}
-
-	fmt.Printf("pod %s memory usage: %d bytes\n", pod.Name, uint64(result.(model.Vector)[0].Value))
-	return uint64(result.(model.Vector)[0].Value), nil
+	value := float64(vector[0].Value)
+	return &value, nil
 }
 
 // containerCPUUsageQuery is a helper function that generates the PromQL to query a container's
 // CPU usage.
-func containerCPUUsageQuery(containerName string) string {
+func (ps *prometheusSource) containerCPUUsageQuery(containerName string) string {
 	var query strings.Builder
 	query.WriteString("sum(rate(container_cpu_usage_seconds_total{name=\"")
 	query.WriteString(containerName)
 	query.WriteString("\"}[")
-	query.WriteString(UsageComputeDuration)
+	query.WriteString(ps.window)
 	query.WriteString("])) by (name)")
 	return query.String()
 }
 
 // containerMemoryUsageQuery is a helper function that generates the PromQL to query a container's
 // memory usage.
-func containerMemoryUsageQuery(containerName string) string {
+func (ps *prometheusSource) containerMemoryUsageQuery(containerName string) string {
 	var query strings.Builder
 	query.WriteString("avg_over_time(container_memory_usage_bytes{name=\"")
 	query.WriteString(containerName)
 	query.WriteString("\"}[")
-	query.WriteString(UsageComputeDuration)
+	query.WriteString(ps.window)
 	query.WriteString("])")
 	return query.String()
 }
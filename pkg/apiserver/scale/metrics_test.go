@@ -0,0 +1,100 @@
+package scale
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"p9t.io/kuberboat/pkg/api/core"
+)
+
+func TestPrometheusSourceQuery(t *testing.T) {
+	source, err := NewPrometheusSource(PrometheusConfig{
+		Address: "http://localhost:9090",
+		Window:  30 * time.Second,
+	})
+	assert.Nil(t, err)
+
+	pod := &core.Pod{}
+	pod.Spec.Containers = []core.Container{{Name: "nginx"}}
+	query := source.(*prometheusSource).podQuery(pod, source.(*prometheusSource).containerCPUUsageQuery)
+	assert.Contains(t, query, "[30s]")
+	assert.Contains(t, query, core.GetPodSpecificName(pod, "nginx"))
+	assert.Contains(t, query, core.GetPodSpecificPauseName(pod))
+
+	_, err = NewPrometheusSource(PrometheusConfig{Address: "http://localhost:9090"})
+	assert.NotNil(t, err)
+}
+
+type fakePodMetricsProvider struct {
+	metrics map[string]*core.PodMetrics
+}
+
+func (p *fakePodMetricsProvider) PodMetrics(names []string, selector map[string]string) (
+	[]*core.PodMetrics,
+	[]string,
+) {
+	found := make([]*core.PodMetrics, 0)
+	notFound := make([]string, 0)
+	for _, name := range names {
+		if m, ok := p.metrics[name]; ok {
+			found = append(found, m)
+		} else {
+			notFound = append(notFound, name)
+		}
+	}
+	return found, notFound
+}
+
+func TestKubeletStatsSource(t *testing.T) {
+	provider := &fakePodMetricsProvider{metrics: map[string]*core.PodMetrics{
+		"pod": {
+			Name: "pod",
+			Usage: core.ResourceList{
+				core.ResourceCPU:    core.MustParseQuantity("250m"),
+				core.ResourceMemory: core.MustParseQuantity("64Mi"),
+			},
+		},
+	}}
+	source := NewKubeletStatsSource(provider)
+
+	pod := &core.Pod{}
+	pod.Name = "pod"
+	cpu, err := source.PodCPUUsage(pod)
+	assert.Nil(t, err)
+	assert.Equal(t, 0.25, cpu)
+	memory, err := source.PodMemoryUsage(pod)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(64<<20), memory)
+
+	pod.Name = "missing"
+	_, err = source.PodCPUUsage(pod)
+	assert.NotNil(t, err)
+}
+
+func TestPrometheusCustomMetricQueries(t *testing.T) {
+	source, err := NewPrometheusSource(PrometheusConfig{Address: DefaultPrometheusAddress, Window: time.Minute})
+	assert.Nil(t, err)
+	ps := source.(*prometheusSource)
+
+	pod := &core.Pod{}
+	pod.Name = "web-1"
+	assert.Equal(
+		t,
+		`sum(avg_over_time(http_requests{pod="web-1"}[1m]))`,
+		ps.podMetricQuery(pod, &core.PodsMetricSource{MetricName: "http_requests"}),
+	)
+	assert.Equal(
+		t,
+		`sum(rate(requests_total{pod="web-1"}[1m]))`,
+		ps.podMetricQuery(pod, &core.PodsMetricSource{Query: `sum(rate(requests_total{pod="$pod"}[$window]))`}),
+	)
+	assert.Equal(
+		t,
+		`sum(queue_depth{queue="jobs",vhost="main"})`,
+		ps.externalMetricQuery(&core.ExternalMetricSource{
+			MetricName: "queue_depth",
+			Selector:   map[string]string{"vhost": "main", "queue": "jobs"},
+		}),
+	)
+}
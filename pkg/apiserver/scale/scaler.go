@@ -0,0 +1,13 @@
+package scale
+
+import "p9t.io/kuberboat/pkg/api/core"
+
+// Scaler reads and sets the replicas of deployments. Autoscalers scale deployments through it, the
+// same way kubectl scale does, so that the new replicas are persisted.
+type Scaler interface {
+	// GetScale returns the desired and observed replicas of a deployment.
+	GetScale(deploymentName string) (*core.Scale, error)
+	// UpdateScale sets the desired replicas of a deployment. If currentReplicas is not nil, the
+	// replicas are only set if the deployment still desires that many.
+	UpdateScale(deploymentName string, replicas uint32, currentReplicas *uint32) error
+}
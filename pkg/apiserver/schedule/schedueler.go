@@ -10,8 +10,10 @@ import (
 
 // PodScheduler selects a node to create and run a pod.
 type PodScheduler interface {
-	// SchedulePod schedules a pod by round robin. If an affinity pod is specified, the pod will be
-	// scheduled to the node where its affinity pod has been scheduled.
+	// SchedulePod schedules a pod by round robin. If a node name is specified, the pod will be
+	// scheduled to that node. If an affinity pod is specified, the pod will be
+	// scheduled to the node where its affinity pod has been scheduled. Nodes under resource
+	// pressure, or without enough resources left for the requests of the pod, are not considered.
 	SchedulePod(pod *core.Pod) (*core.Node, error)
 }
 
@@ -37,13 +39,41 @@ type schedulerInner struct {
 }
 
 func (s *schedulerInner) SchedulePod(pod *core.Pod) (*core.Node, error) {
-	if pod.Spec.Affinity != "" {
+	if pod.Spec.NodeName != "" {
+		return s.scheduleByNodeName(pod)
+	} else if pod.Spec.Affinity != "" {
 		return s.scheduleByAffinity(pod)
 	} else {
 		return s.scheduleByRoundRobin(pod), nil
 	}
 }
 
+// scheduleByNodeName schedules a pod to the node it names.
+func (s *schedulerInner) scheduleByNodeName(pod *core.Pod) (*core.Node, error) {
+	var node *core.Node
+	for _, n := range s.nodeManager.RegisteredNodes() {
+		if n.Name == pod.Spec.NodeName {
+			node = n
+			break
+		}
+	}
+	if node == nil {
+		return nil, fmt.Errorf("node %s for pod %s is not registered", pod.Spec.NodeName, pod.Name)
+	}
+	if len(node.Status.Pressures) > 0 {
+		return nil, fmt.Errorf(
+			"node %s for pod %s is under pressure: %v",
+			node.Name,
+			pod.Name,
+			node.Status.Pressures,
+		)
+	}
+	if !s.fitsNode(pod, node) {
+		return nil, fmt.Errorf("node %s for pod %s does not have enough resources left", node.Name, pod.Name)
+	}
+	return node, nil
+}
+
 // scheduleByAffinity schedules a pod to where its affinity pod has been scheduled.
 func (s *schedulerInner) scheduleByAffinity(pod *core.Pod) (*core.Node, error) {
 	affinityPodName := pod.Spec.Affinity
@@ -62,20 +92,60 @@ func (s *schedulerInner) scheduleByAffinity(pod *core.Pod) (*core.Node, error) {
 			pod.Name,
 		)
 	}
-	return s.nodeManager.NodeByIP(affinityPod.Status.HostIP), nil
+	node := s.nodeManager.NodeByIP(affinityPod.Status.HostIP)
+	if node != nil && len(node.Status.Pressures) > 0 {
+		return nil, fmt.Errorf(
+			"node of affinity pod %s for pod %s is under pressure: %v",
+			affinityPodName,
+			pod.Name,
+			node.Status.Pressures,
+		)
+	}
+	if node != nil && !s.fitsNode(pod, node) {
+		return nil, fmt.Errorf(
+			"node of affinity pod %s for pod %s does not have enough resources left",
+			affinityPodName,
+			pod.Name,
+		)
+	}
+	return node, nil
 }
 
 // scheduleByRoundRobin schedules a pod by round robin.
 func (s *schedulerInner) scheduleByRoundRobin(pod *core.Pod) *core.Node {
 	nodes := s.nodeManager.RegisteredNodes()
-	if s.nextIdx >= len(nodes) {
-		s.nextIdx = 0
-	}
-	if len(nodes) == 0 {
-		return nil
-	} else {
+	// Try each node at most once, skipping those under pressure or too full.
+	for range nodes {
+		if s.nextIdx >= len(nodes) {
+			s.nextIdx = 0
+		}
 		node := nodes[s.nextIdx]
 		s.nextIdx++
-		return node
+		if len(node.Status.Pressures) == 0 && s.fitsNode(pod, node) {
+			return node
+		}
+	}
+	return nil
+}
+
+// fitsNode tells whether the capacity of a node covers the requests of a pod along with those of
+// the unfinished pods already on the node. Resources whose capacity is unknown are not limited.
+func (s *schedulerInner) fitsNode(pod *core.Pod, node *core.Node) bool {
+	requested := core.PodRequests(pod)
+	for _, other := range s.componentManager.ListPods() {
+		if other.Status.HostIP != node.Status.Address ||
+			other.Status.Phase == core.PodSucceeded ||
+			other.Status.Phase == core.PodFailed {
+			continue
+		}
+		for name, request := range core.PodRequests(other) {
+			requested[name] = requested[name].Add(request)
+		}
+	}
+	for name, capacity := range node.Status.Capacity {
+		if requested[name].Cmp(capacity) > 0 {
+			return false
+		}
 	}
+	return true
 }
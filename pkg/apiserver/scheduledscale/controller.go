@@ -0,0 +1,283 @@
+package scheduledscale
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"p9t.io/kuberboat/pkg/api/core"
+	"p9t.io/kuberboat/pkg/apiserver"
+	"p9t.io/kuberboat/pkg/apiserver/scale"
+)
+
+type Controller interface {
+	// ApplyScheduledScaler creates a scheduled scaler, or replaces the one of the same name.
+	ApplyScheduledScaler(scaler *core.ScheduledScaler) error
+	// DeleteScheduledScalerByName deletes a scheduled scaler and lifts the bounds it set.
+	DeleteScheduledScalerByName(name string) error
+	// DescribeScheduledScalers returns information about scheduled scalers specified by names.
+	// Return value is composed of scheduled scalers that are found and names of those that do
+	// not exist.
+	DescribeScheduledScalers(all bool, names []string) ([]*core.ScheduledScaler, []string)
+}
+
+type basicController struct {
+	mtx                  sync.Mutex
+	componentManager     apiserver.ComponentManager
+	autoscalerController scale.Controller
+	// stopChs are closed to stop the runners of scheduled scalers, indexed by name.
+	stopChs map[string]chan struct{}
+	// now returns the current time. It is replaced in tests.
+	now func() time.Time
+}
+
+func NewScheduledScalerController(
+	componentManager apiserver.ComponentManager,
+	autoscalerController scale.Controller,
+) Controller {
+	return &basicController{
+		componentManager:     componentManager,
+		autoscalerController: autoscalerController,
+		stopChs:              map[string]chan struct{}{},
+		now:                  time.Now,
+	}
+}
+
+// parsedSchedule is a schedule along with its parsed cron expression.
+type parsedSchedule struct {
+	*core.ScalingSchedule
+	cron *cronSchedule
+}
+
+// parseSchedules validates the spec of a scheduled scaler and parses its schedules.
+func parseSchedules(spec *core.ScheduledScalerSpec) ([]parsedSchedule, *time.Location, error) {
+	location, err := time.LoadLocation(spec.TimeZone)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid time zone %v: %v", spec.TimeZone, err)
+	}
+	if len(spec.Schedules) == 0 {
+		return nil, nil, fmt.Errorf("no schedule specified")
+	}
+	schedules := make([]parsedSchedule, 0, len(spec.Schedules))
+	names := map[string]bool{}
+	for i := range spec.Schedules {
+		schedule := &spec.Schedules[i]
+		if schedule.Name == "" {
+			return nil, nil, fmt.Errorf("name of schedule %v not specified", i)
+		}
+		if names[schedule.Name] {
+			return nil, nil, fmt.Errorf("duplicate schedule: %v", schedule.Name)
+		}
+		names[schedule.Name] = true
+		if schedule.MaxReplicas != 0 && schedule.MinReplicas > schedule.MaxReplicas {
+			return nil, nil, fmt.Errorf("min replicas of schedule %v exceeds its max replicas", schedule.Name)
+		}
+		cron, err := parseCron(schedule.Schedule)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid schedule %v: %v", schedule.Name, err)
+		}
+		schedules = append(schedules, parsedSchedule{ScalingSchedule: schedule, cron: cron})
+	}
+	return schedules, location, nil
+}
+
+// lastSchedule returns the schedule that fired last at or before now, and when it fired. If
+// several schedules fire at once, the last one of them in the spec wins.
+func lastSchedule(schedules []parsedSchedule, now time.Time) (*parsedSchedule, time.Time) {
+	var last *parsedSchedule
+	var lastTime time.Time
+	for i := range schedules {
+		fired := schedules[i].cron.prev(now)
+		if !fired.IsZero() && !fired.Before(lastTime) {
+			last, lastTime = &schedules[i], fired
+		}
+	}
+	return last, lastTime
+}
+
+// nextScheduleTime returns when a schedule fires next after now, or the zero time if none does.
+func nextScheduleTime(schedules []parsedSchedule, now time.Time) time.Time {
+	var next time.Time
+	for i := range schedules {
+		fires := schedules[i].cron.next(now)
+		if !fires.IsZero() && (next.IsZero() || fires.Before(next)) {
+			next = fires
+		}
+	}
+	return next
+}
+
+func (bc *basicController) ApplyScheduledScaler(scaler *core.ScheduledScaler) error {
+	bc.mtx.Lock()
+	defer bc.mtx.Unlock()
+
+	if scaler.Name == "" {
+		return fmt.Errorf("name of scheduled scaler not specified")
+	}
+	if scaler.Spec.ScaleTargetRef.Kind != core.DeploymentType {
+		return fmt.Errorf("target of scheduled scaler must be deployment")
+	}
+	schedules, location, err := parseSchedules(&scaler.Spec)
+	if err != nil {
+		return err
+	}
+	if nextScheduleTime(schedules, bc.now().In(location)).IsZero() {
+		return fmt.Errorf("no schedule of %v ever fires", scaler.Name)
+	}
+	deploymentName := scaler.Spec.ScaleTargetRef.Name
+	if !bc.componentManager.DeploymentExistsByName(deploymentName) {
+		return fmt.Errorf("no such deployment to be scaled on schedule: %v", deploymentName)
+	}
+	for _, other := range bc.componentManager.ListScheduledScalers() {
+		if other.Name != scaler.Name && other.Spec.ScaleTargetRef.Name == deploymentName {
+			return fmt.Errorf("deployment %v already scaled on schedule by %v", deploymentName, other.Name)
+		}
+	}
+
+	oldScaler := bc.componentManager.GetScheduledScalerByName(scaler.Name)
+	if oldScaler != nil {
+		if stopCh, ok := bc.stopChs[scaler.Name]; ok {
+			close(stopCh)
+		}
+		if oldScaler.Spec.ScaleTargetRef.Name != deploymentName {
+			bc.autoscalerController.ClearScheduledBounds(oldScaler.Spec.ScaleTargetRef.Name)
+		}
+		scaler.CreationTimestamp = oldScaler.CreationTimestamp
+	} else {
+		scaler.CreationTimestamp = bc.now()
+	}
+	scaler.Status = core.ScheduledScalerStatus{}
+	bc.componentManager.SetScheduledScaler(scaler)
+
+	// The schedule that fired last before the scaler was applied is already in effect.
+	if schedule, fired := lastSchedule(schedules, bc.now().In(location)); schedule != nil {
+		bc.activate(scaler, schedule, fired)
+	} else {
+		bc.autoscalerController.ClearScheduledBounds(deploymentName)
+	}
+
+	stopCh := make(chan struct{})
+	bc.stopChs[scaler.Name] = stopCh
+	go bc.runScheduledScaler(scaler, schedules, location, stopCh)
+
+	if oldScaler != nil {
+		glog.Infof("SCHEDULED SCALER [%v]: updated on deployment %v", scaler.Name, deploymentName)
+	} else {
+		glog.Infof("SCHEDULED SCALER [%v]: created on deployment %v", scaler.Name, deploymentName)
+	}
+	return nil
+}
+
+// activate makes a schedule the active one of a scheduled scaler.
+func (bc *basicController) activate(scaler *core.ScheduledScaler, schedule *parsedSchedule, fired time.Time) {
+	deploymentName := scaler.Spec.ScaleTargetRef.Name
+	bc.autoscalerController.SetScheduledBounds(deploymentName, scale.ReplicaBounds{
+		Min: schedule.MinReplicas,
+		Max: schedule.MaxReplicas,
+	})
+	scaler.Status.ActiveSchedule = schedule.Name
+	scaler.Status.LastScheduleTime = &fired
+	glog.Infof(
+		"SCHEDULED SCALER [%v]: schedule %v active on deployment %v, replicas within [%v, %v]",
+		scaler.Name,
+		schedule.Name,
+		deploymentName,
+		schedule.MinReplicas,
+		schedule.MaxReplicas,
+	)
+}
+
+// runScheduledScaler activates the schedules of a scheduled scaler as they fire, until stopCh is
+// closed or the target deployment is gone.
+func (bc *basicController) runScheduledScaler(
+	scaler *core.ScheduledScaler,
+	schedules []parsedSchedule,
+	location *time.Location,
+	stopCh chan struct{},
+) {
+	for {
+		bc.mtx.Lock()
+		next := nextScheduleTime(schedules, bc.now().In(location))
+		if next.IsZero() {
+			scaler.Status.NextScheduleTime = nil
+		} else {
+			scaler.Status.NextScheduleTime = &next
+		}
+		bc.mtx.Unlock()
+		if next.IsZero() {
+			glog.Warningf("SCHEDULED SCALER [%v]: no schedule fires any more", scaler.Name)
+			return
+		}
+
+		timer := time.NewTimer(next.Sub(bc.now()))
+		select {
+		case <-stopCh:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		bc.mtx.Lock()
+		select {
+		case <-stopCh:
+			// Updated or deleted while the timer fired.
+			bc.mtx.Unlock()
+			return
+		default:
+		}
+		deploymentName := scaler.Spec.ScaleTargetRef.Name
+		if !bc.componentManager.DeploymentExistsByName(deploymentName) {
+			// Deployment does not exist. Just delete the scheduled scaler.
+			bc.deleteScheduledScaler(scaler.Name)
+			bc.mtx.Unlock()
+			glog.Infof("SCHEDULED SCALER [%v]: deleted since deployment %v is gone", scaler.Name, deploymentName)
+			return
+		}
+		if schedule, fired := lastSchedule(schedules, next); schedule != nil {
+			bc.activate(scaler, schedule, fired)
+		}
+		bc.mtx.Unlock()
+	}
+}
+
+// deleteScheduledScaler stops a scheduled scaler and lifts its bounds. The caller must hold the
+// lock.
+func (bc *basicController) deleteScheduledScaler(name string) {
+	scaler := bc.componentManager.GetScheduledScalerByName(name)
+	if stopCh, ok := bc.stopChs[name]; ok {
+		close(stopCh)
+		delete(bc.stopChs, name)
+	}
+	bc.autoscalerController.ClearScheduledBounds(scaler.Spec.ScaleTargetRef.Name)
+	bc.componentManager.DeleteScheduledScalerByName(name)
+}
+
+func (bc *basicController) DeleteScheduledScalerByName(name string) error {
+	bc.mtx.Lock()
+	defer bc.mtx.Unlock()
+
+	if bc.componentManager.GetScheduledScalerByName(name) == nil {
+		return fmt.Errorf("no such scheduled scaler: %v", name)
+	}
+	bc.deleteScheduledScaler(name)
+	glog.Infof("SCHEDULED SCALER [%v]: deleted", name)
+	return nil
+}
+
+func (bc *basicController) DescribeScheduledScalers(all bool, names []string) ([]*core.ScheduledScaler, []string) {
+	if all {
+		return bc.componentManager.ListScheduledScalers(), []string{}
+	}
+	found := make([]*core.ScheduledScaler, 0, len(names))
+	notFound := make([]string, 0)
+	for _, name := range names {
+		scaler := bc.componentManager.GetScheduledScalerByName(name)
+		if scaler == nil {
+			notFound = append(notFound, name)
+			continue
+		}
+		found = append(found, scaler)
+	}
+	return found, notFound
+}
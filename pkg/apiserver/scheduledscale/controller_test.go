@@ -0,0 +1,99 @@
+package scheduledscale
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"p9t.io/kuberboat/pkg/api/core"
+	"p9t.io/kuberboat/pkg/apiserver"
+	"p9t.io/kuberboat/pkg/apiserver/scale"
+)
+
+// fakeScaler sets the replicas of the deployments in a component manager without persisting them.
+type fakeScaler struct {
+	scale.Scaler
+	componentManager apiserver.ComponentManager
+}
+
+func (fs *fakeScaler) UpdateScale(deploymentName string, replicas uint32, currentReplicas *uint32) error {
+	fs.componentManager.GetDeploymentByName(deploymentName).Spec.Replicas = replicas
+	return nil
+}
+
+func newTestScheduledScaler() *core.ScheduledScaler {
+	scaler := &core.ScheduledScaler{}
+	scaler.Name = "test-scheduled-scaler"
+	scaler.Spec.ScaleTargetRef = core.ScaleTarget{Kind: core.DeploymentType, Name: "test-deployment"}
+	scaler.Spec.Schedules = []core.ScalingSchedule{
+		{Name: "day", Schedule: "0 8 * * *", MinReplicas: 4},
+		{Name: "night", Schedule: "0 20 * * *", MinReplicas: 1, MaxReplicas: 1},
+	}
+	return scaler
+}
+
+func TestApplyScheduledScalerActivatesLastSchedule(t *testing.T) {
+	componentManager := apiserver.NewComponentManager()
+	deployment := &core.Deployment{}
+	deployment.Name = "test-deployment"
+	deployment.Spec.Replicas = 2
+	componentManager.SetDeployment(deployment)
+
+	autoscalerController := scale.NewAutoscalerController(
+		componentManager,
+		scale.NewFakeMetricsSource(),
+		&fakeScaler{componentManager: componentManager},
+	)
+	controller := NewScheduledScalerController(componentManager, autoscalerController).(*basicController)
+	controller.now = func() time.Time { return time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC) }
+
+	scaler := newTestScheduledScaler()
+	assert.Nil(t, controller.ApplyScheduledScaler(scaler))
+	assert.Equal(t, "day", scaler.Status.ActiveSchedule)
+	assert.Equal(t, time.Date(2022, 6, 1, 8, 0, 0, 0, time.UTC), *scaler.Status.LastScheduleTime)
+	assert.Equal(t, uint32(4), deployment.Spec.Replicas)
+
+	// Applying it again at night replaces it and scales the deployment in.
+	controller.now = func() time.Time { return time.Date(2022, 6, 1, 23, 0, 0, 0, time.UTC) }
+	scaler = newTestScheduledScaler()
+	assert.Nil(t, controller.ApplyScheduledScaler(scaler))
+	assert.Equal(t, "night", scaler.Status.ActiveSchedule)
+	assert.Equal(t, uint32(1), deployment.Spec.Replicas)
+	assert.Equal(t, 1, len(controller.stopChs))
+
+	assert.Nil(t, controller.DeleteScheduledScalerByName(scaler.Name))
+	assert.NotNil(t, controller.DeleteScheduledScalerByName(scaler.Name))
+	assert.Equal(t, 0, len(componentManager.ListScheduledScalers()))
+}
+
+func TestApplyScheduledScalerRejectsInvalidSpecs(t *testing.T) {
+	componentManager := apiserver.NewComponentManager()
+	deployment := &core.Deployment{}
+	deployment.Name = "test-deployment"
+	componentManager.SetDeployment(deployment)
+	autoscalerController := scale.NewAutoscalerController(
+		componentManager,
+		scale.NewFakeMetricsSource(),
+		&fakeScaler{componentManager: componentManager},
+	)
+	controller := NewScheduledScalerController(componentManager, autoscalerController)
+
+	invalidSpecs := []func(*core.ScheduledScaler){
+		func(s *core.ScheduledScaler) { s.Spec.ScaleTargetRef.Name = "unknown" },
+		func(s *core.ScheduledScaler) { s.Spec.TimeZone = "Nowhere/Unknown" },
+		func(s *core.ScheduledScaler) { s.Spec.Schedules = nil },
+		func(s *core.ScheduledScaler) { s.Spec.Schedules[0].Schedule = "0 8 * *" },
+		func(s *core.ScheduledScaler) { s.Spec.Schedules[1].Name = "day" },
+		func(s *core.ScheduledScaler) { s.Spec.Schedules[1].MinReplicas = 2 },
+		func(s *core.ScheduledScaler) {
+			s.Spec.Schedules = s.Spec.Schedules[:1]
+			s.Spec.Schedules[0].Schedule = "0 0 30 2 *"
+		},
+	}
+	for i, invalidate := range invalidSpecs {
+		scaler := newTestScheduledScaler()
+		invalidate(scaler)
+		assert.NotNil(t, controller.ApplyScheduledScaler(scaler), i)
+	}
+	assert.Equal(t, 0, len(componentManager.ListScheduledScalers()))
+}
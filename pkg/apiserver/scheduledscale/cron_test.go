@@ -0,0 +1,66 @@
+package scheduledscale
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCron(t *testing.T) {
+	schedule, err := parseCron("*/15 8-10,20 1 jan-mar mon")
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1|1<<15|1<<30|1<<45), schedule.minute)
+	assert.Equal(t, uint64(1<<8|1<<9|1<<10|1<<20), schedule.hour)
+	assert.Equal(t, uint64(1<<1), schedule.dom)
+	assert.Equal(t, uint64(1<<1|1<<2|1<<3), schedule.month)
+	assert.Equal(t, uint64(1<<1), schedule.dow)
+	assert.False(t, schedule.domStar)
+	assert.False(t, schedule.dowStar)
+
+	// Both 0 and 7 are Sunday.
+	schedule, err = parseCron("@weekly")
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), schedule.dow)
+	schedule, err = parseCron("0 0 * * 7")
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1|1<<7), schedule.dow)
+
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "* * * foo *"} {
+		_, err := parseCron(expr)
+		assert.NotNil(t, err, expr)
+	}
+}
+
+func TestCronNextAndPrev(t *testing.T) {
+	zone := time.FixedZone("UTC+8", 8*60*60)
+	// 2022-06-01 is a Wednesday.
+	now := time.Date(2022, 6, 1, 12, 30, 45, 0, zone)
+
+	schedule, _ := parseCron("0 8 * * mon-fri")
+	assert.Equal(t, time.Date(2022, 6, 2, 8, 0, 0, 0, zone), schedule.next(now))
+	assert.Equal(t, time.Date(2022, 6, 1, 8, 0, 0, 0, zone), schedule.prev(now))
+	// On a Saturday the next time is on Monday, and the previous one on Friday.
+	saturday := time.Date(2022, 6, 4, 9, 0, 0, 0, zone)
+	assert.Equal(t, time.Date(2022, 6, 6, 8, 0, 0, 0, zone), schedule.next(saturday))
+	assert.Equal(t, time.Date(2022, 6, 3, 8, 0, 0, 0, zone), schedule.prev(saturday))
+
+	// A time the schedule fires at is its own previous time, but not its next one.
+	fires := time.Date(2022, 6, 1, 8, 0, 0, 0, zone)
+	assert.Equal(t, fires, schedule.prev(fires))
+	assert.Equal(t, time.Date(2022, 6, 2, 8, 0, 0, 0, zone), schedule.next(fires))
+
+	// A restricted day of month and day of week match either of them.
+	schedule, _ = parseCron("30 12 15 * sun")
+	assert.Equal(t, time.Date(2022, 6, 5, 12, 30, 0, 0, zone), schedule.next(now))
+	assert.Equal(t, time.Date(2022, 5, 29, 12, 30, 0, 0, zone), schedule.prev(now))
+
+	schedule, _ = parseCron("0 0 1 1 *")
+	assert.Equal(t, time.Date(2023, 1, 1, 0, 0, 0, 0, zone), schedule.next(now))
+	assert.Equal(t, time.Date(2022, 1, 1, 0, 0, 0, 0, zone), schedule.prev(now))
+
+	// February 30 never comes.
+	schedule, _ = parseCron("0 0 30 2 *")
+	assert.True(t, schedule.next(now).IsZero())
+	assert.True(t, schedule.prev(now).IsZero())
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"container/list"
 	"fmt"
 	"net"
 	"sync"
@@ -12,6 +13,7 @@ import (
 	"p9t.io/kuberboat/pkg/api/core"
 	"p9t.io/kuberboat/pkg/apiserver"
 	"p9t.io/kuberboat/pkg/apiserver/client"
+	"p9t.io/kuberboat/pkg/apiserver/dns"
 	"p9t.io/kuberboat/pkg/apiserver/etcd"
 	"p9t.io/kuberboat/pkg/apiserver/node"
 )
@@ -22,6 +24,8 @@ type Controller interface {
 	// 		2. Fill some system-generated properties of the service.
 	// 		3. Notify all the nodes in the cluster about the service creation.
 	//		4. Modify metadata in component manager.
+	// A headless service gets no cluster IP and is left out by the nodes. Instead, each of its
+	// ready pods gets a DNS name.
 	CreateService(service *core.Service) error
 	// DeleteServiceByName
 	// 		1. Notify all the nodes in the cluster about the service deletion.
@@ -31,6 +35,10 @@ type Controller interface {
 	DeleteAllServices() error
 	// DescribeServices return all the services and their respective pods.
 	DescribeServices(all bool, names []string) ([]*core.Service, [][]string, []string)
+	// SetServiceSelector switches a service to the ready pods matching a new selector at once, which
+	// blue-green deployments rely on to move traffic between replica sets. Headless services are not
+	// supported.
+	SetServiceSelector(name string, selector map[string]string) error
 	// Set the current ip of clusterIPAssigner
 	SetCurrentIP(ip net.IP)
 }
@@ -71,15 +79,25 @@ func (c *basicController) CreateService(service *core.Service) error {
 		return fmt.Errorf("service already exists: %v", service.Name)
 	}
 
+	service.UUID = uuid.New()
+	service.CreationTimestamp = time.Now()
+	selectedPods := c.componentManager.ListPodsByLabelsAndPhase(&service.Spec.Selector, core.PodReady)
+
+	if isHeadless(service) {
+		for e := selectedPods.Front(); e != nil; e = e.Next() {
+			pod := e.Value.(*core.Pod)
+			if err := dns.SetARecord(core.PodDomainName(pod.Name, service.Name), pod.Status.PodIP); err != nil {
+				return err
+			}
+		}
+		return c.storeService(service, selectedPods)
+	}
+
 	clusterIP, err := c.clusterIPAssigner.NextClusterIP()
 	if err != nil {
 		return err
 	}
 	service.Spec.ClusterIP = clusterIP
-	service.UUID = uuid.New()
-	service.CreationTimestamp = time.Now()
-
-	selectedPods := c.componentManager.ListPodsByLabelsAndPhase(&service.Spec.Selector, core.PodReady)
 
 	clients := c.nodeManager.Clients()
 	errors := make(chan error, len(clients))
@@ -103,12 +121,17 @@ func (c *basicController) CreateService(service *core.Service) error {
 		}
 	}
 
+	return c.storeService(service, selectedPods)
+}
+
+// storeService records a newly created service along with its pods.
+func (c *basicController) storeService(service *core.Service, selectedPods *list.List) error {
 	// Store service metadata
-	if err = etcd.Put(fmt.Sprintf("/Services/Meta/%s", service.Name), service); err != nil {
+	if err := etcd.Put(fmt.Sprintf("/Services/Meta/%s", service.Name), service); err != nil {
 		return err
 	}
 	// Store map between service to its pods
-	if err = etcd.Put(fmt.Sprintf("/Services/Pods/%s", service.Name), core.GetPodNames(selectedPods)); err != nil {
+	if err := etcd.Put(fmt.Sprintf("/Services/Pods/%s", service.Name), core.GetPodNames(selectedPods)); err != nil {
 		return err
 	}
 	c.componentManager.SetService(service, selectedPods)
@@ -131,6 +154,20 @@ func (c *basicController) DeleteServiceByName(name string) error {
 		return fmt.Errorf("race condition on service: %v", name)
 	}
 
+	if isHeadless(service) {
+		pods := c.componentManager.ListPodsByServiceName(name)
+		for e := pods.Front(); e != nil; e = e.Next() {
+			pod := e.Value.(*core.Pod)
+			if err := dns.DeleteARecord(core.PodDomainName(pod.Name, name)); err != nil {
+				return err
+			}
+		}
+		deleteServiceInEtcd(name)
+		c.componentManager.DeleteServiceByName(name)
+		glog.Infof("SERVICE [%v]: headless service deleted", name)
+		return nil
+	}
+
 	clients := c.nodeManager.Clients()
 	errors := make(chan error, len(clients))
 	var wg sync.WaitGroup
@@ -171,6 +208,100 @@ func (c *basicController) DeleteAllServices() error {
 	return nil
 }
 
+func (c *basicController) SetServiceSelector(name string, selector map[string]string) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	service := c.componentManager.GetServiceByName(name)
+	if service == nil {
+		return fmt.Errorf("no such service: %v", name)
+	}
+	if isHeadless(service) {
+		return fmt.Errorf("cannot set the selector of headless service %v", name)
+	}
+
+	selectedPods := c.componentManager.ListPodsByLabelsAndPhase(&selector, core.PodReady)
+	podNames, podIPs := podNamesAndIPs(selectedPods)
+	oldPodNames, oldPodIPs := podNamesAndIPs(c.componentManager.ListPodsByServiceName(name))
+
+	// The service is replaced rather than modified, since others may be matching pods against it.
+	updated := *service
+	updated.Spec.Selector = selector
+	switched, err := setServicePodsOnNodes(c.nodeManager.Clients(), name, podNames, podIPs)
+	if err == nil {
+		if err = setServiceInEtcd(&updated, podNames); err != nil {
+			if restoreErr := setServiceInEtcd(service, oldPodNames); restoreErr != nil {
+				glog.Errorf("SERVICE [%v]: failed to restore service's metadata: %v", name, restoreErr)
+			}
+		}
+	}
+	if err != nil {
+		// Switch the nodes that succeeded back, so that all of them keep serving the same pods.
+		if _, rollbackErr := setServicePodsOnNodes(switched, name, oldPodNames, oldPodIPs); rollbackErr != nil {
+			glog.Errorf("SERVICE [%v]: failed to switch nodes back to the old pods: %v", name, rollbackErr)
+		}
+		return err
+	}
+	c.componentManager.SetService(&updated, selectedPods)
+
+	glog.Infof("SERVICE [%v]: selector set to %v with %v pods", name, selector, len(podNames))
+
+	return nil
+}
+
+// setServicePodsOnNodes sets the pods of a service on the nodes of clients. It returns the clients
+// of the nodes that succeeded, along with the first error if any node failed.
+func setServicePodsOnNodes(
+	clients []*client.ApiserverClient,
+	name string,
+	podNames []string,
+	podIPs []string,
+) ([]*client.ApiserverClient, error) {
+	type result struct {
+		cli *client.ApiserverClient
+		err error
+	}
+	results := make(chan result, len(clients))
+	for _, cli := range clients {
+		go func(cli *client.ApiserverClient) {
+			_, err := cli.SetServicePods(name, podNames, podIPs)
+			results <- result{cli: cli, err: err}
+		}(cli)
+	}
+
+	succeeded := make([]*client.ApiserverClient, 0, len(clients))
+	var firstErr error
+	for range clients {
+		r := <-results
+		if r.err != nil {
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			continue
+		}
+		succeeded = append(succeeded, r.cli)
+	}
+	return succeeded, firstErr
+}
+
+func podNamesAndIPs(pods *list.List) ([]string, []string) {
+	podNames := make([]string, 0, pods.Len())
+	podIPs := make([]string, 0, pods.Len())
+	for e := pods.Front(); e != nil; e = e.Next() {
+		pod := e.Value.(*core.Pod)
+		podNames = append(podNames, pod.Name)
+		podIPs = append(podIPs, pod.Status.PodIP)
+	}
+	return podNames, podIPs
+}
+
+func setServiceInEtcd(service *core.Service, podNames []string) error {
+	if err := etcd.Put(fmt.Sprintf("/Services/Meta/%s", service.Name), service); err != nil {
+		return err
+	}
+	return etcd.Put(fmt.Sprintf("/Services/Pods/%s", service.Name), podNames)
+}
+
 func deleteServiceInEtcd(serviceName string) error {
 	// TODO(WindowsXp): maybe we should check delete count and for the following case, it should be 2
 	if err := etcd.Delete(fmt.Sprintf("/Services/Meta/%s", serviceName)); err != nil {
@@ -240,7 +371,13 @@ func (c *basicController) HandleEvent(event apiserver.Event) {
 
 func (c *basicController) handlePodReady(podName string) error {
 	pod := c.componentManager.GetPodByName(podName)
-	serviceNames := c.componentManager.ListServicesByLabels(&pod.Labels)
+	headlessNames, serviceNames := c.splitHeadless(c.componentManager.ListServicesByLabels(&pod.Labels))
+	for _, serviceName := range headlessNames {
+		if err := dns.SetARecord(core.PodDomainName(podName, serviceName), pod.Status.PodIP); err != nil {
+			return err
+		}
+		c.componentManager.AddPodToService(serviceName, pod)
+	}
 	// No service need update
 	if len(serviceNames) == 0 {
 		return nil
@@ -276,7 +413,12 @@ func (c *basicController) handlePodReady(podName string) error {
 }
 
 func (c *basicController) handlePodDeletion(pod *core.Pod) error {
-	serviceNames := c.componentManager.ListServicesByLabels(&pod.Labels)
+	headlessNames, serviceNames := c.splitHeadless(c.componentManager.ListServicesByLabels(&pod.Labels))
+	for _, serviceName := range headlessNames {
+		if err := dns.DeleteARecord(core.PodDomainName(pod.Name, serviceName)); err != nil {
+			return err
+		}
+	}
 	// No service need update
 	if len(serviceNames) == 0 {
 		return nil
@@ -306,6 +448,25 @@ func (c *basicController) handlePodDeletion(pod *core.Pod) error {
 	return nil
 }
 
+// splitHeadless separates the names of headless services from those of the others.
+func (c *basicController) splitHeadless(serviceNames []string) ([]string, []string) {
+	headless := make([]string, 0)
+	others := make([]string, 0, len(serviceNames))
+	for _, name := range serviceNames {
+		if service := c.componentManager.GetServiceByName(name); service != nil && isHeadless(service) {
+			headless = append(headless, name)
+		} else {
+			others = append(others, name)
+		}
+	}
+	return headless, others
+}
+
+// isHeadless tells whether a service is headless, i.e. has no cluster IP.
+func isHeadless(service *core.Service) bool {
+	return service.Spec.ClusterIP == core.ClusterIPNone
+}
+
 func (c *basicController) SetCurrentIP(ip net.IP) {
 	c.clusterIPAssigner.currentIP = ip
 }
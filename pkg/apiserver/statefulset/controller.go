@@ -0,0 +1,489 @@
+package statefulset
+
+import (
+	"container/list"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/google/uuid"
+	"p9t.io/kuberboat/pkg/api/core"
+	"p9t.io/kuberboat/pkg/apiserver"
+	"p9t.io/kuberboat/pkg/apiserver/etcd"
+	"p9t.io/kuberboat/pkg/apiserver/node"
+	"p9t.io/kuberboat/pkg/apiserver/pod"
+)
+
+const (
+	// Interval in seconds between two checks of stateful set spec against status.
+	monitorInterval = 3
+)
+
+// Controller manages stateful sets.
+type Controller interface {
+	// DescribeStatefulSets return all the stateful sets and their respective pods, ordered by
+	// ordinal.
+	DescribeStatefulSets(all bool, names []string) ([]*core.StatefulSet, [][]string, []string)
+	// ApplyStatefulSet creates a stateful set if no stateful set with the same name exists.
+	// Otherwise, it updates the replicas and the template of the stateful set, the latter only
+	// affecting the pods created afterwards. The service name and the volume claim templates
+	// cannot be changed.
+	ApplyStatefulSet(statefulSet *core.StatefulSet) error
+	// DeleteStatefulSetByName deletes a stateful set and its pods, the highest ordinal first. The
+	// volume claims of the pods are kept.
+	DeleteStatefulSetByName(name string) error
+	// DeleteAllStatefulSets deletes all stateful sets by calling DeleteStatefulSetByName.
+	DeleteAllStatefulSets() error
+	// monitorStatefulSets takes the next step of each stateful set towards its spec: creating the
+	// lowest missing pod once all the ones below it are ready, or deleting the highest surplus one
+	// once all the others are ready. No step is taken until the kubelets confirm the deletion of
+	// the pods deleted before.
+	monitorStatefulSets()
+}
+
+type basicController struct {
+	mtx sync.Mutex
+	// componentManager stores the stateful sets and their pods.
+	componentManager apiserver.ComponentManager
+	// podController performs the actual creating/deleting pods.
+	podController pod.Controller
+	// nodeManager tells which node a pod has been scheduled to.
+	nodeManager node.NodeManager
+	// terminatingPods are the names of the deleted pods whose deletion the kubelets have not
+	// confirmed yet. A stateful set takes no step while any of its pods is terminating, so that a
+	// pod is neither created again nor followed by the next ordinal while its containers still run.
+	terminatingPods map[string]bool
+}
+
+// actionType is the kind of step a stateful set takes towards its spec.
+type actionType int
+
+const (
+	// actionNone means the stateful set matches its spec, or is waiting for a pod to be ready.
+	actionNone actionType = iota
+	// actionCreate means the pod of the ordinal is to be created.
+	actionCreate
+	// actionDelete means the pod of the ordinal is to be deleted.
+	actionDelete
+)
+
+// action is the next step of a stateful set towards its spec.
+type action struct {
+	kind    actionType
+	ordinal int
+}
+
+func NewStatefulSetController(
+	componentManager apiserver.ComponentManager,
+	pc pod.Controller,
+	nodeManager node.NodeManager,
+) Controller {
+	controller := &basicController{
+		componentManager: componentManager,
+		podController:    pc,
+		nodeManager:      nodeManager,
+		terminatingPods:  map[string]bool{},
+	}
+	go func() {
+		for range time.Tick(time.Second * monitorInterval) {
+			controller.monitorStatefulSets()
+		}
+	}()
+
+	apiserver.SubscribeToEvent(controller, apiserver.PodDeletion)
+	apiserver.SubscribeToEvent(controller, apiserver.PodFail)
+
+	return controller
+}
+
+func (m *basicController) DescribeStatefulSets(all bool, names []string) ([]*core.StatefulSet, [][]string, []string) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	statefulSetPods := make([][]string, 0)
+	if all {
+		statefulSets := m.componentManager.ListStatefulSets()
+		for _, statefulSet := range statefulSets {
+			statefulSetPods = append(statefulSetPods, m.getPodNames(statefulSet))
+		}
+		return statefulSets, statefulSetPods, make([]string, 0)
+	}
+	foundStatefulSets := make([]*core.StatefulSet, 0)
+	notFoundStatefulSets := make([]string, 0)
+	for _, name := range names {
+		statefulSet := m.componentManager.GetStatefulSetByName(name)
+		if statefulSet == nil {
+			notFoundStatefulSets = append(notFoundStatefulSets, name)
+			continue
+		}
+		foundStatefulSets = append(foundStatefulSets, statefulSet)
+		statefulSetPods = append(statefulSetPods, m.getPodNames(statefulSet))
+	}
+	return foundStatefulSets, statefulSetPods, notFoundStatefulSets
+}
+
+// getPodNames returns the names of the pods of a stateful set, ordered by ordinal. The caller must
+// hold the lock.
+func (m *basicController) getPodNames(statefulSet *core.StatefulSet) []string {
+	pods := podsByOrdinal(statefulSet.Name, m.componentManager.ListPodsByStatefulSetName(statefulSet.Name))
+	names := make([]string, 0, len(pods))
+	for _, ordinal := range sortedOrdinals(pods) {
+		names = append(names, pods[ordinal].Name)
+	}
+	return names
+}
+
+func (m *basicController) ApplyStatefulSet(statefulSet *core.StatefulSet) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if err := validateStatefulSet(statefulSet); err != nil {
+		return err
+	}
+	m.checkService(statefulSet)
+
+	existingStatefulSet := m.componentManager.GetStatefulSetByName(statefulSet.Name)
+	if existingStatefulSet == nil {
+		statefulSet.Kind = core.StatefulSetType
+		statefulSet.UUID = uuid.New()
+		statefulSet.CreationTimestamp = time.Now()
+		statefulSet.Status = core.StatefulSetStatus{ClaimNodes: map[string]string{}}
+		if err := setStatefulSetInEtcd(statefulSet); err != nil {
+			return err
+		}
+		m.componentManager.SetStatefulSet(statefulSet)
+		glog.Infof(
+			"STATEFULSET [%v]: stateful set created with %d replicas",
+			statefulSet.Name,
+			statefulSet.Spec.Replicas,
+		)
+		return nil
+	}
+
+	if existingStatefulSet.Spec.ServiceName != statefulSet.Spec.ServiceName {
+		return fmt.Errorf("the service name of stateful set %v cannot be changed", statefulSet.Name)
+	}
+	if !sameClaimTemplates(existingStatefulSet.Spec.VolumeClaimTemplates, statefulSet.Spec.VolumeClaimTemplates) {
+		return fmt.Errorf("the volume claim templates of stateful set %v cannot be changed", statefulSet.Name)
+	}
+	// Build the update on a copy, so that a failed update leaves the stateful set as it is in etcd.
+	updated := *existingStatefulSet
+	updated.Spec.Replicas = statefulSet.Spec.Replicas
+	updated.Spec.Template = statefulSet.Spec.Template
+	if err := setStatefulSetInEtcd(&updated); err != nil {
+		return err
+	}
+	*existingStatefulSet = updated
+	glog.Infof(
+		"STATEFULSET [%v]: stateful set updated to %d replicas",
+		statefulSet.Name,
+		statefulSet.Spec.Replicas,
+	)
+	return nil
+}
+
+// validateStatefulSet checks that each volume claim template of a stateful set names a volume
+// mounted by its pods.
+func validateStatefulSet(statefulSet *core.StatefulSet) error {
+	mounted := make(map[string]bool)
+	for _, c := range statefulSet.Spec.Template.Spec.Containers {
+		for _, m := range c.VolumeMounts {
+			mounted[m.Name] = true
+		}
+	}
+	for _, claim := range statefulSet.Spec.VolumeClaimTemplates {
+		if !mounted[claim] {
+			return fmt.Errorf("volume claim template %v of stateful set %v is not mounted", claim, statefulSet.Name)
+		}
+	}
+	return nil
+}
+
+// checkService warns if the service of a stateful set cannot give its pods DNS names. The service
+// may still be created later.
+func (m *basicController) checkService(statefulSet *core.StatefulSet) {
+	serviceName := statefulSet.Spec.ServiceName
+	if serviceName == "" {
+		glog.Warningf("STATEFULSET [%v]: no service is given, the pods will not get DNS names", statefulSet.Name)
+		return
+	}
+	service := m.componentManager.GetServiceByName(serviceName)
+	if service == nil {
+		glog.Warningf("STATEFULSET [%v]: service [%v] does not exist yet", statefulSet.Name, serviceName)
+	} else if service.Spec.ClusterIP != core.ClusterIPNone {
+		glog.Warningf("STATEFULSET [%v]: service [%v] is not headless", statefulSet.Name, serviceName)
+	}
+}
+
+func sameClaimTemplates(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *basicController) DeleteStatefulSetByName(name string) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	statefulSet := m.componentManager.GetStatefulSetByName(name)
+	if statefulSet == nil {
+		return fmt.Errorf("no such stateful set: %v", name)
+	}
+
+	glog.Infof("STATEFULSET [%v]: deleting", name)
+	pods := podsByOrdinal(name, m.componentManager.ListPodsByStatefulSetName(name))
+	ordinals := sortedOrdinals(pods)
+	for i := len(ordinals) - 1; i >= 0; i-- {
+		podName := pods[ordinals[i]].Name
+		if err := m.deletePod(podName); err != nil {
+			glog.Errorf("STATEFULSET [%v]: unable to delete pod [%v]: %v", name, podName, err.Error())
+			continue
+		}
+		glog.Infof("STATEFULSET [%v]: deleted pod [%v]", name, podName)
+	}
+	if err := etcd.Delete(fmt.Sprintf("/StatefulSets/%s", name)); err != nil {
+		return err
+	}
+	m.componentManager.DeleteStatefulSetByName(name)
+	glog.Infof("STATEFULSET [%v]: stateful set deleted, volume claims are kept", name)
+	return nil
+}
+
+func (m *basicController) DeleteAllStatefulSets() error {
+	for _, statefulSet := range m.componentManager.ListStatefulSets() {
+		if err := m.DeleteStatefulSetByName(statefulSet.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *basicController) HandleEvent(event apiserver.Event) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	switch event.Type() {
+	case apiserver.PodDeletion:
+		delete(m.terminatingPods, event.(*apiserver.PodDeletionEvent).Pod.Name)
+	case apiserver.PodFail:
+		podName := event.(*apiserver.PodFailEvent).PodName
+		pod := m.componentManager.GetPodByName(podName)
+		if pod == nil {
+			return
+		}
+		statefulSet := m.componentManager.GetStatefulSetByName(pod.Labels[core.StatefulSetLabel])
+		if statefulSet == nil {
+			return
+		}
+		// The pod is created again with the same name once its deletion is confirmed.
+		if err := m.deletePod(podName); err != nil {
+			glog.Errorf("STATEFULSET [%v]: failed to delete failed pod [%v]: %v", statefulSet.Name, podName, err)
+			return
+		}
+		glog.Infof("STATEFULSET [%v]: deleted failed pod [%v]", statefulSet.Name, podName)
+	}
+}
+
+func (m *basicController) monitorStatefulSets() {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	for _, statefulSet := range m.componentManager.ListStatefulSets() {
+		pods := podsByOrdinal(statefulSet.Name, m.componentManager.ListPodsByStatefulSetName(statefulSet.Name))
+		next := action{kind: actionNone}
+		if !m.hasTerminatingPods(statefulSet) {
+			next = nextAction(statefulSet.Spec.Replicas, pods)
+		}
+		switch next.kind {
+		case actionCreate:
+			if err := m.createPod(statefulSet, next.ordinal); err != nil {
+				glog.Errorf(
+					"STATEFULSET [%v]: failed to create pod [%v]: %v",
+					statefulSet.Name,
+					podName(statefulSet.Name, next.ordinal),
+					err,
+				)
+			}
+		case actionDelete:
+			name := pods[next.ordinal].Name
+			if err := m.deletePod(name); err != nil {
+				glog.Errorf("STATEFULSET [%v]: failed to delete pod [%v]: %v", statefulSet.Name, name, err)
+			} else {
+				glog.Infof("STATEFULSET [%v]: deleted pod [%v]", statefulSet.Name, name)
+			}
+		}
+		m.refreshStatus(statefulSet)
+	}
+}
+
+// deletePod deletes a pod of a stateful set, and remembers it until the kubelet confirms its
+// deletion. The caller must hold the lock.
+func (m *basicController) deletePod(name string) error {
+	if err := m.podController.DeletePodByName(name); err != nil {
+		return err
+	}
+	m.terminatingPods[name] = true
+	return nil
+}
+
+// hasTerminatingPods tells whether some deleted pod of a stateful set may still be running. The
+// caller must hold the lock.
+func (m *basicController) hasTerminatingPods(statefulSet *core.StatefulSet) bool {
+	for name := range m.terminatingPods {
+		if _, ok := podOrdinal(statefulSet.Name, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// createPod creates the pod of an ordinal of a stateful set. A pod with volume claims runs on the
+// node holding them, and the node of a new claim is recorded. The caller must hold the lock.
+func (m *basicController) createPod(statefulSet *core.StatefulSet, ordinal int) error {
+	p := &core.Pod{Kind: core.PodType}
+	p.Name = podName(statefulSet.Name, ordinal)
+	p.Labels = podLabels(statefulSet)
+	p.Spec = statefulSet.Spec.Template.Spec
+	if len(statefulSet.Spec.VolumeClaimTemplates) > 0 {
+		p.Spec.VolumeClaims = make(map[string]string, len(statefulSet.Spec.VolumeClaimTemplates))
+		for _, claim := range statefulSet.Spec.VolumeClaimTemplates {
+			p.Spec.VolumeClaims[claim] = claimName(claim, p.Name)
+		}
+		if nodeName, ok := statefulSet.Status.ClaimNodes[p.Name]; ok {
+			p.Spec.NodeName = nodeName
+		}
+	}
+
+	if err := m.podController.CreatePod(p); err != nil {
+		return err
+	}
+	glog.Infof("STATEFULSET [%v]: added pod [%v]", statefulSet.Name, p.Name)
+
+	if len(p.Spec.VolumeClaims) == 0 || p.Spec.NodeName != "" {
+		return nil
+	}
+	node := m.nodeManager.NodeByIP(p.Status.HostIP)
+	if node == nil {
+		return fmt.Errorf("unknown node of pod %v: %v", p.Name, p.Status.HostIP)
+	}
+	if statefulSet.Status.ClaimNodes == nil {
+		statefulSet.Status.ClaimNodes = map[string]string{}
+	}
+	statefulSet.Status.ClaimNodes[p.Name] = node.Name
+	return setStatefulSetInEtcd(statefulSet)
+}
+
+// refreshStatus counts the pods of a stateful set, and persists its status if it changes. The
+// caller must hold the lock.
+func (m *basicController) refreshStatus(statefulSet *core.StatefulSet) {
+	pods := m.componentManager.ListPodsByStatefulSetName(statefulSet.Name)
+	var replicas, readyReplicas uint32 = uint32(pods.Len()), 0
+	for it := pods.Front(); it != nil; it = it.Next() {
+		if it.Value.(*core.Pod).Status.Phase == core.PodReady {
+			readyReplicas++
+		}
+	}
+	if statefulSet.Status.Replicas == replicas && statefulSet.Status.ReadyReplicas == readyReplicas {
+		return
+	}
+	statefulSet.Status.Replicas = replicas
+	statefulSet.Status.ReadyReplicas = readyReplicas
+	if err := setStatefulSetInEtcd(statefulSet); err != nil {
+		glog.Errorf("failed to update stateful set's metadata: %v", err)
+	}
+}
+
+// nextAction tells the next step of a stateful set with pods indexed by ordinal towards replicas
+// pods. The lowest missing pod is created once all the pods below it are ready. Once all the
+// wanted pods are ready, the surplus ones are deleted, the highest ordinal first.
+func nextAction(replicas uint32, pods map[int]*core.Pod) action {
+	for i := 0; i < int(replicas); i++ {
+		pod, ok := pods[i]
+		if !ok {
+			return action{kind: actionCreate, ordinal: i}
+		}
+		if pod.Status.Phase != core.PodReady {
+			return action{kind: actionNone, ordinal: i}
+		}
+	}
+	highest := -1
+	for ordinal := range pods {
+		if ordinal >= int(replicas) && ordinal > highest {
+			highest = ordinal
+		}
+	}
+	if highest >= 0 {
+		return action{kind: actionDelete, ordinal: highest}
+	}
+	return action{kind: actionNone}
+}
+
+// podsByOrdinal indexes the pods of a stateful set by ordinal. Pods whose names carry no ordinal
+// are left out.
+func podsByOrdinal(statefulSetName string, pods *list.List) map[int]*core.Pod {
+	indexed := make(map[int]*core.Pod, pods.Len())
+	for it := pods.Front(); it != nil; it = it.Next() {
+		pod := it.Value.(*core.Pod)
+		if ordinal, ok := podOrdinal(statefulSetName, pod.Name); ok {
+			indexed[ordinal] = pod
+		}
+	}
+	return indexed
+}
+
+func sortedOrdinals(pods map[int]*core.Pod) []int {
+	ordinals := make([]int, 0, len(pods))
+	for ordinal := range pods {
+		ordinals = append(ordinals, ordinal)
+	}
+	sort.Ints(ordinals)
+	return ordinals
+}
+
+// podOrdinal parses the ordinal from the name of a pod of a stateful set.
+func podOrdinal(statefulSetName string, podName string) (int, bool) {
+	prefix := statefulSetName + "-"
+	if !strings.HasPrefix(podName, prefix) {
+		return 0, false
+	}
+	suffix := podName[len(prefix):]
+	ordinal, err := strconv.Atoi(suffix)
+	if err != nil || ordinal < 0 || strconv.Itoa(ordinal) != suffix {
+		return 0, false
+	}
+	return ordinal, true
+}
+
+func podName(statefulSetName string, ordinal int) string {
+	return fmt.Sprintf("%v-%v", statefulSetName, ordinal)
+}
+
+// claimName is the name of the volume a pod claims from a volume claim template.
+func claimName(claim string, podName string) string {
+	return fmt.Sprintf("%v-%v", claim, podName)
+}
+
+// podLabels returns the labels of a new pod of a stateful set, which are those of the template
+// along with the name of the stateful set.
+func podLabels(statefulSet *core.StatefulSet) map[string]string {
+	labels := make(map[string]string, len(statefulSet.Spec.Template.Labels)+1)
+	for k, v := range statefulSet.Spec.Template.Labels {
+		labels[k] = v
+	}
+	labels[core.StatefulSetLabel] = statefulSet.Name
+	return labels
+}
+
+func setStatefulSetInEtcd(statefulSet *core.StatefulSet) error {
+	return etcd.Put(fmt.Sprintf("/StatefulSets/%s", statefulSet.Name), statefulSet)
+}
@@ -0,0 +1,85 @@
+package statefulset
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"p9t.io/kuberboat/pkg/api/core"
+	"p9t.io/kuberboat/pkg/apiserver"
+	"p9t.io/kuberboat/pkg/apiserver/pod"
+)
+
+// fakePodController records the pods created and deleted instead of running them.
+type fakePodController struct {
+	pod.Controller
+	created []string
+	deleted []string
+}
+
+func (fc *fakePodController) CreatePod(pod *core.Pod) error {
+	fc.created = append(fc.created, pod.Name)
+	return nil
+}
+
+func (fc *fakePodController) DeletePodByName(name string) error {
+	fc.deleted = append(fc.deleted, name)
+	return nil
+}
+
+func TestPodOrdinal(t *testing.T) {
+	ordinal, ok := podOrdinal("web", "web-12")
+	assert.True(t, ok)
+	assert.Equal(t, 12, ordinal)
+
+	for _, name := range []string{"web-", "web-01", "web--1", "web-a", "webs-1", "web-0-1"} {
+		_, ok := podOrdinal("web", name)
+		assert.False(t, ok, name)
+	}
+}
+
+func TestNextAction(t *testing.T) {
+	// Pods are created one at a time, each waiting for the ones below it to be ready.
+	pods := map[int]*core.Pod{}
+	assert.Equal(t, action{kind: actionCreate, ordinal: 0}, nextAction(3, pods))
+	pods[0] = &core.Pod{ObjectMeta: core.ObjectMeta{Name: "web-0"}, Status: core.PodStatus{Phase: core.PodPending}}
+	assert.Equal(t, action{kind: actionNone, ordinal: 0}, nextAction(3, pods))
+	pods[0].Status.Phase = core.PodReady
+	assert.Equal(t, action{kind: actionCreate, ordinal: 1}, nextAction(3, pods))
+
+	// A missing pod in the middle is created again before going on.
+	pods[2] = &core.Pod{ObjectMeta: core.ObjectMeta{Name: "web-2"}, Status: core.PodStatus{Phase: core.PodReady}}
+	assert.Equal(t, action{kind: actionCreate, ordinal: 1}, nextAction(3, pods))
+
+	// Surplus pods are deleted the highest first, once the wanted ones are ready.
+	pods[1] = &core.Pod{ObjectMeta: core.ObjectMeta{Name: "web-1"}, Status: core.PodStatus{Phase: core.PodPending}}
+	pods[3] = &core.Pod{ObjectMeta: core.ObjectMeta{Name: "web-3"}, Status: core.PodStatus{Phase: core.PodReady}}
+	assert.Equal(t, action{kind: actionNone, ordinal: 1}, nextAction(2, pods))
+	pods[1].Status.Phase = core.PodReady
+	assert.Equal(t, action{kind: actionDelete, ordinal: 3}, nextAction(2, pods))
+	assert.Equal(t, action{kind: actionNone}, nextAction(4, pods))
+}
+
+func TestMonitorWaitsForConfirmedDeletion(t *testing.T) {
+	cm := apiserver.NewComponentManager()
+	pc := &fakePodController{}
+	m := &basicController{componentManager: cm, podController: pc, terminatingPods: map[string]bool{}}
+	statefulSet := &core.StatefulSet{}
+	statefulSet.Name = "web"
+	statefulSet.Spec.Replicas = 1
+	cm.SetStatefulSet(statefulSet)
+	failedPod := &core.Pod{}
+	failedPod.Name = "web-0"
+	failedPod.Labels = map[string]string{core.StatefulSetLabel: "web"}
+	cm.SetPod(failedPod)
+
+	// The failed pod is deleted, but is not created again while its containers may still run.
+	m.HandleEvent(&apiserver.PodFailEvent{PodName: "web-0"})
+	assert.Equal(t, []string{"web-0"}, pc.deleted)
+	cm.DeletePodByName("web-0")
+	m.monitorStatefulSets()
+	assert.Empty(t, pc.created)
+
+	m.HandleEvent(&apiserver.PodDeletionEvent{Pod: failedPod})
+	m.monitorStatefulSets()
+	assert.Equal(t, []string{"web-0"}, pc.created)
+}
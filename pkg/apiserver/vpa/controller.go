@@ -0,0 +1,342 @@
+package vpa
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"p9t.io/kuberboat/pkg/api/core"
+	"p9t.io/kuberboat/pkg/apiserver"
+	"p9t.io/kuberboat/pkg/apiserver/scale"
+)
+
+const (
+	// DefaultSampleInterval is the default interval in seconds between two samples of usage.
+	DefaultSampleInterval int64 = 60
+	// MinSampleInterval is the minimal interval in seconds between two samples of usage.
+	MinSampleInterval int64 = 10
+	// minSamplesToApply is the number of samples of each container needed before the
+	// recommendations are applied.
+	minSamplesToApply = 10
+	// minApplyInterval is the minimal time between two rolling updates applying recommendations.
+	minApplyInterval = 10 * time.Minute
+)
+
+type Controller interface {
+	// ApplyVerticalAutoscaler creates a vertical autoscaler, or replaces the one of the same name.
+	// The usage history is kept if the target stays the same.
+	ApplyVerticalAutoscaler(autoscaler *core.VerticalPodAutoscaler) error
+	// DeleteVerticalAutoscalerByName deletes a vertical autoscaler along with its usage history.
+	DeleteVerticalAutoscalerByName(name string) error
+	// DescribeVerticalAutoscalers returns information about vertical autoscalers specified by
+	// names. Return value is composed of vertical autoscalers that are found and names of those
+	// that do not exist.
+	DescribeVerticalAutoscalers(all bool, names []string) ([]*core.VerticalPodAutoscaler, []string)
+}
+
+// DeploymentApplier updates the template of a deployment, as the deployment controller does.
+type DeploymentApplier interface {
+	// ApplyDeployment replaces a deployment, rolling its pods if the template changes.
+	ApplyDeployment(deployment *core.Deployment) error
+}
+
+type basicController struct {
+	mtx                  sync.Mutex
+	componentManager     apiserver.ComponentManager
+	deploymentController DeploymentApplier
+	metricsSource        scale.MetricsSource
+	// containerStates are the usage histories of the containers of the target of each vertical
+	// autoscaler, indexed by the name of the autoscaler and then the name of the container.
+	containerStates map[string]map[string]*containerState
+	// stopChs are closed to stop the recommenders of vertical autoscalers, indexed by name.
+	stopChs map[string]chan struct{}
+	// now returns the current time. It is replaced in tests.
+	now func() time.Time
+}
+
+func NewVerticalAutoscalerController(
+	componentManager apiserver.ComponentManager,
+	deploymentController DeploymentApplier,
+	metricsSource scale.MetricsSource,
+) Controller {
+	return &basicController{
+		componentManager:     componentManager,
+		deploymentController: deploymentController,
+		metricsSource:        metricsSource,
+		containerStates:      map[string]map[string]*containerState{},
+		stopChs:              map[string]chan struct{}{},
+		now:                  time.Now,
+	}
+}
+
+func (bc *basicController) ApplyVerticalAutoscaler(autoscaler *core.VerticalPodAutoscaler) error {
+	bc.mtx.Lock()
+	defer bc.mtx.Unlock()
+
+	if autoscaler.Name == "" {
+		return fmt.Errorf("name of vertical autoscaler not specified")
+	}
+	if autoscaler.Spec.TargetRef.Kind != core.DeploymentType {
+		return fmt.Errorf("target of vertical autoscaler must be deployment")
+	}
+	switch autoscaler.Spec.UpdateMode {
+	case "":
+		autoscaler.Spec.UpdateMode = core.VerticalUpdateOff
+	case core.VerticalUpdateOff, core.VerticalUpdateAuto:
+	default:
+		return fmt.Errorf("unsupported update mode of vertical autoscaler: %v", autoscaler.Spec.UpdateMode)
+	}
+	if autoscaler.Spec.SampleInterval == 0 {
+		autoscaler.Spec.SampleInterval = DefaultSampleInterval
+	}
+	if autoscaler.Spec.SampleInterval < MinSampleInterval {
+		return fmt.Errorf("sample interval cannot be less than %vs", MinSampleInterval)
+	}
+	for _, policy := range autoscaler.Spec.ResourcePolicies {
+		for name, min := range policy.MinAllowed {
+			if max, ok := policy.MaxAllowed[name]; ok && min.Cmp(max) > 0 {
+				return fmt.Errorf("min allowed %v of container %v exceeds max allowed", name, policy.ContainerName)
+			}
+		}
+	}
+
+	deploymentName := autoscaler.Spec.TargetRef.Name
+	if !bc.componentManager.DeploymentExistsByName(deploymentName) {
+		return fmt.Errorf("no such deployment to be monitored by vertical autoscaler: %v", deploymentName)
+	}
+	for _, other := range bc.componentManager.ListVerticalAutoscalers() {
+		if other.Name != autoscaler.Name && other.Spec.TargetRef.Name == deploymentName {
+			return fmt.Errorf("deployment %v already monitored by vertical autoscaler %v", deploymentName, other.Name)
+		}
+	}
+
+	oldAutoscaler := bc.componentManager.GetVerticalAutoscalerByName(autoscaler.Name)
+	if oldAutoscaler != nil {
+		if stopCh, ok := bc.stopChs[autoscaler.Name]; ok {
+			close(stopCh)
+		}
+		autoscaler.CreationTimestamp = oldAutoscaler.CreationTimestamp
+		if oldAutoscaler.Spec.TargetRef.Name == deploymentName {
+			autoscaler.Status = oldAutoscaler.Status
+		} else {
+			delete(bc.containerStates, autoscaler.Name)
+		}
+	} else {
+		autoscaler.CreationTimestamp = bc.now()
+	}
+	if _, ok := bc.containerStates[autoscaler.Name]; !ok {
+		bc.containerStates[autoscaler.Name] = map[string]*containerState{}
+	}
+	bc.componentManager.SetVerticalAutoscaler(autoscaler)
+
+	stopCh := make(chan struct{})
+	bc.stopChs[autoscaler.Name] = stopCh
+	go bc.runRecommender(autoscaler, stopCh)
+
+	glog.Infof(
+		"VERTICAL AUTOSCALER [%v]: applied on deployment %v in %v mode",
+		autoscaler.Name,
+		deploymentName,
+		autoscaler.Spec.UpdateMode,
+	)
+	return nil
+}
+
+// runRecommender samples the usage of the target of a vertical autoscaler and updates its
+// recommendations every sample interval, until stopCh is closed or the target is gone.
+func (bc *basicController) runRecommender(autoscaler *core.VerticalPodAutoscaler, stopCh chan struct{}) {
+	ticker := time.NewTicker(time.Second * time.Duration(autoscaler.Spec.SampleInterval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+		}
+
+		bc.mtx.Lock()
+		select {
+		case <-stopCh:
+			// Replaced or deleted while the ticker fired.
+			bc.mtx.Unlock()
+			return
+		default:
+		}
+		deploymentName := autoscaler.Spec.TargetRef.Name
+		if !bc.componentManager.DeploymentExistsByName(deploymentName) {
+			// Deployment does not exist. Just delete the vertical autoscaler.
+			bc.deleteVerticalAutoscaler(autoscaler.Name)
+			bc.mtx.Unlock()
+			glog.Infof(
+				"VERTICAL AUTOSCALER [%v]: deleted since deployment %v is gone",
+				autoscaler.Name,
+				deploymentName,
+			)
+			return
+		}
+		bc.recommend(autoscaler, bc.componentManager.GetDeploymentByName(deploymentName))
+		bc.mtx.Unlock()
+	}
+}
+
+// recommend samples the usage of the ready pods of a deployment, updates the recommendations of
+// a vertical autoscaler, and applies them if it should. The caller must hold the lock.
+func (bc *basicController) recommend(autoscaler *core.VerticalPodAutoscaler, deployment *core.Deployment) {
+	now := bc.now()
+	states := bc.containerStates[autoscaler.Name]
+	template := &deployment.Spec.Template.Spec
+
+	pods := bc.componentManager.ListPodsByDeploymentName(deployment.Name)
+	for it := pods.Front(); it != nil; it = it.Next() {
+		pod := it.Value.(*core.Pod)
+		if pod.Status.Phase != core.PodReady {
+			continue
+		}
+		usage, err := bc.metricsSource.ContainerUsage(pod)
+		if err != nil {
+			// The pod may have just started or stopped. It is sampled again next time.
+			glog.Warning(err)
+			continue
+		}
+		for _, container := range usage {
+			state, ok := states[container.Name]
+			if !ok {
+				state = newContainerState()
+				states[container.Name] = state
+			}
+			state.addSample(container.Usage, now)
+		}
+	}
+
+	recommendations := make([]core.ContainerRecommendation, 0, len(template.Containers))
+	for i := range template.Containers {
+		container := &template.Containers[i]
+		state, ok := states[container.Name]
+		if !ok {
+			continue
+		}
+		recommendations = append(recommendations, state.recommend(container, policyOf(autoscaler, container.Name)))
+	}
+	if len(recommendations) == 0 {
+		return
+	}
+	autoscaler.Status.Recommendations = recommendations
+	autoscaler.Status.LastUpdateTime = &now
+
+	if autoscaler.Spec.UpdateMode == core.VerticalUpdateAuto && bc.shouldApply(autoscaler, deployment, now) {
+		bc.applyRecommendations(autoscaler, deployment, now)
+	}
+}
+
+// policyOf returns the resource policy of a container, or nil if there is none.
+func policyOf(autoscaler *core.VerticalPodAutoscaler, containerName string) *core.ContainerResourcePolicy {
+	for i := range autoscaler.Spec.ResourcePolicies {
+		if autoscaler.Spec.ResourcePolicies[i].ContainerName == containerName {
+			return &autoscaler.Spec.ResourcePolicies[i]
+		}
+	}
+	return nil
+}
+
+// shouldApply tells whether the recommendations are worth a rolling update: every container has
+// enough samples, the last update is long enough ago, and some requests are out of bounds.
+func (bc *basicController) shouldApply(
+	autoscaler *core.VerticalPodAutoscaler,
+	deployment *core.Deployment,
+	now time.Time,
+) bool {
+	template := &deployment.Spec.Template.Spec
+	if len(autoscaler.Status.Recommendations) != len(template.Containers) {
+		return false
+	}
+	if autoscaler.Status.LastApplyTime != nil && now.Sub(*autoscaler.Status.LastApplyTime) < minApplyInterval {
+		return false
+	}
+	outOfBounds := false
+	for i := range autoscaler.Status.Recommendations {
+		recommendation := &autoscaler.Status.Recommendations[i]
+		if recommendation.Samples < minSamplesToApply {
+			return false
+		}
+		if needsUpdate(&template.Containers[i], recommendation) {
+			outOfBounds = true
+		}
+	}
+	return outOfBounds
+}
+
+// applyRecommendations sets the resources of the template of a deployment to the recommended
+// ones, which rolls the pods of the deployment.
+func (bc *basicController) applyRecommendations(
+	autoscaler *core.VerticalPodAutoscaler,
+	deployment *core.Deployment,
+	now time.Time,
+) {
+	updated := *deployment
+	containers := make([]core.Container, len(deployment.Spec.Template.Spec.Containers))
+	copy(containers, deployment.Spec.Template.Spec.Containers)
+	for i := range containers {
+		applyRecommendation(&containers[i], &autoscaler.Status.Recommendations[i])
+		if err := core.ValidateResources(&containers[i]); err != nil {
+			glog.Errorf("VERTICAL AUTOSCALER [%v]: cannot apply recommendations: %v", autoscaler.Name, err)
+			return
+		}
+	}
+	updated.Spec.Template.Spec.Containers = containers
+
+	if err := bc.deploymentController.ApplyDeployment(&updated); err != nil {
+		glog.Errorf("VERTICAL AUTOSCALER [%v]: cannot apply recommendations: %v", autoscaler.Name, err)
+		return
+	}
+	autoscaler.Status.LastApplyTime = &now
+	glog.Infof(
+		"VERTICAL AUTOSCALER [%v]: recommendations applied to deployment %v: %v",
+		autoscaler.Name,
+		deployment.Name,
+		autoscaler.Status.Recommendations,
+	)
+}
+
+// deleteVerticalAutoscaler stops a vertical autoscaler and forgets it. The caller must hold the
+// lock.
+func (bc *basicController) deleteVerticalAutoscaler(name string) {
+	if stopCh, ok := bc.stopChs[name]; ok {
+		close(stopCh)
+		delete(bc.stopChs, name)
+	}
+	delete(bc.containerStates, name)
+	bc.componentManager.DeleteVerticalAutoscalerByName(name)
+}
+
+func (bc *basicController) DeleteVerticalAutoscalerByName(name string) error {
+	bc.mtx.Lock()
+	defer bc.mtx.Unlock()
+
+	if bc.componentManager.GetVerticalAutoscalerByName(name) == nil {
+		return fmt.Errorf("no such vertical autoscaler: %v", name)
+	}
+	bc.deleteVerticalAutoscaler(name)
+	glog.Infof("VERTICAL AUTOSCALER [%v]: deleted", name)
+	return nil
+}
+
+func (bc *basicController) DescribeVerticalAutoscalers(all bool, names []string) (
+	[]*core.VerticalPodAutoscaler,
+	[]string,
+) {
+	if all {
+		return bc.componentManager.ListVerticalAutoscalers(), []string{}
+	}
+	found := make([]*core.VerticalPodAutoscaler, 0, len(names))
+	notFound := make([]string, 0)
+	for _, name := range names {
+		autoscaler := bc.componentManager.GetVerticalAutoscalerByName(name)
+		if autoscaler == nil {
+			notFound = append(notFound, name)
+			continue
+		}
+		found = append(found, autoscaler)
+	}
+	return found, notFound
+}
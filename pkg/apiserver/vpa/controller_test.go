@@ -0,0 +1,118 @@
+package vpa
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"p9t.io/kuberboat/pkg/api/core"
+	"p9t.io/kuberboat/pkg/apiserver"
+	"p9t.io/kuberboat/pkg/apiserver/internal/apiservertest"
+	"p9t.io/kuberboat/pkg/apiserver/scale"
+)
+
+// fakeDeploymentApplier records the deployments applied instead of rolling them.
+type fakeDeploymentApplier struct {
+	applied []*core.Deployment
+}
+
+func (fa *fakeDeploymentApplier) ApplyDeployment(deployment *core.Deployment) error {
+	fa.applied = append(fa.applied, deployment)
+	return nil
+}
+
+// testResources request far less than the half a core and 200MB of memory the test container uses.
+var testResources = core.ResourceRequirements{
+	Requests: core.ResourceList{
+		core.ResourceCPU:    core.MustParseQuantity("100m"),
+		core.ResourceMemory: core.MustParseQuantity("64Mi"),
+	},
+	Limits: core.ResourceList{
+		core.ResourceCPU:    core.MustParseQuantity("200m"),
+		core.ResourceMemory: core.MustParseQuantity("128Mi"),
+	},
+}
+
+func TestRecommendOffModeOnlyPublishes(t *testing.T) {
+	componentManager := apiserver.NewComponentManager()
+	metricsSource := scale.NewFakeMetricsSource()
+	applier := &fakeDeploymentApplier{}
+	controller := NewVerticalAutoscalerController(componentManager, applier, metricsSource).(*basicController)
+
+	deployment := apiservertest.NewDeployment("nginx:1.22")
+	deployment.Spec.Replicas = 1
+	deployment.Spec.Template.Spec.Containers[0].Resources = testResources
+	apiservertest.SetDeployment(componentManager, deployment)
+	metricsSource.SetContainerUsage("test-pod-0", "test-container", 0.5, 200*1000*1000)
+	autoscaler := &core.VerticalPodAutoscaler{
+		ObjectMeta: core.ObjectMeta{Name: "test-vertical-autoscaler"},
+		Spec: core.VerticalAutoscalerSpec{
+			TargetRef:      core.ScaleTarget{Kind: core.DeploymentType, Name: deployment.Name},
+			UpdateMode:     core.VerticalUpdateOff,
+			SampleInterval: 3600,
+		},
+	}
+	assert.Nil(t, controller.ApplyVerticalAutoscaler(autoscaler))
+	for i := 0; i < 2*minSamplesToApply; i++ {
+		controller.recommend(autoscaler, deployment)
+	}
+
+	assert.Equal(t, 1, len(autoscaler.Status.Recommendations))
+	recommendation := autoscaler.Status.Recommendations[0]
+	assert.Equal(t, "test-container", recommendation.ContainerName)
+	assert.Equal(t, 2*minSamplesToApply, recommendation.Samples)
+	target := recommendation.Target[core.ResourceCPU]
+	assert.Greater(t, target.MilliValue(), int64(500))
+	assert.Equal(t, 0, len(applier.applied))
+
+	assert.Nil(t, controller.DeleteVerticalAutoscalerByName(autoscaler.Name))
+	assert.NotNil(t, controller.DeleteVerticalAutoscalerByName(autoscaler.Name))
+}
+
+func TestRecommendAutoModeAppliesAfterEnoughSamples(t *testing.T) {
+	componentManager := apiserver.NewComponentManager()
+	metricsSource := scale.NewFakeMetricsSource()
+	applier := &fakeDeploymentApplier{}
+	controller := NewVerticalAutoscalerController(componentManager, applier, metricsSource).(*basicController)
+	now := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	controller.now = func() time.Time { return now }
+
+	deployment := apiservertest.NewDeployment("nginx:1.22")
+	deployment.Spec.Replicas = 1
+	deployment.Spec.Template.Spec.Containers[0].Resources = testResources
+	apiservertest.SetDeployment(componentManager, deployment)
+	metricsSource.SetContainerUsage("test-pod-0", "test-container", 0.5, 200*1000*1000)
+	autoscaler := &core.VerticalPodAutoscaler{
+		ObjectMeta: core.ObjectMeta{Name: "test-vertical-autoscaler"},
+		Spec: core.VerticalAutoscalerSpec{
+			TargetRef:      core.ScaleTarget{Kind: core.DeploymentType, Name: deployment.Name},
+			UpdateMode:     core.VerticalUpdateAuto,
+			SampleInterval: 3600,
+		},
+	}
+	autoscaler.Spec.ResourcePolicies = []core.ContainerResourcePolicy{{
+		ContainerName: "test-container",
+		MaxAllowed:    core.ResourceList{core.ResourceMemory: core.MustParseQuantity("128Mi")},
+	}}
+	assert.Nil(t, controller.ApplyVerticalAutoscaler(autoscaler))
+	for i := 0; i < minSamplesToApply-1; i++ {
+		controller.recommend(autoscaler, deployment)
+	}
+	assert.Equal(t, 0, len(applier.applied))
+
+	controller.recommend(autoscaler, deployment)
+	assert.Equal(t, 1, len(applier.applied))
+	assert.Equal(t, now, *autoscaler.Status.LastApplyTime)
+	resources := applier.applied[0].Spec.Template.Spec.Containers[0].Resources
+	request := resources.Requests[core.ResourceCPU]
+	limit := resources.Limits[core.ResourceCPU]
+	assert.Greater(t, request.MilliValue(), int64(500))
+	assert.Equal(t, 2*request.MilliValue(), limit.MilliValue())
+	assert.Equal(t, core.MustParseQuantity("128Mi"), resources.Requests[core.ResourceMemory])
+	// The template of the deployment itself is left to the deployment controller.
+	assert.Equal(t, core.MustParseQuantity("100m"), deployment.Spec.Template.Spec.Containers[0].Resources.Requests[core.ResourceCPU])
+
+	// Another update is not rolled out right after the last one.
+	controller.recommend(autoscaler, deployment)
+	assert.Equal(t, 1, len(applier.applied))
+}
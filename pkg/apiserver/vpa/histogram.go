@@ -0,0 +1,126 @@
+package vpa
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// halfLife is the time after which a sample weighs half as much as a new one.
+	halfLife = 24 * time.Hour
+	// maxDecayExponent bounds the exponent of the weights of new samples. Beyond it the weights
+	// are scaled down and the reference time moved forward, so that they do not overflow.
+	maxDecayExponent = 100
+)
+
+// histogramOptions are the buckets of a histogram. The first bucket holds the values below
+// firstBucketSize, and each following bucket is ratio times as large as the one before.
+type histogramOptions struct {
+	firstBucketSize float64
+	ratio           float64
+	numBuckets      int
+}
+
+// newHistogramOptions creates buckets that cover the values up to maxValue.
+func newHistogramOptions(firstBucketSize float64, ratio float64, maxValue float64) histogramOptions {
+	numBuckets := int(math.Ceil(math.Log(maxValue*(ratio-1)/firstBucketSize+1)/math.Log(ratio))) + 1
+	return histogramOptions{firstBucketSize: firstBucketSize, ratio: ratio, numBuckets: numBuckets}
+}
+
+var (
+	// cpuHistogramOptions cover 10 millicores to 1000 cores in steps of 5%.
+	cpuHistogramOptions = newHistogramOptions(0.01, 1.05, 1000)
+	// memoryHistogramOptions cover 10MB to 1TB in steps of 5%.
+	memoryHistogramOptions = newHistogramOptions(1e7, 1.05, 1e12)
+)
+
+// bucketStart returns the smallest value of a bucket.
+func (o histogramOptions) bucketStart(bucket int) float64 {
+	if bucket == 0 {
+		return 0
+	}
+	return o.firstBucketSize * (math.Pow(o.ratio, float64(bucket)) - 1) / (o.ratio - 1)
+}
+
+// findBucket returns the bucket of a value.
+func (o histogramOptions) findBucket(value float64) int {
+	if value < o.firstBucketSize {
+		return 0
+	}
+	bucket := int(math.Log(value*(o.ratio-1)/o.firstBucketSize+1) / math.Log(o.ratio))
+	if bucket >= o.numBuckets {
+		return o.numBuckets - 1
+	}
+	// Guard against rounding errors of the logarithm.
+	if value < o.bucketStart(bucket) {
+		bucket--
+	} else if bucket+1 < o.numBuckets && value >= o.bucketStart(bucket+1) {
+		bucket++
+	}
+	return bucket
+}
+
+// decayingHistogram is a histogram of samples whose weights halve every halfLife, so that the
+// recent usage counts the most. Rather than decaying the old samples, it grows the weights of the
+// new ones exponentially with the time since referenceTime.
+type decayingHistogram struct {
+	options       histogramOptions
+	weights       []float64
+	totalWeight   float64
+	referenceTime time.Time
+}
+
+func newDecayingHistogram(options histogramOptions) *decayingHistogram {
+	return &decayingHistogram{
+		options: options,
+		weights: make([]float64, options.numBuckets),
+	}
+}
+
+// addSample adds a value sampled at time t with weight 1 at that time.
+func (h *decayingHistogram) addSample(value float64, t time.Time) {
+	if h.referenceTime.IsZero() {
+		h.referenceTime = t
+	}
+	exponent := float64(t.Sub(h.referenceTime)) / float64(halfLife)
+	if exponent > maxDecayExponent {
+		h.shiftReferenceTime(t)
+		exponent = 0
+	}
+	weight := math.Exp2(exponent)
+	h.weights[h.options.findBucket(value)] += weight
+	h.totalWeight += weight
+}
+
+// shiftReferenceTime moves the reference time to t, scaling the weights down accordingly.
+func (h *decayingHistogram) shiftReferenceTime(t time.Time) {
+	factor := math.Exp2(-float64(t.Sub(h.referenceTime)) / float64(halfLife))
+	h.totalWeight = 0
+	for i := range h.weights {
+		h.weights[i] *= factor
+		h.totalWeight += h.weights[i]
+	}
+	h.referenceTime = t
+}
+
+// isEmpty tells whether the histogram has no sample.
+func (h *decayingHistogram) isEmpty() bool {
+	return h.totalWeight == 0
+}
+
+// percentile returns the end of the bucket below which the given fraction of the weights lie. It
+// returns 0 for an empty histogram.
+func (h *decayingHistogram) percentile(fraction float64) float64 {
+	if h.isEmpty() {
+		return 0
+	}
+	threshold := fraction * h.totalWeight
+	var sum float64
+	for bucket, weight := range h.weights {
+		sum += weight
+		if sum >= threshold && weight > 0 {
+			return h.options.bucketStart(bucket + 1)
+		}
+	}
+	return h.options.bucketStart(h.options.numBuckets)
+}
@@ -0,0 +1,45 @@
+package vpa
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogramBuckets(t *testing.T) {
+	options := newHistogramOptions(1, 2, 100)
+	// Buckets start at 0, 1, 3, 7, 15, 31, 63 and 127.
+	assert.Equal(t, 8, options.numBuckets)
+	assert.Equal(t, 0, options.findBucket(0.5))
+	assert.Equal(t, 1, options.findBucket(1))
+	assert.Equal(t, 2, options.findBucket(3))
+	assert.Equal(t, 3, options.findBucket(7))
+	assert.Equal(t, 7, options.findBucket(1000))
+	assert.Equal(t, 15.0, options.bucketStart(4))
+}
+
+func TestDecayingHistogramPercentile(t *testing.T) {
+	histogram := newDecayingHistogram(newHistogramOptions(1, 2, 100))
+	assert.True(t, histogram.isEmpty())
+	assert.Equal(t, 0.0, histogram.percentile(0.9))
+
+	now := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 9; i++ {
+		histogram.addSample(2, now)
+	}
+	histogram.addSample(50, now)
+	assert.Equal(t, 3.0, histogram.percentile(0.9))
+	assert.Equal(t, 63.0, histogram.percentile(0.95))
+
+	// A day later a sample weighs twice as much, so 5 new samples outweigh the 9 old ones.
+	for i := 0; i < 5; i++ {
+		histogram.addSample(10, now.Add(halfLife))
+	}
+	assert.Equal(t, 15.0, histogram.percentile(0.5))
+
+	// Weights stay finite however long the histogram lives.
+	histogram.addSample(2, now.Add(200*halfLife))
+	assert.Equal(t, 3.0, histogram.percentile(0.5))
+	assert.False(t, histogram.isEmpty())
+}
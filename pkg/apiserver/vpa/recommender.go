@@ -0,0 +1,153 @@
+package vpa
+
+import (
+	"math"
+	"time"
+
+	"p9t.io/kuberboat/pkg/api/core"
+)
+
+const (
+	// The target requests cover this fraction of the usage, and the bounds beyond which the
+	// requests are worth changing cover the other two.
+	targetPercentile     = 0.9
+	lowerBoundPercentile = 0.5
+	upperBoundPercentile = 0.95
+	// safetyMargin is added to the usage when recommending, e.g. 0.15 recommends 15% more.
+	safetyMargin = 0.15
+)
+
+var (
+	// minRecommendation is the least recommended for each resource, whatever the usage.
+	minRecommendation = core.ResourceList{
+		core.ResourceCPU:    core.MustParseQuantity("10m"),
+		core.ResourceMemory: core.MustParseQuantity("16Mi"),
+	}
+	// recommendedResources are the resources that are recommended.
+	recommendedResources = []core.ResourceName{core.ResourceCPU, core.ResourceMemory}
+)
+
+// containerState is the usage history of a container of the target.
+type containerState struct {
+	cpu    *decayingHistogram
+	memory *decayingHistogram
+	// samples is the number of samples added.
+	samples int
+}
+
+func newContainerState() *containerState {
+	return &containerState{
+		cpu:    newDecayingHistogram(cpuHistogramOptions),
+		memory: newDecayingHistogram(memoryHistogramOptions),
+	}
+}
+
+// addSample adds the usage of the container sampled at time t.
+func (s *containerState) addSample(usage core.ResourceList, t time.Time) {
+	cpu := usage[core.ResourceCPU]
+	memory := usage[core.ResourceMemory]
+	s.cpu.addSample(float64(cpu.MilliValue())/1000, t)
+	s.memory.addSample(float64(memory.Value()), t)
+	s.samples++
+}
+
+// estimate returns the given percentile of the usage with the safety margin.
+func (s *containerState) estimate(fraction float64) core.ResourceList {
+	cpu := s.cpu.percentile(fraction) * (1 + safetyMargin)
+	memory := s.memory.percentile(fraction) * (1 + safetyMargin)
+	return core.ResourceList{
+		core.ResourceCPU:    core.NewMilliQuantity(int64(math.Ceil(cpu * 1000))),
+		core.ResourceMemory: core.NewMilliQuantity(int64(math.Ceil(memory)) * 1000),
+	}
+}
+
+// recommend computes the recommendation for a container from its usage history. The policy may be
+// nil.
+func (s *containerState) recommend(
+	container *core.Container,
+	policy *core.ContainerResourcePolicy,
+) core.ContainerRecommendation {
+	target := applyPolicy(s.estimate(targetPercentile), policy)
+	return core.ContainerRecommendation{
+		ContainerName: container.Name,
+		Target:        target,
+		Limits:        proportionalLimits(container, target),
+		LowerBound:    applyPolicy(s.estimate(lowerBoundPercentile), policy),
+		UpperBound:    applyPolicy(s.estimate(upperBoundPercentile), policy),
+		Samples:       s.samples,
+	}
+}
+
+// applyPolicy brings an estimate within the least recommended and the bounds of a policy.
+func applyPolicy(estimate core.ResourceList, policy *core.ContainerResourcePolicy) core.ResourceList {
+	for name, value := range estimate {
+		if min := minRecommendation[name]; value.Cmp(min) < 0 {
+			value = min
+		}
+		if policy != nil {
+			if min, ok := policy.MinAllowed[name]; ok && value.Cmp(min) < 0 {
+				value = min
+			}
+			if max, ok := policy.MaxAllowed[name]; ok && value.Cmp(max) > 0 {
+				value = max
+			}
+		}
+		estimate[name] = value
+	}
+	return estimate
+}
+
+// proportionalLimits scales the limits of a container so that their ratio to the requests stays
+// the same when the requests become target.
+func proportionalLimits(container *core.Container, target core.ResourceList) core.ResourceList {
+	limits := core.ResourceList{}
+	requests := core.ContainerRequests(container)
+	for _, name := range recommendedResources {
+		limit, ok := container.Resources.Limits[name]
+		request := requests[name]
+		if !ok || request.IsZero() {
+			continue
+		}
+		ratio := float64(limit.MilliValue()) / float64(request.MilliValue())
+		milli := int64(math.Ceil(float64(target[name].MilliValue()) * ratio))
+		if name == core.ResourceMemory {
+			// Memory is set in whole bytes.
+			milli = (milli + 999) / 1000 * 1000
+		}
+		limits[name] = core.NewMilliQuantity(milli)
+	}
+	return limits
+}
+
+// needsUpdate tells whether the requests of a container are out of the recommended bounds.
+func needsUpdate(container *core.Container, recommendation *core.ContainerRecommendation) bool {
+	requests := core.ContainerRequests(container)
+	for _, name := range recommendedResources {
+		request, ok := requests[name]
+		if !ok ||
+			request.Cmp(recommendation.LowerBound[name]) < 0 ||
+			request.Cmp(recommendation.UpperBound[name]) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// applyRecommendation sets the requests and limits of a container to the recommended ones.
+func applyRecommendation(container *core.Container, recommendation *core.ContainerRecommendation) {
+	requests := core.ResourceList{}
+	for name, request := range container.Resources.Requests {
+		requests[name] = request
+	}
+	limits := core.ResourceList{}
+	for name, limit := range container.Resources.Limits {
+		limits[name] = limit
+	}
+	for name, request := range recommendation.Target {
+		requests[name] = request
+	}
+	for name, limit := range recommendation.Limits {
+		limits[name] = limit
+	}
+	container.Resources = core.ResourceRequirements{Requests: requests, Limits: limits}
+}
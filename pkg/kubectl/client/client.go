@@ -104,6 +104,46 @@ func (c *ctlClient) DeleteDeployment(deploymentName string) (*pb.DefaultResponse
 	})
 }
 
+func (c *ctlClient) CreateReplicaSet(replicaSet *core.ReplicaSet) (*pb.DefaultResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CONN_TIMEOUT)
+	defer cancel()
+	data, err := json.Marshal(replicaSet)
+	if err != nil {
+		return &pb.DefaultResponse{Status: 1}, err
+	}
+	return c.client.CreateReplicaSet(ctx, &pb.CreateReplicaSetRequest{
+		ReplicaSet: data,
+	})
+}
+
+func (c *ctlClient) DeleteReplicaSet(replicaSetName string) (*pb.DefaultResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CONN_TIMEOUT)
+	defer cancel()
+	return c.client.DeleteReplicaSet(ctx, &pb.DeleteReplicaSetRequest{
+		ReplicaSetName: replicaSetName,
+	})
+}
+
+func (c *ctlClient) CreateStatefulSet(statefulSet *core.StatefulSet) (*pb.DefaultResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CONN_TIMEOUT)
+	defer cancel()
+	data, err := json.Marshal(statefulSet)
+	if err != nil {
+		return &pb.DefaultResponse{Status: 1}, err
+	}
+	return c.client.CreateStatefulSet(ctx, &pb.CreateStatefulSetRequest{
+		StatefulSet: data,
+	})
+}
+
+func (c *ctlClient) DeleteStatefulSet(statefulSetName string) (*pb.DefaultResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CONN_TIMEOUT)
+	defer cancel()
+	return c.client.DeleteStatefulSet(ctx, &pb.DeleteStatefulSetRequest{
+		StatefulSetName: statefulSetName,
+	})
+}
+
 func (c *ctlClient) CreateService(service *core.Service) (*pb.DefaultResponse, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), CONN_TIMEOUT)
 	defer cancel()
@@ -133,6 +173,88 @@ func (c *ctlClient) DescribeDeployments(all bool, names []string) (*pb.DescribeD
 	})
 }
 
+func (c *ctlClient) DescribeReplicaSets(all bool, names []string) (*pb.DescribeReplicaSetsResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CONN_TIMEOUT)
+	defer cancel()
+	return c.client.DescribeReplicaSets(ctx, &pb.DescribeReplicaSetsRequest{
+		All:             all,
+		ReplicaSetNames: names,
+	})
+}
+
+func (c *ctlClient) DescribeStatefulSets(all bool, names []string) (*pb.DescribeStatefulSetsResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CONN_TIMEOUT)
+	defer cancel()
+	return c.client.DescribeStatefulSets(ctx, &pb.DescribeStatefulSetsRequest{
+		All:              all,
+		StatefulSetNames: names,
+	})
+}
+
+func (c *ctlClient) DeploymentHistory(name string) (*pb.DeploymentHistoryResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CONN_TIMEOUT)
+	defer cancel()
+	return c.client.DeploymentHistory(ctx, &pb.DeploymentHistoryRequest{
+		DeploymentName: name,
+	})
+}
+
+func (c *ctlClient) RollbackDeployment(name string, toRevision uint64) (*pb.DefaultResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CONN_TIMEOUT)
+	defer cancel()
+	return c.client.RollbackDeployment(ctx, &pb.RollbackDeploymentRequest{
+		DeploymentName: name,
+		ToRevision:     toRevision,
+	})
+}
+
+func (c *ctlClient) PauseDeployment(name string) (*pb.DefaultResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CONN_TIMEOUT)
+	defer cancel()
+	return c.client.PauseDeployment(ctx, &pb.PauseDeploymentRequest{
+		DeploymentName: name,
+	})
+}
+
+func (c *ctlClient) ResumeDeployment(name string) (*pb.DefaultResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CONN_TIMEOUT)
+	defer cancel()
+	return c.client.ResumeDeployment(ctx, &pb.ResumeDeploymentRequest{
+		DeploymentName: name,
+	})
+}
+
+func (c *ctlClient) PromoteDeployment(name string) (*pb.DefaultResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CONN_TIMEOUT)
+	defer cancel()
+	return c.client.PromoteDeployment(ctx, &pb.PromoteDeploymentRequest{
+		DeploymentName: name,
+	})
+}
+
+func (c *ctlClient) GetScale(name string) (*pb.GetScaleResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CONN_TIMEOUT)
+	defer cancel()
+	return c.client.GetScale(ctx, &pb.GetScaleRequest{
+		DeploymentName: name,
+	})
+}
+
+// UpdateScale sets the replicas of a deployment, only if it has currentReplicas unless they are nil.
+func (c *ctlClient) UpdateScale(name string, replicas uint32, currentReplicas *uint32) (*pb.DefaultResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CONN_TIMEOUT)
+	defer cancel()
+	request := &pb.UpdateScaleRequest{
+		DeploymentName: name,
+		Replicas:       replicas,
+	}
+	if currentReplicas != nil {
+		request.CheckCurrentReplicas = true
+		request.CurrentReplicas = *currentReplicas
+	}
+	return c.client.UpdateScale(ctx, request)
+}
+
 func (c *ctlClient) DescribeServices(all bool, names []string) (*pb.DescribeServicesResponse, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), CONN_TIMEOUT)
 	defer cancel()
@@ -183,6 +305,35 @@ func (c *ctlClient) GetJobLog(jobName string) (*pb.LogJobResponse, error) {
 	})
 }
 
+// StreamPodLog opens a log stream of a container in a pod. No timeout is set on the stream
+// since it might be followed indefinitely.
+func (c *ctlClient) StreamPodLog(
+	ctx context.Context,
+	podName string,
+	opts *core.PodLogOptions,
+) (pb.ApiServerCtlService_StreamPodLogClient, error) {
+	return c.client.StreamPodLog(ctx, &pb.StreamPodLogRequest{
+		PodName:       podName,
+		ContainerName: opts.Container,
+		Follow:        opts.Follow,
+		TailLines:     opts.TailLines,
+		SinceSeconds:  opts.SinceSeconds,
+		Timestamps:    opts.Timestamps,
+		Previous:      opts.Previous,
+	})
+}
+
+// Exec opens an exec stream. No timeout is set on the stream since it is interactive.
+func (c *ctlClient) Exec(ctx context.Context) (pb.ApiServerCtlService_ExecClient, error) {
+	return c.client.Exec(ctx)
+}
+
+// PortForward opens a port forward stream. No timeout is set on the stream since it lives as
+// long as the forwarded connection.
+func (c *ctlClient) PortForward(ctx context.Context) (pb.ApiServerCtlService_PortForwardClient, error) {
+	return c.client.PortForward(ctx)
+}
+
 func (c *ctlClient) CreateAutoscaler(autoscaler *core.HorizontalPodAutoscaler) (*pb.DefaultResponse, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), CONN_TIMEOUT)
 	defer cancel()
@@ -195,6 +346,84 @@ func (c *ctlClient) CreateAutoscaler(autoscaler *core.HorizontalPodAutoscaler) (
 	})
 }
 
+func (c *ctlClient) UpdateAutoscaler(autoscaler *core.HorizontalPodAutoscaler) (*pb.DefaultResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CONN_TIMEOUT)
+	defer cancel()
+	data, err := json.Marshal(autoscaler)
+	if err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
+	}
+	return c.client.UpdateAutoscaler(ctx, &pb.UpdateAutoscalerRequest{
+		Autoscaler: data,
+	})
+}
+
+func (c *ctlClient) DeleteAutoscaler(autoscalerName string) (*pb.DefaultResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CONN_TIMEOUT)
+	defer cancel()
+	return c.client.DeleteAutoscaler(ctx, &pb.DeleteAutoscalerRequest{
+		AutoscalerName: autoscalerName,
+	})
+}
+
+func (c *ctlClient) CreateScheduledScaler(scaler *core.ScheduledScaler) (*pb.DefaultResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CONN_TIMEOUT)
+	defer cancel()
+	data, err := json.Marshal(scaler)
+	if err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
+	}
+	return c.client.CreateScheduledScaler(ctx, &pb.CreateScheduledScalerRequest{
+		ScheduledScaler: data,
+	})
+}
+
+func (c *ctlClient) DeleteScheduledScaler(name string) (*pb.DefaultResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CONN_TIMEOUT)
+	defer cancel()
+	return c.client.DeleteScheduledScaler(ctx, &pb.DeleteScheduledScalerRequest{
+		ScheduledScalerName: name,
+	})
+}
+
+func (c *ctlClient) DescribeScheduledScalers(all bool, names []string) (*pb.DescribeScheduledScalersResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CONN_TIMEOUT)
+	defer cancel()
+	return c.client.DescribeScheduledScalers(ctx, &pb.DescribeScheduledScalersRequest{
+		All:                  all,
+		ScheduledScalerNames: names,
+	})
+}
+
+func (c *ctlClient) CreateVerticalAutoscaler(autoscaler *core.VerticalPodAutoscaler) (*pb.DefaultResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CONN_TIMEOUT)
+	defer cancel()
+	data, err := json.Marshal(autoscaler)
+	if err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
+	}
+	return c.client.CreateVerticalAutoscaler(ctx, &pb.CreateVerticalAutoscalerRequest{
+		VerticalAutoscaler: data,
+	})
+}
+
+func (c *ctlClient) DeleteVerticalAutoscaler(name string) (*pb.DefaultResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CONN_TIMEOUT)
+	defer cancel()
+	return c.client.DeleteVerticalAutoscaler(ctx, &pb.DeleteVerticalAutoscalerRequest{
+		VerticalAutoscalerName: name,
+	})
+}
+
+func (c *ctlClient) DescribeVerticalAutoscalers(all bool, names []string) (*pb.DescribeVerticalAutoscalersResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CONN_TIMEOUT)
+	defer cancel()
+	return c.client.DescribeVerticalAutoscalers(ctx, &pb.DescribeVerticalAutoscalersRequest{
+		All:                     all,
+		VerticalAutoscalerNames: names,
+	})
+}
+
 func (c *ctlClient) DescribeNodes() (*pb.DescribeNodesResponse, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), CONN_TIMEOUT)
 	defer cancel()
@@ -209,3 +438,51 @@ func (c *ctlClient) DescribeAutoscalers(all bool, names []string) (*pb.DescribeA
 		AutoscalerNames: names,
 	})
 }
+
+func (c *ctlClient) CreateRegistryCredential(credential *core.RegistryCredential) (*pb.DefaultResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CONN_TIMEOUT)
+	defer cancel()
+	data, err := json.Marshal(credential)
+	if err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
+	}
+	return c.client.CreateRegistryCredential(ctx, &pb.CreateRegistryCredentialRequest{
+		RegistryCredential: data,
+	})
+}
+
+func (c *ctlClient) DeleteRegistryCredential(name string) (*pb.DefaultResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CONN_TIMEOUT)
+	defer cancel()
+	return c.client.DeleteRegistryCredential(ctx, &pb.DeleteRegistryCredentialRequest{
+		RegistryCredentialName: name,
+	})
+}
+
+func (c *ctlClient) DescribeRegistryCredentials(all bool, names []string) (*pb.DescribeRegistryCredentialsResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CONN_TIMEOUT)
+	defer cancel()
+	return c.client.DescribeRegistryCredentials(ctx, &pb.DescribeRegistryCredentialsRequest{
+		All:                     all,
+		RegistryCredentialNames: names,
+	})
+}
+
+// METRICS_TIMEOUT bounds the requests for metrics, which take a kubelet about a second to sample
+// the CPU usage.
+var METRICS_TIMEOUT time.Duration = 15 * time.Second
+
+func (c *ctlClient) GetNodeMetrics(names []string) (*pb.GetNodeMetricsResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), METRICS_TIMEOUT)
+	defer cancel()
+	return c.client.GetNodeMetrics(ctx, &pb.GetNodeMetricsRequest{NodeNames: names})
+}
+
+func (c *ctlClient) GetPodMetrics(names []string, selector map[string]string) (*pb.GetPodMetricsResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), METRICS_TIMEOUT)
+	defer cancel()
+	return c.client.GetPodMetrics(ctx, &pb.GetPodMetricsRequest{
+		PodNames: names,
+		Selector: selector,
+	})
+}
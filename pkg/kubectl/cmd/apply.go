@@ -44,6 +44,10 @@ Examples:
 				applyNode(data)
 			case string(core.DeploymentType):
 				applyDeployment(data)
+			case string(core.ReplicaSetType):
+				applyReplicaSet(data)
+			case string(core.StatefulSetType):
+				applyStatefulSet(data)
 			case string(core.ServiceType):
 				applyService(data)
 			case string(core.DNSType):
@@ -52,6 +56,12 @@ Examples:
 				applyJob(data)
 			case string(core.AutoscalerType):
 				applyAutoscaler(data)
+			case string(core.RegistryCredentialType):
+				applyRegistryCredential(data)
+			case string(core.ScheduledScalerType):
+				applyScheduledScaler(data)
+			case string(core.VerticalAutoscalerType):
+				applyVerticalAutoscaler(data)
 			default:
 				log.Fatalf("%v is not supported", configKind.Kind)
 			}
@@ -106,6 +116,34 @@ func applyDeployment(data []byte) {
 	fmt.Printf("Response status: %v ;Deployment created\n", response.Status)
 }
 
+func applyReplicaSet(data []byte) {
+	var replicaSet core.ReplicaSet
+	if err := yaml.Unmarshal(data, &replicaSet); err != nil {
+		log.Fatalf("cannot unmarshal data: %v", err)
+	}
+
+	client := client.NewCtlClient()
+	response, err := client.CreateReplicaSet(&replicaSet)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Response status: %v ;ReplicaSet created\n", response.Status)
+}
+
+func applyStatefulSet(data []byte) {
+	var statefulSet core.StatefulSet
+	if err := yaml.Unmarshal(data, &statefulSet); err != nil {
+		log.Fatalf("cannot unmarshal data: %v", err)
+	}
+
+	client := client.NewCtlClient()
+	response, err := client.CreateStatefulSet(&statefulSet)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Response status: %v ;StatefulSet created\n", response.Status)
+}
+
 func applyService(data []byte) {
 	var service core.Service
 	if err := yaml.Unmarshal(data, &service); err != nil {
@@ -197,14 +235,89 @@ func applyAutoscaler(data []byte) {
 		log.Fatalf("scale interval cannot be less than 12s")
 	}
 	for _, metric := range autoscaler.Spec.Metrics {
+		if metric.Type != "" && metric.Type != core.MetricTypeResource {
+			continue
+		}
 		if metric.Resource != core.ResourceCPU && metric.Resource != core.ResourceMemory {
 			log.Fatalf("unsupported metric: %s", metric.Resource)
 		}
 	}
 	client := client.NewCtlClient()
+
+	// An autoscaler that already exists is updated in place.
+	existing, err := client.DescribeAutoscalers(false, []string{autoscaler.Name})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if existing.Status == 0 {
+		response, err := client.UpdateAutoscaler(&autoscaler)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Response status: %v ;Autoscaler updated\n", response.Status)
+		return
+	}
+
 	response, err := client.CreateAutoscaler(&autoscaler)
 	if err != nil {
 		log.Fatal(err)
 	}
 	fmt.Printf("Response status: %v ;Autoscaler created\n", response.Status)
 }
+
+func applyRegistryCredential(data []byte) {
+	var credential core.RegistryCredential
+	if err := yaml.Unmarshal(data, &credential); err != nil {
+		log.Fatalf("cannot unmarshal data: %v", err)
+	}
+	if len(credential.Name) == 0 {
+		log.Fatalf("name not specified")
+	}
+	if len(credential.Spec.Server) == 0 {
+		log.Fatalf("server not specified")
+	}
+	client := client.NewCtlClient()
+	response, err := client.CreateRegistryCredential(&credential)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Response status: %v ;Registry credential created\n", response.Status)
+}
+
+func applyScheduledScaler(data []byte) {
+	var scaler core.ScheduledScaler
+	if err := yaml.Unmarshal(data, &scaler); err != nil {
+		log.Fatalf("cannot unmarshal data: %v", err)
+	}
+	if len(scaler.Name) == 0 {
+		log.Fatalf("name not specified")
+	}
+	if scaler.Spec.ScaleTargetRef.Kind != core.DeploymentType {
+		log.Fatalf("target object must be deployment")
+	}
+	client := client.NewCtlClient()
+	response, err := client.CreateScheduledScaler(&scaler)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Response status: %v ;Scheduled scaler applied\n", response.Status)
+}
+
+func applyVerticalAutoscaler(data []byte) {
+	var autoscaler core.VerticalPodAutoscaler
+	if err := yaml.Unmarshal(data, &autoscaler); err != nil {
+		log.Fatalf("cannot unmarshal data: %v", err)
+	}
+	if len(autoscaler.Name) == 0 {
+		log.Fatalf("name not specified")
+	}
+	if autoscaler.Spec.TargetRef.Kind != core.DeploymentType {
+		log.Fatalf("target object must be deployment")
+	}
+	client := client.NewCtlClient()
+	response, err := client.CreateVerticalAutoscaler(&autoscaler)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Response status: %v ;Vertical autoscaler applied\n", response.Status)
+}
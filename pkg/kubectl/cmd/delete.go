@@ -40,7 +40,49 @@ var (
   kubectl delete deployments <deploymentName1> <deploymentName2> ...
   
   # Delete all deployments
-  kubectl delete deployments --all`,
+  kubectl delete deployments --all
+
+  # Delete a replica set that is not owned by a deployment using the name
+  kubectl delete replicaset <replicaSetName>
+
+  # Delete specified replica sets
+  kubectl delete replicasets <replicaSetName1> <replicaSetName2> ...
+
+  # Delete all replica sets that are not owned by deployments
+  kubectl delete replicasets --all
+
+  # Delete a stateful set using the name, keeping the volume claims of its pods
+  kubectl delete statefulset <statefulSetName>
+
+  # Delete specified stateful sets
+  kubectl delete statefulsets <statefulSetName1> <statefulSetName2> ...
+
+  # Delete all stateful sets
+  kubectl delete statefulsets --all
+
+  # Delete an autoscaler using the name
+  kubectl delete hpa <autoscalerName>
+
+  # Delete specified autoscalers
+  kubectl delete hpas <autoscalerName1> <autoscalerName2> ...
+
+  # Delete a scheduled scaler using the name
+  kubectl delete scheduledscaler <scalerName>
+
+  # Delete specified scheduled scalers
+  kubectl delete scheduledscalers <scalerName1> <scalerName2> ...
+
+  # Delete a vertical autoscaler using the name
+  kubectl delete vpa <autoscalerName>
+
+  # Delete specified vertical autoscalers
+  kubectl delete vpas <autoscalerName1> <autoscalerName2> ...
+
+  # Delete a registry credential using the name
+  kubectl delete registrycredential <credentialName>
+
+  # Delete specified registry credentials
+  kubectl delete registrycredentials <credentialName1> <credentialName2> ...`,
 		Args: cobra.MinimumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			resourceType := args[0]
@@ -69,6 +111,38 @@ var (
 				} else {
 					deleteDeployments(args[1:])
 				}
+			case "replicaset", "rs":
+				deleteReplicaSets([]string{args[1]})
+			case "replicasets":
+				if all {
+					deleteReplicaSets(nil)
+				} else {
+					deleteReplicaSets(args[1:])
+				}
+			case "statefulset", "sts":
+				deleteStatefulSets([]string{args[1]})
+			case "statefulsets":
+				if all {
+					deleteStatefulSets(nil)
+				} else {
+					deleteStatefulSets(args[1:])
+				}
+			case "hpa", "autoscaler":
+				deleteAutoscalers([]string{args[1]})
+			case "hpas", "autoscalers":
+				deleteAutoscalers(args[1:])
+			case "scheduledscaler":
+				deleteScheduledScalers([]string{args[1]})
+			case "scheduledscalers":
+				deleteScheduledScalers(args[1:])
+			case "vpa":
+				deleteVerticalAutoscalers([]string{args[1]})
+			case "vpas":
+				deleteVerticalAutoscalers(args[1:])
+			case "registrycredential":
+				deleteRegistryCredentials([]string{args[1]})
+			case "registrycredentials":
+				deleteRegistryCredentials(args[1:])
 			default:
 				log.Fatalf("%v is not supported\n", resourceType)
 			}
@@ -144,3 +218,93 @@ func deleteDeployments(deploymentNames []string) {
 		}
 	}
 }
+
+func deleteReplicaSets(replicaSetNames []string) {
+	client := client.NewCtlClient()
+	if replicaSetNames == nil {
+		response, err := client.DeleteReplicaSet("")
+		if err != nil {
+			log.Print(err)
+		} else {
+			fmt.Printf("Reponse status: %v ;ReplicaSets deleted\n", response.Status)
+		}
+	} else {
+		for _, name := range replicaSetNames {
+			response, err := client.DeleteReplicaSet(name)
+			if err != nil {
+				log.Print(err)
+			} else {
+				fmt.Printf("Response status: %v ;ReplicaSet %v deleted\n", response.Status, name)
+			}
+		}
+	}
+}
+
+func deleteStatefulSets(statefulSetNames []string) {
+	client := client.NewCtlClient()
+	if statefulSetNames == nil {
+		response, err := client.DeleteStatefulSet("")
+		if err != nil {
+			log.Print(err)
+		} else {
+			fmt.Printf("Reponse status: %v ;StatefulSets deleted\n", response.Status)
+		}
+	} else {
+		for _, name := range statefulSetNames {
+			response, err := client.DeleteStatefulSet(name)
+			if err != nil {
+				log.Print(err)
+			} else {
+				fmt.Printf("Response status: %v ;StatefulSet %v deleted\n", response.Status, name)
+			}
+		}
+	}
+}
+
+func deleteAutoscalers(names []string) {
+	client := client.NewCtlClient()
+	for _, name := range names {
+		response, err := client.DeleteAutoscaler(name)
+		if err != nil {
+			log.Print(err)
+		} else {
+			fmt.Printf("Response status: %v ;Autoscaler %v deleted\n", response.Status, name)
+		}
+	}
+}
+
+func deleteScheduledScalers(names []string) {
+	client := client.NewCtlClient()
+	for _, name := range names {
+		response, err := client.DeleteScheduledScaler(name)
+		if err != nil {
+			log.Print(err)
+		} else {
+			fmt.Printf("Response status: %v ;Scheduled scaler %v deleted\n", response.Status, name)
+		}
+	}
+}
+
+func deleteVerticalAutoscalers(names []string) {
+	client := client.NewCtlClient()
+	for _, name := range names {
+		response, err := client.DeleteVerticalAutoscaler(name)
+		if err != nil {
+			log.Print(err)
+		} else {
+			fmt.Printf("Response status: %v ;Vertical autoscaler %v deleted\n", response.Status, name)
+		}
+	}
+}
+
+func deleteRegistryCredentials(names []string) {
+	client := client.NewCtlClient()
+	for _, name := range names {
+		response, err := client.DeleteRegistryCredential(name)
+		if err != nil {
+			log.Print(err)
+		} else {
+			fmt.Printf("Response status: %v ;Registry credential %v deleted\n", response.Status, name)
+		}
+	}
+}
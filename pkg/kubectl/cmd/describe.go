@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"os"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 	"p9t.io/kuberboat/pkg/api/core"
@@ -36,11 +39,47 @@ Examples:
   # Describe all deployments
   kubectl describe deployments,
 
+  # Describe a replica set
+  kubectl describe replicaset replicaSetName1 replicaSetName2
+
+  # Describe all replica sets, including the ones owned by deployments
+  kubectl describe replicasets
+
+  # Describe a stateful set, followed by its pods in order
+  kubectl describe statefulset statefulSetName1 statefulSetName2
+
+  # Describe all stateful sets
+  kubectl describe statefulsets
+
   # Describe a dns configuration
   kubectl describe dns dnsName1 dnsName2
 
   # Describe all dns configurations
-  kubectl describe dnss`,
+  kubectl describe dnss
+
+  # Describe an autoscaler, followed by its status
+  kubectl describe hpa autoscalerName1 autoscalerName2
+
+  # Describe all autoscalers
+  kubectl describe hpas
+
+  # Describe a scheduled scaler
+  kubectl describe scheduledscaler scalerName1 scalerName2
+
+  # Describe all scheduled scalers
+  kubectl describe scheduledscalers
+
+  # Describe a vertical autoscaler, followed by its recommendations
+  kubectl describe vpa autoscalerName1 autoscalerName2
+
+  # Describe all vertical autoscalers
+  kubectl describe vpas
+
+  # Describe a registry credential, with its password redacted
+  kubectl describe registrycredential credentialName1 credentialName2
+
+  # Describe all registry credentials
+  kubectl describe registrycredentials`,
 	Args: cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		resourceType := args[0]
@@ -57,16 +96,36 @@ Examples:
 			describeDeployments(args[1:])
 		case "deployments":
 			describeDeployments(nil)
+		case "replicaset", "rs":
+			describeReplicaSets(args[1:])
+		case "replicasets":
+			describeReplicaSets(nil)
+		case "statefulset", "sts":
+			describeStatefulSets(args[1:])
+		case "statefulsets":
+			describeStatefulSets(nil)
 		case "dns":
 			describeDNSs(args[1:])
 		case "dnss":
 			describeDNSs(nil)
 		case "nodes":
 			describeNodes()
-		case "autoscaler":
+		case "autoscaler", "hpa":
 			describeAutoscalers(args[1:])
-		case "autoscalers":
+		case "autoscalers", "hpas":
 			describeAutoscalers(nil)
+		case "scheduledscaler":
+			describeScheduledScalers(args[1:])
+		case "scheduledscalers":
+			describeScheduledScalers(nil)
+		case "vpa":
+			describeVerticalAutoscalers(args[1:])
+		case "vpas":
+			describeVerticalAutoscalers(nil)
+		case "registrycredential":
+			describeRegistryCredentials(args[1:])
+		case "registrycredentials":
+			describeRegistryCredentials(nil)
 		default:
 			log.Fatalf("%v is not a supported resource type", resourceType)
 		}
@@ -215,6 +274,108 @@ func describeDeployments(deploymentNames []string) {
 	}
 }
 
+func describeReplicaSets(replicaSetNames []string) {
+	type DisplayedReplicaSet struct {
+		ReplicaSet *core.ReplicaSet
+		Pods       []string
+	}
+	client := client.NewCtlClient()
+	var resp *pb.DescribeReplicaSetsResponse
+	var err error
+	if replicaSetNames == nil {
+		resp, err = client.DescribeReplicaSets(true, nil)
+	} else {
+		resp, err = client.DescribeReplicaSets(false, replicaSetNames)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var foundReplicaSets []*core.ReplicaSet
+	var displayedReplicaSets []DisplayedReplicaSet
+	var replicaSetPods [][]string
+	var notFoundReplicaSets []string
+	err = json.Unmarshal(resp.ReplicaSets, &foundReplicaSets)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = json.Unmarshal(resp.ReplicaSetPodNames, &replicaSetPods)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for index, replicaSet := range foundReplicaSets {
+		displayedReplicaSets = append(displayedReplicaSets, DisplayedReplicaSet{
+			ReplicaSet: replicaSet,
+			Pods:       replicaSetPods[index],
+		})
+	}
+	prettyjson, err := json.MarshalIndent(displayedReplicaSets, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(prettyjson))
+
+	if resp.Status == -2 {
+		err = json.Unmarshal(resp.NotFoundReplicaSets, &notFoundReplicaSets)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("The following replica sets are not found: %v\n", notFoundReplicaSets)
+	}
+}
+
+func describeStatefulSets(statefulSetNames []string) {
+	type DisplayedStatefulSet struct {
+		StatefulSet *core.StatefulSet
+		Pods        []string
+	}
+	client := client.NewCtlClient()
+	var resp *pb.DescribeStatefulSetsResponse
+	var err error
+	if statefulSetNames == nil {
+		resp, err = client.DescribeStatefulSets(true, nil)
+	} else {
+		resp, err = client.DescribeStatefulSets(false, statefulSetNames)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var foundStatefulSets []*core.StatefulSet
+	var displayedStatefulSets []DisplayedStatefulSet
+	var statefulSetPods [][]string
+	var notFoundStatefulSets []string
+	err = json.Unmarshal(resp.StatefulSets, &foundStatefulSets)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = json.Unmarshal(resp.StatefulSetPodNames, &statefulSetPods)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for index, statefulSet := range foundStatefulSets {
+		displayedStatefulSets = append(displayedStatefulSets, DisplayedStatefulSet{
+			StatefulSet: statefulSet,
+			Pods:        statefulSetPods[index],
+		})
+	}
+	prettyjson, err := json.MarshalIndent(displayedStatefulSets, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(prettyjson))
+
+	if resp.Status == -2 {
+		err = json.Unmarshal(resp.NotFoundStatefulSets, &notFoundStatefulSets)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("The following stateful sets are not found: %v\n", notFoundStatefulSets)
+	}
+}
+
 func describeDNSs(dnsNames []string) {
 	client := client.NewCtlClient()
 	var resp *pb.DescribeDNSsResponse
@@ -295,11 +456,214 @@ func describeAutoscalers(autoscalerNames []string) {
 		log.Fatal(err)
 	}
 	fmt.Println(string(prettyjson))
+	for _, autoscaler := range foundAutoscalers {
+		printAutoscalerStatus(autoscaler)
+	}
 	if resp.Status == -2 {
 		err = json.Unmarshal(resp.NotFoundAutoscalers, &notFoundAutoscalers)
 		if err != nil {
 			log.Fatal(err)
 		}
-		fmt.Printf("The following pods are not found: %v\n", notFoundAutoscalers)
+		fmt.Printf("The following autoscalers are not found: %v\n", notFoundAutoscalers)
+	}
+}
+
+// printAutoscalerStatus prints the replicas and the metrics last observed by an autoscaler.
+func printAutoscalerStatus(autoscaler *core.HorizontalPodAutoscaler) {
+	status := &autoscaler.Status
+	fmt.Printf("\nAutoscaler %v:\n", autoscaler.Name)
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 3, ' ', 0)
+	fmt.Fprintf(
+		w,
+		"  Reference:\t%v/%v\n",
+		autoscaler.Spec.ScaleTargetRef.Kind,
+		autoscaler.Spec.ScaleTargetRef.Name,
+	)
+	fmt.Fprintf(w, "  Min replicas:\t%v\n", autoscaler.Spec.MinReplicas)
+	fmt.Fprintf(w, "  Max replicas:\t%v\n", autoscaler.Spec.MaxReplicas)
+	fmt.Fprintf(w, "  Current replicas:\t%v\n", status.CurrentReplicas)
+	fmt.Fprintf(w, "  Desired replicas:\t%v\n", status.DesiredReplicas)
+	if status.LastScaleTime == nil {
+		fmt.Fprintf(w, "  Last scale time:\t<none>\n")
+	} else {
+		fmt.Fprintf(w, "  Last scale time:\t%v\n", status.LastScaleTime.Format(time.RFC3339))
+	}
+	w.Flush()
+
+	if len(status.CurrentMetrics) == 0 {
+		fmt.Println("  Metrics: <unknown>")
+		return
+	}
+	fmt.Println("  Metrics: (current / target)")
+	w = tabwriter.NewWriter(os.Stdout, 0, 8, 3, ' ', 0)
+	for i, metric := range status.CurrentMetrics {
+		name := metric.Name
+		if metric.Type == core.MetricTypeResource {
+			name = string(metric.Resource)
+		}
+		current, target := fmt.Sprintf("%g", metric.CurrentAverage), "<unknown>"
+		if i < len(autoscaler.Spec.Metrics) {
+			spec := &autoscaler.Spec.Metrics[i]
+			switch {
+			case spec.Pods != nil:
+				target = fmt.Sprintf("%g", spec.Pods.TargetAverageValue)
+			case spec.External != nil && spec.External.TargetValue != nil:
+				current = fmt.Sprintf("%g", metric.CurrentValue)
+				target = fmt.Sprintf("%g", *spec.External.TargetValue)
+			case spec.External != nil && spec.External.TargetAverageValue != nil:
+				target = fmt.Sprintf("%g (avg)", *spec.External.TargetAverageValue)
+				current += " (avg)"
+			default:
+				target = fmt.Sprintf("%v", spec.TargetUtilization)
+			}
+		}
+		fmt.Fprintf(w, "    %v (%v):\t%v / %v\n", name, metric.Type, current, target)
+	}
+	w.Flush()
+}
+
+func describeScheduledScalers(names []string) {
+	client := client.NewCtlClient()
+	var resp *pb.DescribeScheduledScalersResponse
+	var err error
+	if names == nil {
+		resp, err = client.DescribeScheduledScalers(true, nil)
+	} else {
+		resp, err = client.DescribeScheduledScalers(false, names)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var foundScalers []*core.ScheduledScaler
+	var notFoundScalers []string
+	err = json.Unmarshal(resp.ScheduledScalers, &foundScalers)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	prettyjson, err := json.MarshalIndent(foundScalers, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(prettyjson))
+	if resp.Status == -2 {
+		err = json.Unmarshal(resp.NotFoundScheduledScalers, &notFoundScalers)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("The following scheduled scalers are not found: %v\n", notFoundScalers)
+	}
+}
+
+func describeVerticalAutoscalers(names []string) {
+	client := client.NewCtlClient()
+	var resp *pb.DescribeVerticalAutoscalersResponse
+	var err error
+	if names == nil {
+		resp, err = client.DescribeVerticalAutoscalers(true, nil)
+	} else {
+		resp, err = client.DescribeVerticalAutoscalers(false, names)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var foundAutoscalers []*core.VerticalPodAutoscaler
+	var notFoundAutoscalers []string
+	err = json.Unmarshal(resp.VerticalAutoscalers, &foundAutoscalers)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	prettyjson, err := json.MarshalIndent(foundAutoscalers, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(prettyjson))
+	for _, autoscaler := range foundAutoscalers {
+		printRecommendations(autoscaler)
+	}
+	if resp.Status == -2 {
+		err = json.Unmarshal(resp.NotFoundVerticalAutoscalers, &notFoundAutoscalers)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("The following vertical autoscalers are not found: %v\n", notFoundAutoscalers)
+	}
+}
+
+// printRecommendations prints the resources recommended by a vertical autoscaler.
+func printRecommendations(autoscaler *core.VerticalPodAutoscaler) {
+	status := &autoscaler.Status
+	fmt.Printf("\nVertical autoscaler %v:\n", autoscaler.Name)
+	fmt.Printf("  Reference: %v/%v\n", autoscaler.Spec.TargetRef.Kind, autoscaler.Spec.TargetRef.Name)
+	fmt.Printf("  Update mode: %v\n", autoscaler.Spec.UpdateMode)
+	if status.LastApplyTime != nil {
+		fmt.Printf("  Last apply time: %v\n", status.LastApplyTime.Format(time.RFC3339))
+	}
+	if len(status.Recommendations) == 0 {
+		fmt.Println("  Recommendations: <none>")
+		return
+	}
+	fmt.Printf("  Recommendations (updated %v):\n", status.LastUpdateTime.Format(time.RFC3339))
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, "    CONTAINER\tRESOURCE\tLOWER BOUND\tTARGET\tUPPER BOUND\tLIMIT\tSAMPLES")
+	for _, recommendation := range status.Recommendations {
+		for _, name := range []core.ResourceName{core.ResourceCPU, core.ResourceMemory} {
+			limit := "<none>"
+			if value, ok := recommendation.Limits[name]; ok {
+				limit = value.String()
+			}
+			fmt.Fprintf(
+				w,
+				"    %v\t%v\t%v\t%v\t%v\t%v\t%v\n",
+				recommendation.ContainerName,
+				name,
+				recommendation.LowerBound[name].String(),
+				recommendation.Target[name].String(),
+				recommendation.UpperBound[name].String(),
+				limit,
+				recommendation.Samples,
+			)
+		}
+	}
+	w.Flush()
+}
+
+func describeRegistryCredentials(names []string) {
+	client := client.NewCtlClient()
+	var resp *pb.DescribeRegistryCredentialsResponse
+	var err error
+	if names == nil {
+		resp, err = client.DescribeRegistryCredentials(true, nil)
+	} else {
+		resp, err = client.DescribeRegistryCredentials(false, names)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var foundCredentials []*core.RegistryCredential
+	var notFoundCredentials []string
+	err = json.Unmarshal(resp.RegistryCredentials, &foundCredentials)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	prettyjson, err := json.MarshalIndent(foundCredentials, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(prettyjson))
+	if resp.Status == -2 {
+		err = json.Unmarshal(resp.NotFoundRegistryCredentials, &notFoundCredentials)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("The following registry credentials are not found: %v\n", notFoundCredentials)
 	}
 }
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/moby/term"
+	"github.com/spf13/cobra"
+	"p9t.io/kuberboat/pkg/api/core"
+	"p9t.io/kuberboat/pkg/kubectl/client"
+	pb "p9t.io/kuberboat/pkg/proto"
+)
+
+// execCmd represents the exec command
+var (
+	execOptions core.ExecOptions
+	execCmd     = &cobra.Command{
+		Use:   "exec [-i] [-t] POD [-c CONTAINER] -- COMMAND [args...]",
+		Short: "Execute a command in a container",
+		Long: `Execute a command in a container. If the pod has only one container, the container name is optional.
+
+Examples:
+  # Get output from running the 'date' command from pod mypod
+  kubectl exec mypod -- date
+
+  # Get output from running the 'date' command in redis container from pod mypod
+  kubectl exec mypod -c redis -- date
+
+  # Switch to raw terminal mode; sends stdin to 'bash' in redis container from pod mypod
+  # and sends stdout/stderr from 'bash' back to the client
+  kubectl exec mypod -c redis -i -t -- bash -il`,
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			dash := cmd.ArgsLenAtDash()
+			if dash != 1 {
+				log.Fatal("exec requires exactly one pod name before -- and a command after it")
+			}
+			execOptions.Command = args[dash:]
+			os.Exit(execInPod(args[0], &execOptions))
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(execCmd)
+
+	execCmd.Flags().StringVarP(&execOptions.Container, "container", "c", "", "container name")
+	execCmd.Flags().BoolVarP(&execOptions.Stdin, "stdin", "i", false, "pass stdin to the container")
+	execCmd.Flags().BoolVarP(&execOptions.TTY, "tty", "t", false, "stdin is a TTY")
+}
+
+// execInPod runs the command in the pod and returns the exit code of the command.
+func execInPod(podName string, opts *core.ExecOptions) int {
+	inFd, isTerminal := term.GetFdInfo(os.Stdin)
+	if opts.TTY && !isTerminal {
+		log.Print("unable to use a TTY - input is not a terminal or the right kind of file")
+		opts.TTY = false
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := client.NewCtlClient()
+	execStream, err := client.Exec(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	stream := &execSender{stream: execStream}
+
+	req := &pb.ExecRequest{
+		PodName:       podName,
+		ContainerName: opts.Container,
+		Command:       opts.Command,
+		Stdin:         opts.Stdin,
+		Tty:           opts.TTY,
+	}
+	if opts.TTY {
+		if size, err := term.GetWinsize(inFd); err == nil {
+			req.Width, req.Height = uint32(size.Width), uint32(size.Height)
+		}
+		state, err := term.SetRawTerminal(inFd)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer term.RestoreTerminal(inFd, state)
+	}
+	// The first request starts the command, so it must precede stdin data and resize events.
+	if err := stream.Send(req); err != nil {
+		log.Print(err)
+		return 1
+	}
+	if opts.TTY {
+		go watchTerminalResize(inFd, stream)
+	}
+
+	if opts.Stdin {
+		go func() {
+			buf := make([]byte, 4096)
+			for {
+				n, err := os.Stdin.Read(buf)
+				if n > 0 {
+					if err := stream.Send(&pb.ExecRequest{StdinData: buf[:n]}); err != nil {
+						return
+					}
+				}
+				if err != nil {
+					stream.Send(&pb.ExecRequest{CloseStdin: true})
+					return
+				}
+			}
+		}()
+	}
+
+	exitCode := 0
+	for {
+		resp, err := execStream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Print(err)
+			return 1
+		}
+		os.Stdout.Write(resp.Stdout)
+		os.Stderr.Write(resp.Stderr)
+		if resp.Exited {
+			exitCode = int(resp.ExitCode)
+		}
+	}
+	return exitCode
+}
+
+// execSender serializes the requests sent by stdin and terminal resize goroutines, since a grpc
+// stream does not support concurrent sends.
+type execSender struct {
+	mtx    sync.Mutex
+	stream pb.ApiServerCtlService_ExecClient
+}
+
+func (s *execSender) Send(req *pb.ExecRequest) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.stream.Send(req)
+}
+
+// watchTerminalResize sends the new terminal size through the stream whenever the terminal is resized.
+func watchTerminalResize(fd uintptr, stream *execSender) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGWINCH)
+	defer signal.Stop(sigCh)
+	for range sigCh {
+		size, err := term.GetWinsize(fd)
+		if err != nil {
+			continue
+		}
+		if err := stream.Send(&pb.ExecRequest{
+			Width:  uint32(size.Width),
+			Height: uint32(size.Height),
+		}); err != nil {
+			return
+		}
+	}
+}
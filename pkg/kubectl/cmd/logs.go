@@ -1,36 +1,77 @@
 package cmd
 
 import (
-	"fmt"
+	"context"
+	"io"
 	"log"
+	"os"
+	"time"
 
 	"github.com/spf13/cobra"
+	"p9t.io/kuberboat/pkg/api/core"
 	"p9t.io/kuberboat/pkg/kubectl/client"
 )
 
 // logsCmd represents the logs command
-var logsCmd = &cobra.Command{
-	Use:   "logs JOBNAME",
-	Short: "Print the output of a Cuda Job",
-	Long: `Print the output of a Cuda Job
+var (
+	logOptions core.PodLogOptions
+	logSince   time.Duration
+	logsCmd    = &cobra.Command{
+		Use:   "logs [-f] [-c CONTAINER] POD",
+		Short: "Print the logs for a container in a pod",
+		Long: `Print the logs for a container in a pod. If the pod has only one container, the container name is optional.
 
 Examples:
-  # get output of cuda job`,
-	Args: cobra.MinimumNArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		getLog(args[0])
-	},
-}
+  # Return snapshot logs from pod nginx with only one container
+  kubectl logs nginx
+
+  # Return snapshot logs of container redis in pod my-pod
+  kubectl logs my-pod -c redis
+
+  # Begin streaming the logs of container redis in pod my-pod
+  kubectl logs -f my-pod -c redis
+
+  # Display only the most recent 20 lines of output in pod nginx
+  kubectl logs --tail=20 nginx
+
+  # Show all logs from pod nginx written in the last hour, with timestamps
+  kubectl logs --since=1h --timestamps nginx
+
+  # Return logs of the terminated container of a cuda job
+  kubectl logs --previous my-job`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			logOptions.SinceSeconds = int64(logSince.Seconds())
+			streamPodLog(args[0], &logOptions)
+		},
+	}
+)
 
 func init() {
 	rootCmd.AddCommand(logsCmd)
+
+	logsCmd.Flags().StringVarP(&logOptions.Container, "container", "c", "", "print the logs of this container")
+	logsCmd.Flags().BoolVarP(&logOptions.Follow, "follow", "f", false, "specify if the logs should be streamed")
+	logsCmd.Flags().Int64Var(&logOptions.TailLines, "tail", -1, "lines of recent log file to display, -1 to show all log lines")
+	logsCmd.Flags().DurationVar(&logSince, "since", 0, "only return logs newer than a relative duration like 5s, 2m, or 3h")
+	logsCmd.Flags().BoolVar(&logOptions.Timestamps, "timestamps", false, "include timestamps on each line in the log output")
+	logsCmd.Flags().BoolVarP(&logOptions.Previous, "previous", "p", false, "print the logs for the previous instance of the container")
 }
 
-func getLog(jobName string) {
+func streamPodLog(podName string, opts *core.PodLogOptions) {
 	client := client.NewCtlClient()
-	resp, err := client.GetJobLog(jobName)
+	stream, err := client.StreamPodLog(context.Background(), podName, opts)
 	if err != nil {
 		log.Fatal(err)
 	}
-	fmt.Print(resp.Log)
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		os.Stdout.Write(resp.Content)
+	}
 }
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"p9t.io/kuberboat/pkg/api/core"
+	"p9t.io/kuberboat/pkg/kubectl/client"
+	pb "p9t.io/kuberboat/pkg/proto"
+)
+
+// portForwardCmd represents the port-forward command
+var portForwardCmd = &cobra.Command{
+	Use:   "port-forward TYPE/NAME LOCAL_PORT:REMOTE_PORT",
+	Short: "Forward a local port to a pod",
+	Long: `Forward a local port to a pod. TYPE is either pod or service. When a service is given, the
+remote port is a service port and the connection is forwarded to its target port on one of the
+ready pods of the service.
+
+Examples:
+  # Listen on port 8888 locally, forwarding to port 5000 in the pod
+  kubectl port-forward pod/mypod 8888:5000
+
+  # Listen on port 8443 locally, forwarding to the target port of service port 443 of service myservice
+  kubectl port-forward service/myservice 8443:443`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		localPort, remotePort, err := parsePortMapping(args[1])
+		if err != nil {
+			log.Fatal(err)
+		}
+		podName, podPort := resolvePortForwardTarget(args[0], remotePort)
+		portForward(podName, localPort, podPort)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(portForwardCmd)
+}
+
+// parsePortMapping parses "LOCAL:REMOTE", or "PORT" for the same port on both sides.
+func parsePortMapping(mapping string) (uint16, uint16, error) {
+	parts := strings.Split(mapping, ":")
+	if len(parts) > 2 {
+		return 0, 0, fmt.Errorf("invalid port mapping %v, expected LOCAL_PORT:REMOTE_PORT", mapping)
+	}
+	ports := make([]uint16, 0, 2)
+	for _, part := range parts {
+		port, err := strconv.ParseUint(part, 10, 16)
+		if err != nil || port == 0 {
+			return 0, 0, fmt.Errorf("invalid port %v in port mapping %v", part, mapping)
+		}
+		ports = append(ports, uint16(port))
+	}
+	if len(ports) == 1 {
+		return ports[0], ports[0], nil
+	}
+	return ports[0], ports[1], nil
+}
+
+// resolvePortForwardTarget returns the pod and the pod port to forward to.
+func resolvePortForwardTarget(target string, remotePort uint16) (string, uint16) {
+	parts := strings.SplitN(target, "/", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		log.Fatalf("invalid target %v, expected pod/NAME or service/NAME", target)
+	}
+	switch parts[0] {
+	case "pod", "pods", "po":
+		return parts[1], remotePort
+	case "service", "services", "svc":
+		return resolveServicePod(parts[1], remotePort)
+	default:
+		log.Fatalf("cannot port-forward to resource type %v", parts[0])
+	}
+	return "", 0
+}
+
+// resolveServicePod picks a ready pod of the service and maps the service port to its target port.
+func resolveServicePod(serviceName string, servicePort uint16) (string, uint16) {
+	client := client.NewCtlClient()
+	resp, err := client.DescribeServices(false, []string{serviceName})
+	if err != nil {
+		log.Fatal(err)
+	}
+	var services []*core.Service
+	var servicePods [][]string
+	if err := json.Unmarshal(resp.Services, &services); err != nil {
+		log.Fatal(err)
+	}
+	if err := json.Unmarshal(resp.ServicePodNames, &servicePods); err != nil {
+		log.Fatal(err)
+	}
+	if len(services) == 0 {
+		log.Fatalf("service %v not found", serviceName)
+	}
+
+	var targetPort uint16
+	for _, port := range services[0].Spec.Ports {
+		if port.Port == servicePort {
+			targetPort = port.TargetPort
+			if targetPort == 0 {
+				targetPort = port.Port
+			}
+			break
+		}
+	}
+	if targetPort == 0 {
+		log.Fatalf("service %v does not have port %v", serviceName, servicePort)
+	}
+	if len(servicePods[0]) == 0 {
+		log.Fatalf("service %v has no ready pods", serviceName)
+	}
+	return servicePods[0][0], targetPort
+}
+
+func portForward(podName string, localPort uint16, podPort uint16) {
+	listener, err := net.Listen("tcp", fmt.Sprintf("localhost:%v", localPort))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Forwarding from %v -> %v\n", listener.Addr(), podPort)
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Handling connection for %v\n", localPort)
+		go forwardConnection(conn, podName, podPort)
+	}
+}
+
+// forwardConnection relays one local connection through its own port forward stream.
+func forwardConnection(conn net.Conn, podName string, podPort uint16) {
+	defer conn.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := client.NewCtlClient()
+	stream, err := client.PortForward(ctx)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	if err := stream.Send(&pb.PortForwardRequest{PodName: podName, Port: uint32(podPort)}); err != nil {
+		log.Print(err)
+		return
+	}
+
+	go func() {
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := conn.Read(buf)
+			if n > 0 {
+				if err := stream.Send(&pb.PortForwardRequest{Data: buf[:n]}); err != nil {
+					return
+				}
+			}
+			if err != nil {
+				stream.CloseSend()
+				return
+			}
+		}
+	}()
+
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			log.Printf("error forwarding port %v to pod %v: %v", podPort, podName, err)
+			return
+		}
+		if _, err := conn.Write(resp.Data); err != nil {
+			return
+		}
+	}
+}
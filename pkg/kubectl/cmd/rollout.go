@@ -0,0 +1,292 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"p9t.io/kuberboat/pkg/api/core"
+	"p9t.io/kuberboat/pkg/kubectl/client"
+)
+
+// rolloutCmd represents the rollout command
+var (
+	historyRevision uint64
+	undoToRevision  uint64
+	statusTimeout   time.Duration
+	rolloutCmd      = &cobra.Command{
+		Use:   "rollout",
+		Short: "Manage the rollout of a deployment.",
+		Long: `Manage the rollout of a deployment.
+
+Examples:
+  # View the rollout history of a deployment
+  kubectl rollout history deployment <deploymentName>
+
+  # View the template of revision 3
+  kubectl rollout history deployment <deploymentName> --revision=3
+
+  # Roll back to the previous revision
+  kubectl rollout undo deployment <deploymentName>
+
+  # Roll back to revision 3
+  kubectl rollout undo deployment <deploymentName> --to-revision=3
+
+  # Wait until the rollout of a deployment is done
+  kubectl rollout status deployment <deploymentName>
+
+  # Pause a rollout halfway, then resume it
+  kubectl rollout pause deployment <deploymentName>
+  kubectl rollout resume deployment <deploymentName>
+
+  # Go on with a canary rollout held by a pause step, or switch a blue-green deployment to its
+  # new pods
+  kubectl rollout promote deployment <deploymentName>`,
+	}
+	rolloutHistoryCmd = &cobra.Command{
+		Use:   "history deployment <deploymentName>",
+		Short: "View the rollout history of a deployment.",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			rolloutHistory(deploymentNameOf(args), historyRevision)
+		},
+	}
+	rolloutStatusCmd = &cobra.Command{
+		Use:   "status deployment <deploymentName>",
+		Short: "Watch the rollout of a deployment until it is done or has failed.",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			rolloutStatus(deploymentNameOf(args), statusTimeout)
+		},
+	}
+	rolloutPauseCmd = &cobra.Command{
+		Use:   "pause deployment <deploymentName>",
+		Short: "Pause the rollout of a deployment.",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			rolloutPause(deploymentNameOf(args))
+		},
+	}
+	rolloutResumeCmd = &cobra.Command{
+		Use:   "resume deployment <deploymentName>",
+		Short: "Resume a paused deployment.",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			rolloutResume(deploymentNameOf(args))
+		},
+	}
+	rolloutPromoteCmd = &cobra.Command{
+		Use:   "promote deployment <deploymentName>",
+		Short: "Promote a canary or blue-green rollout awaiting promotion.",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			rolloutPromote(deploymentNameOf(args))
+		},
+	}
+	rolloutUndoCmd = &cobra.Command{
+		Use:   "undo deployment <deploymentName>",
+		Short: "Roll a deployment back to a previous revision.",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			rolloutUndo(deploymentNameOf(args), undoToRevision)
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(rolloutCmd)
+	rolloutCmd.AddCommand(rolloutHistoryCmd)
+	rolloutCmd.AddCommand(rolloutUndoCmd)
+	rolloutCmd.AddCommand(rolloutStatusCmd)
+	rolloutCmd.AddCommand(rolloutPauseCmd)
+	rolloutCmd.AddCommand(rolloutResumeCmd)
+	rolloutCmd.AddCommand(rolloutPromoteCmd)
+
+	rolloutHistoryCmd.Flags().Uint64Var(&historyRevision, "revision", 0, "see the details of the given revision")
+	rolloutUndoCmd.Flags().Uint64Var(&undoToRevision, "to-revision", 0, "the revision to roll back to, 0 for the previous one")
+	rolloutStatusCmd.Flags().DurationVar(&statusTimeout, "timeout", 0, "how long to wait before giving up, 0 to wait forever")
+}
+
+// deploymentNameOf returns the name in the arguments of a rollout subcommand, which only support
+// deployments.
+func deploymentNameOf(args []string) string {
+	switch args[0] {
+	case "deployment", "deployments", "deploy":
+		return args[1]
+	default:
+		log.Fatalf("%v is not supported\n", args[0])
+	}
+	return ""
+}
+
+func rolloutHistory(name string, revision uint64) {
+	client := client.NewCtlClient()
+	response, err := client.DeploymentHistory(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var revisions []core.DeploymentRevision
+	if err := json.Unmarshal(response.Revisions, &revisions); err != nil {
+		log.Fatal(err)
+	}
+
+	if revision != 0 {
+		for _, r := range revisions {
+			if r.Revision == revision {
+				data, err := json.MarshalIndent(r, "", "  ")
+				if err != nil {
+					log.Fatal(err)
+				}
+				fmt.Println(string(data))
+				return
+			}
+		}
+		log.Fatalf("unable to find revision %v of deployment %v", revision, name)
+	}
+
+	fmt.Printf("deployment/%v\n", name)
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, "REVISION\tTEMPLATE HASH\tCREATED\tCHANGE-CAUSE")
+	for _, r := range revisions {
+		changeCause := r.ChangeCause
+		if changeCause == "" {
+			changeCause = "<none>"
+		}
+		fmt.Fprintf(
+			w,
+			"%v\t%v\t%v\t%v\n",
+			r.Revision,
+			r.TemplateHash[0:10],
+			r.CreationTimestamp.Format(time.RFC3339),
+			changeCause,
+		)
+	}
+	w.Flush()
+}
+
+func rolloutUndo(name string, toRevision uint64) {
+	client := client.NewCtlClient()
+	response, err := client.RollbackDeployment(name, toRevision)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Response status: %v ;Deployment %v rolled back\n", response.Status, name)
+}
+
+// rolloutStatusInterval is the interval between two checks of the status of a rollout.
+const rolloutStatusInterval = time.Second
+
+func rolloutStatus(name string, timeout time.Duration) {
+	client := client.NewCtlClient()
+	start := time.Now()
+	lastMessage := ""
+	for {
+		response, err := client.DescribeDeployments(false, []string{name})
+		if err != nil {
+			log.Fatal(err)
+		}
+		if response.Status != 0 {
+			log.Fatalf("no such deployment: %v", name)
+		}
+		var deployments []*core.Deployment
+		if err := json.Unmarshal(response.Deployments, &deployments); err != nil {
+			log.Fatal(err)
+		}
+
+		message, done, err := deploymentRolloutStatus(deployments[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		if message != lastMessage {
+			fmt.Println(message)
+			lastMessage = message
+		}
+		if done {
+			return
+		}
+		if timeout != 0 && time.Since(start) > timeout {
+			log.Fatalf("timed out waiting for the rollout of deployment %v", name)
+		}
+		time.Sleep(rolloutStatusInterval)
+	}
+}
+
+// deploymentRolloutStatus describes how far the rollout of a deployment is, and tells whether it is
+// done. It returns an error if the rollout has failed.
+func deploymentRolloutStatus(deployment *core.Deployment) (string, bool, error) {
+	if deployment.Status.ObservedGeneration < deployment.Generation {
+		return "Waiting for deployment spec update to be observed...", false, nil
+	}
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == core.DeploymentProgressing &&
+			condition.Reason == core.DeploymentReasonProgressDeadlineExceeded {
+			return "", false, fmt.Errorf("deployment %q exceeded its progress deadline", deployment.Name)
+		}
+	}
+	if deployment.Spec.Paused {
+		return fmt.Sprintf("Deployment %q is paused, waiting for it to be resumed...", deployment.Name), false, nil
+	}
+	if deployment.Status.AwaitingPromotion {
+		return fmt.Sprintf("Deployment %q is awaiting promotion...", deployment.Name), false, nil
+	}
+	if deployment.Status.UpdatedReplicas < deployment.Spec.Replicas {
+		return fmt.Sprintf(
+			"Waiting for deployment %q rollout to finish: %d out of %d new replicas are available...",
+			deployment.Name,
+			deployment.Status.UpdatedReplicas,
+			deployment.Spec.Replicas,
+		), false, nil
+	}
+	if deployment.Spec.Strategy == core.BlueGreenDeploymentStrategy {
+		// The old replicas of a blue-green deployment are kept for rollback.
+		for _, condition := range deployment.Status.Conditions {
+			if condition.Type == core.DeploymentProgressing &&
+				condition.Reason == core.DeploymentReasonRolloutComplete {
+				return fmt.Sprintf("deployment %q successfully rolled out", deployment.Name), true, nil
+			}
+		}
+		return fmt.Sprintf(
+			"Waiting for deployment %q to switch its active service to the new replicas...",
+			deployment.Name,
+		), false, nil
+	}
+	if deployment.Status.Replicas > deployment.Status.UpdatedReplicas {
+		return fmt.Sprintf(
+			"Waiting for deployment %q rollout to finish: %d old replicas are pending termination...",
+			deployment.Name,
+			deployment.Status.Replicas-deployment.Status.UpdatedReplicas,
+		), false, nil
+	}
+	return fmt.Sprintf("deployment %q successfully rolled out", deployment.Name), true, nil
+}
+
+func rolloutPause(name string) {
+	client := client.NewCtlClient()
+	response, err := client.PauseDeployment(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Response status: %v ;Deployment %v paused\n", response.Status, name)
+}
+
+func rolloutResume(name string) {
+	client := client.NewCtlClient()
+	response, err := client.ResumeDeployment(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Response status: %v ;Deployment %v resumed\n", response.Status, name)
+}
+
+func rolloutPromote(name string) {
+	client := client.NewCtlClient()
+	response, err := client.PromoteDeployment(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Response status: %v ;Deployment %v promoted\n", response.Status, name)
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+	"p9t.io/kuberboat/pkg/kubectl/client"
+)
+
+// scaleCmd represents the scale command
+var (
+	scaleReplicas        int
+	scaleCurrentReplicas int
+	scaleCmd             = &cobra.Command{
+		Use:   "scale deployment <deploymentName> --replicas=<count>",
+		Short: "Set the number of replicas of a deployment.",
+		Long: `Set the number of replicas of a deployment, without applying it again.
+
+Examples:
+  # Scale a deployment to 3 replicas
+  kubectl scale deployment <deploymentName> --replicas=3
+
+  # Scale a deployment to 3 replicas only if it currently has 2
+  kubectl scale deployment <deploymentName> --current-replicas=2 --replicas=3`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var name string
+			switch args[0] {
+			case "deployment", "deployments", "deploy":
+				name = args[1]
+			default:
+				log.Fatalf("%v is not supported\n", args[0])
+			}
+			scaleDeployment(name, scaleReplicas, scaleCurrentReplicas)
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(scaleCmd)
+
+	scaleCmd.Flags().IntVar(&scaleReplicas, "replicas", -1, "the new number of replicas")
+	scaleCmd.Flags().IntVar(&scaleCurrentReplicas, "current-replicas", -1, "only scale if the deployment currently has this many replicas, -1 for no condition")
+	scaleCmd.MarkFlagRequired("replicas")
+}
+
+func scaleDeployment(name string, replicas int, currentReplicas int) {
+	if replicas < 0 {
+		log.Fatalf("invalid number of replicas: %v", replicas)
+	}
+	var current *uint32 = nil
+	if currentReplicas >= 0 {
+		c := uint32(currentReplicas)
+		current = &c
+	}
+	client := client.NewCtlClient()
+	response, err := client.UpdateScale(name, uint32(replicas), current)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Response status: %v ;Deployment %v scaled\n", response.Status, name)
+}
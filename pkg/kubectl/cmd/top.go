@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"p9t.io/kuberboat/pkg/api/core"
+	"p9t.io/kuberboat/pkg/kubectl/client"
+)
+
+// topCmd represents the top command
+var (
+	topSortBy   string
+	topSelector string
+	topCmd      = &cobra.Command{
+		Use:   "top",
+		Short: "Display resource (CPU/memory) usage of nodes or pods.",
+		Long: `Display resource (CPU/memory) usage of nodes or pods, as sampled by the kubelets.
+
+Examples:
+  # Show metrics for all nodes
+  kubectl top nodes
+
+  # Show metrics for a given node
+  kubectl top node nodeName
+
+  # Show metrics for all pods, the most CPU consuming first
+  kubectl top pods --sort-by=cpu
+
+  # Show metrics for a given pod
+  kubectl top pod podName
+
+  # Show metrics for the pods defined by label app=nginx
+  kubectl top pods -l app=nginx`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if topSortBy != "" && topSortBy != "cpu" && topSortBy != "memory" {
+				log.Fatalf("--sort-by accepts cpu or memory, not %v", topSortBy)
+			}
+			resourceType := args[0]
+			switch resourceType {
+			case "node", "nodes":
+				topNodes(args[1:])
+			case "pod", "pods":
+				selector, err := parseSelector(topSelector)
+				if err != nil {
+					log.Fatal(err)
+				}
+				topPods(args[1:], selector)
+			default:
+				log.Fatalf("%v is not supported\n", resourceType)
+			}
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(topCmd)
+
+	topCmd.Flags().StringVar(&topSortBy, "sort-by", "", "sort the list by cpu or memory, in descending order")
+	topCmd.Flags().StringVarP(&topSelector, "selector", "l", "", "label selector of pods, e.g. app=nginx,env=dev")
+}
+
+// parseSelector parses a comma separated list of key=value labels.
+func parseSelector(s string) (map[string]string, error) {
+	selector := make(map[string]string)
+	if s == "" {
+		return selector, nil
+	}
+	for _, term := range strings.Split(s, ",") {
+		kv := strings.SplitN(term, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("invalid label selector: %v", term)
+		}
+		selector[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return selector, nil
+}
+
+func topNodes(nodeNames []string) {
+	client := client.NewCtlClient()
+	resp, err := client.GetNodeMetrics(nodeNames)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var metrics []*core.NodeMetrics
+	if err := json.Unmarshal(resp.NodeMetrics, &metrics); err != nil {
+		log.Fatal(err)
+	}
+
+	sort.Slice(metrics, func(i, j int) bool {
+		return lessUsage(metrics[i].Usage, metrics[j].Usage, metrics[i].Name, metrics[j].Name)
+	})
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tCPU(cores)\tCPU%\tMEMORY(bytes)\tMEMORY%")
+	for _, m := range metrics {
+		fmt.Fprintf(
+			w,
+			"%v\t%v\t%v\t%v\t%v\n",
+			m.Name,
+			formatCPU(m.Usage[core.ResourceCPU]),
+			formatPercentage(m.Usage[core.ResourceCPU], m.Capacity, core.ResourceCPU),
+			formatMemory(m.Usage[core.ResourceMemory]),
+			formatPercentage(m.Usage[core.ResourceMemory], m.Capacity, core.ResourceMemory),
+		)
+	}
+	w.Flush()
+
+	if resp.Status == -2 {
+		fmt.Printf("Metrics of the following nodes are not available: %v\n", resp.NotFoundNodeNames)
+	}
+}
+
+func topPods(podNames []string, selector map[string]string) {
+	client := client.NewCtlClient()
+	resp, err := client.GetPodMetrics(podNames, selector)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var metrics []*core.PodMetrics
+	if err := json.Unmarshal(resp.PodMetrics, &metrics); err != nil {
+		log.Fatal(err)
+	}
+
+	if len(metrics) == 0 && resp.Status != -2 {
+		fmt.Println("No pod metrics found")
+		return
+	}
+	sort.Slice(metrics, func(i, j int) bool {
+		return lessUsage(metrics[i].Usage, metrics[j].Usage, metrics[i].Name, metrics[j].Name)
+	})
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tCPU(cores)\tMEMORY(bytes)")
+	for _, m := range metrics {
+		fmt.Fprintf(
+			w,
+			"%v\t%v\t%v\n",
+			m.Name,
+			formatCPU(m.Usage[core.ResourceCPU]),
+			formatMemory(m.Usage[core.ResourceMemory]),
+		)
+	}
+	w.Flush()
+
+	if resp.Status == -2 {
+		fmt.Printf("Metrics of the following pods are not available: %v\n", resp.NotFoundPodNames)
+	}
+}
+
+// lessUsage orders by the resource given by --sort-by in descending order, and by name otherwise.
+func lessUsage(a, b core.ResourceList, nameA, nameB string) bool {
+	var resource core.ResourceName
+	switch topSortBy {
+	case "cpu":
+		resource = core.ResourceCPU
+	case "memory":
+		resource = core.ResourceMemory
+	default:
+		return nameA < nameB
+	}
+	if cmp := a[resource].Cmp(b[resource]); cmp != 0 {
+		return cmp > 0
+	}
+	return nameA < nameB
+}
+
+func formatCPU(q core.Quantity) string {
+	return fmt.Sprintf("%vm", q.MilliValue())
+}
+
+func formatMemory(q core.Quantity) string {
+	return fmt.Sprintf("%vMi", q.Value()/(1<<20))
+}
+
+// formatPercentage formats usage as a percentage of the capacity of resource, or <unknown> if the
+// capacity has not been reported.
+func formatPercentage(usage core.Quantity, capacity core.ResourceList, resource core.ResourceName) string {
+	total, ok := capacity[resource]
+	if !ok || total.IsZero() {
+		return "<unknown>"
+	}
+	return fmt.Sprintf("%v%%", usage.MilliValue()*100/total.MilliValue())
+}
@@ -12,7 +12,10 @@ import (
 	pb "p9t.io/kuberboat/pkg/proto"
 )
 
-const CONN_TIMEOUT time.Duration = time.Second
+const (
+	CONN_TIMEOUT time.Duration = time.Second
+	WAIT_TIMEOUT time.Duration = 30 * time.Second
+)
 
 type KubeletClient struct {
 	connection *grpc.ClientConn
@@ -62,3 +65,78 @@ func (c *KubeletClient) NotifyPodDeletion(success bool, pod *core.Pod) (*pb.Defa
 		DeletedPod: podData,
 	})
 }
+
+// GetNodePods returns the pods that the apiserver has scheduled on the node of this kubelet. The
+// call waits for the apiserver to become reachable, since the kubelet can be notified by an
+// apiserver that is still recovering and not serving yet.
+func (c *KubeletClient) GetNodePods() ([]*core.Pod, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), WAIT_TIMEOUT)
+	defer cancel()
+	resp, err := c.client.GetNodePods(ctx, &pb.EmptyRequest{}, grpc.WaitForReady(true))
+	if err != nil {
+		return nil, err
+	}
+	var pods []*core.Pod
+	if err := json.Unmarshal(resp.Pods, &pods); err != nil {
+		return nil, err
+	}
+	return pods, nil
+}
+
+func (c *KubeletClient) UpdateMirrorPod(pod *core.Pod) (*pb.DefaultResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CONN_TIMEOUT)
+	defer cancel()
+	podData, err := json.Marshal(pod)
+	if err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
+	}
+	return c.client.UpdateMirrorPod(ctx, &pb.UpdateMirrorPodRequest{
+		Pod: podData,
+	})
+}
+
+func (c *KubeletClient) DeleteMirrorPod(podName string) (*pb.DefaultResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CONN_TIMEOUT)
+	defer cancel()
+	return c.client.DeleteMirrorPod(ctx, &pb.DeleteMirrorPodRequest{
+		PodName: podName,
+	})
+}
+
+func (c *KubeletClient) UpdateNodePressures(pressures []core.NodePressure) (*pb.DefaultResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CONN_TIMEOUT)
+	defer cancel()
+	req := &pb.UpdateNodePressuresRequest{Pressures: make([]string, 0, len(pressures))}
+	for _, pressure := range pressures {
+		req.Pressures = append(req.Pressures, string(pressure))
+	}
+	return c.client.UpdateNodePressures(ctx, req)
+}
+
+// UpdateNodeCapacity reports the capacity of the node. Like GetNodePods, it waits for the apiserver
+// to become reachable, since it is sent when the kubelet is notified of its registration.
+func (c *KubeletClient) UpdateNodeCapacity(capacity core.ResourceList) (*pb.DefaultResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), WAIT_TIMEOUT)
+	defer cancel()
+	data, err := json.Marshal(capacity)
+	if err != nil {
+		return &pb.DefaultResponse{Status: -1}, err
+	}
+	return c.client.UpdateNodeCapacity(ctx, &pb.UpdateNodeCapacityRequest{Capacity: data}, grpc.WaitForReady(true))
+}
+
+// GetRegistryCredentials returns the registry credentials of the given names, as well as the names
+// of the credentials that do not exist.
+func (c *KubeletClient) GetRegistryCredentials(names []string) ([]*core.RegistryCredential, []string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CONN_TIMEOUT)
+	defer cancel()
+	resp, err := c.client.GetRegistryCredentials(ctx, &pb.GetRegistryCredentialsRequest{Names: names})
+	if err != nil {
+		return nil, nil, err
+	}
+	var credentials []*core.RegistryCredential
+	if err := json.Unmarshal(resp.RegistryCredentials, &credentials); err != nil {
+		return nil, nil, err
+	}
+	return credentials, resp.NotFoundNames, nil
+}
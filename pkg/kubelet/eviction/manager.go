@@ -0,0 +1,190 @@
+package eviction
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"p9t.io/kuberboat/pkg/api/core"
+)
+
+// monitoringInterval is the number of seconds between two checks of the node resources.
+const monitoringInterval = 10
+
+// PodStats is the resource usage of a pod.
+type PodStats struct {
+	// MemoryUsage is the working set of the containers in bytes.
+	MemoryUsage uint64
+	// DiskUsage is the size of the writable layers of the containers in bytes.
+	DiskUsage uint64
+	// PIDs is the number of processes of the containers.
+	PIDs uint64
+}
+
+// Agent gives the eviction manager access to the node it protects.
+type Agent interface {
+	// ActivePods returns the pods whose containers are running.
+	ActivePods() []*core.Pod
+	// NodeStats observes the resources of the node.
+	NodeStats() (map[Signal]Observation, error)
+	// PodStats returns the resource usage of a pod.
+	PodStats(pod *core.Pod) (*PodStats, error)
+	// EvictPod stops all containers of a pod and marks it as failed because of eviction.
+	EvictPod(pod *core.Pod, message string) error
+	// ReportNodePressures tells the api server about the resource pressures of the node.
+	ReportNodePressures(pressures []core.NodePressure)
+}
+
+// Manager evicts pods when the node is running out of resources, before the kernel OOM killer or
+// a full disk starts breaking arbitrary containers.
+type Manager interface {
+	// Start checks the node resources periodically in the background.
+	Start()
+	// Pressures returns the resource pressures that the node is under.
+	Pressures() []core.NodePressure
+}
+
+type managerInner struct {
+	mtx sync.Mutex
+	// thresholds are the minimum amounts of resources to keep available.
+	thresholds []Threshold
+	// agent provides stats and evicts pods.
+	agent Agent
+	// pressures are the resource pressures that the node was under at the last check.
+	pressures []core.NodePressure
+}
+
+func NewManager(thresholds []Threshold, agent Agent) Manager {
+	return &managerInner{
+		thresholds: thresholds,
+		agent:      agent,
+		pressures:  []core.NodePressure{},
+	}
+}
+
+func (m *managerInner) Start() {
+	if len(m.thresholds) == 0 {
+		glog.Info("no eviction thresholds, eviction manager disabled")
+		return
+	}
+	go func() {
+		for range time.Tick(time.Second * monitoringInterval) {
+			m.synchronize()
+		}
+	}()
+}
+
+func (m *managerInner) Pressures() []core.NodePressure {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return m.pressures
+}
+
+// synchronize checks the thresholds, reports changed pressures, and evicts at most one pod so
+// that the effect of the eviction is observed before the next one.
+func (m *managerInner) synchronize() {
+	observations, err := m.agent.NodeStats()
+	if err != nil {
+		glog.Errorf("cannot observe node resources: %v", err.Error())
+		return
+	}
+
+	crossed := make([]Threshold, 0)
+	pressures := make([]core.NodePressure, 0)
+	for _, threshold := range m.thresholds {
+		observation, ok := observations[threshold.Signal]
+		if !ok || !threshold.Crossed(observation) {
+			continue
+		}
+		crossed = append(crossed, threshold)
+		pressures = appendPressure(pressures, pressureBySignal[threshold.Signal])
+	}
+
+	m.mtx.Lock()
+	changed := !samePressures(m.pressures, pressures)
+	m.pressures = pressures
+	m.mtx.Unlock()
+	if changed {
+		glog.Infof("node pressures changed to %v", pressures)
+		m.agent.ReportNodePressures(pressures)
+	}
+
+	if len(crossed) == 0 {
+		return
+	}
+	threshold := crossed[0]
+	observation := observations[threshold.Signal]
+	candidates := m.rankPods(threshold.Signal)
+	if len(candidates) == 0 {
+		glog.Errorf("node is low on %v but no pod can be evicted", threshold.Signal)
+		return
+	}
+	message := fmt.Sprintf(
+		"The node was low on resource: %v. Threshold quantity: %v, available: %v.",
+		threshold.Signal,
+		threshold.MinAvailable(observation),
+		observation.Available,
+	)
+	pod := candidates[0]
+	glog.Infof("POD [%v]: evicting pod: %v", pod.Name, message)
+	if err := m.agent.EvictPod(pod, message); err != nil {
+		glog.Errorf("POD [%v]: cannot evict pod: %v", pod.Name, err.Error())
+	}
+}
+
+// rankPods orders the active pods by how likely they should be evicted to reclaim the resource
+// measured by signal. For memory, pods using more than they requested come first, ordered by the
+// usage above their requests. For disk and PIDs, pods are ordered by usage.
+func (m *managerInner) rankPods(signal Signal) []*core.Pod {
+	pods := m.agent.ActivePods()
+	scores := make(map[*core.Pod]int64)
+	ranked := make([]*core.Pod, 0, len(pods))
+	for _, pod := range pods {
+		stats, err := m.agent.PodStats(pod)
+		if err != nil {
+			glog.Errorf("POD [%v]: cannot get stats: %v", pod.Name, err.Error())
+			continue
+		}
+		scores[pod] = evictionScore(signal, pod, stats)
+		ranked = append(ranked, pod)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return scores[ranked[i]] > scores[ranked[j]]
+	})
+	return ranked
+}
+
+// evictionScore is higher for pods that should be evicted first.
+func evictionScore(signal Signal, pod *core.Pod, stats *PodStats) int64 {
+	switch signal {
+	case SignalMemoryAvailable:
+		return int64(stats.MemoryUsage) - core.PodRequests(pod)[core.ResourceMemory].Value()
+	case SignalPIDAvailable:
+		return int64(stats.PIDs)
+	default:
+		return int64(stats.DiskUsage)
+	}
+}
+
+func appendPressure(pressures []core.NodePressure, pressure core.NodePressure) []core.NodePressure {
+	for _, p := range pressures {
+		if p == pressure {
+			return pressures
+		}
+	}
+	return append(pressures, pressure)
+}
+
+func samePressures(a []core.NodePressure, b []core.NodePressure) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
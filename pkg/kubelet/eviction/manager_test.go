@@ -0,0 +1,112 @@
+package eviction
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"p9t.io/kuberboat/pkg/api/core"
+)
+
+type fakeAgent struct {
+	pods        []*core.Pod
+	nodeStats   map[Signal]Observation
+	podStats    map[*core.Pod]*PodStats
+	evicted     []*core.Pod
+	pressures   []core.NodePressure
+	reportCount int
+}
+
+func (a *fakeAgent) ActivePods() []*core.Pod {
+	return a.pods
+}
+
+func (a *fakeAgent) NodeStats() (map[Signal]Observation, error) {
+	return a.nodeStats, nil
+}
+
+func (a *fakeAgent) PodStats(pod *core.Pod) (*PodStats, error) {
+	return a.podStats[pod], nil
+}
+
+func (a *fakeAgent) EvictPod(pod *core.Pod, message string) error {
+	a.evicted = append(a.evicted, pod)
+	return nil
+}
+
+func (a *fakeAgent) ReportNodePressures(pressures []core.NodePressure) {
+	a.pressures = pressures
+	a.reportCount++
+}
+
+func podWithMemoryRequest(name string, request int64) *core.Pod {
+	return &core.Pod{
+		ObjectMeta: core.ObjectMeta{Name: name},
+		Spec: core.PodSpec{
+			Containers: []core.Container{
+				{
+					Name: "c",
+					Resources: core.ResourceRequirements{
+						Requests: core.ResourceList{core.ResourceMemory: core.NewMilliQuantity(request * 1000)},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestSynchronizeEvictsPodExceedingRequest(t *testing.T) {
+	big := podWithMemoryRequest("big", 900)
+	greedy := podWithMemoryRequest("greedy", 100)
+	agent := &fakeAgent{
+		pods: []*core.Pod{big, greedy},
+		nodeStats: map[Signal]Observation{
+			SignalMemoryAvailable: {Available: 50, Capacity: 2000},
+			SignalNodeFsAvailable: {Available: 500, Capacity: 1000},
+		},
+		podStats: map[*core.Pod]*PodStats{
+			big:    {MemoryUsage: 800},
+			greedy: {MemoryUsage: 400},
+		},
+	}
+	thresholds := []Threshold{
+		{Signal: SignalMemoryAvailable, Quantity: 100},
+		{Signal: SignalNodeFsAvailable, Percentage: 0.1},
+	}
+	m := NewManager(thresholds, agent).(*managerInner)
+
+	m.synchronize()
+	assert.Equal(t, []*core.Pod{greedy}, agent.evicted)
+	assert.Equal(t, []core.NodePressure{core.NodeMemoryPressure}, agent.pressures)
+	assert.Equal(t, []core.NodePressure{core.NodeMemoryPressure}, m.Pressures())
+
+	// Unchanged pressures are not reported again.
+	m.synchronize()
+	assert.Equal(t, 1, agent.reportCount)
+
+	agent.nodeStats[SignalMemoryAvailable] = Observation{Available: 1000, Capacity: 2000}
+	agent.evicted = nil
+	m.synchronize()
+	assert.Empty(t, agent.evicted)
+	assert.Empty(t, agent.pressures)
+	assert.Equal(t, 2, agent.reportCount)
+}
+
+func TestSynchronizeEvictsLargestDiskUser(t *testing.T) {
+	small := podWithMemoryRequest("small", 0)
+	large := podWithMemoryRequest("large", 0)
+	agent := &fakeAgent{
+		pods: []*core.Pod{small, large},
+		nodeStats: map[Signal]Observation{
+			SignalImageFsAvailable: {Available: 10, Capacity: 1000},
+		},
+		podStats: map[*core.Pod]*PodStats{
+			small: {DiskUsage: 10},
+			large: {DiskUsage: 300},
+		},
+	}
+	m := NewManager([]Threshold{{Signal: SignalImageFsAvailable, Percentage: 0.15}}, agent).(*managerInner)
+
+	m.synchronize()
+	assert.Equal(t, []*core.Pod{large}, agent.evicted)
+	assert.Equal(t, []core.NodePressure{core.NodeDiskPressure}, agent.pressures)
+}
@@ -0,0 +1,99 @@
+package eviction
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"p9t.io/kuberboat/pkg/api/core"
+)
+
+// Signal is a resource of the node that is watched by the eviction manager.
+type Signal string
+
+// These are the valid eviction signals.
+const (
+	// SignalMemoryAvailable is the memory that is available for new processes.
+	SignalMemoryAvailable Signal = "memory.available"
+	// SignalNodeFsAvailable is the free space of the root file system.
+	SignalNodeFsAvailable Signal = "nodefs.available"
+	// SignalImageFsAvailable is the free space of the file system where docker stores images and
+	// container layers.
+	SignalImageFsAvailable Signal = "imagefs.available"
+	// SignalPIDAvailable is the number of processes that can still be created.
+	SignalPIDAvailable Signal = "pid.available"
+)
+
+// pressureBySignal is the node pressure caused by crossing the threshold of a signal.
+var pressureBySignal = map[Signal]core.NodePressure{
+	SignalMemoryAvailable:  core.NodeMemoryPressure,
+	SignalNodeFsAvailable:  core.NodeDiskPressure,
+	SignalImageFsAvailable: core.NodeDiskPressure,
+	SignalPIDAvailable:     core.NodePIDPressure,
+}
+
+// DefaultThresholds are the thresholds used when the kubelet is not configured otherwise.
+const DefaultThresholds = "memory.available<100Mi,nodefs.available<10%,imagefs.available<15%"
+
+// Threshold is the minimum amount of a resource that must be available on the node. Either
+// Quantity or Percentage of the capacity is set.
+type Threshold struct {
+	Signal     Signal
+	Quantity   uint64
+	Percentage float64
+}
+
+// Observation is the amount of a resource that is available on the node and its capacity.
+type Observation struct {
+	Available uint64
+	Capacity  uint64
+}
+
+// MinAvailable returns the amount of the resource below which the threshold is crossed.
+func (t *Threshold) MinAvailable(observation Observation) uint64 {
+	if t.Percentage > 0 {
+		return uint64(t.Percentage * float64(observation.Capacity))
+	}
+	return t.Quantity
+}
+
+// Crossed tells whether the available resource is below the threshold.
+func (t *Threshold) Crossed(observation Observation) bool {
+	return observation.Available < t.MinAvailable(observation)
+}
+
+// ParseThresholds parses thresholds in the form of "memory.available<100Mi,nodefs.available<10%".
+// Quantities are written like the resources of containers, e.g. 100Mi.
+func ParseThresholds(expr string) ([]Threshold, error) {
+	thresholds := make([]Threshold, 0)
+	if strings.TrimSpace(expr) == "" {
+		return thresholds, nil
+	}
+	for _, item := range strings.Split(expr, ",") {
+		parts := strings.Split(strings.TrimSpace(item), "<")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid eviction threshold %v, expected SIGNAL<QUANTITY", item)
+		}
+		signal := Signal(parts[0])
+		if _, ok := pressureBySignal[signal]; !ok {
+			return nil, fmt.Errorf("unknown eviction signal %v", signal)
+		}
+		threshold := Threshold{Signal: signal}
+		value := parts[1]
+		if strings.HasSuffix(value, "%") {
+			percentage, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+			if err != nil || percentage <= 0 || percentage > 100 {
+				return nil, fmt.Errorf("invalid percentage %v for eviction signal %v", value, signal)
+			}
+			threshold.Percentage = percentage / 100
+		} else {
+			quantity, err := core.ParseQuantity(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid quantity %v for eviction signal %v", value, signal)
+			}
+			threshold.Quantity = uint64(quantity.Value())
+		}
+		thresholds = append(thresholds, threshold)
+	}
+	return thresholds, nil
+}
@@ -0,0 +1,41 @@
+package eviction
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseThresholds(t *testing.T) {
+	thresholds, err := ParseThresholds("memory.available<100Mi, nodefs.available<10%,pid.available<1000")
+	assert.Nil(t, err)
+	assert.Equal(t, []Threshold{
+		{Signal: SignalMemoryAvailable, Quantity: 100 << 20},
+		{Signal: SignalNodeFsAvailable, Percentage: 0.1},
+		{Signal: SignalPIDAvailable, Quantity: 1000},
+	}, thresholds)
+
+	thresholds, err = ParseThresholds("")
+	assert.Nil(t, err)
+	assert.Empty(t, thresholds)
+
+	_, err = ParseThresholds("cpu.available<1")
+	assert.NotNil(t, err)
+	_, err = ParseThresholds("memory.available>100Mi")
+	assert.NotNil(t, err)
+	_, err = ParseThresholds("memory.available<100MB")
+	assert.NotNil(t, err)
+	_, err = ParseThresholds("nodefs.available<120%")
+	assert.NotNil(t, err)
+}
+
+func TestThresholdCrossed(t *testing.T) {
+	quantity := Threshold{Signal: SignalMemoryAvailable, Quantity: 100}
+	assert.True(t, quantity.Crossed(Observation{Available: 99, Capacity: 1000}))
+	assert.False(t, quantity.Crossed(Observation{Available: 100, Capacity: 1000}))
+
+	percentage := Threshold{Signal: SignalNodeFsAvailable, Percentage: 0.1}
+	assert.Equal(t, uint64(100), percentage.MinAvailable(Observation{Available: 50, Capacity: 1000}))
+	assert.True(t, percentage.Crossed(Observation{Available: 50, Capacity: 1000}))
+	assert.False(t, percentage.Crossed(Observation{Available: 500, Capacity: 1000}))
+}
@@ -0,0 +1,54 @@
+package kubelet
+
+import (
+	"context"
+
+	"github.com/golang/glog"
+	"p9t.io/kuberboat/pkg/api/core"
+	"p9t.io/kuberboat/pkg/kubelet/eviction"
+)
+
+func (kl *dockerKubelet) StartEvictionManager(thresholds []eviction.Threshold) {
+	kl.evictionManager = eviction.NewManager(thresholds, kl)
+	kl.evictionManager.Start()
+}
+
+func (kl *dockerKubelet) ActivePods() []*core.Pod {
+	pods := make([]*core.Pod, 0)
+	for _, pod := range kl.GetPods() {
+		if pod.Status.Phase == core.PodReady {
+			pods = append(pods, pod)
+		}
+	}
+	return pods
+}
+
+func (kl *dockerKubelet) EvictPod(pod *core.Pod, message string) error {
+	// Mark the pod as failed first, so that the status sync triggered by the containers being
+	// stopped leaves it alone.
+	kl.mtx.Lock()
+	if pod.Status.Phase != core.PodReady {
+		kl.mtx.Unlock()
+		return nil
+	}
+	pod.Status.Phase = core.PodFailed
+	pod.Status.RunningContainers = 0
+	pod.Status.Reason = core.PodReasonEvicted
+	pod.Status.Message = message
+	kl.mtx.Unlock()
+
+	// The pod is kept until the api server deletes it, but its containers and volumes are removed
+	// to reclaim the resources.
+	err := kl.killPod(context.Background(), pod)
+	kl.reportPodStatus(pod)
+	return err
+}
+
+func (kl *dockerKubelet) ReportNodePressures(pressures []core.NodePressure) {
+	if kl.apiClient == nil {
+		return
+	}
+	if _, err := kl.apiClient.UpdateNodePressures(pressures); err != nil {
+		glog.Errorf("cannot report node pressures: %v", err.Error())
+	}
+}
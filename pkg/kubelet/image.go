@@ -0,0 +1,291 @@
+package kubelet
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	dockertypes "github.com/docker/docker/api/types"
+	dockerclient "github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
+	units "github.com/docker/go-units"
+	"github.com/golang/glog"
+	"p9t.io/kuberboat/pkg/api/core"
+)
+
+const (
+	// imagePullBackOffInitial is the delay before a pod whose image failed to pull is retried. It
+	// doubles with every failure up to imagePullBackOffMax.
+	imagePullBackOffInitial = 10 * time.Second
+	imagePullBackOffMax     = 300 * time.Second
+	// pullProgressInterval is the minimum interval between two reports of the pull progress.
+	pullProgressInterval = 5 * time.Second
+	// defaultRegistry is the registry of images whose names do not include one.
+	defaultRegistry = "docker.io"
+)
+
+// imagePullError means the images of a pod are not ready, so that the pod has to wait.
+type imagePullError struct {
+	// reason is the CamelCase reason of the pending pod.
+	reason string
+	// message is the detail of the error.
+	message string
+}
+
+func (e *imagePullError) Error() string {
+	return e.message
+}
+
+// pullPodImages makes sure that the images of a pod are present according to their pull policies.
+func (kl *dockerKubelet) pullPodImages(ctx context.Context, pod *core.Pod) error {
+	if err := kl.ensureImage(ctx, pod, pauseImage, core.PullIfNotPresent, ""); err != nil {
+		return err
+	}
+	credentials := kl.podRegistryCredentials(pod)
+	for i := range pod.Spec.Containers {
+		c := &pod.Spec.Containers[i]
+		auth, err := registryAuth(c.Image, credentials)
+		if err != nil {
+			return err
+		}
+		if err := kl.ensureImage(ctx, pod, c.Image, core.ContainerPullPolicy(c), auth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureImage pulls an image if required by the pull policy.
+func (kl *dockerKubelet) ensureImage(
+	ctx context.Context,
+	pod *core.Pod,
+	image string,
+	policy core.PullPolicy,
+	auth string,
+) error {
+	_, _, err := kl.dockerClient.ImageInspectWithRaw(ctx, image)
+	if err != nil && !dockerclient.IsErrNotFound(err) {
+		return err
+	}
+	present := err == nil
+
+	if policy == core.PullNever && !present {
+		return &imagePullError{
+			reason:  core.PodReasonErrImageNeverPull,
+			message: fmt.Sprintf("image %v is not present with pull policy of Never", image),
+		}
+	}
+	if policy == core.PullAlways || !present {
+		if err := kl.pullImage(ctx, pod, image, auth); err != nil {
+			return &imagePullError{
+				reason:  core.PodReasonImagePullBackOff,
+				message: fmt.Sprintf("cannot pull image %v: %v", image, err.Error()),
+			}
+		}
+	}
+
+	if imageJson, _, err := kl.dockerClient.ImageInspectWithRaw(ctx, image); err == nil {
+		kl.recordImageUse(imageJson.ID)
+	}
+	return nil
+}
+
+// pullImage pulls an image and reports its progress as the message of the pod.
+func (kl *dockerKubelet) pullImage(ctx context.Context, pod *core.Pod, image string, auth string) error {
+	glog.Infof("POD [%v]: pulling image %v", pod.Name, image)
+	out, err := kl.dockerClient.ImagePull(ctx, image, dockertypes.ImagePullOptions{RegistryAuth: auth})
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	// The pull stream has a progress message for every layer, indexed by layer ID.
+	progress := make(map[string]*jsonmessage.JSONProgress)
+	lastReport := time.Time{}
+	decoder := json.NewDecoder(out)
+	for {
+		var msg jsonmessage.JSONMessage
+		if err := decoder.Decode(&msg); err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if msg.ID != "" && msg.Progress != nil && msg.Progress.Total > 0 {
+			progress[msg.ID] = msg.Progress
+		}
+		if time.Since(lastReport) >= pullProgressInterval {
+			lastReport = time.Now()
+			pod.Status.Reason = core.PodReasonPullingImage
+			pod.Status.Message = pullProgressMessage(image, progress)
+			kl.reportPodStatus(pod)
+		}
+	}
+	glog.Infof("POD [%v]: pulled image %v", pod.Name, image)
+	return nil
+}
+
+// pullProgressMessage summarizes the progress of the layers being pulled.
+func pullProgressMessage(image string, progress map[string]*jsonmessage.JSONProgress) string {
+	var current, total int64
+	for _, p := range progress {
+		current += p.Current
+		total += p.Total
+	}
+	if total == 0 {
+		return fmt.Sprintf("pulling image %v", image)
+	}
+	if current > total {
+		current = total
+	}
+	return fmt.Sprintf(
+		"pulling image %v: %v / %v",
+		image,
+		units.HumanSize(float64(current)),
+		units.HumanSize(float64(total)),
+	)
+}
+
+// podRegistryCredentials returns the registry credentials referenced by the image pull secrets of
+// a pod. Missing credentials are skipped, so that public images still pull.
+func (kl *dockerKubelet) podRegistryCredentials(pod *core.Pod) []*core.RegistryCredential {
+	if len(pod.Spec.ImagePullSecrets) == 0 {
+		return nil
+	}
+	if kl.apiClient == nil {
+		glog.Warningf("POD [%v]: image pull secrets are ignored without api server", pod.Name)
+		return nil
+	}
+	credentials, notFound, err := kl.apiClient.GetRegistryCredentials(pod.Spec.ImagePullSecrets)
+	if err != nil {
+		glog.Errorf("POD [%v]: cannot get registry credentials: %v", pod.Name, err.Error())
+		return nil
+	}
+	if len(notFound) > 0 {
+		glog.Warningf("POD [%v]: registry credentials not found: %v", pod.Name, notFound)
+	}
+	return credentials
+}
+
+// registryAuth returns the encoded docker auth config of the credential whose server is the
+// registry of image, or an empty string if there is none.
+func registryAuth(image string, credentials []*core.RegistryCredential) (string, error) {
+	registry := imageRegistry(image)
+	for _, credential := range credentials {
+		if normalizeRegistry(credential.Spec.Server) != registry {
+			continue
+		}
+		data, err := json.Marshal(dockertypes.AuthConfig{
+			Username:      credential.Spec.Username,
+			Password:      credential.Spec.Password,
+			ServerAddress: credential.Spec.Server,
+		})
+		if err != nil {
+			return "", err
+		}
+		return base64.URLEncoding.EncodeToString(data), nil
+	}
+	return "", nil
+}
+
+// imageRegistry returns the registry of an image. The first component of the image name is the
+// registry only if it looks like a host name, as in registry.example.com:5000/app.
+func imageRegistry(image string) string {
+	i := strings.Index(image, "/")
+	if i < 0 {
+		return defaultRegistry
+	}
+	domain := image[:i]
+	if !strings.ContainsAny(domain, ".:") && domain != "localhost" {
+		return defaultRegistry
+	}
+	return normalizeRegistry(domain)
+}
+
+// normalizeRegistry strips the scheme and path of a registry address, and maps the aliases of
+// Docker Hub to docker.io.
+func normalizeRegistry(server string) string {
+	server = strings.TrimPrefix(server, "https://")
+	server = strings.TrimPrefix(server, "http://")
+	server = strings.Split(server, "/")[0]
+	if server == "index.docker.io" || server == "registry-1.docker.io" {
+		return defaultRegistry
+	}
+	return server
+}
+
+// startPod pulls the images of a pod and runs its containers. If the images are not ready, the
+// pod stays pending and is started again after a back-off.
+func (kl *dockerKubelet) startPod(ctx context.Context, pod *core.Pod) error {
+	if err := kl.pullPodImages(ctx, pod); err != nil {
+		kl.backOffPodStart(pod, err)
+		return err
+	}
+	kl.resetPullBackOff(pod)
+	pod.Status.Reason = ""
+	pod.Status.Message = ""
+	return kl.runPod(ctx, pod)
+}
+
+// backOffPodStart reports why the images of a pod are not ready, and schedules the next start.
+func (kl *dockerKubelet) backOffPodStart(pod *core.Pod, err error) {
+	delay := kl.nextPullBackOff(pod)
+	pod.Status.Reason = core.PodReasonImagePullBackOff
+	if pullErr, ok := err.(*imagePullError); ok {
+		pod.Status.Reason = pullErr.reason
+	}
+	pod.Status.Message = fmt.Sprintf("%v, retrying in %v", err.Error(), delay)
+	glog.Errorf("POD [%v]: %v", pod.Name, pod.Status.Message)
+	kl.reportPodStatus(pod)
+
+	time.AfterFunc(delay, func() {
+		// The pod may have been deleted or replaced while waiting.
+		if current, ok := kl.GetPodByName(pod.Name); !ok || current != pod {
+			kl.resetPullBackOff(pod)
+			return
+		}
+		if err := kl.startPod(context.Background(), pod); err != nil {
+			glog.Errorf("failed to create pod: %v", err.Error())
+		}
+	})
+}
+
+// nextPullBackOff returns how long a pod waits before pulling its images again.
+func (kl *dockerKubelet) nextPullBackOff(pod *core.Pod) time.Duration {
+	kl.imagesMtx.Lock()
+	defer kl.imagesMtx.Unlock()
+	delay := nextBackOff(kl.pullBackOff[pod.UUID.String()])
+	kl.pullBackOff[pod.UUID.String()] = delay
+	return delay
+}
+
+func (kl *dockerKubelet) resetPullBackOff(pod *core.Pod) {
+	kl.imagesMtx.Lock()
+	defer kl.imagesMtx.Unlock()
+	delete(kl.pullBackOff, pod.UUID.String())
+}
+
+// nextBackOff doubles the previous back-off, starting at imagePullBackOffInitial.
+func nextBackOff(previous time.Duration) time.Duration {
+	if previous == 0 {
+		return imagePullBackOffInitial
+	}
+	if previous*2 > imagePullBackOffMax {
+		return imagePullBackOffMax
+	}
+	return previous * 2
+}
+
+// recordImageUse remembers when an image was last used to start a pod, so that recently used
+// images are garbage collected last.
+func (kl *dockerKubelet) recordImageUse(imageID string) {
+	kl.imagesMtx.Lock()
+	defer kl.imagesMtx.Unlock()
+	kl.imageLastUsed[imageID] = time.Now()
+}
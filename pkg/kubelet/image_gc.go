@@ -0,0 +1,172 @@
+package kubelet
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	dockertypes "github.com/docker/docker/api/types"
+	"github.com/golang/glog"
+)
+
+const (
+	// imageGCInterval is the number of seconds between two checks of the image disk usage.
+	imageGCInterval = 300
+	// imageMinAge is how long an image is kept after it was last used, so that images pulled for a
+	// pod that is starting are not removed.
+	imageMinAge = 2 * time.Minute
+)
+
+// ImageGCPolicy tells when unused images are removed. When the disk usage of the image file system
+// exceeds HighThresholdPercent, the least recently used images are removed until it is at most
+// LowThresholdPercent.
+type ImageGCPolicy struct {
+	HighThresholdPercent int
+	LowThresholdPercent  int
+}
+
+// Validate checks that the thresholds are percentages and the low one is below the high one.
+func (p *ImageGCPolicy) Validate() error {
+	if p.HighThresholdPercent < 0 || p.HighThresholdPercent > 100 {
+		return fmt.Errorf("invalid image gc high threshold %v, must be in [0, 100]", p.HighThresholdPercent)
+	}
+	if p.LowThresholdPercent < 0 || p.LowThresholdPercent > 100 {
+		return fmt.Errorf("invalid image gc low threshold %v, must be in [0, 100]", p.LowThresholdPercent)
+	}
+	if p.LowThresholdPercent > p.HighThresholdPercent {
+		return fmt.Errorf(
+			"image gc low threshold %v is greater than high threshold %v",
+			p.LowThresholdPercent,
+			p.HighThresholdPercent,
+		)
+	}
+	return nil
+}
+
+func (kl *dockerKubelet) StartImageGC(policy ImageGCPolicy) {
+	if policy.HighThresholdPercent >= 100 {
+		glog.Info("image gc high threshold is 100%, image garbage collection disabled")
+		return
+	}
+	go func() {
+		for range time.Tick(time.Second * imageGCInterval) {
+			if err := kl.garbageCollectImages(context.Background(), policy); err != nil {
+				glog.Errorf("image garbage collection failed: %v", err.Error())
+			}
+		}
+	}()
+}
+
+// garbageCollectImages removes images that no container uses when the image file system is
+// fuller than the high threshold.
+func (kl *dockerKubelet) garbageCollectImages(ctx context.Context, policy ImageGCPolicy) error {
+	info, err := kl.dockerClient.Info(ctx)
+	if err != nil {
+		return err
+	}
+	fs, err := fsObservation(info.DockerRootDir)
+	if err != nil {
+		return err
+	}
+	if fs.Capacity == 0 {
+		return nil
+	}
+	used := int64(fs.Capacity - fs.Available)
+	usagePercent := int(used * 100 / int64(fs.Capacity))
+	if usagePercent < policy.HighThresholdPercent {
+		return nil
+	}
+	bytesToFree := used - int64(fs.Capacity)*int64(policy.LowThresholdPercent)/100
+	glog.Infof("image disk usage is %v%%, freeing %v bytes", usagePercent, bytesToFree)
+
+	// Images of stopped containers are in use as well, since the containers cannot be removed.
+	containers, err := kl.dockerClient.ContainerList(ctx, dockertypes.ContainerListOptions{All: true})
+	if err != nil {
+		return err
+	}
+	inUse := make(map[string]bool)
+	for _, c := range containers {
+		inUse[c.ImageID] = true
+	}
+	// The pause image is needed by every pod.
+	if pauseJson, _, err := kl.dockerClient.ImageInspectWithRaw(ctx, pauseImage); err == nil {
+		inUse[pauseJson.ID] = true
+	}
+
+	summaries, err := kl.dockerClient.ImageList(ctx, dockertypes.ImageListOptions{})
+	if err != nil {
+		return err
+	}
+	images := make([]imageRecord, 0, len(summaries))
+	kl.imagesMtx.Lock()
+	for _, summary := range summaries {
+		lastUsed, ok := kl.imageLastUsed[summary.ID]
+		if !ok {
+			lastUsed = time.Unix(summary.Created, 0)
+		}
+		images = append(images, imageRecord{id: summary.ID, size: summary.Size, lastUsed: lastUsed})
+	}
+	kl.imagesMtx.Unlock()
+
+	var freed int64
+	for _, image := range selectImagesToRemove(images, inUse, bytesToFree, imageMinAge, time.Now()) {
+		_, err := kl.dockerClient.ImageRemove(ctx, image.id, dockertypes.ImageRemoveOptions{
+			Force:         true,
+			PruneChildren: true,
+		})
+		if err != nil {
+			glog.Errorf("cannot remove image %v: %v", image.id, err.Error())
+			continue
+		}
+		kl.imagesMtx.Lock()
+		delete(kl.imageLastUsed, image.id)
+		kl.imagesMtx.Unlock()
+		freed += image.size
+		glog.Infof("removed unused image %v", image.id)
+	}
+	if freed < bytesToFree {
+		return fmt.Errorf("freed %v bytes of images, %v bytes requested", freed, bytesToFree)
+	}
+	return nil
+}
+
+// imageRecord is an image on the node considered by the garbage collector.
+type imageRecord struct {
+	id   string
+	size int64
+	// lastUsed is when a pod was last started with the image, or when it was created if unknown.
+	lastUsed time.Time
+}
+
+// selectImagesToRemove returns the images to remove to free bytesToFree bytes, least recently used
+// first. Images in use and images used within minAge are kept.
+func selectImagesToRemove(
+	images []imageRecord,
+	inUse map[string]bool,
+	bytesToFree int64,
+	minAge time.Duration,
+	now time.Time,
+) []imageRecord {
+	candidates := make([]imageRecord, 0, len(images))
+	for _, image := range images {
+		if inUse[image.id] || now.Sub(image.lastUsed) < minAge {
+			continue
+		}
+		candidates = append(candidates, image)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].lastUsed.Before(candidates[j].lastUsed)
+	})
+
+	selected := make([]imageRecord, 0)
+	var freed int64
+	for _, image := range candidates {
+		if freed >= bytesToFree {
+			break
+		}
+		selected = append(selected, image)
+		freed += image.size
+	}
+	return selected
+}
@@ -0,0 +1,100 @@
+package kubelet
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	dockertypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/stretchr/testify/assert"
+	"p9t.io/kuberboat/pkg/api/core"
+)
+
+func TestContainerPullPolicy(t *testing.T) {
+	cases := map[string]core.PullPolicy{
+		"nginx":                         core.PullAlways,
+		"nginx:latest":                  core.PullAlways,
+		"nginx:1.21":                    core.PullIfNotPresent,
+		"localhost:5000/app":            core.PullAlways,
+		"localhost:5000/app:v1":         core.PullIfNotPresent,
+		"nginx@sha256:0123456789abcdef": core.PullIfNotPresent,
+	}
+	for image, policy := range cases {
+		assert.Equal(t, policy, core.ContainerPullPolicy(&core.Container{Image: image}), image)
+	}
+	assert.Equal(t, core.PullNever, core.ContainerPullPolicy(&core.Container{
+		Image:           "nginx",
+		ImagePullPolicy: core.PullNever,
+	}))
+}
+
+func TestImageRegistry(t *testing.T) {
+	assert.Equal(t, "docker.io", imageRegistry("nginx"))
+	assert.Equal(t, "docker.io", imageRegistry("library/nginx:1.21"))
+	assert.Equal(t, "docker.io", imageRegistry("docker.io/library/nginx"))
+	assert.Equal(t, "registry.example.com:5000", imageRegistry("registry.example.com:5000/team/app:v1"))
+	assert.Equal(t, "localhost", imageRegistry("localhost/app"))
+	assert.Equal(t, "docker.io", normalizeRegistry("https://index.docker.io/v1/"))
+}
+
+func TestRegistryAuth(t *testing.T) {
+	credentials := []*core.RegistryCredential{
+		{Spec: core.RegistryCredentialSpec{Server: "https://registry.example.com", Username: "u", Password: "p"}},
+	}
+	auth, err := registryAuth("registry.example.com/app", credentials)
+	assert.Nil(t, err)
+	data, err := base64.URLEncoding.DecodeString(auth)
+	assert.Nil(t, err)
+	var config dockertypes.AuthConfig
+	assert.Nil(t, json.Unmarshal(data, &config))
+	assert.Equal(t, "u", config.Username)
+	assert.Equal(t, "p", config.Password)
+
+	auth, err = registryAuth("nginx", credentials)
+	assert.Nil(t, err)
+	assert.Empty(t, auth)
+}
+
+func TestNextBackOff(t *testing.T) {
+	delay := nextBackOff(0)
+	assert.Equal(t, imagePullBackOffInitial, delay)
+	assert.Equal(t, 2*imagePullBackOffInitial, nextBackOff(delay))
+	assert.Equal(t, imagePullBackOffMax, nextBackOff(imagePullBackOffMax))
+}
+
+func TestPullProgressMessage(t *testing.T) {
+	assert.Equal(t, "pulling image nginx", pullProgressMessage("nginx", nil))
+	progress := map[string]*jsonmessage.JSONProgress{
+		"a": {Current: 1000, Total: 2000},
+		"b": {Current: 500, Total: 1000},
+	}
+	assert.Equal(t, "pulling image nginx: 1.5kB / 3kB", pullProgressMessage("nginx", progress))
+}
+
+func TestSelectImagesToRemove(t *testing.T) {
+	now := time.Now()
+	images := []imageRecord{
+		{id: "recent", size: 100, lastUsed: now.Add(-time.Minute)},
+		{id: "used", size: 100, lastUsed: now.Add(-3 * time.Hour)},
+		{id: "old", size: 100, lastUsed: now.Add(-2 * time.Hour)},
+		{id: "older", size: 100, lastUsed: now.Add(-4 * time.Hour)},
+		{id: "newer", size: 100, lastUsed: now.Add(-time.Hour)},
+	}
+	inUse := map[string]bool{"used": true}
+
+	selected := selectImagesToRemove(images, inUse, 150, imageMinAge, now)
+	assert.Equal(t, []string{"older", "old"}, imageIDs(selected))
+
+	selected = selectImagesToRemove(images, inUse, 1000, imageMinAge, now)
+	assert.Equal(t, []string{"older", "old", "newer"}, imageIDs(selected))
+}
+
+func imageIDs(images []imageRecord) []string {
+	ids := make([]string, 0, len(images))
+	for _, image := range images {
+		ids = append(ids, image.id)
+	}
+	return ids
+}
@@ -31,6 +31,9 @@ type KubeProxy interface {
 	AddPodToServices(serviceNames []string, podName string, podIP string) error
 	// DeletePodFromService deletes a pod from an existing service by rewriting all the rules.
 	DeletePodFromServices(serviceNames []string, podName string) error
+	// SetServicePods replaces all the pods of an existing service at once, so that no request is
+	// routed to a mix of the old and new pods in between.
+	SetServicePods(serviceName string, podNames []string, podIPs []string) error
 }
 
 type kubeProxyInner struct {
@@ -282,6 +285,107 @@ func (kp *kubeProxyInner) DeletePodFromServices(serviceNames []string, podName s
 	return err
 }
 
+func (kp *kubeProxyInner) SetServicePods(serviceName string, podNames []string, podIPs []string) error {
+	kp.mtx.Lock()
+	defer kp.mtx.Unlock()
+
+	if !kp.serviceMetaManager.ServiceExists(serviceName) {
+		return fmt.Errorf("no such service: %s", serviceName)
+	}
+
+	serviceChains := kp.serviceMetaManager.GetServiceChains(serviceName)
+
+	// Prepare the chains of the new pods for every port before touching any service chain.
+	newPodChains := make([][]*kubeproxy.PodChain, len(serviceChains))
+	for i, serviceChain := range serviceChains {
+		for j := range podNames {
+			podChain := &kubeproxy.PodChain{
+				ChainName: kp.iptablesClient.CreatePodChain(),
+				PodName:   podNames[j],
+				PodIP:     podIPs[j],
+			}
+			newPodChains[i] = append(newPodChains[i], podChain)
+			_, exist := kp.podMetaManager.PodByName(podChain.PodName)
+			err := kp.iptablesClient.ApplyPodChainRules(
+				podChain.ChainName,
+				podChain.PodIP,
+				serviceChain.ServicePort.TargetPort,
+				exist,
+			)
+			if err != nil {
+				for _, podChains := range newPodChains {
+					kp.deletePodChains(podChains)
+				}
+				return err
+			}
+		}
+	}
+
+	// Switch the service chains to the new pods. If one fails, the switched ones go back to the old
+	// pods, which the metadata still holds.
+	for i, serviceChain := range serviceChains {
+		err := kp.switchServiceChain(serviceName, serviceChain.ChainName, newPodChains[i])
+		if err == nil {
+			continue
+		}
+		for _, switched := range serviceChains[:i+1] {
+			oldPodChains := kp.serviceMetaManager.GetPodChains(switched.ChainName)
+			if err := kp.switchServiceChain(serviceName, switched.ChainName, oldPodChains); err != nil {
+				glog.Error(err)
+			}
+		}
+		for _, podChains := range newPodChains {
+			kp.deletePodChains(podChains)
+		}
+		return err
+	}
+
+	// Remove the chains of the old pods and update metadata.
+	for i, serviceChain := range serviceChains {
+		kp.deletePodChains(kp.serviceMetaManager.GetPodChains(serviceChain.ChainName))
+		kp.serviceMetaManager.DeletePodChains(serviceChain.ChainName)
+		for _, podChain := range newPodChains[i] {
+			kp.serviceMetaManager.AddPodChain(serviceChain.ChainName, podChain)
+		}
+	}
+
+	return nil
+}
+
+// switchServiceChain makes a service chain jump to pod chains by round robin, instead of the ones
+// it jumped to.
+func (kp *kubeProxyInner) switchServiceChain(
+	serviceName string,
+	serviceChainName string,
+	podChains []*kubeproxy.PodChain,
+) error {
+	if err := kp.iptablesClient.ClearServiceChain(serviceName, serviceChainName); err != nil {
+		return err
+	}
+	for i, podChain := range podChains {
+		err := kp.iptablesClient.ApplyPodChain(
+			serviceName,
+			serviceChainName,
+			podChain.PodName,
+			podChain.ChainName,
+			i+1,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deletePodChains deletes pod chains and their rules, logging the errors.
+func (kp *kubeProxyInner) deletePodChains(podChains []*kubeproxy.PodChain) {
+	for _, podChain := range podChains {
+		if err := kp.iptablesClient.DeletePodChain(podChain.PodName, podChain.ChainName); err != nil {
+			glog.Error(err)
+		}
+	}
+}
+
 // getNetInterfaceIpv4Addr gets the IPV4 address of given net interface.
 func getNetInterfaceIpv4Addr(interfaceName string) string {
 	ifaces, _ := net.Interfaces()
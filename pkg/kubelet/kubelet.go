@@ -7,7 +7,6 @@ import (
 	"io"
 	"net"
 	"os"
-	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
@@ -18,14 +17,17 @@ import (
 
 	dockertypes "github.com/docker/docker/api/types"
 	dockercontainer "github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/strslice"
+	dockervolume "github.com/docker/docker/api/types/volume"
 	dockerclient "github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
 	dockernat "github.com/docker/go-connections/nat"
 	etcd "go.etcd.io/etcd/client/v3"
+	"golang.org/x/sys/unix"
 	"p9t.io/kuberboat/pkg/api"
 	"p9t.io/kuberboat/pkg/api/core"
 	"p9t.io/kuberboat/pkg/kubelet/client"
+	"p9t.io/kuberboat/pkg/kubelet/eviction"
 	kubeletpod "p9t.io/kuberboat/pkg/kubelet/pod"
 )
 
@@ -41,20 +43,23 @@ const (
 	resolvConfAnchor        = "# Kuberboat DNS name server."
 	etcdPort                = 2379
 	etcdDialTimeout         = 2000000000
-	monitorInterval         = 3
+	relistInterval          = 30
+	eventsRetryDelay        = time.Second
 )
 
 // Kubelet defines public methods of a PodManager.
 // All methods are thread safe.
 type Kubelet interface {
-	// ConnectToServer initializes grpc client to the api server.
+	// ConnectToServer initializes grpc client to the api server, and reconciles the pods on the
+	// node with the pods that the api server has scheduled on it.
 	ConnectToServer(cluster *core.ApiserverStatus) error
 	// GetPods returns the pods bound to the kubelet and their spec.
 	GetPods() []*core.Pod
 	// GetPodByName provides the pod that matches name, as well as whether the pod was found.
 	GetPodByName(name string) (*core.Pod, bool)
 	// AddPod runs a pod based on the pod spec passed in as parameter.
-	// The status and metadata of the pod will be managed.
+	// The status and metadata of the pod will be managed. A pod whose images are not ready stays
+	// pending and is started again after a back-off.
 	AddPod(ctx context.Context, pod *core.Pod) error
 	// DeletePodByName destroys a pod indexed by name and all its containers.
 	DeletePodByName(ctx context.Context, name string) error
@@ -62,9 +67,39 @@ type Kubelet interface {
 	StartCAdvisor() error
 	// GetPodLog gets the logs of pod's container.
 	GetPodLog(ctx context.Context, podName string) string
-	// MonitorPods checks the status of each pod.
-	// The rule is that if all the containers except pause is down then the Pod is down.
-	monitorPods()
+	// StreamPodLog writes the log of a container in a pod to out according to opts. If opts.Follow
+	// is set, it blocks until ctx is cancelled or the container stops.
+	StreamPodLog(ctx context.Context, podName string, opts *core.PodLogOptions, out io.Writer) error
+	// ExecInContainer runs a command in a container of a pod and wires up its standard streams.
+	// If opts.TTY is set, terminal size changes are read from resize until it is closed.
+	// It blocks until the command exits and returns its exit code.
+	ExecInContainer(
+		ctx context.Context,
+		podName string,
+		opts *core.ExecOptions,
+		stdin io.Reader,
+		stdout, stderr io.Writer,
+		resize <-chan core.TerminalSize,
+	) (int, error)
+	// DialPodPort opens a TCP connection to a port of a pod from inside the pod's network
+	// namespace, so that ports only listening on localhost are reachable as well.
+	DialPodPort(ctx context.Context, podName string, port uint16) (net.Conn, error)
+	// RunStaticPods runs the pods described by the YAML manifests in manifestDir without the api
+	// server, reports them to the api server as read-only mirror pods, and keeps them in sync with
+	// the manifests. It never returns.
+	RunStaticPods(manifestDir string)
+	// StartEvictionManager starts evicting pods whenever a resource of the node falls below its
+	// threshold, and reporting the resource pressures of the node to the api server.
+	StartEvictionManager(thresholds []eviction.Threshold)
+	// StartImageGC starts removing unused images whenever the disk usage of images exceeds the
+	// high threshold of policy.
+	StartImageGC(policy ImageGCPolicy)
+	// StatsSummary collects the CPU, memory, network and filesystem usage of the node and of each
+	// of its pods and containers.
+	StatsSummary(ctx context.Context) (*core.StatsSummary, error)
+	// relistPods syncs the status of every pod with the container runtime. It is the fallback
+	// for container events missed by the event watcher.
+	relistPods()
 }
 
 // Kubelet is the core data structure of the component. It manages pods, containers, monitors.
@@ -73,7 +108,7 @@ type dockerKubelet struct {
 	dnsIP string
 	// Client to communicate with API server.
 	apiClient *client.KubeletClient
-	// Ensure concurrent access to inner data structures are safe.
+	// Ensure concurrent access to inner data structures are safe. Serializes pod status syncs.
 	mtx sync.Mutex
 	// Docker client to access docker apis.
 	dockerClient *dockerclient.Client
@@ -81,6 +116,18 @@ type dockerKubelet struct {
 	podMetaManager kubeletpod.MetaManager
 	// Manage pod runtime data.
 	podRuntimeManager kubeletpod.RuntimeManager
+	// Static pods described by the manifest directory, indexed by name.
+	staticPods map[string]*core.Pod
+	// Ensure concurrent access to static pods is safe.
+	staticPodsMtx sync.RWMutex
+	// Evict pods when the node runs out of resources. Nil until StartEvictionManager is called.
+	evictionManager eviction.Manager
+	// Back-off of the next image pull of pending pods, indexed by pod UUID.
+	pullBackOff map[string]time.Duration
+	// Time when images were last used to start a pod, indexed by image ID.
+	imageLastUsed map[string]time.Time
+	// Ensure concurrent access to image records is safe.
+	imagesMtx sync.Mutex
 }
 
 // newKubelet creates a new Kubelet object.
@@ -94,10 +141,13 @@ func NewKubelet(podMetaManager kubeletpod.MetaManager) Kubelet {
 		dockerClient:      cli,
 		podMetaManager:    podMetaManager,
 		podRuntimeManager: kubeletpod.NewRuntimeManager(),
+		pullBackOff:       map[string]time.Duration{},
+		imageLastUsed:     map[string]time.Time{},
 	}
+	go kubelet.watchContainerEvents()
 	go func() {
-		for range time.Tick(time.Second * monitorInterval) {
-			kubelet.monitorPods()
+		for range time.Tick(time.Second * relistInterval) {
+			kubelet.relistPods()
 		}
 	}()
 	return kubelet
@@ -110,6 +160,9 @@ func (kl *dockerKubelet) ConnectToServer(apiserverStatus *core.ApiserverStatus)
 	}
 	kl.apiClient = apiClient
 	glog.Infof("connected to api server at %v:%v", apiserverStatus.IP, apiserverStatus.Port)
+	if err := saveApiserverStatus(apiserverStatus); err != nil {
+		glog.Errorf("cannot save apiserver status, pods will not be recovered after restart: %v", err.Error())
+	}
 
 	// Get CoreDNS IP from etcd. This IP is a pod IP, which will be used by pods.
 	var dnsIP string
@@ -138,6 +191,13 @@ func (kl *dockerKubelet) ConnectToServer(apiserverStatus *core.ApiserverStatus)
 	}
 	kl.dnsIP = dnsIP
 
+	// The apiserver may be recovering, so reconcile pods without blocking the notification.
+	go kl.reconcilePods()
+	go kl.reportNodeCapacity()
+	if kl.evictionManager != nil {
+		go kl.ReportNodePressures(kl.evictionManager.Pressures())
+	}
+
 	// Get CoreDNS-host IP from etcd. This IP is used to modify /etc/resolv.conf,
 	// for use of host machine to access domain name.
 	if os.Getenv(api.CiMode) != "ON" {
@@ -218,6 +278,21 @@ func updateResolvConf(dnsHostIP string) error {
 	return nil
 }
 
+// reportPodStatus notifies the api server of the status of a pod. Static pods are reported as
+// mirror pods, and are not reported at all while the kubelet is not connected to an api server.
+func (kl *dockerKubelet) reportPodStatus(pod *core.Pod) {
+	if kl.apiClient == nil {
+		return
+	}
+	if core.IsMirrorPod(pod) {
+		if _, err := kl.apiClient.UpdateMirrorPod(pod); err != nil {
+			glog.Errorf("cannot update mirror pod %v: %v", pod.Name, err.Error())
+		}
+		return
+	}
+	kl.apiClient.UpdatePodStatus(pod)
+}
+
 func (kl *dockerKubelet) GetPods() []*core.Pod {
 	return kl.podMetaManager.Pods()
 }
@@ -238,17 +313,30 @@ func (kl *dockerKubelet) AddPod(ctx context.Context, pod *core.Pod) error {
 	kl.podMetaManager.AddPod(pod)
 	// TODO: Defer broadcasting condition variable. CV and mtx should be a member of the kubelet.
 
+	return kl.startPod(ctx, pod)
+}
+
+// runPod runs the sandbox and containers of a pod whose images are present.
+func (kl *dockerKubelet) runPod(ctx context.Context, pod *core.Pod) error {
 	// Start sandbox pause container. If sandbox container fails to start, then no other container
 	// could get started and the pod will be marked as failed.
 	if err := kl.runPodSandBox(ctx, pod); err != nil {
 		glog.Errorf("cannot create sandbox: %v", err.Error())
 		pod.Status.Phase = core.PodFailed
-		kl.apiClient.UpdatePodStatus(pod)
+		kl.reportPodStatus(pod)
+		return err
+	}
+
+	// Create pod volumes up front, so that they are labeled and can be found after a restart.
+	if err := kl.createPodVolumes(ctx, pod); err != nil {
+		glog.Errorf("cannot create volumes: %v", err.Error())
+		pod.Status.Phase = core.PodFailed
+		kl.reportPodStatus(pod)
 		return err
 	}
 
 	// Start user containers. Here we won't care about whether the container has started successfully.
-	// This will be checked by the monitor.
+	// This will be checked by the pod status sync.
 	for _, c := range pod.Spec.Containers {
 		err := kl.runPodContainer(ctx, pod, &c)
 		if err != nil {
@@ -258,35 +346,19 @@ func (kl *dockerKubelet) AddPod(ctx context.Context, pod *core.Pod) error {
 
 	// Notify API server.
 	pod.Status.Phase = core.PodReady
-	kl.apiClient.UpdatePodStatus(pod)
+	kl.reportPodStatus(pod)
 
-	// TODO(yuanxin.cao): Start a monitor to monitor pod status.
+	// Containers may have terminated before the pod became ready, and their events were ignored.
+	kl.syncPodStatus(pod)
 	return nil
 }
 
-// runPodSandBox pulls pause image and runs pause container.
+// runPodSandBox runs pause container.
 // The name of the pause container will be "<pod UUID>_pause"
 // User pods will share network and PID space with this container.
 func (kl *dockerKubelet) runPodSandBox(ctx context.Context, pod *core.Pod) error {
 	cli := kl.dockerClient
 
-	// Pull pause image.
-	out, err := cli.ImagePull(ctx, pauseImage, dockertypes.ImagePullOptions{})
-	if err != nil {
-		return err
-	}
-	pullRes, err := io.ReadAll(out)
-	if err != nil {
-		return nil
-	}
-	glog.Info(string(pullRes[:]))
-	defer func(out io.ReadCloser) {
-		err := out.Close()
-		if err != nil {
-			glog.Error(err)
-		}
-	}(out)
-
 	// Populate exposed ports.
 	ports := make(map[dockernat.Port]struct{})
 	for _, c := range pod.Spec.Containers {
@@ -299,6 +371,7 @@ func (kl *dockerKubelet) runPodSandBox(ctx context.Context, pod *core.Pod) error
 	resp, err := cli.ContainerCreate(ctx, &dockercontainer.Config{
 		Image:        pauseImage,
 		ExposedPorts: ports,
+		Labels:       podResourceLabels(pod, sandBoxContainerName),
 	}, &dockercontainer.HostConfig{
 		DNS:     []string{kl.dnsIP},
 		IpcMode: "shareable",
@@ -327,70 +400,87 @@ func (kl *dockerKubelet) runPodSandBox(ctx context.Context, pod *core.Pod) error
 	return nil
 }
 
+// createPodVolumes creates the docker volumes mounted by the containers of a pod. Jobs mount
+// volumes shared across pods, which are left to docker. Claimed volumes outlive the pod, so they
+// are neither labelled nor recorded for removal, and are reused if they already exist.
+func (kl *dockerKubelet) createPodVolumes(ctx context.Context, pod *core.Pod) error {
+	if _, isJob := pod.Labels["JobSpecificLabel"]; isJob {
+		return nil
+	}
+	created := make(map[string]bool)
+	for _, c := range pod.Spec.Containers {
+		for _, m := range c.VolumeMounts {
+			if created[m.Name] {
+				continue
+			}
+			if claim, ok := pod.Spec.VolumeClaims[m.Name]; ok {
+				_, err := kl.dockerClient.VolumeCreate(ctx, dockervolume.VolumeCreateBody{Name: claim})
+				if err != nil {
+					return err
+				}
+				created[m.Name] = true
+				continue
+			}
+			name := core.GetPodSpecificName(pod, m.Name)
+			_, err := kl.dockerClient.VolumeCreate(ctx, dockervolume.VolumeCreateBody{
+				Name:   name,
+				Labels: podResourceLabels(pod, m.Name),
+			})
+			if err != nil {
+				return err
+			}
+			kl.podRuntimeManager.AddPodVolume(pod, name)
+			created[m.Name] = true
+		}
+	}
+	return nil
+}
+
 // runPodContainer runs a container and joins it to pod's pause container.
 func (kl *dockerKubelet) runPodContainer(ctx context.Context, pod *core.Pod, c *core.Container) error {
 	pauseContainerName := core.GetPodSpecificPauseName(pod)
 	cli := kl.dockerClient
 
-	// Pull image.
-	out, err := cli.ImagePull(ctx, c.Image, dockertypes.ImagePullOptions{})
-	if err != nil {
-		return err
-	}
-	pullRes, err := io.ReadAll(out)
-	if err != nil {
-		return nil
-	}
-	glog.Info(string(pullRes[:]))
-	defer func(out io.ReadCloser) {
-		err := out.Close()
-		if err != nil {
-			glog.Error(err)
-		}
-	}(out)
-
 	// Populate volume bindings.
 	vBinds := make([]string, 0, len(c.VolumeMounts))
 	_, isJob := pod.Labels["JobSpecificLabel"]
 	for _, m := range c.VolumeMounts {
-		if isJob {
+		if claim, ok := pod.Spec.VolumeClaims[m.Name]; ok {
+			vBinds = append(vBinds, fmt.Sprintf("%v:%v", claim, m.MountPath))
+		} else if isJob {
 			vBinds = append(vBinds, fmt.Sprintf("%v:%v", m.Name, m.MountPath))
 		} else {
 			vBinds = append(vBinds, fmt.Sprintf("%v:%v", core.GetPodSpecificName(pod, m.Name), m.MountPath))
 		}
 	}
 
-	// Populate resources.
-	resources := dockercontainer.Resources{}
-	if bytes, ok := c.Resources["memory"]; ok {
-		if int64(bytes) < 0 {
-			return fmt.Errorf("memory limit overflow: %v", bytes)
-		} else {
-			resources.Memory = int64(bytes)
-		}
-	}
-	if cpu, ok := c.Resources["cpu"]; ok {
-		if int64(cpu) < 0 {
-			return fmt.Errorf("cpu limit overflow: %v", cpu)
-		}
-		if int(cpu) > runtime.NumCPU() {
-			cpu = uint64(runtime.NumCPU())
-		}
-		resources.NanoCPUs = 1000000000 * int64(cpu)
+	// Populate resources. Requests weigh the container against others under contention, while
+	// limits cap what it can use.
+	resources := containerResources(c)
+	var memoryCapacity int64
+	if memory, err := memoryObservation(); err == nil {
+		memoryCapacity = int64(memory.Capacity)
 	}
 
 	// Create container.
 	mode := fmt.Sprintf("container:%v", pauseContainerName)
-	resp, err := cli.ContainerCreate(ctx, &dockercontainer.Config{
-		Image: c.Image,
-		Cmd:   c.Commands,
-	}, &dockercontainer.HostConfig{
+	config := &dockercontainer.Config{
+		Image:  c.Image,
+		Cmd:    c.Commands,
+		Labels: podResourceLabels(pod, c.Name),
+	}
+	hostConfig := &dockercontainer.HostConfig{
 		Binds:       vBinds,
 		NetworkMode: dockercontainer.NetworkMode(mode),
 		IpcMode:     dockercontainer.IpcMode(mode),
 		PidMode:     dockercontainer.PidMode(mode),
 		Resources:   resources,
-	}, nil, nil, core.GetPodSpecificName(pod, c.Name))
+		OomScoreAdj: oomScoreAdj(pod, c, memoryCapacity),
+	}
+	if err := applySecurityContext(core.EffectiveSecurityContext(pod, c), config, hostConfig); err != nil {
+		return err
+	}
+	resp, err := cli.ContainerCreate(ctx, config, hostConfig, nil, nil, core.GetPodSpecificName(pod, c.Name))
 	if err != nil {
 		return err
 	}
@@ -422,6 +512,11 @@ func (kl *dockerKubelet) DeletePodByName(ctx context.Context, name string) (err
 	// TODO: Wait until pod is done adding. By doing while () { cv.Wait() }
 	kl.podMetaManager.DeletePodByName(name)
 
+	return kl.killPod(ctx, pod)
+}
+
+// killPod stops and removes the containers and volumes of a pod that is no longer managed.
+func (kl *dockerKubelet) killPod(ctx context.Context, pod *core.Pod) error {
 	// Remove user containers.
 	containers, _ := kl.podRuntimeManager.ContainersByPod(pod)
 	for _, c := range containers {
@@ -565,69 +660,206 @@ func (kl *dockerKubelet) GetPodLog(ctx context.Context, podName string) string {
 	return logBuilder.String()
 }
 
-func (kl *dockerKubelet) monitorPods() {
-	pods := kl.GetPods()
+func (kl *dockerKubelet) StreamPodLog(
+	ctx context.Context,
+	podName string,
+	opts *core.PodLogOptions,
+	out io.Writer,
+) error {
+	pod, ok := kl.GetPodByName(podName)
+	if !ok {
+		return fmt.Errorf("pod %v not found", podName)
+	}
+	container, err := selectPodContainer(pod, opts.Container)
+	if err != nil {
+		return err
+	}
+	containerName := core.GetPodSpecificName(pod, container.Name)
+
+	containerJson, err := kl.dockerClient.ContainerInspect(ctx, containerName)
+	if err != nil {
+		return fmt.Errorf("cannot find container %v in pod %v: %v", container.Name, podName, err.Error())
+	}
+	// Containers are never restarted in place, so the previous instance of a container is
+	// the container itself once it has terminated.
+	if opts.Previous && containerJson.State.Running {
+		return fmt.Errorf("previous terminated container %v in pod %v not found", container.Name, podName)
+	}
+
+	logOptions := dockertypes.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     opts.Follow && !opts.Previous,
+		Timestamps: opts.Timestamps,
+	}
+	if opts.TailLines >= 0 {
+		logOptions.Tail = strconv.FormatInt(opts.TailLines, 10)
+	}
+	if opts.SinceSeconds > 0 {
+		logOptions.Since = strconv.FormatInt(time.Now().Unix()-opts.SinceSeconds, 10)
+	}
+	logReader, err := kl.dockerClient.ContainerLogs(ctx, containerJson.ID, logOptions)
+	if err != nil {
+		return fmt.Errorf("fail to get container %v's log: %v", container.Name, err.Error())
+	}
+	defer logReader.Close()
+
+	// Containers are created without TTY, so stdout and stderr are multiplexed in the stream.
+	if _, err := stdcopy.StdCopy(out, out, logReader); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+func (kl *dockerKubelet) ExecInContainer(
+	ctx context.Context,
+	podName string,
+	opts *core.ExecOptions,
+	stdin io.Reader,
+	stdout, stderr io.Writer,
+	resize <-chan core.TerminalSize,
+) (int, error) {
 	cli := kl.dockerClient
-	exitCodeReg, _ := regexp.Compile(`\(\d+\)`)
-	for _, pod := range pods {
-		if pod.Status.Phase == core.PodSucceeded || pod.Status.Phase == core.PodFailed {
-			continue
-		}
-		containerIds, ok := kl.podRuntimeManager.ContainersByPod(pod)
-		if ok {
-			isFinished := true
-			isFailed := false
-			numRunningContainers := 0
-			// first check if all the containers are created
-			if len(containerIds) != len(pod.Spec.Containers) && pod.Status.Phase == core.PodReady {
-				isFailed = true
-			} else {
-				for _, containerId := range containerIds {
-					containers, err := cli.ContainerList(context.Background(), dockertypes.ContainerListOptions{
-						All: true,
-						Filters: filters.NewArgs(
-							filters.Arg("id", containerId),
-						),
-					})
-					if err != nil {
-						glog.Errorf("fail to query container %v's status: %v", containerId, err)
-						continue
-					}
-					container := containers[0]
-					switch container.State {
-					case "exited":
-						m := exitCodeReg.FindString(container.Status)
-						exitCode, err := strconv.Atoi(m[1 : len(m)-1])
-						if err != nil {
-							glog.Errorf("fail to parse container %v's exit code: %v", containerId, err)
-						}
-						if exitCode != 0 {
-							isFailed = true
-						}
-					case "dead":
-						isFailed = true
-					default:
-						numRunningContainers++
-						isFinished = false
-					}
-				}
+	pod, ok := kl.GetPodByName(podName)
+	if !ok {
+		return -1, fmt.Errorf("pod %v not found", podName)
+	}
+	container, err := selectPodContainer(pod, opts.Container)
+	if err != nil {
+		return -1, err
+	}
+	if len(opts.Command) == 0 {
+		return -1, fmt.Errorf("no command specified")
+	}
+
+	execResp, err := cli.ContainerExecCreate(ctx, core.GetPodSpecificName(pod, container.Name), dockertypes.ExecConfig{
+		Cmd:          opts.Command,
+		Tty:          opts.TTY,
+		AttachStdin:  opts.Stdin,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return -1, fmt.Errorf("cannot exec in container %v of pod %v: %v", container.Name, podName, err.Error())
+	}
+	hijacked, err := cli.ContainerExecAttach(ctx, execResp.ID, dockertypes.ExecStartCheck{Tty: opts.TTY})
+	if err != nil {
+		return -1, fmt.Errorf("cannot attach to exec in container %v of pod %v: %v", container.Name, podName, err.Error())
+	}
+	defer hijacked.Close()
+
+	if opts.Stdin && stdin != nil {
+		go func() {
+			if _, err := io.Copy(hijacked.Conn, stdin); err != nil {
+				glog.Warningf("exec stdin of pod %v interrupted: %v", podName, err)
 			}
-			if isFinished {
-				if isFailed || numRunningContainers == 0 {
-					pod.Status.Phase = core.PodFailed
-					glog.Infof("pod %v failed", pod.Name)
-				} else {
-					pod.Status.Phase = core.PodSucceeded
-					glog.Infof("pod %v succeed", pod.Name)
+			hijacked.CloseWrite()
+		}()
+	}
+	if opts.TTY && resize != nil {
+		go func() {
+			for size := range resize {
+				if err := cli.ContainerExecResize(ctx, execResp.ID, dockertypes.ResizeOptions{
+					Height: uint(size.Height),
+					Width:  uint(size.Width),
+				}); err != nil {
+					glog.Warningf("cannot resize exec terminal of pod %v: %v", podName, err)
 				}
-				pod.Status.RunningContainers = numRunningContainers
-				kl.apiClient.UpdatePodStatus(pod)
-			} else if pod.Status.RunningContainers != numRunningContainers {
-				pod.Status.RunningContainers = numRunningContainers
-				kl.apiClient.UpdatePodStatus(pod)
 			}
-		} else {
-			glog.Errorf("pod %v has no containers", pod.Name)
+		}()
+	}
+
+	// Output of a TTY is raw, otherwise stdout and stderr are multiplexed.
+	if opts.TTY {
+		_, err = io.Copy(stdout, hijacked.Reader)
+	} else {
+		_, err = stdcopy.StdCopy(stdout, stderr, hijacked.Reader)
+	}
+	if err != nil && ctx.Err() == nil {
+		return -1, err
+	}
+
+	inspect, err := cli.ContainerExecInspect(ctx, execResp.ID)
+	if err != nil {
+		return -1, err
+	}
+	return inspect.ExitCode, nil
+}
+
+func (kl *dockerKubelet) DialPodPort(ctx context.Context, podName string, port uint16) (net.Conn, error) {
+	pod, ok := kl.GetPodByName(podName)
+	if !ok {
+		return nil, fmt.Errorf("pod %v not found", podName)
+	}
+	sandBox, ok := kl.podRuntimeManager.SandBoxByPod(pod)
+	if !ok {
+		return nil, fmt.Errorf("cannot find sandbox for pod: %v", podName)
+	}
+	containerJson, err := kl.dockerClient.ContainerInspect(ctx, sandBox)
+	if err != nil {
+		return nil, err
+	}
+	if !containerJson.State.Running || containerJson.State.Pid == 0 {
+		return nil, fmt.Errorf("sandbox of pod %v is not running", podName)
+	}
+	return dialInNetNS(containerJson.State.Pid, fmt.Sprintf("127.0.0.1:%v", port))
+}
+
+// dialInNetNS dials addr from inside the network namespace of process pid. The socket belongs
+// to the namespace it is created in, so it stays there after the thread switches back.
+func dialInNetNS(pid int, addr string) (net.Conn, error) {
+	runtime.LockOSThread()
+	hostNS, err := os.Open(fmt.Sprintf("/proc/self/task/%d/ns/net", unix.Gettid()))
+	if err != nil {
+		runtime.UnlockOSThread()
+		return nil, err
+	}
+	defer hostNS.Close()
+	podNS, err := os.Open(fmt.Sprintf("/proc/%d/ns/net", pid))
+	if err != nil {
+		runtime.UnlockOSThread()
+		return nil, err
+	}
+	defer podNS.Close()
+
+	if err := unix.Setns(int(podNS.Fd()), unix.CLONE_NEWNET); err != nil {
+		runtime.UnlockOSThread()
+		return nil, fmt.Errorf("cannot enter network namespace of process %v: %v", pid, err.Error())
+	}
+	conn, dialErr := net.Dial("tcp", addr)
+	if err := unix.Setns(int(hostNS.Fd()), unix.CLONE_NEWNET); err != nil {
+		// Leave the thread locked so that it is destroyed with the goroutine instead of being
+		// reused in the wrong namespace.
+		if conn != nil {
+			conn.Close()
+		}
+		return nil, fmt.Errorf("cannot restore network namespace: %v", err.Error())
+	}
+	runtime.UnlockOSThread()
+	return conn, dialErr
+}
+
+// selectPodContainer finds the container named name in pod. If name is empty, the pod must
+// have exactly one container.
+func selectPodContainer(pod *core.Pod, name string) (*core.Container, error) {
+	if name == "" {
+		if len(pod.Spec.Containers) != 1 {
+			names := make([]string, 0, len(pod.Spec.Containers))
+			for _, c := range pod.Spec.Containers {
+				names = append(names, c.Name)
+			}
+			return nil, fmt.Errorf(
+				"a container name must be specified for pod %v, choose one of: %v",
+				pod.Name,
+				names,
+			)
+		}
+		return &pod.Spec.Containers[0], nil
+	}
+	for i := range pod.Spec.Containers {
+		if pod.Spec.Containers[i].Name == name {
+			return &pod.Spec.Containers[i], nil
 		}
 	}
+	return nil, fmt.Errorf("container %v is not valid for pod %v", name, pod.Name)
 }
@@ -3,9 +3,12 @@ package kubelet
 import (
 	"context"
 	"flag"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	dockertypes "github.com/docker/docker/api/types"
 	"github.com/golang/glog"
 
 	"github.com/google/uuid"
@@ -130,3 +133,79 @@ func TestAddInvalidPod(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.NotEmpty(t, kl.GetPods())
 }
+
+func TestSelectPodContainer(t *testing.T) {
+	c, err := selectPodContainer(&testPod, "redis")
+	assert.Nil(t, err)
+	assert.Equal(t, "redis", c.Name)
+
+	_, err = selectPodContainer(&testPod, "")
+	assert.NotNil(t, err)
+
+	_, err = selectPodContainer(&testPod, "mysql")
+	assert.NotNil(t, err)
+
+	singleContainerPod := testPod
+	singleContainerPod.Spec.Containers = testPod.Spec.Containers[:1]
+	c, err = selectPodContainer(&singleContainerPod, "")
+	assert.Nil(t, err)
+	assert.Equal(t, "nginx", c.Name)
+}
+
+func TestComputePodPhase(t *testing.T) {
+	running := &dockertypes.ContainerState{Status: "running", Running: true}
+	succeeded := &dockertypes.ContainerState{Status: "exited"}
+	failed := &dockertypes.ContainerState{Status: "exited", ExitCode: 1}
+	oomKilled := &dockertypes.ContainerState{Status: "exited", ExitCode: 0, OOMKilled: true}
+
+	phase, n := computePodPhase([]*dockertypes.ContainerState{running, succeeded}, 2)
+	assert.Equal(t, core.PodReady, phase)
+	assert.Equal(t, 1, n)
+
+	phase, _ = computePodPhase([]*dockertypes.ContainerState{running, failed}, 2)
+	assert.Equal(t, core.PodReady, phase)
+
+	phase, n = computePodPhase([]*dockertypes.ContainerState{succeeded, succeeded}, 2)
+	assert.Equal(t, core.PodSucceeded, phase)
+	assert.Equal(t, 0, n)
+
+	phase, _ = computePodPhase([]*dockertypes.ContainerState{succeeded, failed}, 2)
+	assert.Equal(t, core.PodFailed, phase)
+
+	phase, _ = computePodPhase([]*dockertypes.ContainerState{oomKilled}, 1)
+	assert.Equal(t, core.PodFailed, phase)
+
+	phase, _ = computePodPhase([]*dockertypes.ContainerState{running}, 2)
+	assert.Equal(t, core.PodFailed, phase)
+}
+
+func TestReadStaticPodManifests(t *testing.T) {
+	dir := t.TempDir()
+	manifest := []byte(`kind: Pod
+metadata:
+  name: log-shipper
+spec:
+  containers:
+    - name: fluentd
+      image: fluentd:latest
+`)
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "log-shipper.yaml"), manifest, 0644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a manifest"), 0644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "invalid.yaml"), []byte("kind: Service"), 0644))
+
+	pods, err := readStaticPodManifests(dir, "node1")
+	assert.Nil(t, err)
+	assert.Len(t, pods, 1)
+	pod := pods["log-shipper-node1"]
+	assert.NotNil(t, pod)
+	assert.True(t, core.IsMirrorPod(pod))
+	assert.Equal(t, "node1", pod.Labels[core.MirrorPodLabel])
+
+	// The UUID only changes with the manifest.
+	again, _ := readStaticPodManifests(dir, "node1")
+	assert.Equal(t, pod.UUID, again["log-shipper-node1"].UUID)
+	changed := append(manifest, []byte("      commands: [\"fluentd\", \"-v\"]\n")...)
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "log-shipper.yaml"), changed, 0644))
+	again, _ = readStaticPodManifests(dir, "node1")
+	assert.NotEqual(t, pod.UUID, again["log-shipper-node1"].UUID)
+}
@@ -0,0 +1,156 @@
+package kubelet
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	dockertypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	dockerclient "github.com/docker/docker/client"
+	"github.com/golang/glog"
+	"p9t.io/kuberboat/pkg/api/core"
+)
+
+// watchContainerEvents subscribes to the docker events stream and syncs the status of a pod
+// whenever one of its containers starts or terminates. If the stream breaks, the pods are
+// relisted, since events may have been lost, and the subscription is established again.
+func (kl *dockerKubelet) watchContainerEvents() {
+	for {
+		ctx, cancel := context.WithCancel(context.Background())
+		messages, errs := kl.dockerClient.Events(ctx, dockertypes.EventsOptions{
+			Filters: filters.NewArgs(
+				filters.Arg("type", events.ContainerEventType),
+				filters.Arg("event", "start"),
+				filters.Arg("event", "die"),
+				filters.Arg("event", "oom"),
+				filters.Arg("event", "destroy"),
+			),
+		})
+		kl.handleContainerEvents(messages, errs)
+		cancel()
+
+		kl.relistPods()
+		time.Sleep(eventsRetryDelay)
+	}
+}
+
+// handleContainerEvents consumes messages until the events stream reports an error.
+func (kl *dockerKubelet) handleContainerEvents(messages <-chan events.Message, errs <-chan error) {
+	for {
+		select {
+		case msg := <-messages:
+			name := strings.TrimPrefix(msg.Actor.Attributes["name"], "/")
+			if pod, ok := kl.podByContainerName(name); ok {
+				glog.Infof("POD [%v]: container %v: %v", pod.Name, name, msg.Action)
+				kl.syncPodStatus(pod)
+			}
+		case err := <-errs:
+			glog.Errorf("docker events stream closed: %v", err)
+			return
+		}
+	}
+}
+
+// podByContainerName finds the pod owning a container named after core.GetPodSpecificName.
+func (kl *dockerKubelet) podByContainerName(name string) (*core.Pod, bool) {
+	idx := strings.Index(name, "_")
+	if idx == -1 {
+		return nil, false
+	}
+	uuid := name[:idx]
+	for _, pod := range kl.GetPods() {
+		if pod.UUID.String() == uuid {
+			return pod, true
+		}
+	}
+	return nil, false
+}
+
+func (kl *dockerKubelet) relistPods() {
+	for _, pod := range kl.GetPods() {
+		kl.syncPodStatus(pod)
+	}
+}
+
+// syncPodStatus inspects the containers of a ready pod and notifies the apiserver if its phase
+// or the number of running containers has changed.
+func (kl *dockerKubelet) syncPodStatus(pod *core.Pod) {
+	kl.mtx.Lock()
+	defer kl.mtx.Unlock()
+
+	// The pod may have been deleted while waiting for the lock. Pending pods are still being
+	// created and are synced by AddPod once all of their containers are started.
+	if p, ok := kl.podMetaManager.PodByName(pod.Name); !ok || p != pod {
+		return
+	}
+	if pod.Status.Phase != core.PodReady {
+		return
+	}
+
+	containerIds, ok := kl.podRuntimeManager.ContainersByPod(pod)
+	if !ok {
+		glog.Errorf("pod %v has no containers", pod.Name)
+		return
+	}
+	states := make([]*dockertypes.ContainerState, 0, len(containerIds))
+	for _, containerId := range containerIds {
+		containerJson, err := kl.dockerClient.ContainerInspect(context.Background(), containerId)
+		if dockerclient.IsErrNotFound(err) {
+			// The container was removed behind our back, which is as good as dead.
+			states = append(states, &dockertypes.ContainerState{Status: "dead", Dead: true})
+			continue
+		}
+		if err != nil {
+			glog.Errorf("fail to inspect container %v of pod %v: %v", containerId, pod.Name, err)
+			return
+		}
+		states = append(states, containerJson.State)
+	}
+
+	phase, running := computePodPhase(states, len(pod.Spec.Containers))
+	if phase == pod.Status.Phase && running == pod.Status.RunningContainers {
+		return
+	}
+	switch phase {
+	case core.PodFailed:
+		glog.Infof("pod %v failed", pod.Name)
+	case core.PodSucceeded:
+		glog.Infof("pod %v succeed", pod.Name)
+	}
+	pod.Status.Phase = phase
+	pod.Status.RunningContainers = running
+	kl.reportPodStatus(pod)
+}
+
+// computePodPhase derives the phase of a ready pod from the states of its user containers, along
+// with the number of running containers. The pod is finished once none of its containers is
+// running, and it has failed if any container died or exited with a non-zero code, or if not all
+// expected containers were created.
+func computePodPhase(states []*dockertypes.ContainerState, expected int) (core.PodPhase, int) {
+	if len(states) != expected {
+		return core.PodFailed, 0
+	}
+	failed := false
+	running := 0
+	for _, state := range states {
+		switch state.Status {
+		case "exited":
+			if state.ExitCode != 0 || state.OOMKilled {
+				failed = true
+			}
+		case "dead":
+			failed = true
+		default:
+			running++
+		}
+	}
+	if running > 0 {
+		return core.PodReady, running
+	}
+	if failed {
+		return core.PodFailed, 0
+	}
+	return core.PodSucceeded, 0
+}
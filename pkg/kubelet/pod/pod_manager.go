@@ -83,7 +83,7 @@ type RuntimeManager interface {
 	AddPodSandBox(pod *core.Pod, name string)
 	// AddPodVolume records a volume as being used by a pod.
 	AddPodVolume(pod *core.Pod, name string)
-	// DeletePodContaiers removes all containers belonging to a pod.
+	// DeletePodContaiers removes all containers belonging to a pod, including the pause container.
 	DeletePodContainers(pod *core.Pod)
 	// DeletePodVolumes removes all volumes belonging to a pod.
 	DeletePodVolumes(pod *core.Pod)
@@ -141,6 +141,7 @@ func (rm *dockerRuntimeManager) DeletePodContainers(pod *core.Pod) {
 	rm.mtx.Lock()
 	defer rm.mtx.Unlock()
 	delete(rm.containersByPod, pod)
+	delete(rm.sandBoxByPod, pod)
 }
 
 func (rm *dockerRuntimeManager) DeletePodVolumes(pod *core.Pod) {
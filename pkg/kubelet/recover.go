@@ -0,0 +1,215 @@
+package kubelet
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	dockertypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/golang/glog"
+	"p9t.io/kuberboat/pkg/api/core"
+)
+
+const (
+	// Labels attached to the docker containers and volumes of a pod. They allow the kubelet to
+	// find the resources of its pods after a restart.
+	podNameLabel      = "io.kuberboat.pod.name"
+	podUUIDLabel      = "io.kuberboat.pod.uuid"
+	resourceNameLabel = "io.kuberboat.resource.name"
+	staticPodLabel    = "io.kuberboat.pod.static"
+	// sandBoxContainerName is the resource name of the pause container of a pod.
+	sandBoxContainerName = "pause"
+	// apiserverStatusPath is where the kubelet remembers the apiserver it is registered with.
+	apiserverStatusPath = "/var/lib/kuberboat/apiserver.json"
+)
+
+// podResources are the docker resources of a pod found by their labels.
+type podResources struct {
+	podName    string
+	static     bool
+	sandBox    string
+	containers []string
+	volumes    []string
+}
+
+// podResourceLabels returns the labels of a docker resource named name that belongs to pod.
+func podResourceLabels(pod *core.Pod, name string) map[string]string {
+	labels := map[string]string{
+		podNameLabel:      pod.Name,
+		podUUIDLabel:      pod.UUID.String(),
+		resourceNameLabel: name,
+	}
+	if core.IsMirrorPod(pod) {
+		labels[staticPodLabel] = "true"
+	}
+	return labels
+}
+
+func saveApiserverStatus(apiserverStatus *core.ApiserverStatus) error {
+	data, err := json.Marshal(apiserverStatus)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(apiserverStatusPath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(apiserverStatusPath, data, 0644)
+}
+
+// LoadApiserverStatus returns the apiserver that the kubelet was connected to before it was
+// restarted, as well as whether there was one.
+func LoadApiserverStatus() (*core.ApiserverStatus, bool) {
+	data, err := os.ReadFile(apiserverStatusPath)
+	if err != nil {
+		return nil, false
+	}
+	var apiserverStatus core.ApiserverStatus
+	if err := json.Unmarshal(data, &apiserverStatus); err != nil {
+		glog.Errorf("invalid apiserver status in %v: %v", apiserverStatusPath, err.Error())
+		return nil, false
+	}
+	return &apiserverStatus, true
+}
+
+// listPodResources returns the labeled docker containers and volumes indexed by pod UUID.
+func (kl *dockerKubelet) listPodResources(ctx context.Context) (map[string]*podResources, error) {
+	resourcesByUUID := make(map[string]*podResources)
+	resourcesOf := func(labels map[string]string) *podResources {
+		uuid := labels[podUUIDLabel]
+		if _, ok := resourcesByUUID[uuid]; !ok {
+			resourcesByUUID[uuid] = &podResources{
+				podName: labels[podNameLabel],
+				static:  labels[staticPodLabel] == "true",
+			}
+		}
+		return resourcesByUUID[uuid]
+	}
+
+	labelFilter := filters.NewArgs(filters.Arg("label", podUUIDLabel))
+	containers, err := kl.dockerClient.ContainerList(ctx, dockertypes.ContainerListOptions{
+		All:     true,
+		Filters: labelFilter,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range containers {
+		resources := resourcesOf(c.Labels)
+		if c.Labels[resourceNameLabel] == sandBoxContainerName {
+			resources.sandBox = c.ID
+		} else {
+			resources.containers = append(resources.containers, c.ID)
+		}
+	}
+
+	volumes, err := kl.dockerClient.VolumeList(ctx, labelFilter)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range volumes.Volumes {
+		resources := resourcesOf(v.Labels)
+		resources.volumes = append(resources.volumes, v.Name)
+	}
+
+	return resourcesByUUID, nil
+}
+
+// adoptPodResources records the docker resources of a pod in the runtime manager.
+func (kl *dockerKubelet) adoptPodResources(pod *core.Pod, resources *podResources) {
+	if resources.sandBox != "" {
+		kl.podRuntimeManager.AddPodSandBox(pod, resources.sandBox)
+	}
+	for _, c := range resources.containers {
+		kl.podRuntimeManager.AddPodContainer(pod, c)
+	}
+	for _, v := range resources.volumes {
+		kl.podRuntimeManager.AddPodVolume(pod, v)
+	}
+}
+
+// reconcilePods rebuilds the pod and runtime state from the docker resources left by a previous
+// run of the kubelet. Pods that the apiserver still schedules on this node are adopted, the
+// resources of any other pod are removed, and desired pods without resources are created. Pods
+// managed by this run of the kubelet are left untouched.
+func (kl *dockerKubelet) reconcilePods() {
+	ctx := context.Background()
+	desiredPods, err := kl.apiClient.GetNodePods()
+	if err != nil {
+		glog.Errorf("cannot get pods scheduled on this node: %v", err.Error())
+		return
+	}
+	resourcesByUUID, err := kl.listPodResources(ctx)
+	if err != nil {
+		glog.Errorf("cannot list pod resources: %v", err.Error())
+		return
+	}
+
+	desired := make(map[string]*core.Pod)
+	for _, pod := range desiredPods {
+		desired[pod.Name] = pod
+	}
+	for _, pod := range kl.GetPods() {
+		delete(resourcesByUUID, pod.UUID.String())
+		delete(desired, pod.Name)
+	}
+
+	// Static pods are run from the manifest directory rather than the api server. Mirror pods that
+	// no longer match a manifest are removed, and the others get the current status reported.
+	for name, pod := range desired {
+		if !core.IsMirrorPod(pod) {
+			continue
+		}
+		if staticPod, ok := kl.staticPodByName(name); !ok || staticPod.UUID != pod.UUID {
+			if _, err := kl.apiClient.DeleteMirrorPod(name); err != nil {
+				glog.Errorf("cannot remove mirror pod %v: %v", name, err.Error())
+			}
+		}
+		delete(desired, name)
+	}
+	for _, pod := range kl.GetPods() {
+		if core.IsMirrorPod(pod) {
+			kl.reportPodStatus(pod)
+		}
+	}
+
+	for uuid, resources := range resourcesByUUID {
+		if resources.static {
+			continue
+		}
+		pod, ok := desired[resources.podName]
+		// A pending pod was being created when the kubelet went down, so its resources may be
+		// incomplete. It is created from scratch instead.
+		if ok && pod.UUID.String() == uuid && pod.Status.Phase != core.PodPending {
+			kl.podMetaManager.AddPod(pod)
+			kl.adoptPodResources(pod, resources)
+			delete(desired, pod.Name)
+			glog.Infof("POD [%v]: adopted %v containers", pod.Name, len(resources.containers))
+			continue
+		}
+
+		// Resources of a pod that is gone are removed through a placeholder pod.
+		glog.Infof("POD [%v]: removing resources of pod no longer scheduled on this node", resources.podName)
+		orphan := &core.Pod{ObjectMeta: core.ObjectMeta{Name: resources.podName}}
+		kl.adoptPodResources(orphan, resources)
+		if err := kl.killPod(ctx, orphan); err != nil {
+			glog.Errorf("cannot remove resources of pod %v: %v", resources.podName, err.Error())
+		}
+	}
+
+	// Finished pods whose containers are gone are not run again.
+	for _, pod := range desired {
+		if pod.Status.Phase != core.PodPending && pod.Status.Phase != core.PodReady {
+			continue
+		}
+		go func(pod *core.Pod) {
+			if err := kl.AddPod(ctx, pod); err != nil {
+				glog.Errorf("failed to create pod: %v", err.Error())
+			}
+		}(pod)
+	}
+
+	// The containers of adopted pods may have changed state while the kubelet was down.
+	kl.relistPods()
+}
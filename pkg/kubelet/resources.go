@@ -0,0 +1,98 @@
+package kubelet
+
+import (
+	"runtime"
+
+	dockercontainer "github.com/docker/docker/api/types/container"
+	"github.com/golang/glog"
+	"p9t.io/kuberboat/pkg/api/core"
+)
+
+const (
+	// cpuPeriod is the CFS period in microseconds over which the CPU quota of a container applies.
+	cpuPeriod = 100000
+	// minCPUShares and minCPUQuota are the smallest values accepted by the kernel.
+	minCPUShares = 2
+	minCPUQuota  = 1000
+	// sharesPerCPU is the CPU shares of a container requesting one core.
+	sharesPerCPU = 1024
+	// OOM score adjustments by QoS class. Guaranteed containers are killed last and best effort
+	// ones first, while burstable ones are in between depending on their memory requests.
+	guaranteedOOMScoreAdj = -997
+	bestEffortOOMScoreAdj = 1000
+)
+
+// containerResources maps the resources of a container to docker. The CPU request becomes CPU
+// shares, the CPU limit becomes a CFS quota, and the memory limit becomes a hard memory limit
+// while the memory request becomes a soft one.
+func containerResources(c *core.Container) dockercontainer.Resources {
+	resources := dockercontainer.Resources{}
+	requests := core.ContainerRequests(c)
+
+	shares := int64(minCPUShares)
+	if cpu, ok := requests[core.ResourceCPU]; ok {
+		shares = cpu.MilliValue() * sharesPerCPU / 1000
+		if shares < minCPUShares {
+			shares = minCPUShares
+		}
+	}
+	resources.CPUShares = shares
+
+	if cpu, ok := c.Resources.Limits[core.ResourceCPU]; ok {
+		quota := cpu.MilliValue() * cpuPeriod / 1000
+		if quota < minCPUQuota {
+			quota = minCPUQuota
+		}
+		resources.CPUPeriod = cpuPeriod
+		resources.CPUQuota = quota
+	}
+
+	if memory, ok := c.Resources.Limits[core.ResourceMemory]; ok {
+		resources.Memory = memory.Value()
+	}
+	if memory, ok := requests[core.ResourceMemory]; ok && memory.Value() < resources.Memory {
+		resources.MemoryReservation = memory.Value()
+	}
+	return resources
+}
+
+// oomScoreAdj returns the OOM score adjustment of a container, so that the kernel kills
+// containers of lower QoS classes first when the node runs out of memory.
+func oomScoreAdj(pod *core.Pod, c *core.Container, memoryCapacity int64) int {
+	switch core.PodQOSClass(pod) {
+	case core.QOSGuaranteed:
+		return guaranteedOOMScoreAdj
+	case core.QOSBestEffort:
+		return bestEffortOOMScoreAdj
+	}
+	if memoryCapacity <= 0 {
+		return bestEffortOOMScoreAdj - 1
+	}
+	// The more memory a burstable container requests, the less likely it is killed.
+	memory := core.ContainerRequests(c)[core.ResourceMemory]
+	adj := 1000 - 1000*memory.Value()/memoryCapacity
+	if adj < 2 {
+		return 2
+	}
+	if adj > bestEffortOOMScoreAdj-1 {
+		return bestEffortOOMScoreAdj - 1
+	}
+	return int(adj)
+}
+
+// reportNodeCapacity tells the api server about the CPU and memory of the node, so that pods are
+// scheduled according to their requests.
+func (kl *dockerKubelet) reportNodeCapacity() {
+	memory, err := memoryObservation()
+	if err != nil {
+		glog.Errorf("cannot observe node memory: %v", err.Error())
+		return
+	}
+	capacity := core.ResourceList{
+		core.ResourceCPU:    core.NewMilliQuantity(int64(runtime.NumCPU()) * 1000),
+		core.ResourceMemory: core.NewMilliQuantity(int64(memory.Capacity) * 1000),
+	}
+	if _, err := kl.apiClient.UpdateNodeCapacity(capacity); err != nil {
+		glog.Errorf("cannot report node capacity: %v", err.Error())
+	}
+}
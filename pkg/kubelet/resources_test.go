@@ -0,0 +1,56 @@
+package kubelet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"p9t.io/kuberboat/pkg/api/core"
+)
+
+func TestContainerResources(t *testing.T) {
+	assert := assert.New(t)
+
+	c := &core.Container{
+		Resources: core.ResourceRequirements{
+			Requests: core.ResourceList{
+				core.ResourceCPU:    core.MustParseQuantity("250m"),
+				core.ResourceMemory: core.MustParseQuantity("64Mi"),
+			},
+			Limits: core.ResourceList{
+				core.ResourceCPU:    core.MustParseQuantity("500m"),
+				core.ResourceMemory: core.MustParseQuantity("128Mi"),
+			},
+		},
+	}
+	resources := containerResources(c)
+	assert.Equal(int64(256), resources.CPUShares)
+	assert.Equal(int64(cpuPeriod), resources.CPUPeriod)
+	assert.Equal(int64(50000), resources.CPUQuota)
+	assert.Equal(int64(128<<20), resources.Memory)
+	assert.Equal(int64(64<<20), resources.MemoryReservation)
+
+	resources = containerResources(&core.Container{})
+	assert.Equal(int64(minCPUShares), resources.CPUShares)
+	assert.Zero(resources.CPUQuota)
+	assert.Zero(resources.Memory)
+}
+
+func TestOOMScoreAdj(t *testing.T) {
+	assert := assert.New(t)
+
+	pod := &core.Pod{}
+	pod.Spec.Containers = []core.Container{{}}
+	assert.Equal(bestEffortOOMScoreAdj, oomScoreAdj(pod, &pod.Spec.Containers[0], 1<<30))
+
+	pod.Spec.Containers[0].Resources.Limits = core.ResourceList{
+		core.ResourceCPU:    core.MustParseQuantity("1"),
+		core.ResourceMemory: core.MustParseQuantity("256Mi"),
+	}
+	assert.Equal(guaranteedOOMScoreAdj, oomScoreAdj(pod, &pod.Spec.Containers[0], 1<<30))
+
+	pod.Spec.Containers[0].Resources.Requests = core.ResourceList{
+		core.ResourceMemory: core.MustParseQuantity("256Mi"),
+	}
+	pod.Spec.Containers[0].Resources.Limits[core.ResourceMemory] = core.MustParseQuantity("512Mi")
+	assert.Equal(750, oomScoreAdj(pod, &pod.Spec.Containers[0], 1<<30))
+}
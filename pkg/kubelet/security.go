@@ -0,0 +1,59 @@
+package kubelet
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	dockercontainer "github.com/docker/docker/api/types/container"
+	"p9t.io/kuberboat/pkg/api/core"
+)
+
+// seccompProfileDir is the directory of the seccomp profiles referenced by localhost/<name>.
+const seccompProfileDir = "/var/lib/kuberboat/seccomp"
+
+// applySecurityContext translates the security context of a container into the docker config
+// and host config of the container.
+func applySecurityContext(
+	sc *core.SecurityContext,
+	config *dockercontainer.Config,
+	hostConfig *dockercontainer.HostConfig,
+) error {
+	if sc.RunAsUser != nil {
+		config.User = fmt.Sprint(*sc.RunAsUser)
+		if sc.RunAsGroup != nil {
+			config.User = fmt.Sprintf("%v:%v", *sc.RunAsUser, *sc.RunAsGroup)
+		}
+	}
+	hostConfig.Privileged = sc.Privileged
+	hostConfig.ReadonlyRootfs = sc.ReadOnlyRootFilesystem
+	if sc.Capabilities != nil {
+		for _, capability := range sc.Capabilities.Add {
+			hostConfig.CapAdd = append(hostConfig.CapAdd, core.NormalizeCapability(capability))
+		}
+		for _, capability := range sc.Capabilities.Drop {
+			hostConfig.CapDrop = append(hostConfig.CapDrop, core.NormalizeCapability(capability))
+		}
+	}
+	if sc.AllowPrivilegeEscalation != nil && !*sc.AllowPrivilegeEscalation {
+		hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, "no-new-privileges")
+	}
+
+	switch profile := sc.SeccompProfile; {
+	case profile == "" || profile == core.SeccompProfileRuntimeDefault:
+	case profile == core.SeccompProfileUnconfined:
+		hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, "seccomp=unconfined")
+	case strings.HasPrefix(profile, core.SeccompProfileLocalhostPrefix):
+		// Docker takes the content of the profile rather than its path.
+		name := strings.TrimPrefix(profile, core.SeccompProfileLocalhostPrefix)
+		content, err := os.ReadFile(filepath.Join(seccompProfileDir, filepath.Clean("/"+name)))
+		if err != nil {
+			return fmt.Errorf("cannot read seccomp profile %v: %v", profile, err.Error())
+		}
+		hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, fmt.Sprintf("seccomp=%s", content))
+	default:
+		return fmt.Errorf("invalid seccomp profile: %v", profile)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package kubelet
+
+import (
+	"testing"
+
+	dockercontainer "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/strslice"
+	"github.com/stretchr/testify/assert"
+	"p9t.io/kuberboat/pkg/api/core"
+)
+
+func TestApplySecurityContext(t *testing.T) {
+	assert := assert.New(t)
+	user, group, no := int64(1000), int64(3000), false
+
+	config := &dockercontainer.Config{}
+	hostConfig := &dockercontainer.HostConfig{}
+	err := applySecurityContext(&core.SecurityContext{
+		RunAsUser:                &user,
+		RunAsGroup:               &group,
+		ReadOnlyRootFilesystem:   true,
+		AllowPrivilegeEscalation: &no,
+		Capabilities: &core.Capabilities{
+			Add:  []string{"CAP_NET_ADMIN"},
+			Drop: []string{"all"},
+		},
+		SeccompProfile: core.SeccompProfileUnconfined,
+	}, config, hostConfig)
+	assert.Nil(err)
+	assert.Equal("1000:3000", config.User)
+	assert.True(hostConfig.ReadonlyRootfs)
+	assert.False(hostConfig.Privileged)
+	assert.Equal(strslice.StrSlice{"NET_ADMIN"}, hostConfig.CapAdd)
+	assert.Equal(strslice.StrSlice{"ALL"}, hostConfig.CapDrop)
+	assert.Equal([]string{"no-new-privileges", "seccomp=unconfined"}, hostConfig.SecurityOpt)
+
+	err = applySecurityContext(
+		&core.SecurityContext{SeccompProfile: "localhost/missing.json"},
+		&dockercontainer.Config{},
+		&dockercontainer.HostConfig{},
+	)
+	assert.NotNil(err)
+}
@@ -0,0 +1,197 @@
+package kubelet
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v2"
+	"p9t.io/kuberboat/pkg/api/core"
+)
+
+// staticPodSyncInterval is the number of seconds between two scans of the manifest directory.
+const staticPodSyncInterval = 20
+
+func (kl *dockerKubelet) RunStaticPods(manifestDir string) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		glog.Errorf("cannot run static pods without host name: %v", err.Error())
+		return
+	}
+	glog.Infof("running static pods from %v", manifestDir)
+	for {
+		kl.syncStaticPods(context.Background(), manifestDir, hostname)
+		time.Sleep(time.Second * staticPodSyncInterval)
+	}
+}
+
+// syncStaticPods starts the static pods whose manifests are new or changed, restarts those that
+// have finished, and removes those whose manifests are gone.
+func (kl *dockerKubelet) syncStaticPods(ctx context.Context, manifestDir string, hostname string) {
+	staticPods, err := readStaticPodManifests(manifestDir, hostname)
+	if err != nil {
+		glog.Errorf("cannot read static pod manifests: %v", err.Error())
+		return
+	}
+	kl.staticPodsMtx.Lock()
+	kl.staticPods = staticPods
+	kl.staticPodsMtx.Unlock()
+
+	resourcesByUUID, err := kl.listPodResources(ctx)
+	if err != nil {
+		glog.Errorf("cannot list pod resources: %v", err.Error())
+		return
+	}
+
+	// Static pods removed or changed while the kubelet was down leave their containers behind.
+	desiredUUIDs := make(map[string]bool)
+	for _, pod := range staticPods {
+		desiredUUIDs[pod.UUID.String()] = true
+	}
+	for uuid, resources := range resourcesByUUID {
+		if !resources.static || desiredUUIDs[uuid] {
+			continue
+		}
+		if pod, ok := kl.GetPodByName(resources.podName); ok && pod.UUID.String() == uuid {
+			continue
+		}
+		orphan := &core.Pod{ObjectMeta: core.ObjectMeta{Name: resources.podName}}
+		kl.adoptPodResources(orphan, resources)
+		if err := kl.killPod(ctx, orphan); err != nil {
+			glog.Errorf("cannot remove resources of static pod %v: %v", resources.podName, err.Error())
+		}
+	}
+
+	for name, pod := range staticPods {
+		running, ok := kl.GetPodByName(name)
+		if ok && !core.IsMirrorPod(running) {
+			glog.Errorf("POD [%v]: static pod conflicts with a pod from the api server", name)
+			continue
+		}
+		if ok && running.UUID == pod.UUID &&
+			(running.Status.Phase == core.PodPending || running.Status.Phase == core.PodReady) {
+			continue
+		}
+		if ok {
+			glog.Infof("POD [%v]: restarting static pod", name)
+			kl.removeStaticPod(ctx, running)
+		}
+		if err := kl.startStaticPod(ctx, pod, resourcesByUUID[pod.UUID.String()]); err != nil {
+			glog.Errorf("POD [%v]: cannot start static pod: %v", name, err.Error())
+		}
+	}
+
+	for _, running := range kl.GetPods() {
+		if _, ok := staticPods[running.Name]; core.IsMirrorPod(running) && !ok {
+			glog.Infof("POD [%v]: manifest of static pod is removed", running.Name)
+			kl.removeStaticPod(ctx, running)
+		}
+	}
+}
+
+// startStaticPod runs a static pod. The containers that an unchanged static pod left behind when
+// the kubelet stopped are adopted instead, if none of them is missing.
+func (kl *dockerKubelet) startStaticPod(ctx context.Context, pod *core.Pod, resources *podResources) error {
+	if resources != nil {
+		if resources.sandBox != "" && len(resources.containers) == len(pod.Spec.Containers) {
+			containerJson, err := kl.dockerClient.ContainerInspect(ctx, resources.sandBox)
+			if err == nil {
+				kl.podMetaManager.AddPod(pod)
+				kl.adoptPodResources(pod, resources)
+				pod.Status.PodIP = containerJson.NetworkSettings.DefaultNetworkSettings.IPAddress
+				pod.Status.Phase = core.PodReady
+				kl.reportPodStatus(pod)
+				kl.syncPodStatus(pod)
+				glog.Infof("POD [%v]: adopted containers of static pod", pod.Name)
+				return nil
+			}
+		}
+		orphan := &core.Pod{ObjectMeta: core.ObjectMeta{Name: pod.Name}}
+		kl.adoptPodResources(orphan, resources)
+		if err := kl.killPod(ctx, orphan); err != nil {
+			return err
+		}
+	}
+
+	if err := kl.AddPod(ctx, pod); err != nil {
+		// A pod waiting for its images is retried after the pull back-off. Any other pod is
+		// forgotten, so that it is retried by the next sync.
+		if _, ok := err.(*imagePullError); !ok {
+			kl.removeStaticPod(ctx, pod)
+		}
+		return err
+	}
+	return nil
+}
+
+// removeStaticPod stops a static pod and removes its mirror pod.
+func (kl *dockerKubelet) removeStaticPod(ctx context.Context, pod *core.Pod) {
+	kl.podMetaManager.DeletePodByName(pod.Name)
+	if err := kl.killPod(ctx, pod); err != nil {
+		glog.Errorf("cannot remove static pod %v: %v", pod.Name, err.Error())
+	}
+	if kl.apiClient != nil {
+		if _, err := kl.apiClient.DeleteMirrorPod(pod.Name); err != nil {
+			glog.Errorf("cannot remove mirror pod %v: %v", pod.Name, err.Error())
+		}
+	}
+}
+
+// staticPodByName returns the static pod currently described by the manifest directory.
+func (kl *dockerKubelet) staticPodByName(name string) (*core.Pod, bool) {
+	kl.staticPodsMtx.RLock()
+	defer kl.staticPodsMtx.RUnlock()
+	pod, ok := kl.staticPods[name]
+	return pod, ok
+}
+
+// readStaticPodManifests parses the pod YAML files in manifestDir, indexed by pod name. The name
+// of a static pod is suffixed with the host name, so that it is unique in the cluster, and its
+// UUID is derived from the manifest, so that it only changes when the manifest does.
+func readStaticPodManifests(manifestDir string, hostname string) (map[string]*core.Pod, error) {
+	entries, err := os.ReadDir(manifestDir)
+	if err != nil {
+		return nil, err
+	}
+	pods := make(map[string]*core.Pod)
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		path := filepath.Join(manifestDir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			glog.Errorf("cannot read static pod manifest %v: %v", path, err.Error())
+			continue
+		}
+		var pod core.Pod
+		if err := yaml.Unmarshal(data, &pod); err != nil {
+			glog.Errorf("cannot unmarshal static pod manifest %v: %v", path, err.Error())
+			continue
+		}
+		if pod.Kind != core.PodType || pod.Name == "" || len(pod.Spec.Containers) == 0 {
+			glog.Errorf("static pod manifest %v does not describe a valid pod", path)
+			continue
+		}
+
+		pod.Name = fmt.Sprintf("%v-%v", pod.Name, hostname)
+		if _, ok := pods[pod.Name]; ok {
+			glog.Errorf("static pod manifest %v duplicates pod %v", path, pod.Name)
+			continue
+		}
+		if pod.Labels == nil {
+			pod.Labels = map[string]string{}
+		}
+		pod.Labels[core.MirrorPodLabel] = hostname
+		pod.UUID = uuid.NewSHA1(uuid.NameSpaceOID, append([]byte(hostname+"/"), data...))
+		pod.CreationTimestamp = time.Now()
+		pod.Status.Phase = core.PodPending
+		pods[pod.Name] = &pod
+	}
+	return pods, nil
+}
@@ -0,0 +1,173 @@
+package kubelet
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	dockertypes "github.com/docker/docker/api/types"
+	"golang.org/x/sys/unix"
+	"p9t.io/kuberboat/pkg/api/core"
+	"p9t.io/kuberboat/pkg/kubelet/eviction"
+)
+
+const (
+	memInfoPath = "/proc/meminfo"
+	loadAvgPath = "/proc/loadavg"
+	pidMaxPath  = "/proc/sys/kernel/pid_max"
+)
+
+func (kl *dockerKubelet) NodeStats() (map[eviction.Signal]eviction.Observation, error) {
+	observations := make(map[eviction.Signal]eviction.Observation)
+
+	memory, err := memoryObservation()
+	if err != nil {
+		return nil, err
+	}
+	observations[eviction.SignalMemoryAvailable] = *memory
+
+	nodeFs, err := fsObservation("/")
+	if err != nil {
+		return nil, err
+	}
+	observations[eviction.SignalNodeFsAvailable] = *nodeFs
+
+	info, err := kl.dockerClient.Info(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	imageFs, err := fsObservation(info.DockerRootDir)
+	if err != nil {
+		return nil, err
+	}
+	observations[eviction.SignalImageFsAvailable] = *imageFs
+
+	pids, err := pidObservation()
+	if err != nil {
+		return nil, err
+	}
+	observations[eviction.SignalPIDAvailable] = *pids
+
+	return observations, nil
+}
+
+func (kl *dockerKubelet) PodStats(pod *core.Pod) (*eviction.PodStats, error) {
+	containers, _ := kl.podRuntimeManager.ContainersByPod(pod)
+	podStats := &eviction.PodStats{}
+	for _, c := range containers {
+		stats, err := kl.containerStats(context.Background(), c)
+		if err != nil {
+			return nil, err
+		}
+		podStats.MemoryUsage += workingSet(&stats.MemoryStats)
+		podStats.PIDs += stats.PidsStats.Current
+
+		containerJson, _, err := kl.dockerClient.ContainerInspectWithRaw(context.Background(), c, true)
+		if err != nil {
+			return nil, err
+		}
+		if containerJson.SizeRw != nil {
+			podStats.DiskUsage += uint64(*containerJson.SizeRw)
+		}
+	}
+	return podStats, nil
+}
+
+// containerStats takes a single sample of the resource usage of a container.
+func (kl *dockerKubelet) containerStats(ctx context.Context, containerId string) (*dockertypes.StatsJSON, error) {
+	resp, err := kl.dockerClient.ContainerStatsOneShot(ctx, containerId)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var stats dockertypes.StatsJSON
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// workingSet is the memory of a container that cannot be reclaimed under pressure, which is the
+// usage without the inactive page cache.
+func workingSet(stats *dockertypes.MemoryStats) uint64 {
+	inactive, ok := stats.Stats["total_inactive_file"]
+	if !ok {
+		inactive = stats.Stats["inactive_file"]
+	}
+	if inactive > stats.Usage {
+		return 0
+	}
+	return stats.Usage - inactive
+}
+
+func memoryObservation() (*eviction.Observation, error) {
+	file, err := os.Open(memInfoPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	values := make(map[string]uint64)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// Lines look like "MemAvailable:    1234567 kB".
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		values[strings.TrimSuffix(fields[0], ":")] = value * 1024
+	}
+	total, okTotal := values["MemTotal"]
+	available, okAvailable := values["MemAvailable"]
+	if !okTotal || !okAvailable {
+		return nil, fmt.Errorf("cannot find memory capacity in %v", memInfoPath)
+	}
+	return &eviction.Observation{Available: available, Capacity: total}, nil
+}
+
+func fsObservation(path string) (*eviction.Observation, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(path, &stat); err != nil {
+		return nil, err
+	}
+	return &eviction.Observation{
+		Available: stat.Bavail * uint64(stat.Bsize),
+		Capacity:  stat.Blocks * uint64(stat.Bsize),
+	}, nil
+}
+
+func pidObservation() (*eviction.Observation, error) {
+	data, err := os.ReadFile(pidMaxPath)
+	if err != nil {
+		return nil, err
+	}
+	pidMax, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	// The fourth field of loadavg is "<runnable>/<total>" scheduling entities.
+	data, err = os.ReadFile(loadAvgPath)
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 4 || !strings.Contains(fields[3], "/") {
+		return nil, fmt.Errorf("unexpected format of %v", loadAvgPath)
+	}
+	used, err := strconv.ParseUint(strings.Split(fields[3], "/")[1], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if used > pidMax {
+		used = pidMax
+	}
+	return &eviction.Observation{Available: pidMax - used, Capacity: pidMax}, nil
+}
@@ -0,0 +1,236 @@
+package kubelet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	dockertypes "github.com/docker/docker/api/types"
+	"github.com/golang/glog"
+	"p9t.io/kuberboat/pkg/api/core"
+)
+
+const (
+	procStatPath = "/proc/stat"
+	// nodeCPUSampleInterval is the interval between the two samples of the node CPU time, from
+	// which its CPU usage is derived.
+	nodeCPUSampleInterval = 200 * time.Millisecond
+	// nanoSecondsPerTick is the length of a clock tick of /proc/stat, which is 1/100 second on
+	// every architecture docker runs on.
+	nanoSecondsPerTick = 10000000
+)
+
+// StatsSummary collects the resource usage of the node and of every pod running on it.
+func (kl *dockerKubelet) StatsSummary(ctx context.Context) (*core.StatsSummary, error) {
+	summary := &core.StatsSummary{Timestamp: time.Now()}
+
+	nodeStats, err := kl.nodeStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	summary.Node = *nodeStats
+
+	// Sampling the CPU usage of a container takes about a second, so pods are sampled in parallel.
+	pods := kl.ActivePods()
+	podStats := make([]*core.PodStats, len(pods))
+	errs := make([]error, len(pods))
+	var wg sync.WaitGroup
+	for i, pod := range pods {
+		wg.Add(1)
+		go func(i int, pod *core.Pod) {
+			defer wg.Done()
+			podStats[i], errs[i] = kl.podStatsSummary(ctx, pod, &summary.Node.Fs)
+		}(i, pod)
+	}
+	wg.Wait()
+
+	summary.Pods = make([]core.PodStats, 0, len(pods))
+	for i := range pods {
+		// A pod whose stats fail, such as one whose container has just exited, is left out rather
+		// than failing the whole summary.
+		if errs[i] != nil {
+			glog.Warningf("cannot collect stats of pod %v: %v", pods[i].Name, errs[i].Error())
+			continue
+		}
+		summary.Pods = append(summary.Pods, *podStats[i])
+	}
+	return summary, nil
+}
+
+// nodeStats collects the resource usage of the whole node.
+func (kl *dockerKubelet) nodeStats(ctx context.Context) (*core.NodeStats, error) {
+	stats := &core.NodeStats{}
+
+	cpu, err := nodeCPUStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stats.CPU = *cpu
+
+	memory, err := memoryObservation()
+	if err != nil {
+		return nil, err
+	}
+	stats.Memory.UsageBytes = memory.Capacity - memory.Available
+	stats.Memory.WorkingSetBytes = stats.Memory.UsageBytes
+
+	fs, err := fsObservation("/")
+	if err != nil {
+		return nil, err
+	}
+	stats.Fs = core.FsStats{
+		UsedBytes:      fs.Capacity - fs.Available,
+		CapacityBytes:  fs.Capacity,
+		AvailableBytes: fs.Available,
+	}
+	return stats, nil
+}
+
+// podStatsSummary collects the resource usage of a pod from docker stats of its containers and of
+// its sandbox, which owns the network namespace of the pod.
+func (kl *dockerKubelet) podStatsSummary(
+	ctx context.Context,
+	pod *core.Pod,
+	nodeFs *core.FsStats,
+) (*core.PodStats, error) {
+	podStats := &core.PodStats{Name: pod.Name}
+	podStats.Fs.CapacityBytes = nodeFs.CapacityBytes
+	podStats.Fs.AvailableBytes = nodeFs.AvailableBytes
+
+	containers, _ := kl.podRuntimeManager.ContainersByPod(pod)
+	for _, id := range containers {
+		stats, err := kl.sampleContainerStats(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		containerJson, _, err := kl.dockerClient.ContainerInspectWithRaw(ctx, id, true)
+		if err != nil {
+			return nil, err
+		}
+
+		containerStats := core.ContainerStats{
+			Name: containerJson.Config.Labels[resourceNameLabel],
+			CPU:  cpuStats(stats),
+			Memory: core.MemoryStats{
+				UsageBytes:      stats.MemoryStats.Usage,
+				WorkingSetBytes: workingSet(&stats.MemoryStats),
+			},
+			Fs: core.FsStats{
+				CapacityBytes:  nodeFs.CapacityBytes,
+				AvailableBytes: nodeFs.AvailableBytes,
+			},
+		}
+		if containerJson.SizeRw != nil {
+			containerStats.Fs.UsedBytes = uint64(*containerJson.SizeRw)
+		}
+		podStats.Containers = append(podStats.Containers, containerStats)
+
+		podStats.CPU.UsageNanoCores += containerStats.CPU.UsageNanoCores
+		podStats.CPU.UsageCoreNanoSeconds += containerStats.CPU.UsageCoreNanoSeconds
+		podStats.Memory.UsageBytes += containerStats.Memory.UsageBytes
+		podStats.Memory.WorkingSetBytes += containerStats.Memory.WorkingSetBytes
+		podStats.Fs.UsedBytes += containerStats.Fs.UsedBytes
+	}
+
+	if sandbox, ok := kl.podRuntimeManager.SandBoxByPod(pod); ok {
+		stats, err := kl.containerStats(ctx, sandbox)
+		if err != nil {
+			return nil, err
+		}
+		for _, network := range stats.Networks {
+			podStats.Network.RxBytes += network.RxBytes
+			podStats.Network.TxBytes += network.TxBytes
+		}
+	}
+	return podStats, nil
+}
+
+// sampleContainerStats takes two samples of the resource usage of a container, so that its CPU
+// usage can be derived from the difference of its CPU time.
+func (kl *dockerKubelet) sampleContainerStats(ctx context.Context, containerId string) (*dockertypes.StatsJSON, error) {
+	resp, err := kl.dockerClient.ContainerStats(ctx, containerId, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var stats dockertypes.StatsJSON
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// cpuStats derives the CPU usage of a container from the two samples of docker stats.
+func cpuStats(stats *dockertypes.StatsJSON) core.CPUStats {
+	cpu := core.CPUStats{UsageCoreNanoSeconds: stats.CPUStats.CPUUsage.TotalUsage}
+	elapsed := stats.Read.Sub(stats.PreRead)
+	used := stats.CPUStats.CPUUsage.TotalUsage
+	previous := stats.PreCPUStats.CPUUsage.TotalUsage
+	if stats.PreRead.IsZero() || elapsed <= 0 || used < previous {
+		return cpu
+	}
+	cpu.UsageNanoCores = uint64(float64(used-previous) / elapsed.Seconds())
+	return cpu
+}
+
+// nodeCPUStats derives the CPU usage of the node from two samples of /proc/stat.
+func nodeCPUStats(ctx context.Context) (*core.CPUStats, error) {
+	before, err := nodeCPUTime()
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	select {
+	case <-time.After(nodeCPUSampleInterval):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	after, err := nodeCPUTime()
+	if err != nil {
+		return nil, err
+	}
+	cpu := &core.CPUStats{UsageCoreNanoSeconds: after}
+	if after > before {
+		cpu.UsageNanoCores = uint64(float64(after-before) / time.Since(start).Seconds())
+	}
+	return cpu, nil
+}
+
+// nodeCPUTime returns the cumulative busy CPU time of the node in nanoseconds, which is all the
+// time in the aggregate cpu line of /proc/stat except idle and iowait.
+func nodeCPUTime() (uint64, error) {
+	data, err := os.ReadFile(procStatPath)
+	if err != nil {
+		return 0, err
+	}
+	return parseNodeCPUTime(string(data))
+}
+
+func parseNodeCPUTime(stat string) (uint64, error) {
+	for _, line := range strings.Split(stat, "\n") {
+		// The line looks like "cpu  user nice system idle iowait irq softirq steal ...".
+		fields := strings.Fields(line)
+		if len(fields) < 6 || fields[0] != "cpu" {
+			continue
+		}
+		var busy uint64
+		for i, field := range fields[1:] {
+			ticks, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				return 0, err
+			}
+			// Guest time is already counted in user time.
+			if i == 3 || i == 4 || i >= 8 {
+				continue
+			}
+			busy += ticks
+		}
+		return busy * nanoSecondsPerTick, nil
+	}
+	return 0, fmt.Errorf("cannot find cpu time in %v", procStatPath)
+}
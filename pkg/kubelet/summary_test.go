@@ -0,0 +1,37 @@
+package kubelet
+
+import (
+	"testing"
+	"time"
+
+	dockertypes "github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNodeCPUTime(t *testing.T) {
+	stat := "cpu  100 5 50 1000 20 1 2 3 7 0\ncpu0 100 5 50 1000 20 1 2 3 7 0\nintr 1234\n"
+	busy, err := parseNodeCPUTime(stat)
+	assert.Nil(t, err)
+	// user + nice + system + irq + softirq + steal, without idle, iowait and guest.
+	assert.Equal(t, uint64(161*nanoSecondsPerTick), busy)
+
+	_, err = parseNodeCPUTime("intr 1234\n")
+	assert.NotNil(t, err)
+}
+
+func TestCPUStats(t *testing.T) {
+	now := time.Now()
+	stats := &dockertypes.StatsJSON{}
+	stats.Read = now
+	stats.PreRead = now.Add(-time.Second)
+	stats.CPUStats.CPUUsage.TotalUsage = 3000000000
+	stats.PreCPUStats.CPUUsage.TotalUsage = 2500000000
+
+	cpu := cpuStats(stats)
+	assert.Equal(t, uint64(3000000000), cpu.UsageCoreNanoSeconds)
+	assert.Equal(t, uint64(500000000), cpu.UsageNanoCores)
+
+	// Without a previous sample the usage rate is unknown.
+	stats.PreRead = time.Time{}
+	assert.Zero(t, cpuStats(stats).UsageNanoCores)
+}